@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var authorCmd = &cobra.Command{
+	Use:   "author",
+	Short: "Manage authors",
+}
+
+var authorCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a new author",
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+
+		author, err := bookman.CreateAuthor(models.Author{Name: name})
+		handleErr(err)
+		printAuthorsTable([]models.Author{author})
+	},
+}
+
+var authorListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all authors",
+	Run: func(cmd *cobra.Command, args []string) {
+		authors, err := bookman.GetAuthors()
+		handleErr(err)
+		printAuthorsTable(authors)
+	},
+}
+
+var authorGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Get an author and the books crediting them",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		authorID, err := strconv.Atoi(id)
+		handleErr(err)
+
+		author, err := bookman.GetAuthor(authorID)
+		handleErr(err)
+		books, err := bookman.GetAuthorBooks(authorID)
+		handleErr(err)
+
+		fmt.Println("Author:")
+		printAuthorsTable([]models.Author{author})
+		if len(books) > 0 {
+			fmt.Println("\nBooks:")
+			printBooksTable(books)
+		}
+	},
+}
+
+var authorUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Rename an author",
+	Long:  "Rename an author. The author is renamed on all of their books.",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		authorID, err := strconv.Atoi(id)
+		handleErr(err)
+		name, _ := cmd.Flags().GetString("name")
+
+		err = bookman.UpdateAuthor(models.Author{ID: authorID, Name: name})
+		handleErr(err)
+		fmt.Println("Author updated successfully")
+	},
+}
+
+var authorDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete an author who is not credited on any book",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		authorID, err := strconv.Atoi(id)
+		handleErr(err)
+
+		err = bookman.DeleteAuthor(authorID)
+		handleErr(err)
+		fmt.Println("Author deleted successfully")
+	},
+}
+
+func printAuthorsTable(authors []models.Author) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Name"})
+
+	for _, author := range authors {
+		table.Append([]string{
+			strconv.Itoa(author.ID),
+			author.Name,
+		})
+	}
+
+	table.Render()
+}
+
+func init() {
+	authorCreateCmd.Flags().String("name", "", "Name of the author")
+
+	authorGetCmd.Flags().String("id", "", "ID of the author")
+	authorUpdateCmd.Flags().String("id", "", "ID of the author")
+	authorUpdateCmd.Flags().String("name", "", "New name of the author")
+	authorDeleteCmd.Flags().String("id", "", "ID of the author")
+
+	authorCmd.AddCommand(authorCreateCmd)
+	authorCmd.AddCommand(authorListCmd)
+	authorCmd.AddCommand(authorGetCmd)
+	authorCmd.AddCommand(authorUpdateCmd)
+	authorCmd.AddCommand(authorDeleteCmd)
+}
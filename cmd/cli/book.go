@@ -3,13 +3,23 @@ package main
 import (
 	"fmt"
 	"os"
+	"os/user"
+	"reflect"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/mayank-02/bookman/internal/isbn"
 	"github.com/mayank-02/bookman/internal/models"
+	"github.com/mayank-02/bookman/pkg/client"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
 
+// defaultPageSize is the number of books per page when only --page is given.
+const defaultPageSize = 20
+
 var bookCmd = &cobra.Command{
 	Use:   "book",
 	Short: "Manage books",
@@ -27,19 +37,39 @@ func parseBookFlags(cmd *cobra.Command) (models.Book, error) {
 		return models.Book{}, fmt.Errorf("title, author, and published are mandatory fields")
 	}
 
-	return models.Book{
+	book := models.Book{
 		Title:         title,
 		Author:        author,
 		PublishedDate: publishedDate,
 		Edition:       edition,
 		Description:   description,
 		Genre:         genre,
-	}, nil
+	}
+	book.Tags, _ = cmd.Flags().GetStringSlice("tags")
+	book.Publisher, _ = cmd.Flags().GetString("publisher")
+	book.Language, _ = cmd.Flags().GetString("language")
+	book.PageCount, _ = cmd.Flags().GetInt("pages")
+	book.Format, _ = cmd.Flags().GetString("format")
+	book.Dimensions.Height, _ = cmd.Flags().GetFloat64("height")
+	book.Dimensions.Width, _ = cmd.Flags().GetFloat64("width")
+	book.Dimensions.Thickness, _ = cmd.Flags().GetFloat64("thickness")
+	custom, err := parseCustomFlags(cmd)
+	if err != nil {
+		return models.Book{}, err
+	}
+	book.Custom = custom
+	if value, _ := cmd.Flags().GetString("isbn"); value != "" {
+		book.ISBN10, book.ISBN13, err = isbn.Parse(value)
+		if err != nil {
+			return models.Book{}, fmt.Errorf("invalid ISBN %q: %w", value, err)
+		}
+	}
+	return book, nil
 }
 
 func printBooksTable(books []models.Book) {
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"ID", "Title", "Author", "Published Date", "Edition", "Description", "Genre"})
+	table.SetHeader([]string{"ID", "Title", "Author", "Published Date", "Edition", "ISBN", "Description", "Genre", "Tags"})
 
 	for _, book := range books {
 		table.Append([]string{
@@ -48,8 +78,31 @@ func printBooksTable(books []models.Book) {
 			book.Author,
 			book.PublishedDate,
 			book.Edition,
+			book.ISBN13,
 			book.Description,
 			book.Genre,
+			strings.Join(book.Tags, ", "),
+		})
+	}
+
+	table.Render()
+}
+
+// searchHighlighter turns the <mark> tags of search snippets into something
+// readable on a terminal.
+var searchHighlighter = strings.NewReplacer("<mark>", "*", "</mark>", "*")
+
+func printSearchResultsTable(results []models.BookSearchResult) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Title", "Author", "Score", "Match"})
+
+	for _, result := range results {
+		table.Append([]string{
+			strconv.Itoa(result.Book.ID),
+			result.Book.Title,
+			result.Book.Author,
+			strconv.FormatFloat(result.Score, 'f', 2, 64),
+			searchHighlighter.Replace(result.Snippet),
 		})
 	}
 
@@ -77,17 +130,138 @@ var bookListCmd = &cobra.Command{
 		genre, _ := cmd.Flags().GetString("genre")
 		from, _ := cmd.Flags().GetString("from")
 		to, _ := cmd.Flags().GetString("to")
+		sort, _ := cmd.Flags().GetString("sort")
+		desc, _ := cmd.Flags().GetBool("desc")
+		limit, _ := cmd.Flags().GetInt("limit")
+		pageNumber, _ := cmd.Flags().GetInt("page")
+		tags, _ := cmd.Flags().GetStringArray("tag")
+		status, _ := cmd.Flags().GetString("status")
+		minRating, _ := cmd.Flags().GetFloat64("min-rating")
+		byWork, _ := cmd.Flags().GetBool("by-work")
+		publisher, _ := cmd.Flags().GetString("publisher")
+		language, _ := cmd.Flags().GetString("language")
+		format, _ := cmd.Flags().GetString("format")
+		minPages, _ := cmd.Flags().GetInt("min-pages")
+		maxPages, _ := cmd.Flags().GetInt("max-pages")
+		maxHeight, _ := cmd.Flags().GetFloat64("max-height")
+		maxWidth, _ := cmd.Flags().GetFloat64("max-width")
+		maxThickness, _ := cmd.Flags().GetFloat64("max-thickness")
+		var custom map[string]string
+		pairs, _ := cmd.Flags().GetStringArray("custom")
+		for _, pair := range pairs {
+			name, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				handleErr(fmt.Errorf("invalid --custom %q, expected name=value", pair))
+			}
+			if custom == nil {
+				custom = map[string]string{}
+			}
+			custom[name] = value
+		}
+
+		opts := client.BookListOptions{Author: author, Genre: genre, Tags: tags, Status: status, MinRating: minRating,
+			CollapseWorks: byWork, Publisher: publisher, Language: language, Format: format, MinPages: minPages, MaxPages: maxPages,
+			MaxHeight: maxHeight, MaxWidth: maxWidth, MaxThickness: maxThickness, Custom: custom, From: from, To: to, Sort: sort, Desc: desc}
+
+		// Without paging flags list everything, like before pages existed
+		if limit == 0 && pageNumber == 0 {
+			var books []models.Book
+			it := bookman.Books(opts)
+			for it.Next() {
+				books = append(books, it.Book())
+			}
+			handleErr(it.Err())
+			printBooksTable(books)
+			return
+		}
+
+		if limit <= 0 {
+			limit = defaultPageSize
+		}
+		if pageNumber <= 0 {
+			pageNumber = 1
+		}
+		opts.Limit = limit
 
-		books, err := bookman.GetBooks(author, genre, from, to)
+		// Pages are cursor based, so earlier pages are walked to reach the
+		// requested one
+		page, err := bookman.ListBooks(opts)
 		handleErr(err)
-		printBooksTable(books)
+		for i := 1; i < pageNumber; i++ {
+			if page.Next == "" {
+				handleErr(fmt.Errorf("page %d is past the last page", pageNumber))
+			}
+			opts.After = page.Next
+			page, err = bookman.ListBooks(opts)
+			handleErr(err)
+		}
+		printBooksTable(page.Books)
+		fmt.Printf("Page %d of %d, %d books in total\n", pageNumber, max(1, (page.Total+limit-1)/limit), page.Total)
+	},
+}
 
+var bookSearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search books by title, author, description and genre",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		results, err := bookman.SearchBooks(strings.Join(args, " "))
+		handleErr(err)
+		printSearchResultsTable(results)
 	},
 }
 
 var bookGetCmd = &cobra.Command{
 	Use:   "get",
 	Short: "Get details of a specific book",
+	Long:  "Get details of a specific book, identified by --id or by --isbn in either of its forms.",
+	Run: func(cmd *cobra.Command, args []string) {
+		var book models.Book
+		if value, _ := cmd.Flags().GetString("isbn"); value != "" {
+			var err error
+			book, err = bookman.GetBookByISBN(value)
+			handleErr(err)
+		} else {
+			id, _ := cmd.Flags().GetString("id")
+			bookID, err := strconv.Atoi(id)
+			if err != nil {
+				fmt.Println("Invalid ID format:", err)
+				return
+			}
+			book, err = bookman.GetBook(bookID)
+			handleErr(err)
+		}
+		printBooksTable([]models.Book{book})
+		if details := formatDetails(book); details != "" {
+			fmt.Println(details)
+		}
+		printCustom(book.Custom)
+		for _, s := range book.Series {
+			fmt.Printf("Book %s of %s\n", formatPosition(s.Position), s.Name)
+		}
+		if book.WorkID != nil {
+			work, err := bookman.GetWork(*book.WorkID)
+			handleErr(err)
+			fmt.Printf("Edition of %s (work %d, %d editions)\n", work.Title, work.ID, work.EditionCount)
+		}
+		if book.ReadingStatus != "" {
+			fmt.Printf("Reading status: %s\n", book.ReadingStatus)
+		}
+		if book.RatingCount > 0 {
+			fmt.Printf("Rating: %s from %d reviews\n", formatRating(book.AverageRating), book.RatingCount)
+		}
+		if book.Cover != nil {
+			fmt.Printf("Cover: %dx%d %s, %s\n", book.Cover.Width, book.Cover.Height, book.Cover.ContentType, formatSize(book.Cover.Size))
+		}
+		fmt.Printf("Version %d\n", book.Version)
+	},
+}
+
+var bookUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update a book's information",
+	Long: `Update a book's information. Only the fields given as flags are changed, pass an empty value to clear an optional field.
+With --if-version the update only goes through if nobody changed the book since it was at that version, as shown by "book get".`,
 	Run: func(cmd *cobra.Command, args []string) {
 		id, _ := cmd.Flags().GetString("id")
 		bookID, err := strconv.Atoi(id)
@@ -96,15 +270,91 @@ var bookGetCmd = &cobra.Command{
 			return
 		}
 
-		book, err := bookman.GetBook(bookID)
+		patch := client.BookPatch{
+			Title:         changedString(cmd, "title"),
+			Author:        changedString(cmd, "author"),
+			PublishedDate: changedString(cmd, "published"),
+			Edition:       changedString(cmd, "edition"),
+			Description:   changedString(cmd, "description"),
+			Genre:         changedString(cmd, "genre"),
+			Publisher:     changedString(cmd, "publisher"),
+			Language:      changedString(cmd, "language"),
+			Format:        changedString(cmd, "format"),
+		}
+		if cmd.Flags().Changed("pages") {
+			pages, _ := cmd.Flags().GetInt("pages")
+			patch.PageCount = &pages
+		}
+		var dimensions client.DimensionsPatch
+		for name, value := range map[string]**float64{"height": &dimensions.Height, "width": &dimensions.Width, "thickness": &dimensions.Thickness} {
+			if cmd.Flags().Changed(name) {
+				millimetres, _ := cmd.Flags().GetFloat64(name)
+				*value = &millimetres
+			}
+		}
+		if dimensions != (client.DimensionsPatch{}) {
+			patch.Dimensions = &dimensions
+		}
+		if value := changedString(cmd, "isbn"); value != nil {
+			// Both forms are sent, so the server does not keep a stale one
+			isbn10, isbn13 := "", ""
+			if *value != "" {
+				isbn10, isbn13, err = isbn.Parse(*value)
+				if err != nil {
+					handleErr(fmt.Errorf("invalid ISBN %q: %w", *value, err))
+				}
+			}
+			patch.ISBN10, patch.ISBN13 = &isbn10, &isbn13
+		}
+		if cmd.Flags().Changed("tags") {
+			tags, _ := cmd.Flags().GetStringSlice("tags")
+			patch.Tags = &tags
+		}
+		patch.Custom, err = parseCustomFlags(cmd)
 		handleErr(err)
-		printBooksTable([]models.Book{book})
+		if reflect.DeepEqual(patch, client.BookPatch{}) {
+			fmt.Println("Nothing to update, set at least one of the book's fields")
+			return
+		}
+
+		version, _ := cmd.Flags().GetInt("if-version")
+		book, err := bookman.PatchBookIfMatch(bookID, version, patch)
+		handleErr(err)
+		fmt.Printf("Book updated successfully, now at version %d\n", book.Version)
 	},
 }
 
-var bookUpdateCmd = &cobra.Command{
-	Use:   "update",
-	Short: "Update a book's information",
+// formatDetails describes the known publishing details of a book, like
+// "Chilton Books, en, 412 pages, hardcover, 235 x 157 x 38 mm".
+func formatDetails(book models.Book) string {
+	var details []string
+	for _, value := range []string{book.Publisher, book.Language} {
+		if value != "" {
+			details = append(details, value)
+		}
+	}
+	if book.PageCount > 0 {
+		details = append(details, fmt.Sprintf("%d pages", book.PageCount))
+	}
+	if book.Format != "" {
+		details = append(details, book.Format)
+	}
+	if d := book.Dimensions; d != (models.Dimensions{}) {
+		millimetres := func(value float64) string { return strconv.FormatFloat(value, 'f', -1, 64) }
+		details = append(details, fmt.Sprintf("%s x %s x %s mm", millimetres(d.Height), millimetres(d.Width), millimetres(d.Thickness)))
+	}
+	return strings.Join(details, ", ")
+}
+
+var reviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "Rate and review books",
+}
+
+var reviewAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Rate and review a book",
+	Long:  "Rate and review a book. Reviewing a book you already reviewed replaces your review, the earlier version stays in its history.",
 	Run: func(cmd *cobra.Command, args []string) {
 		id, _ := cmd.Flags().GetString("id")
 		bookID, err := strconv.Atoi(id)
@@ -112,20 +362,148 @@ var bookUpdateCmd = &cobra.Command{
 			fmt.Println("Invalid ID format:", err)
 			return
 		}
+		rating, _ := cmd.Flags().GetFloat64("rating")
+		body, _ := cmd.Flags().GetString("body")
+		if file, _ := cmd.Flags().GetString("file"); file != "" {
+			content, err := os.ReadFile(file)
+			handleErr(err)
+			body = string(content)
+		}
+		reviewer, _ := cmd.Flags().GetString("reviewer")
+		if reviewer == "" {
+			reviewer = currentUser()
+		}
 
-		book, err := parseBookFlags(cmd)
+		review := models.Review{BookID: bookID, Reviewer: reviewer, Rating: rating, Body: body}
+		reviews, err := bookman.GetBookReviews(bookID)
+		handleErr(err)
+		for _, r := range reviews {
+			if r.Reviewer == strings.TrimSpace(reviewer) {
+				review.ID = r.ID
+			}
+		}
+		if review.ID != 0 {
+			review, err = bookman.UpdateReview(review)
+			handleErr(err)
+			fmt.Printf("Review %d updated, %d earlier versions kept\n", review.ID, len(review.History))
+			return
+		}
+		review, err = bookman.CreateReview(review)
+		handleErr(err)
+		fmt.Printf("Review %d added\n", review.ID)
+	},
+}
+
+var reviewShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show a review with its earlier versions",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		reviewID, err := strconv.Atoi(id)
 		if err != nil {
-			fmt.Println(err)
+			fmt.Println("Invalid ID format:", err)
 			return
 		}
-		book.ID = bookID
 
-		err = bookman.UpdateBook(book)
+		review, err := bookman.GetReview(reviewID)
 		handleErr(err)
-		fmt.Println("Book updated successfully")
+		book, err := bookman.GetBook(review.BookID)
+		handleErr(err)
+		fmt.Printf("%s reviewed %q (book %d)\n", review.Reviewer, book.Title, book.ID)
+		fmt.Printf("Rating: %s\n", formatRating(review.Rating))
+		fmt.Printf("Written %s, last edited %s\n", formatTime(review.CreatedAt), formatTime(review.UpdatedAt))
+		if review.Body != "" {
+			fmt.Printf("\n%s\n", strings.TrimRight(review.Body, "\n"))
+		}
+		// Newest first, like the review itself
+		for i := len(review.History) - 1; i >= 0; i-- {
+			revision := review.History[i]
+			fmt.Printf("\n--- Version of %s, %s\n", formatTime(revision.WrittenAt), formatRating(revision.Rating))
+			if revision.Body != "" {
+				fmt.Println(strings.TrimRight(revision.Body, "\n"))
+			}
+		}
 	},
 }
 
+var reviewListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List reviews of a book or by a reviewer",
+	Run: func(cmd *cobra.Command, args []string) {
+		reviewer, _ := cmd.Flags().GetString("reviewer")
+		var reviews []models.Review
+		if id, _ := cmd.Flags().GetString("book-id"); id != "" {
+			bookID, err := strconv.Atoi(id)
+			if err != nil {
+				fmt.Println("Invalid ID format:", err)
+				return
+			}
+			reviews, err = bookman.GetBookReviews(bookID)
+			handleErr(err)
+			if reviewer != "" {
+				reviews = slices.DeleteFunc(reviews, func(r models.Review) bool { return r.Reviewer != strings.TrimSpace(reviewer) })
+			}
+		} else {
+			var err error
+			reviews, err = bookman.GetReviews(reviewer)
+			handleErr(err)
+		}
+		printReviewsTable(reviews)
+	},
+}
+
+// currentUser names the reviewer when --reviewer is left out.
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// formatRating draws a rating as stars, like "★★★★½ (4.5)".
+func formatRating(rating float64) string {
+	halves := int(rating*2 + 0.5)
+	stars := strings.Repeat("★", halves/2)
+	if halves%2 == 1 {
+		stars += "½"
+	}
+	return fmt.Sprintf("%s (%s)", stars, strconv.FormatFloat(rating, 'f', -1, 64))
+}
+
+func formatTime(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func printReviewsTable(reviews []models.Review) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Book", "Reviewer", "Rating", "Updated", "Review"})
+
+	for _, review := range reviews {
+		// Only the first line of the review, the rest is for "review show"
+		summary, _, _ := strings.Cut(strings.TrimSpace(review.Body), "\n")
+		table.Append([]string{
+			strconv.Itoa(review.ID),
+			strconv.Itoa(review.BookID),
+			review.Reviewer,
+			formatRating(review.Rating),
+			formatTime(review.UpdatedAt),
+			summary,
+		})
+	}
+
+	table.Render()
+}
+
+// changedString returns the value of a string flag the user set on the
+// command line, and nil if it was left out.
+func changedString(cmd *cobra.Command, name string) *string {
+	if !cmd.Flags().Changed(name) {
+		return nil
+	}
+	value, _ := cmd.Flags().GetString(name)
+	return &value
+}
+
 var bookDeleteCmd = &cobra.Command{
 	Use:   "delete",
 	Short: "Delete a book",
@@ -137,36 +515,90 @@ var bookDeleteCmd = &cobra.Command{
 			return
 		}
 
-		err = bookman.DeleteBook(bookID)
+		version, _ := cmd.Flags().GetInt("if-version")
+		err = bookman.DeleteBookIfMatch(bookID, version)
 		handleErr(err)
-		fmt.Println("Book deleted successfully")
+		fmt.Printf("Book moved to the trash, restore it with: bookman trash restore --book %d\n", bookID)
 	},
 }
 
 func init() {
 	bookAddCmd.Flags().String("title", "", "Title of the book")
 	bookAddCmd.Flags().String("author", "", "Author of the book")
-	bookAddCmd.Flags().String("published", "", "Published date of the book")
+	bookAddCmd.Flags().String("published", "", "Published date of the book, YYYY-MM-DD, YYYY-MM, YYYY or \"c. YYYY\" if approximate")
 	bookAddCmd.Flags().String("edition", "", "Edition of the book")
 	bookAddCmd.Flags().String("description", "", "Description of the book")
 	bookAddCmd.Flags().String("genre", "", "Genre of the book")
+	bookAddCmd.Flags().String("isbn", "", "ISBN-10 or ISBN-13 of the book")
+	bookAddCmd.Flags().StringSlice("tags", nil, "Tags of the book, comma separated")
+	bookAddCmd.Flags().String("publisher", "", "Publisher of the book")
+	bookAddCmd.Flags().String("language", "", "Language of the book as a BCP 47 tag, e.g. en or pt-BR")
+	bookAddCmd.Flags().Int("pages", 0, "Number of pages")
+	bookAddCmd.Flags().String("format", "", "Format of the book: hardcover, paperback, ebook, audiobook or other")
+	bookAddCmd.Flags().Float64("height", 0, "Height of the book in millimetres")
+	bookAddCmd.Flags().Float64("width", 0, "Width of the book in millimetres")
+	bookAddCmd.Flags().Float64("thickness", 0, "Thickness of the book in millimetres")
+	bookAddCmd.Flags().StringArray("set", nil, "Set a custom field as name=value, repeat for several fields")
 
 	bookListCmd.Flags().String("author", "", "Filter books by author")
 	bookListCmd.Flags().String("genre", "", "Filter books by genre")
-	bookListCmd.Flags().String("from", "", "Filter books published after this date")
-	bookListCmd.Flags().String("to", "", "Filter books published before this date")
+	bookListCmd.Flags().StringArray("tag", nil, "Filter books by tag, repeat to require several tags, separate alternatives with commas")
+	bookListCmd.Flags().String("status", "", "Filter books by reading status: want-to-read, reading, finished or abandoned")
+	bookListCmd.Flags().String("from", "", "Filter books that may have been published on or after this date, which may be partial like 1850 or 1850-03")
+	bookListCmd.Flags().String("to", "", "Filter books that may have been published on or before this date, which may be partial like 1850 or 1850-03")
+	bookListCmd.Flags().Float64("min-rating", 0, "Only list books with at least this average rating")
+	bookListCmd.Flags().Bool("by-work", false, "List one edition per work")
+	bookListCmd.Flags().String("publisher", "", "Filter books by publisher")
+	bookListCmd.Flags().String("language", "", "Filter books by language, en also matches en-GB")
+	bookListCmd.Flags().String("format", "", "Filter books by format")
+	bookListCmd.Flags().Int("min-pages", 0, "Only list books with at least this many pages")
+	bookListCmd.Flags().Int("max-pages", 0, "Only list books with at most this many pages")
+	bookListCmd.Flags().Float64("max-height", 0, "Only list books at most this high, in millimetres")
+	bookListCmd.Flags().Float64("max-width", 0, "Only list books at most this wide, in millimetres")
+	bookListCmd.Flags().Float64("max-thickness", 0, "Only list books at most this thick, in millimetres")
+	bookListCmd.Flags().StringArray("custom", nil, "Filter books by a custom field as name=value, repeat to require several")
+	bookListCmd.Flags().String("sort", "", "Sort books by title, author, published_date, created_at or rating")
+	bookListCmd.Flags().Bool("desc", false, "Sort in descending order")
+	bookListCmd.Flags().Int("limit", 0, fmt.Sprintf("Number of books per page (default %d when --page is set)", defaultPageSize))
+	bookListCmd.Flags().Int("page", 0, "Page to show, starting at 1")
 
 	bookGetCmd.Flags().String("id", "", "ID of the book")
+	bookGetCmd.Flags().String("isbn", "", "ISBN-10 or ISBN-13 of the book, instead of the ID")
 
 	bookUpdateCmd.Flags().String("id", "", "ID of the book")
 	bookUpdateCmd.Flags().String("title", "", "Title of the book")
 	bookUpdateCmd.Flags().String("author", "", "Author of the book")
-	bookUpdateCmd.Flags().String("published", "", "Published date of the book")
+	bookUpdateCmd.Flags().String("published", "", "Published date of the book, YYYY-MM-DD, YYYY-MM, YYYY or \"c. YYYY\" if approximate")
 	bookUpdateCmd.Flags().String("edition", "", "Edition of the book")
 	bookUpdateCmd.Flags().String("description", "", "Description of the book")
 	bookUpdateCmd.Flags().String("genre", "", "Genre of the book")
+	bookUpdateCmd.Flags().String("isbn", "", "ISBN-10 or ISBN-13 of the book")
+	bookUpdateCmd.Flags().StringSlice("tags", nil, "Tags of the book, comma separated, replacing the current ones")
+	bookUpdateCmd.Flags().String("publisher", "", "Publisher of the book")
+	bookUpdateCmd.Flags().String("language", "", "Language of the book as a BCP 47 tag, e.g. en or pt-BR")
+	bookUpdateCmd.Flags().Int("pages", 0, "Number of pages")
+	bookUpdateCmd.Flags().String("format", "", "Format of the book: hardcover, paperback, ebook, audiobook or other")
+	bookUpdateCmd.Flags().Float64("height", 0, "Height of the book in millimetres")
+	bookUpdateCmd.Flags().Float64("width", 0, "Width of the book in millimetres")
+	bookUpdateCmd.Flags().Float64("thickness", 0, "Thickness of the book in millimetres")
+	bookUpdateCmd.Flags().StringArray("set", nil, "Set a custom field as name=value, repeat for several fields, an empty value unsets it")
+	bookUpdateCmd.Flags().Int("if-version", 0, "Only update the book if it is still at this version")
 
 	bookDeleteCmd.Flags().String("id", "", "ID of the book")
+	bookDeleteCmd.Flags().Int("if-version", 0, "Only delete the book if it is still at this version")
+
+	bookCmd.AddCommand(bookAddCmd, bookListCmd, bookSearchCmd, bookGetCmd, bookUpdateCmd, bookDeleteCmd)
+
+	reviewAddCmd.Flags().String("id", "", "ID of the book")
+	reviewAddCmd.Flags().Float64("rating", 0, "Rating from 1 to 5, in steps of 0.5")
+	reviewAddCmd.Flags().String("body", "", "Review text, markdown is kept as written")
+	reviewAddCmd.Flags().String("file", "", "Read the review text from a markdown file instead")
+	reviewAddCmd.Flags().String("reviewer", "", "Name of the reviewer (default the current user)")
+
+	reviewShowCmd.Flags().String("id", "", "ID of the review")
+
+	reviewListCmd.Flags().String("book-id", "", "Only list the reviews of this book")
+	reviewListCmd.Flags().String("reviewer", "", "Only list the reviews of this reviewer")
 
-	bookCmd.AddCommand(bookAddCmd, bookListCmd, bookGetCmd, bookUpdateCmd, bookDeleteCmd)
+	reviewCmd.AddCommand(reviewAddCmd, reviewShowCmd, reviewListCmd)
 }
@@ -83,7 +83,7 @@ var collectionDeleteCmd = &cobra.Command{
 
 		err = bookman.DeleteCollection(collectionID)
 		handleErr(err)
-		fmt.Println("Collection deleted successfully")
+		fmt.Printf("Collection moved to the trash, restore it with: bookman trash restore --collection %d\n", collectionID)
 	},
 }
 
@@ -143,6 +143,7 @@ func printCollectionDetails(collection models.Collection) {
 	table.Append([]string{strconv.Itoa(collection.ID), collection.Name})
 
 	table.Render()
+	fmt.Printf("Version %d\n", collection.Version)
 
 	// Print books in the collection
 	if len(collection.Books) > 0 {
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/mayank-02/bookman/pkg/client"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var copyCmd = &cobra.Command{
+	Use:   "copy",
+	Short: "Manage the copies you own of books",
+}
+
+var copyAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a copy of a book",
+	Run: func(cmd *cobra.Command, args []string) {
+		bookID := readBookID(cmd)
+		copy := models.Copy{BookID: bookID}
+		copy.Format, _ = cmd.Flags().GetString("format")
+		copy.Condition, _ = cmd.Flags().GetString("condition")
+		copy.AcquiredOn, _ = cmd.Flags().GetString("acquired")
+		copy.Barcode, _ = cmd.Flags().GetString("barcode")
+		if cmd.Flags().Changed("price") {
+			price, _ := cmd.Flags().GetFloat64("price")
+			copy.Price = &price
+		}
+		copy.LocationID = readLocationFlag(cmd, "location")
+
+		copy, err := bookman.CreateCopy(copy)
+		handleErr(err)
+		fmt.Printf("Copy %d of book %d added", copy.ID, bookID)
+		if copy.Location != "" {
+			fmt.Printf(" at %s", copy.Location)
+		}
+		fmt.Println()
+	},
+}
+
+var copyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List copies, or the copies of a book",
+	Run: func(cmd *cobra.Command, args []string) {
+		barcode, _ := cmd.Flags().GetString("barcode")
+		format, _ := cmd.Flags().GetString("format")
+
+		var copies []models.Copy
+		var err error
+		if id, _ := cmd.Flags().GetString("book-id"); id != "" {
+			bookID, err := strconv.Atoi(id)
+			if err != nil {
+				fmt.Println("Invalid ID format:", err)
+				return
+			}
+			copies, err = bookman.GetBookCopies(bookID)
+			handleErr(err)
+		} else {
+			copies, err = bookman.GetCopies(client.CopyListOptions{Barcode: barcode, Format: format, Sort: "title"})
+			handleErr(err)
+		}
+		printCopiesTable(copies)
+	},
+}
+
+var copyUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update a copy's details",
+	Long:  "Update a copy's details. Only the fields given as flags are changed, pass an empty value to clear an optional field.",
+	Run: func(cmd *cobra.Command, args []string) {
+		copyID := readCopyID(cmd)
+		copy, err := bookman.GetCopy(copyID)
+		handleErr(err)
+
+		for flag, field := range map[string]*string{
+			"format":    &copy.Format,
+			"condition": &copy.Condition,
+			"acquired":  &copy.AcquiredOn,
+			"barcode":   &copy.Barcode,
+		} {
+			if value := changedString(cmd, flag); value != nil {
+				*field = *value
+			}
+		}
+		if cmd.Flags().Changed("price") {
+			price, _ := cmd.Flags().GetFloat64("price")
+			copy.Price = &price
+		}
+		if cmd.Flags().Changed("location") {
+			copy.LocationID = readLocationFlag(cmd, "location")
+		}
+
+		copy, err = bookman.UpdateCopy(copy)
+		handleErr(err)
+		fmt.Printf("Copy %d updated\n", copy.ID)
+	},
+}
+
+var copyMoveCmd = &cobra.Command{
+	Use:   "move",
+	Short: "Move copies to another location",
+	Long:  "Move one or more copies to a location. Without --to the copies are taken out of any location.",
+	Run: func(cmd *cobra.Command, args []string) {
+		ids, _ := cmd.Flags().GetStringSlice("id")
+		var copyIDs []int
+		for _, id := range ids {
+			copyID, err := strconv.Atoi(strings.TrimSpace(id))
+			if err != nil {
+				fmt.Println("Invalid ID format:", err)
+				return
+			}
+			copyIDs = append(copyIDs, copyID)
+		}
+		if len(copyIDs) == 0 {
+			handleErr(fmt.Errorf("--id is required"))
+		}
+		locationID := readLocationFlag(cmd, "to")
+
+		err := bookman.MoveCopies(copyIDs, locationID)
+		handleErr(err)
+		if locationID == nil {
+			fmt.Printf("Moved %d copies out of their locations\n", len(copyIDs))
+			return
+		}
+		location, err := bookman.GetLocation(*locationID)
+		handleErr(err)
+		fmt.Printf("Moved %d copies to %s\n", len(copyIDs), location.Path)
+	},
+}
+
+var copyDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a copy",
+	Run: func(cmd *cobra.Command, args []string) {
+		copyID := readCopyID(cmd)
+		err := bookman.DeleteCopy(copyID)
+		handleErr(err)
+		fmt.Printf("Copy %d deleted\n", copyID)
+	},
+}
+
+func readCopyID(cmd *cobra.Command) int {
+	id, _ := cmd.Flags().GetString("id")
+	copyID, err := strconv.Atoi(id)
+	handleErr(err)
+	return copyID
+}
+
+// readLocationFlag returns the location ID given in a flag, nil when the
+// flag is empty.
+func readLocationFlag(cmd *cobra.Command, name string) *int {
+	value, _ := cmd.Flags().GetString(name)
+	if value == "" {
+		return nil
+	}
+	locationID, err := strconv.Atoi(value)
+	handleErr(err)
+	return &locationID
+}
+
+func printCopiesTable(copies []models.Copy) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Book", "Format", "Condition", "Acquired", "Price", "Barcode", "Location"})
+
+	for _, copy := range copies {
+		price := ""
+		if copy.Price != nil {
+			price = strconv.FormatFloat(*copy.Price, 'f', 2, 64)
+		}
+		table.Append([]string{
+			strconv.Itoa(copy.ID),
+			fmt.Sprintf("%s (%d)", copy.Title, copy.BookID),
+			copy.Format,
+			copy.Condition,
+			copy.AcquiredOn,
+			price,
+			copy.Barcode,
+			copy.Location,
+		})
+	}
+
+	table.Render()
+}
+
+func init() {
+	copyAddCmd.Flags().String("id", "", "ID of the book")
+	copyAddCmd.Flags().String("format", "paperback", "Format: "+strings.Join(models.CopyFormats, ", "))
+	copyUpdateCmd.Flags().String("id", "", "ID of the copy")
+	copyUpdateCmd.Flags().String("format", "", "Format: "+strings.Join(models.CopyFormats, ", "))
+	for _, cmd := range []*cobra.Command{copyAddCmd, copyUpdateCmd} {
+		cmd.Flags().String("condition", "", "Condition: "+strings.Join(models.CopyConditions, ", "))
+		cmd.Flags().String("acquired", "", "Date the copy was acquired, YYYY-MM-DD")
+		cmd.Flags().Float64("price", 0, "Price paid for the copy")
+		cmd.Flags().String("barcode", "", "Barcode or shelf label of the copy, unique across copies")
+		cmd.Flags().String("location", "", "ID of the location the copy is kept at")
+	}
+
+	copyListCmd.Flags().String("book-id", "", "Only list the copies of this book")
+	copyListCmd.Flags().String("barcode", "", "Only list the copy with this barcode")
+	copyListCmd.Flags().String("format", "", "Only list copies in this format")
+
+	copyMoveCmd.Flags().StringSlice("id", nil, "IDs of the copies, comma separated")
+	copyMoveCmd.Flags().String("to", "", "ID of the location to move the copies to")
+	copyDeleteCmd.Flags().String("id", "", "ID of the copy")
+
+	copyCmd.AddCommand(copyAddCmd, copyListCmd, copyUpdateCmd, copyMoveCmd, copyDeleteCmd)
+}
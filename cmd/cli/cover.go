@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var bookCoverCmd = &cobra.Command{
+	Use:   "cover",
+	Short: "Upload, download or remove the cover image of a book",
+}
+
+var bookCoverSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Upload a JPEG, PNG or WebP image as the cover of a book",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		bookID, err := strconv.Atoi(id)
+		handleErr(err)
+		file, _ := cmd.Flags().GetString("file")
+		if file == "" {
+			handleErr(fmt.Errorf("--file is required"))
+		}
+
+		image, err := os.ReadFile(file)
+		handleErr(err)
+		cover, err := bookman.SetBookCover(bookID, http.DetectContentType(image), image)
+		handleErr(err)
+		fmt.Printf("Cover set, %dx%d %s, %s\n", cover.Width, cover.Height, cover.ContentType, formatSize(cover.Size))
+	},
+}
+
+var bookCoverGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Download the cover of a book or one of its thumbnails",
+	Long: `Download the cover of a book or one of its thumbnails, which are JPEGs 120 (small), 300 (medium) or 600 (large) pixels wide.
+Without --output the image is saved as book-<id>.jpg, .png or .webp in the current directory, "-" writes it to standard output.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		bookID, err := strconv.Atoi(id)
+		handleErr(err)
+		size, _ := cmd.Flags().GetString("size")
+		output, _ := cmd.Flags().GetString("output")
+
+		image, contentType, err := bookman.GetBookCover(bookID, size)
+		handleErr(err)
+		if output == "-" {
+			_, err = os.Stdout.Write(image)
+			handleErr(err)
+			return
+		}
+		if output == "" {
+			extensions := map[string]string{"image/jpeg": ".jpg", "image/png": ".png", "image/webp": ".webp"}
+			output = fmt.Sprintf("book-%d%s", bookID, extensions[contentType])
+		}
+		handleErr(os.WriteFile(output, image, 0o644))
+		fmt.Printf("Cover saved to %s\n", output)
+	},
+}
+
+var bookCoverDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Remove the cover of a book",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		bookID, err := strconv.Atoi(id)
+		handleErr(err)
+
+		handleErr(bookman.DeleteBookCover(bookID))
+		fmt.Println("Cover deleted successfully")
+	},
+}
+
+// formatSize formats a number of bytes for humans.
+func formatSize(bytes int) string {
+	switch {
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(bytes)/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", bytes)
+}
+
+func init() {
+	bookCoverSetCmd.Flags().String("id", "", "ID of the book")
+	bookCoverSetCmd.Flags().String("file", "", "Path of the image to upload")
+
+	bookCoverGetCmd.Flags().String("id", "", "ID of the book")
+	bookCoverGetCmd.Flags().String("size", "", "Thumbnail to download instead of the cover: small, medium or large")
+	bookCoverGetCmd.Flags().StringP("output", "o", "", "File to save the image to, - for standard output")
+
+	bookCoverDeleteCmd.Flags().String("id", "", "ID of the book")
+
+	bookCoverCmd.AddCommand(bookCoverSetCmd, bookCoverGetCmd, bookCoverDeleteCmd)
+	bookCmd.AddCommand(bookCoverCmd)
+}
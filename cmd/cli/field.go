@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var fieldCmd = &cobra.Command{
+	Use:   "field",
+	Short: "Define custom fields for books",
+	Long: `Define custom fields for books. Fields are strings, integers (int), dates (YYYY-MM-DD), booleans (bool) or one of a list of choices (enum).
+Their values are set with "book update --set name=value".`,
+}
+
+var fieldListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all custom fields",
+	Run: func(cmd *cobra.Command, args []string) {
+		fields, err := bookman.GetCustomFields()
+		handleErr(err)
+		printFieldsTable(fields)
+	},
+}
+
+var fieldCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Define a new custom field",
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+		fieldType, _ := cmd.Flags().GetString("type")
+		choices, _ := cmd.Flags().GetStringSlice("choices")
+		pattern, _ := cmd.Flags().GetString("pattern")
+
+		field, err := bookman.CreateCustomField(models.CustomField{Name: name, Type: fieldType, Choices: choices, Pattern: pattern})
+		handleErr(err)
+		printFieldsTable([]models.CustomField{field})
+	},
+}
+
+var fieldUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Rename a custom field or change what it accepts",
+	Long: `Rename a custom field or change what it accepts. Only the flags that are given are changed.
+Values books already have must stay valid, so the type only changes while no book has a value.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		fieldID, err := strconv.Atoi(id)
+		handleErr(err)
+
+		field, err := bookman.GetCustomField(fieldID)
+		handleErr(err)
+		if value := changedString(cmd, "name"); value != nil {
+			field.Name = *value
+		}
+		if value := changedString(cmd, "type"); value != nil {
+			field.Type = *value
+		}
+		if cmd.Flags().Changed("choices") {
+			field.Choices, _ = cmd.Flags().GetStringSlice("choices")
+		}
+		if value := changedString(cmd, "pattern"); value != nil {
+			field.Pattern = *value
+		}
+
+		field, err = bookman.UpdateCustomField(field)
+		handleErr(err)
+		printFieldsTable([]models.CustomField{field})
+	},
+}
+
+var fieldDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a custom field and its values on all books",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		fieldID, err := strconv.Atoi(id)
+		handleErr(err)
+
+		err = bookman.DeleteCustomField(fieldID)
+		handleErr(err)
+		fmt.Println("Custom field deleted successfully")
+	},
+}
+
+// parseCustomFlags reads the name=value pairs given with --set and converts
+// the values to the types of their fields. An empty value unsets a field.
+func parseCustomFlags(cmd *cobra.Command) (map[string]interface{}, error) {
+	pairs, _ := cmd.Flags().GetStringArray("set")
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	fields, err := bookman.GetCustomFields()
+	if err != nil {
+		return nil, err
+	}
+	byName := map[string]models.CustomField{}
+	for _, field := range fields {
+		byName[field.Name] = field
+	}
+
+	custom := map[string]interface{}{}
+	for _, pair := range pairs {
+		name, text, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --set %q, expected name=value", pair)
+		}
+		field, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown custom field %q, see \"bookman field list\"", name)
+		}
+		custom[name], err = parseCustomValue(field, text)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return custom, nil
+}
+
+func parseCustomValue(field models.CustomField, text string) (interface{}, error) {
+	if text == "" {
+		return nil, nil
+	}
+	switch field.Type {
+	case "int":
+		value, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("custom field %q takes an integer, not %q", field.Name, text)
+		}
+		return value, nil
+	case "bool":
+		value, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("custom field %q takes true or false, not %q", field.Name, text)
+		}
+		return value, nil
+	}
+	return text, nil
+}
+
+// printCustom lists the custom values of a book by name.
+func printCustom(custom map[string]interface{}) {
+	names := make([]string, 0, len(custom))
+	for name := range custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := custom[name]
+		// Integers come back from JSON as float64
+		if number, ok := value.(float64); ok {
+			value = strconv.FormatFloat(number, 'f', -1, 64)
+		}
+		fmt.Printf("%s: %v\n", name, value)
+	}
+}
+
+func printFieldsTable(fields []models.CustomField) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Name", "Type", "Accepts", "Books"})
+
+	for _, field := range fields {
+		accepts := strings.Join(field.Choices, ", ")
+		if field.Pattern != "" {
+			accepts = field.Pattern
+		}
+		table.Append([]string{
+			strconv.Itoa(field.ID),
+			field.Name,
+			field.Type,
+			accepts,
+			strconv.Itoa(field.BookCount),
+		})
+	}
+
+	table.Render()
+}
+
+func init() {
+	fieldCreateCmd.Flags().String("name", "", "Name of the field, lower case letters, digits and underscores")
+	fieldCreateCmd.Flags().String("type", "string", "Type of the field: string, int, date, bool or enum")
+	fieldCreateCmd.Flags().StringSlice("choices", nil, "Values an enum field accepts, comma separated")
+	fieldCreateCmd.Flags().String("pattern", "", "Regular expression the whole value of a string field must match")
+
+	fieldUpdateCmd.Flags().String("id", "", "ID of the field")
+	fieldUpdateCmd.Flags().String("name", "", "New name of the field")
+	fieldUpdateCmd.Flags().String("type", "", "New type of the field")
+	fieldUpdateCmd.Flags().StringSlice("choices", nil, "Values an enum field accepts, comma separated, replacing the current ones")
+	fieldUpdateCmd.Flags().String("pattern", "", "Regular expression the whole value of a string field must match")
+
+	fieldDeleteCmd.Flags().String("id", "", "ID of the field")
+
+	fieldCmd.AddCommand(fieldCreateCmd, fieldListCmd, fieldUpdateCmd, fieldDeleteCmd)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/mayank-02/bookman/pkg/client"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var bookHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show who changed a book and its collection memberships, and what they changed",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		action, _ := cmd.Flags().GetString("action")
+		actor, _ := cmd.Flags().GetString("actor")
+		since, _ := cmd.Flags().GetString("since")
+		newest, _ := cmd.Flags().GetBool("newest")
+
+		bookID, err := strconv.Atoi(id)
+		handleErr(err)
+		entries, err := bookman.GetBookHistory(bookID, client.AuditListOptions{Action: action, Actor: actor, Since: since, Newest: newest})
+		handleErr(err)
+		if len(entries) == 0 {
+			fmt.Println("No changes were recorded")
+			return
+		}
+		printAuditTable(entries)
+	},
+}
+
+func printAuditTable(entries []models.AuditEntry) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "At", "Actor", "Action", "Changes"})
+	table.SetAutoWrapText(false)
+
+	for _, e := range entries {
+		table.Append([]string{
+			strconv.Itoa(e.ID),
+			formatTime(e.At),
+			e.Actor,
+			e.Action + " " + e.Entity,
+			formatChanges(e),
+		})
+	}
+
+	table.Render()
+}
+
+// formatChanges describes a change one field per line, like
+// `title: "Dune" -> "Dune Messiah"`, or names the collection of a
+// membership.
+func formatChanges(e models.AuditEntry) string {
+	if e.Entity == models.AuditMembership {
+		if e.Action == models.AuditCreate {
+			return fmt.Sprintf("added to collection %d", e.CollectionID)
+		}
+		return fmt.Sprintf("removed from collection %d", e.CollectionID)
+	}
+	var lines []string
+	for _, c := range e.Changes {
+		lines = append(lines, fmt.Sprintf("%s: %s -> %s", c.Field, shorten(string(c.Before)), shorten(string(c.After))))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// shorten cuts long values down to 40 characters.
+func shorten(s string) string {
+	if runes := []rune(s); len(runes) > 40 {
+		return string(runes[:39]) + "…"
+	}
+	return s
+}
+
+func init() {
+	bookHistoryCmd.Flags().String("id", "", "ID of the book")
+	bookHistoryCmd.Flags().String("action", "", "Only show changes of this kind: create, update, delete, restore or purge")
+	bookHistoryCmd.Flags().String("actor", "", "Only show changes made by this actor")
+	bookHistoryCmd.Flags().String("since", "", "Only show changes made on or after this date")
+	bookHistoryCmd.Flags().Bool("newest", false, "Show the newest changes first")
+
+	bookCmd.AddCommand(bookHistoryCmd)
+}
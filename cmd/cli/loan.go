@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/mayank-02/bookman/pkg/client"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// defaultLoanDays is the loan period when neither --due nor --days is given.
+const defaultLoanDays = 14
+
+var loanCmd = &cobra.Command{
+	Use:   "loan",
+	Short: "Lend books and keep track of who has them",
+}
+
+var loanOutCmd = &cobra.Command{
+	Use:   "out",
+	Short: "Lend a book to someone",
+	Long:  fmt.Sprintf("Lend a book to someone. The book is due on --due, or --days after it was lent (default %d).", defaultLoanDays),
+	Run: func(cmd *cobra.Command, args []string) {
+		bookID := readBookID(cmd)
+		borrower, _ := cmd.Flags().GetString("borrower")
+		contact, _ := cmd.Flags().GetString("contact")
+		loanedAt, _ := cmd.Flags().GetString("date")
+		dueAt, _ := cmd.Flags().GetString("due")
+		days, _ := cmd.Flags().GetInt("days")
+		if borrower == "" {
+			handleErr(fmt.Errorf("--borrower is required"))
+		}
+
+		if dueAt == "" {
+			start := time.Now()
+			if loanedAt != "" {
+				var err error
+				start, err = time.Parse("2006-01-02", loanedAt)
+				handleErr(err)
+			}
+			dueAt = start.AddDate(0, 0, days).Format("2006-01-02")
+		}
+
+		loan, err := bookman.CreateLoan(models.Loan{BookID: bookID, Borrower: borrower, Contact: contact, LoanedAt: loanedAt, DueAt: dueAt})
+		handleErr(err)
+		fmt.Printf("Book %d lent to %s, due on %s (loan %d)\n", bookID, loan.Borrower, loan.DueAt, loan.ID)
+	},
+}
+
+var loanReturnCmd = &cobra.Command{
+	Use:   "return",
+	Short: "Record that a lent book came back",
+	Run: func(cmd *cobra.Command, args []string) {
+		bookID := readBookID(cmd)
+		date, _ := cmd.Flags().GetString("date")
+
+		loans, err := bookman.GetBookLoans(bookID, client.LoanListOptions{Open: true})
+		handleErr(err)
+		if len(loans) == 0 {
+			handleErr(fmt.Errorf("book %d is not on loan", bookID))
+		}
+		loan, err := bookman.ReturnLoan(loans[0].ID, date)
+		handleErr(err)
+		fmt.Printf("Book %d returned by %s on %s\n", bookID, loan.Borrower, loan.ReturnedAt)
+	},
+}
+
+var loanListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List loans, or the loan history of a book",
+	Run: func(cmd *cobra.Command, args []string) {
+		borrower, _ := cmd.Flags().GetString("borrower")
+		open, _ := cmd.Flags().GetBool("open")
+		opts := client.LoanListOptions{Borrower: borrower, Open: open}
+
+		var loans []models.Loan
+		var err error
+		if id, _ := cmd.Flags().GetString("book-id"); id != "" {
+			bookID, err := strconv.Atoi(id)
+			if err != nil {
+				fmt.Println("Invalid ID format:", err)
+				return
+			}
+			loans, err = bookman.GetBookLoans(bookID, opts)
+			handleErr(err)
+		} else {
+			loans, err = bookman.GetLoans(opts)
+			handleErr(err)
+		}
+		printLoansTable(loans)
+	},
+}
+
+var loanOverdueCmd = &cobra.Command{
+	Use:   "overdue",
+	Short: "List lent books past their due date, the longest overdue first",
+	Run: func(cmd *cobra.Command, args []string) {
+		loans, err := bookman.GetLoans(client.LoanListOptions{Overdue: true, Sort: "due_at"})
+		handleErr(err)
+		if len(loans) == 0 {
+			fmt.Println("No overdue loans")
+			return
+		}
+		printLoansTable(loans)
+	},
+}
+
+func printLoansTable(loans []models.Loan) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Book", "Borrower", "Contact", "Loaned", "Due", "Returned", "Overdue"})
+
+	// Look up each book once, loan lists often repeat them
+	titles := map[int]string{}
+	for _, loan := range loans {
+		title, ok := titles[loan.BookID]
+		if !ok {
+			book, err := bookman.GetBook(loan.BookID)
+			handleErr(err)
+			title = book.Title
+			titles[loan.BookID] = title
+		}
+		table.Append([]string{
+			strconv.Itoa(loan.ID),
+			fmt.Sprintf("%s (%d)", title, loan.BookID),
+			loan.Borrower,
+			loan.Contact,
+			loan.LoanedAt,
+			loan.DueAt,
+			loan.ReturnedAt,
+			formatOverdue(loan),
+		})
+	}
+
+	table.Render()
+}
+
+// formatOverdue tells for how many days an overdue loan is late.
+func formatOverdue(loan models.Loan) string {
+	if !loan.Overdue {
+		return ""
+	}
+	due, err := time.Parse("2006-01-02", loan.DueAt)
+	if err != nil {
+		return "yes"
+	}
+	today, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	days := int(today.Sub(due).Hours() / 24)
+	if days == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{loanOutCmd, loanReturnCmd} {
+		cmd.Flags().String("id", "", "ID of the book")
+	}
+	loanOutCmd.Flags().String("borrower", "", "Name of the borrower")
+	loanOutCmd.Flags().String("contact", "", "How to reach the borrower, e.g. an email address")
+	loanOutCmd.Flags().String("date", "", "Date the book was lent, YYYY-MM-DD (default today)")
+	loanOutCmd.Flags().String("due", "", "Date the book is due back, YYYY-MM-DD")
+	loanOutCmd.Flags().Int("days", defaultLoanDays, "Days until the book is due back, unless --due is given")
+	loanReturnCmd.Flags().String("date", "", "Date the book came back, YYYY-MM-DD (default today)")
+
+	loanListCmd.Flags().String("book-id", "", "Only list the loans of this book, oldest first")
+	loanListCmd.Flags().String("borrower", "", "Only list the loans of this borrower")
+	loanListCmd.Flags().Bool("open", false, "Only list books that are still out")
+
+	loanCmd.AddCommand(loanOutCmd, loanReturnCmd, loanListCmd, loanOverdueCmd)
+}
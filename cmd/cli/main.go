@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -15,10 +16,24 @@ var (
 const Version = "v1.0.0"
 
 func handleErr(err error) {
-	if err != nil {
-		fmt.Println("Error:", err)
+	if err == nil {
+		return
+	}
+
+	// List rejected fields one per line rather than in a single sentence
+	var apiErr *client.Error
+	if errors.As(err, &apiErr) && len(apiErr.Errors) > 0 {
+		fmt.Printf("Error: failed to %s: %s\n", apiErr.Op, apiErr.Detail)
+		for _, fieldErr := range apiErr.Errors {
+			fmt.Printf("  - %s %s\n", fieldErr.Field, fieldErr.Message)
+		}
 		os.Exit(1)
 	}
+	fmt.Println("Error:", err)
+	if errors.Is(err, client.ErrPreconditionFailed) {
+		fmt.Println("It was changed in the meantime, fetch it again and retry.")
+	}
+	os.Exit(1)
 }
 
 func main() {
@@ -34,6 +49,18 @@ func main() {
 
 	rootCmd.AddCommand(bookCmd)
 	rootCmd.AddCommand(collectionCmd)
+	rootCmd.AddCommand(authorCmd)
+	rootCmd.AddCommand(publisherCmd)
+	rootCmd.AddCommand(tagCmd)
+	rootCmd.AddCommand(fieldCmd)
+	rootCmd.AddCommand(seriesCmd)
+	rootCmd.AddCommand(readCmd)
+	rootCmd.AddCommand(reviewCmd)
+	rootCmd.AddCommand(loanCmd)
+	rootCmd.AddCommand(copyCmd)
+	rootCmd.AddCommand(shelfCmd)
+	rootCmd.AddCommand(workCmd)
+	rootCmd.AddCommand(trashCmd)
 	rootCmd.AddCommand(versionCmd)
 
 	// Check for version flag in rootCmd PreRun
@@ -45,6 +72,13 @@ func main() {
 		}
 	}
 
+	// Changes are recorded in the audit log under $BOOKMAN_ACTOR, or the
+	// name of the user running bookman
+	bookman.Actor = os.Getenv("BOOKMAN_ACTOR")
+	if bookman.Actor == "" {
+		bookman.Actor = currentUser()
+	}
+
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
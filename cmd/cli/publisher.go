@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var publisherCmd = &cobra.Command{
+	Use:   "publisher",
+	Short: "Manage publishers",
+	Long:  "Manage publishers. Publishers are created by naming them on a book with --publisher.",
+}
+
+var publisherListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all publishers",
+	Run: func(cmd *cobra.Command, args []string) {
+		publishers, err := bookman.GetPublishers()
+		handleErr(err)
+		printPublishersTable(publishers)
+	},
+}
+
+var publisherGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Get a publisher and its books",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		publisherID, err := strconv.Atoi(id)
+		handleErr(err)
+
+		publisher, err := bookman.GetPublisher(publisherID)
+		handleErr(err)
+		books, err := bookman.GetPublisherBooks(publisherID)
+		handleErr(err)
+
+		fmt.Println("Publisher:")
+		printPublishersTable([]models.Publisher{publisher})
+		if len(books) > 0 {
+			fmt.Println("\nBooks:")
+			printBooksTable(books)
+		}
+	},
+}
+
+var publisherUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Rename a publisher",
+	Long:  "Rename a publisher. The publisher is renamed on all of its books.",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		publisherID, err := strconv.Atoi(id)
+		handleErr(err)
+		name, _ := cmd.Flags().GetString("name")
+
+		err = bookman.UpdatePublisher(models.Publisher{ID: publisherID, Name: name})
+		handleErr(err)
+		fmt.Println("Publisher updated successfully")
+	},
+}
+
+var publisherDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a publisher that no book names",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		publisherID, err := strconv.Atoi(id)
+		handleErr(err)
+
+		err = bookman.DeletePublisher(publisherID)
+		handleErr(err)
+		fmt.Println("Publisher deleted successfully")
+	},
+}
+
+func printPublishersTable(publishers []models.Publisher) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Name", "Books"})
+
+	for _, publisher := range publishers {
+		table.Append([]string{
+			strconv.Itoa(publisher.ID),
+			publisher.Name,
+			strconv.Itoa(publisher.BookCount),
+		})
+	}
+
+	table.Render()
+}
+
+func init() {
+	publisherGetCmd.Flags().String("id", "", "ID of the publisher")
+	publisherUpdateCmd.Flags().String("id", "", "ID of the publisher")
+	publisherUpdateCmd.Flags().String("name", "", "New name of the publisher")
+	publisherDeleteCmd.Flags().String("id", "", "ID of the publisher")
+
+	publisherCmd.AddCommand(publisherListCmd)
+	publisherCmd.AddCommand(publisherGetCmd)
+	publisherCmd.AddCommand(publisherUpdateCmd)
+	publisherCmd.AddCommand(publisherDeleteCmd)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var readCmd = &cobra.Command{
+	Use:   "read",
+	Short: "Track reading status and progress",
+}
+
+var readWantCmd = &cobra.Command{
+	Use:   "want",
+	Short: "Put a book on the want-to-read list",
+	Run: func(cmd *cobra.Command, args []string) {
+		bookID := readBookID(cmd)
+
+		_, err := bookman.CreateReadingSession(models.ReadingSession{BookID: bookID, Status: "want-to-read"})
+		handleErr(err)
+		fmt.Printf("Book %d is on the want-to-read list\n", bookID)
+	},
+}
+
+var readStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start reading a book",
+	Long:  "Start reading a book. A book on the want-to-read list is started, a book read before gets a new session for the re-read.",
+	Run: func(cmd *cobra.Command, args []string) {
+		bookID := readBookID(cmd)
+		date, _ := cmd.Flags().GetString("date")
+
+		log, err := bookman.GetReadingLog(bookID)
+		handleErr(err)
+		session := models.ReadingSession{BookID: bookID, Status: "reading", StartedOn: date}
+		if log.Status == "want-to-read" {
+			session.ID = log.Sessions[len(log.Sessions)-1].ID
+			session, err = bookman.UpdateReadingSession(session)
+		} else {
+			session, err = bookman.CreateReadingSession(session)
+		}
+		handleErr(err)
+		fmt.Printf("Started reading book %d on %s\n", bookID, session.StartedOn)
+	},
+}
+
+var readProgressCmd = &cobra.Command{
+	Use:   "progress",
+	Short: "Record how far you got in the book you are reading",
+	Run: func(cmd *cobra.Command, args []string) {
+		bookID := readBookID(cmd)
+		var progress models.ReadingProgress
+		if cmd.Flags().Changed("page") {
+			page, _ := cmd.Flags().GetInt("page")
+			progress.Page = &page
+		}
+		if cmd.Flags().Changed("percent") {
+			percent, _ := cmd.Flags().GetFloat64("percent")
+			progress.Percent = &percent
+		}
+
+		session, err := currentSession(bookID)
+		handleErr(err)
+		session, err = bookman.AddReadingProgress(bookID, session.ID, progress)
+		handleErr(err)
+		fmt.Printf("Recorded progress: %s\n", formatProgress(session.Progress))
+	},
+}
+
+var readFinishCmd = &cobra.Command{
+	Use:   "finish",
+	Short: "Finish or abandon the book you are reading",
+	Run: func(cmd *cobra.Command, args []string) {
+		bookID := readBookID(cmd)
+		date, _ := cmd.Flags().GetString("date")
+		status := "finished"
+		if abandon, _ := cmd.Flags().GetBool("abandon"); abandon {
+			status = "abandoned"
+		}
+
+		session, err := currentSession(bookID)
+		handleErr(err)
+		session.Status, session.FinishedOn = status, date
+		session, err = bookman.UpdateReadingSession(session)
+		handleErr(err)
+		fmt.Printf("Book %d %s on %s\n", bookID, status, session.FinishedOn)
+	},
+}
+
+var readLogCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Show every reading session of a book",
+	Run: func(cmd *cobra.Command, args []string) {
+		bookID := readBookID(cmd)
+
+		log, err := bookman.GetReadingLog(bookID)
+		handleErr(err)
+		printReadingLogTable(log)
+	},
+}
+
+func readBookID(cmd *cobra.Command) int {
+	id, _ := cmd.Flags().GetString("id")
+	bookID, err := strconv.Atoi(id)
+	handleErr(err)
+	return bookID
+}
+
+// currentSession returns the session of a book that is being read.
+func currentSession(bookID int) (models.ReadingSession, error) {
+	log, err := bookman.GetReadingLog(bookID)
+	if err != nil {
+		return models.ReadingSession{}, err
+	}
+	if log.Status != "reading" {
+		return models.ReadingSession{}, fmt.Errorf("book %d is not being read, start it with bookman read start", bookID)
+	}
+	return log.Sessions[len(log.Sessions)-1], nil
+}
+
+// formatProgress describes the latest progress entry, like "p. 120 (45%)".
+func formatProgress(progress []models.ReadingProgress) string {
+	if len(progress) == 0 {
+		return ""
+	}
+	p := progress[len(progress)-1]
+	switch {
+	case p.Page != nil && p.Percent != nil:
+		return fmt.Sprintf("p. %d (%s%%)", *p.Page, strconv.FormatFloat(*p.Percent, 'f', -1, 64))
+	case p.Page != nil:
+		return fmt.Sprintf("p. %d", *p.Page)
+	default:
+		return strconv.FormatFloat(*p.Percent, 'f', -1, 64) + "%"
+	}
+}
+
+func printReadingLogTable(log models.ReadingLog) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Session", "Status", "Started", "Finished", "Progress", "Updates"})
+
+	for _, s := range log.Sessions {
+		table.Append([]string{
+			strconv.Itoa(s.ID),
+			s.Status,
+			s.StartedOn,
+			s.FinishedOn,
+			formatProgress(s.Progress),
+			strconv.Itoa(len(s.Progress)),
+		})
+	}
+
+	table.Render()
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{readWantCmd, readStartCmd, readProgressCmd, readFinishCmd, readLogCmd} {
+		cmd.Flags().String("id", "", "ID of the book")
+	}
+	readStartCmd.Flags().String("date", "", "Date the reading started, YYYY-MM-DD (default today)")
+	readProgressCmd.Flags().Int("page", 0, "Page reached")
+	readProgressCmd.Flags().Float64("percent", 0, "Percentage of the book read")
+	readFinishCmd.Flags().String("date", "", "Date the reading ended, YYYY-MM-DD (default today)")
+	readFinishCmd.Flags().Bool("abandon", false, "Abandon the book instead of finishing it")
+
+	readCmd.AddCommand(readWantCmd, readStartCmd, readProgressCmd, readFinishCmd, readLogCmd)
+}
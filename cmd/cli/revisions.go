@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var bookRevisionsCmd = &cobra.Command{
+	Use:   "revisions",
+	Short: "List the revisions of a book, which it can be reverted to",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		bookID, err := strconv.Atoi(id)
+		handleErr(err)
+
+		revisions, err := bookman.GetBookRevisions(bookID)
+		handleErr(err)
+		if len(revisions) == 0 {
+			fmt.Println("The book has not been changed since revisions are kept")
+			return
+		}
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Revision", "At", "Actor", "Title", "Author"})
+		for _, r := range revisions {
+			table.Append([]string{strconv.Itoa(r.Revision), formatTime(r.At), r.Actor, r.Book.Title, r.Book.Author})
+		}
+		table.Render()
+	},
+}
+
+var bookRevertCmd = &cobra.Command{
+	Use:   "revert",
+	Short: "Revert a book's information to an earlier revision",
+	Long: `Revert a book's information to an earlier revision, as listed by "book revisions".
+The changes are shown and confirmed before they are applied, unless --yes is given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		revision, _ := cmd.Flags().GetInt("to")
+		yes, _ := cmd.Flags().GetBool("yes")
+		bookID, err := strconv.Atoi(id)
+		handleErr(err)
+		if revision < 1 {
+			handleErr(fmt.Errorf("--to must be a revision number, starting at 1"))
+		}
+
+		// The revert only applies to the version the preview was made of
+		book, err := bookman.GetBook(bookID)
+		handleErr(err)
+		diff, err := bookman.DiffBookRevisions(bookID, 0, revision)
+		handleErr(err)
+		if len(diff.Changes) == 0 {
+			fmt.Printf("Book %d already matches revision %d\n", bookID, revision)
+			return
+		}
+		printDiffTable(diff)
+		if !yes && !confirm(fmt.Sprintf("Revert book %d to revision %d?", bookID, revision)) {
+			fmt.Println("Nothing was changed")
+			return
+		}
+
+		book, err = bookman.RestoreBookRevision(bookID, revision, book.Version)
+		handleErr(err)
+		fmt.Printf("Book reverted to revision %d, now at version %d\n", revision, book.Version)
+	},
+}
+
+var collectionRevisionsCmd = &cobra.Command{
+	Use:   "revisions",
+	Short: "List the revisions of a collection, which it can be reverted to",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		collectionID, err := strconv.Atoi(id)
+		handleErr(err)
+
+		revisions, err := bookman.GetCollectionRevisions(collectionID)
+		handleErr(err)
+		if len(revisions) == 0 {
+			fmt.Println("The collection has not been changed since revisions are kept")
+			return
+		}
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Revision", "At", "Actor", "Name", "Books"})
+		for _, r := range revisions {
+			var books []string
+			for _, bookID := range r.BookIDs {
+				books = append(books, strconv.Itoa(bookID))
+			}
+			table.Append([]string{strconv.Itoa(r.Revision), formatTime(r.At), r.Actor, r.Name, strings.Join(books, ", ")})
+		}
+		table.Render()
+	},
+}
+
+var collectionRevertCmd = &cobra.Command{
+	Use:   "revert",
+	Short: "Revert a collection's name and books to an earlier revision",
+	Long: `Revert a collection's name and books to an earlier revision, as listed by "collection revisions".
+The changes are shown and confirmed before they are applied, unless --yes is given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		revision, _ := cmd.Flags().GetInt("to")
+		yes, _ := cmd.Flags().GetBool("yes")
+		collectionID, err := strconv.Atoi(id)
+		handleErr(err)
+		if revision < 1 {
+			handleErr(fmt.Errorf("--to must be a revision number, starting at 1"))
+		}
+
+		collection, err := bookman.GetCollection(collectionID)
+		handleErr(err)
+		diff, err := bookman.DiffCollectionRevisions(collectionID, 0, revision)
+		handleErr(err)
+		if len(diff.Changes) == 0 {
+			fmt.Printf("Collection %d already matches revision %d\n", collectionID, revision)
+			return
+		}
+		printDiffTable(diff)
+		if !yes && !confirm(fmt.Sprintf("Revert collection %d to revision %d?", collectionID, revision)) {
+			fmt.Println("Nothing was changed")
+			return
+		}
+
+		collection, err = bookman.RestoreCollectionRevision(collectionID, revision, collection.Version)
+		handleErr(err)
+		fmt.Printf("Collection reverted to revision %d, it has %d books\n", revision, len(collection.Books))
+	},
+}
+
+// printDiffTable shows the fields that differ between the current state and
+// the revision a diff is to.
+func printDiffTable(diff models.RevisionDiff) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Field", "Now", fmt.Sprintf("Revision %d", diff.To)})
+	table.SetAutoWrapText(false)
+	for _, c := range diff.Changes {
+		table.Append([]string{c.Field, shorten(string(c.Before)), shorten(string(c.After))})
+	}
+	table.Render()
+}
+
+// confirm asks a question on the terminal, the answer is no unless it
+// starts with a y.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "y")
+}
+
+func init() {
+	bookRevisionsCmd.Flags().String("id", "", "ID of the book")
+	bookRevertCmd.Flags().String("id", "", "ID of the book")
+	bookRevertCmd.Flags().Int("to", 0, "Revision to revert to")
+	bookRevertCmd.Flags().Bool("yes", false, "Revert without asking")
+	bookRevertCmd.MarkFlagRequired("to")
+	collectionRevisionsCmd.Flags().String("id", "", "ID of the collection")
+	collectionRevertCmd.Flags().String("id", "", "ID of the collection")
+	collectionRevertCmd.Flags().Int("to", 0, "Revision to revert to")
+	collectionRevertCmd.Flags().Bool("yes", false, "Revert without asking")
+	collectionRevertCmd.MarkFlagRequired("to")
+
+	bookCmd.AddCommand(bookRevisionsCmd)
+	bookCmd.AddCommand(bookRevertCmd)
+	collectionCmd.AddCommand(collectionRevisionsCmd)
+	collectionCmd.AddCommand(collectionRevertCmd)
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var seriesCmd = &cobra.Command{
+	Use:   "series",
+	Short: "Manage book series",
+}
+
+var seriesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all series",
+	Run: func(cmd *cobra.Command, args []string) {
+		series, err := bookman.GetAllSeries()
+		handleErr(err)
+		printSeriesTable(series)
+	},
+}
+
+var seriesCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a new series",
+	Run: func(cmd *cobra.Command, args []string) {
+		var series models.Series
+		series.Name, _ = cmd.Flags().GetString("name")
+		series.Description, _ = cmd.Flags().GetString("description")
+
+		created, err := bookman.CreateSeries(series)
+		handleErr(err)
+		printSeriesTable([]models.Series{created})
+	},
+}
+
+var seriesGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "List the volumes of a series in order",
+	Long:  "List the volumes of a series in order. Whole positions without a volume are shown as missing.",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		seriesID, err := strconv.Atoi(id)
+		handleErr(err)
+
+		series, err := bookman.GetSeries(seriesID)
+		handleErr(err)
+		printSeriesTable([]models.Series{series})
+		printVolumesTable(series)
+	},
+}
+
+var seriesUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update the name or description of a series",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		seriesID, err := strconv.Atoi(id)
+		handleErr(err)
+
+		series, err := bookman.GetSeries(seriesID)
+		handleErr(err)
+		if name := changedString(cmd, "name"); name != nil {
+			series.Name = *name
+		}
+		if description := changedString(cmd, "description"); description != nil {
+			series.Description = *description
+		}
+
+		series, err = bookman.UpdateSeries(seriesID, series)
+		handleErr(err)
+		printSeriesTable([]models.Series{series})
+	},
+}
+
+var seriesDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a series, keeping its books",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		seriesID, err := strconv.Atoi(id)
+		handleErr(err)
+
+		err = bookman.DeleteSeries(seriesID)
+		handleErr(err)
+		fmt.Println("Series deleted successfully")
+	},
+}
+
+var seriesAddBookCmd = &cobra.Command{
+	Use:   "add-book",
+	Short: "Add a book to a series, or move it to another position",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("series-id")
+		seriesID, err := strconv.Atoi(id)
+		handleErr(err)
+		id, _ = cmd.Flags().GetString("book-id")
+		bookID, err := strconv.Atoi(id)
+		handleErr(err)
+		position, _ := cmd.Flags().GetFloat64("position")
+
+		err = bookman.SetSeriesBook(seriesID, bookID, position)
+		handleErr(err)
+		fmt.Printf("Book is now number %s of the series\n", formatPosition(position))
+	},
+}
+
+var seriesRemoveBookCmd = &cobra.Command{
+	Use:   "remove-book",
+	Short: "Remove a book from a series",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("series-id")
+		seriesID, err := strconv.Atoi(id)
+		handleErr(err)
+		id, _ = cmd.Flags().GetString("book-id")
+		bookID, err := strconv.Atoi(id)
+		handleErr(err)
+
+		err = bookman.RemoveSeriesBook(seriesID, bookID)
+		handleErr(err)
+		fmt.Println("Book removed from the series successfully")
+	},
+}
+
+// formatPosition prints positions without trailing zeros, 3 rather than 3.000000.
+func formatPosition(position float64) string {
+	return strconv.FormatFloat(position, 'f', -1, 64)
+}
+
+func printSeriesTable(series []models.Series) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Name", "Description"})
+
+	for _, s := range series {
+		table.Append([]string{
+			strconv.Itoa(s.ID),
+			s.Name,
+			s.Description,
+		})
+	}
+
+	table.Render()
+}
+
+// printVolumesTable lists the books of the series by position, with a red
+// row for each whole position that has no book.
+func printVolumesTable(series models.Series) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Position", "ID", "Title", "Author", "Published Date"})
+	missing := []tablewriter.Colors{{tablewriter.FgRedColor}, {tablewriter.FgRedColor}, {tablewriter.FgRedColor}, {}, {}}
+
+	gaps := series.Missing
+	for _, sb := range series.Books {
+		for len(gaps) > 0 && float64(gaps[0]) < sb.Position {
+			table.Rich([]string{strconv.Itoa(gaps[0]), "-", "missing", "", ""}, missing)
+			gaps = gaps[1:]
+		}
+		table.Append([]string{
+			formatPosition(sb.Position),
+			strconv.Itoa(sb.Book.ID),
+			sb.Book.Title,
+			sb.Book.Author,
+			sb.Book.PublishedDate,
+		})
+	}
+
+	table.Render()
+	if len(series.Missing) > 0 {
+		positions := make([]string, len(series.Missing))
+		for i, p := range series.Missing {
+			positions[i] = strconv.Itoa(p)
+		}
+		fmt.Printf("Missing volumes: %s\n", strings.Join(positions, ", "))
+	}
+}
+
+func init() {
+	seriesCreateCmd.Flags().String("name", "", "Name of the series")
+	seriesCreateCmd.Flags().String("description", "", "Description of the series")
+
+	seriesGetCmd.Flags().String("id", "", "ID of the series")
+
+	seriesUpdateCmd.Flags().String("id", "", "ID of the series")
+	seriesUpdateCmd.Flags().String("name", "", "New name of the series")
+	seriesUpdateCmd.Flags().String("description", "", "New description of the series")
+
+	seriesDeleteCmd.Flags().String("id", "", "ID of the series")
+
+	seriesAddBookCmd.Flags().String("series-id", "", "ID of the series")
+	seriesAddBookCmd.Flags().String("book-id", "", "ID of the book")
+	seriesAddBookCmd.Flags().Float64("position", 0, "Position of the book in the series, fractions like 2.5 allowed")
+	seriesRemoveBookCmd.Flags().String("series-id", "", "ID of the series")
+	seriesRemoveBookCmd.Flags().String("book-id", "", "ID of the book")
+
+	seriesCmd.AddCommand(seriesListCmd, seriesCreateCmd, seriesGetCmd, seriesUpdateCmd, seriesDeleteCmd, seriesAddBookCmd, seriesRemoveBookCmd)
+}
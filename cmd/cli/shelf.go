@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var shelfCmd = &cobra.Command{
+	Use:   "shelf",
+	Short: "Manage the rooms, bookcases and shelves copies are kept at",
+}
+
+var shelfListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all locations as a tree",
+	Run: func(cmd *cobra.Command, args []string) {
+		locations, err := bookman.GetLocations()
+		handleErr(err)
+		if len(locations) == 0 {
+			fmt.Println("No locations yet, add one with bookman shelf create")
+			return
+		}
+
+		children := map[int][]models.Location{}
+		for _, location := range locations {
+			parentID := 0
+			if location.ParentID != nil {
+				parentID = *location.ParentID
+			}
+			children[parentID] = append(children[parentID], location)
+		}
+		printLocationTree(children, 0, "")
+	},
+}
+
+// printLocationTree prints the locations inside parentID, sorted by name,
+// each followed by the locations inside it.
+func printLocationTree(children map[int][]models.Location, parentID int, indent string) {
+	locations := children[parentID]
+	sort.Slice(locations, func(i, j int) bool { return locations[i].Name < locations[j].Name })
+	for _, location := range locations {
+		fmt.Printf("%s%s (%s %d)\n", indent, location.Name, location.Kind, location.ID)
+		printLocationTree(children, location.ID, indent+"  ")
+	}
+}
+
+var shelfCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a room, bookcase or shelf",
+	Run: func(cmd *cobra.Command, args []string) {
+		location := models.Location{ParentID: readLocationFlag(cmd, "parent")}
+		location.Name, _ = cmd.Flags().GetString("name")
+		location.Kind, _ = cmd.Flags().GetString("kind")
+
+		location, err := bookman.CreateLocation(location)
+		handleErr(err)
+		fmt.Printf("Location %d created: %s\n", location.ID, location.Path)
+	},
+}
+
+var shelfUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Rename a location or move it elsewhere",
+	Long:  "Rename a location, change its kind or move it into another one with --parent, pass an empty --parent to make it top-level. Everything inside it moves along.",
+	Run: func(cmd *cobra.Command, args []string) {
+		locationID := readLocationID(cmd)
+		location, err := bookman.GetLocation(locationID)
+		handleErr(err)
+
+		if value := changedString(cmd, "name"); value != nil {
+			location.Name = *value
+		}
+		if value := changedString(cmd, "kind"); value != nil {
+			location.Kind = *value
+		}
+		if cmd.Flags().Changed("parent") {
+			location.ParentID = readLocationFlag(cmd, "parent")
+		}
+
+		location, err = bookman.UpdateLocation(location)
+		handleErr(err)
+		fmt.Printf("Location %d updated: %s\n", location.ID, location.Path)
+	},
+}
+
+var shelfDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete an empty location",
+	Run: func(cmd *cobra.Command, args []string) {
+		locationID := readLocationID(cmd)
+		err := bookman.DeleteLocation(locationID)
+		handleErr(err)
+		fmt.Printf("Location %d deleted\n", locationID)
+	},
+}
+
+var shelfShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "List the copies at a location",
+	Run: func(cmd *cobra.Command, args []string) {
+		locationID := readLocationID(cmd)
+		recursive, _ := cmd.Flags().GetBool("recursive")
+		location, err := bookman.GetLocation(locationID)
+		handleErr(err)
+		copies, err := bookman.GetLocationCopies(locationID, recursive)
+		handleErr(err)
+
+		fmt.Printf("%s (%s)\n", location.Path, location.Kind)
+		if len(copies) == 0 {
+			fmt.Println("No copies here")
+			return
+		}
+		printCopiesTable(copies)
+	},
+}
+
+var shelfCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check the inventory of a location against scanned barcodes",
+	Long: `Compare the barcodes found at a location, and the locations inside it, with the copies recorded there.
+Barcodes are given with --barcodes or read from --file, one per line ("-" reads standard input).
+With --apply copies found here but recorded elsewhere are moved here.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		locationID := readLocationID(cmd)
+		barcodes, _ := cmd.Flags().GetStringSlice("barcodes")
+		apply, _ := cmd.Flags().GetBool("apply")
+		if file, _ := cmd.Flags().GetString("file"); file != "" {
+			scanned, err := readBarcodes(file)
+			handleErr(err)
+			barcodes = append(barcodes, scanned...)
+		}
+
+		report, err := bookman.CheckInventory(locationID, barcodes, apply)
+		handleErr(err)
+
+		fmt.Printf("Found: %d, missing: %d, misplaced: %d, unknown: %d\n", len(report.Found), len(report.Missing), len(report.Misplaced), len(report.Unknown))
+		if len(report.Missing) > 0 {
+			fmt.Println("\nMissing, recorded here but not found:")
+			printCopiesTable(report.Missing)
+		}
+		if len(report.Misplaced) > 0 {
+			if report.Applied {
+				fmt.Println("\nMisplaced, found here and moved here from:")
+			} else {
+				fmt.Println("\nMisplaced, found here but recorded at (use --apply to move them here):")
+			}
+			printCopiesTable(report.Misplaced)
+		}
+		if len(report.Unknown) > 0 {
+			fmt.Println("\nUnknown barcodes:", strings.Join(report.Unknown, ", "))
+		}
+	},
+}
+
+// readBarcodes reads one barcode per line from a file, or from standard
+// input for "-", skipping blank lines.
+func readBarcodes(file string) ([]string, error) {
+	f := os.Stdin
+	if file != "-" {
+		var err error
+		f, err = os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+	}
+	var barcodes []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			barcodes = append(barcodes, line)
+		}
+	}
+	return barcodes, scanner.Err()
+}
+
+func readLocationID(cmd *cobra.Command) int {
+	id, _ := cmd.Flags().GetString("id")
+	locationID, err := strconv.Atoi(id)
+	handleErr(err)
+	return locationID
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{shelfUpdateCmd, shelfDeleteCmd, shelfShowCmd, shelfCheckCmd} {
+		cmd.Flags().String("id", "", "ID of the location")
+	}
+	for _, cmd := range []*cobra.Command{shelfCreateCmd, shelfUpdateCmd} {
+		cmd.Flags().String("name", "", "Name of the location, e.g. \"Top shelf\"")
+		cmd.Flags().String("parent", "", "ID of the location it is inside of")
+	}
+	shelfCreateCmd.Flags().String("kind", "shelf", "Kind: "+strings.Join(models.LocationKinds, ", "))
+	shelfUpdateCmd.Flags().String("kind", "", "Kind: "+strings.Join(models.LocationKinds, ", "))
+
+	shelfShowCmd.Flags().Bool("recursive", false, "Also list the copies in the locations inside it")
+	shelfCheckCmd.Flags().StringSlice("barcodes", nil, "Barcodes found at the location, comma separated")
+	shelfCheckCmd.Flags().String("file", "", "File with the barcodes found, one per line")
+	shelfCheckCmd.Flags().Bool("apply", false, "Move misplaced copies to the location")
+
+	shelfCmd.AddCommand(shelfListCmd, shelfCreateCmd, shelfUpdateCmd, shelfDeleteCmd, shelfShowCmd, shelfCheckCmd)
+}
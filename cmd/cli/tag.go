@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "Manage tags",
+}
+
+var tagListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all tags with the number of books having them",
+	Run: func(cmd *cobra.Command, args []string) {
+		tags, err := bookman.GetTags()
+		handleErr(err)
+		printTagsTable(tags)
+	},
+}
+
+var tagCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a new tag",
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+
+		tag, err := bookman.CreateTag(models.Tag{Name: name})
+		handleErr(err)
+		printTagsTable([]models.Tag{tag})
+	},
+}
+
+var tagRenameCmd = &cobra.Command{
+	Use:   "rename",
+	Short: "Rename a tag on all of its books",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		tagID, err := strconv.Atoi(id)
+		handleErr(err)
+		name, _ := cmd.Flags().GetString("name")
+
+		tag, err := bookman.RenameTag(tagID, name)
+		handleErr(err)
+		printTagsTable([]models.Tag{tag})
+	},
+}
+
+var tagMergeCmd = &cobra.Command{
+	Use:   "merge",
+	Short: "Merge a tag into another one",
+	Long:  "Merge a tag into another one. The books of the tag get the other tag instead, and the tag is deleted.",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		tagID, err := strconv.Atoi(id)
+		handleErr(err)
+		into, _ := cmd.Flags().GetString("into")
+		intoID, err := strconv.Atoi(into)
+		handleErr(err)
+
+		tag, err := bookman.MergeTags(tagID, intoID)
+		handleErr(err)
+		printTagsTable([]models.Tag{tag})
+	},
+}
+
+var tagDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a tag and remove it from its books",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		tagID, err := strconv.Atoi(id)
+		handleErr(err)
+
+		err = bookman.DeleteTag(tagID)
+		handleErr(err)
+		fmt.Println("Tag deleted successfully")
+	},
+}
+
+var tagAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add tags to several books at once",
+	Run: func(cmd *cobra.Command, args []string) {
+		bookIDs, _ := cmd.Flags().GetIntSlice("books")
+		tags, _ := cmd.Flags().GetStringSlice("tags")
+
+		err := bookman.TagBooks(bookIDs, tags)
+		handleErr(err)
+		fmt.Printf("Tagged %d books\n", len(bookIDs))
+	},
+}
+
+var tagRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove tags from several books at once",
+	Run: func(cmd *cobra.Command, args []string) {
+		bookIDs, _ := cmd.Flags().GetIntSlice("books")
+		tags, _ := cmd.Flags().GetStringSlice("tags")
+
+		err := bookman.UntagBooks(bookIDs, tags)
+		handleErr(err)
+		fmt.Printf("Untagged %d books\n", len(bookIDs))
+	},
+}
+
+func printTagsTable(tags []models.Tag) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Name", "Books"})
+
+	for _, tag := range tags {
+		table.Append([]string{
+			strconv.Itoa(tag.ID),
+			tag.Name,
+			strconv.Itoa(tag.Books),
+		})
+	}
+
+	table.Render()
+}
+
+func init() {
+	tagCreateCmd.Flags().String("name", "", "Name of the tag")
+
+	tagRenameCmd.Flags().String("id", "", "ID of the tag")
+	tagRenameCmd.Flags().String("name", "", "New name of the tag")
+
+	tagMergeCmd.Flags().String("id", "", "ID of the tag to merge")
+	tagMergeCmd.Flags().String("into", "", "ID of the tag to merge into")
+
+	tagDeleteCmd.Flags().String("id", "", "ID of the tag")
+
+	tagAddCmd.Flags().IntSlice("books", nil, "IDs of the books, comma separated")
+	tagAddCmd.Flags().StringSlice("tags", nil, "Tags to add, comma separated")
+	tagRemoveCmd.Flags().IntSlice("books", nil, "IDs of the books, comma separated")
+	tagRemoveCmd.Flags().StringSlice("tags", nil, "Tags to remove, comma separated")
+
+	tagCmd.AddCommand(tagListCmd, tagCreateCmd, tagRenameCmd, tagMergeCmd, tagDeleteCmd, tagAddCmd, tagRemoveCmd)
+}
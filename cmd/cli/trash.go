@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List, restore and purge deleted books and collections",
+}
+
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the books and collections in the trash",
+	Run: func(cmd *cobra.Command, args []string) {
+		kind, _ := cmd.Flags().GetString("type")
+
+		items, err := bookman.GetTrash(kind)
+		handleErr(err)
+		if len(items) == 0 {
+			fmt.Println("The trash is empty")
+			return
+		}
+		printTrashTable(items)
+	},
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore a book or a collection from the trash",
+	Run: func(cmd *cobra.Command, args []string) {
+		book, _ := cmd.Flags().GetString("book")
+		collection, _ := cmd.Flags().GetString("collection")
+		if (book == "") == (collection == "") {
+			fmt.Println("Either --book or --collection is required")
+			os.Exit(1)
+		}
+
+		if book != "" {
+			bookID, err := strconv.Atoi(book)
+			handleErr(err)
+			restored, err := bookman.RestoreBook(bookID)
+			handleErr(err)
+			fmt.Printf("Book %q restored, now at version %d\n", restored.Title, restored.Version)
+			return
+		}
+		collectionID, err := strconv.Atoi(collection)
+		handleErr(err)
+		restored, err := bookman.RestoreCollection(collectionID)
+		handleErr(err)
+		fmt.Printf("Collection %q restored with %d books\n", restored.Name, len(restored.Books))
+	},
+}
+
+var trashPurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Permanently delete the books and collections in the trash",
+	Run: func(cmd *cobra.Command, args []string) {
+		days, _ := cmd.Flags().GetInt("older-than")
+
+		items, err := bookman.PurgeTrash(days)
+		handleErr(err)
+		if len(items) == 0 {
+			fmt.Println("Nothing to purge")
+			return
+		}
+		printTrashTable(items)
+		fmt.Printf("Purged %d items\n", len(items))
+	},
+}
+
+func printTrashTable(items []models.TrashItem) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Type", "ID", "Name", "Memberships", "Deleted At"})
+
+	for _, item := range items {
+		name := item.Name
+		if item.Book != nil && item.Book.Author != "" {
+			name += " by " + item.Book.Author
+		}
+		table.Append([]string{
+			item.Type,
+			strconv.Itoa(item.ID),
+			name,
+			strconv.Itoa(item.Memberships),
+			formatTime(item.DeletedAt),
+		})
+	}
+
+	table.Render()
+}
+
+func init() {
+	trashListCmd.Flags().String("type", "", "Only list items of this type, book or collection")
+	trashRestoreCmd.Flags().String("book", "", "ID of the book to restore")
+	trashRestoreCmd.Flags().String("collection", "", "ID of the collection to restore")
+	trashPurgeCmd.Flags().Int("older-than", 0, "Only purge items that have been in the trash for at least this many days")
+
+	trashCmd.AddCommand(trashListCmd)
+	trashCmd.AddCommand(trashRestoreCmd)
+	trashCmd.AddCommand(trashPurgeCmd)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var workCmd = &cobra.Command{
+	Use:   "work",
+	Short: "Group editions and translations of the same book into works",
+}
+
+var workListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all works",
+	Run: func(cmd *cobra.Command, args []string) {
+		works, err := bookman.GetWorks()
+		handleErr(err)
+		printWorksTable(works)
+	},
+}
+
+var workCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a work, optionally with its editions",
+	Run: func(cmd *cobra.Command, args []string) {
+		var work models.Work
+		work.Title, _ = cmd.Flags().GetString("title")
+		work.OriginalLanguage, _ = cmd.Flags().GetString("language")
+		work.OriginalPublishedDate, _ = cmd.Flags().GetString("published")
+		bookIDs, _ := cmd.Flags().GetIntSlice("books")
+
+		work, err := bookman.CreateWork(work)
+		handleErr(err)
+		if len(bookIDs) > 0 {
+			work, err = bookman.MergeEditions(work.ID, bookIDs)
+			handleErr(err)
+		}
+		printWorksTable([]models.Work{work})
+	},
+}
+
+var workGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Get a work and its editions, oldest first",
+	Run: func(cmd *cobra.Command, args []string) {
+		workID := readWorkID(cmd)
+		work, err := bookman.GetWork(workID)
+		handleErr(err)
+		editions, err := bookman.GetWorkEditions(workID)
+		handleErr(err)
+
+		printWorksTable([]models.Work{work})
+		if len(editions) > 0 {
+			printBooksTable(editions)
+		}
+	},
+}
+
+var workUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update the title, original language or original publication date of a work",
+	Run: func(cmd *cobra.Command, args []string) {
+		workID := readWorkID(cmd)
+		work, err := bookman.GetWork(workID)
+		handleErr(err)
+		if value := changedString(cmd, "title"); value != nil {
+			work.Title = *value
+		}
+		if value := changedString(cmd, "language"); value != nil {
+			work.OriginalLanguage = *value
+		}
+		if value := changedString(cmd, "published"); value != nil {
+			work.OriginalPublishedDate = *value
+		}
+
+		work, err = bookman.UpdateWork(work)
+		handleErr(err)
+		printWorksTable([]models.Work{work})
+	},
+}
+
+var workDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a work, keeping its editions as separate books",
+	Run: func(cmd *cobra.Command, args []string) {
+		workID := readWorkID(cmd)
+		err := bookman.DeleteWork(workID)
+		handleErr(err)
+		fmt.Printf("Work %d deleted\n", workID)
+	},
+}
+
+var workMergeCmd = &cobra.Command{
+	Use:   "merge",
+	Short: "Make books editions of a work",
+	Long:  "Make books editions of a work, taking them from the works they were editions of. Works left without editions are deleted.",
+	Run: func(cmd *cobra.Command, args []string) {
+		workID := readWorkID(cmd)
+		bookIDs, _ := cmd.Flags().GetIntSlice("books")
+
+		work, err := bookman.MergeEditions(workID, bookIDs)
+		handleErr(err)
+		fmt.Printf("%s now has %d editions\n", work.Title, work.EditionCount)
+	},
+}
+
+var workSplitCmd = &cobra.Command{
+	Use:   "split",
+	Short: "Move editions of a work into a new work",
+	Long:  "Move editions of a work into a new work, named after the first of them. At least one edition has to stay with the work.",
+	Run: func(cmd *cobra.Command, args []string) {
+		workID := readWorkID(cmd)
+		bookIDs, _ := cmd.Flags().GetIntSlice("books")
+
+		work, err := bookman.SplitEditions(workID, bookIDs)
+		handleErr(err)
+		printWorksTable([]models.Work{work})
+	},
+}
+
+func readWorkID(cmd *cobra.Command) int {
+	id, _ := cmd.Flags().GetString("id")
+	workID, err := strconv.Atoi(id)
+	handleErr(err)
+	return workID
+}
+
+func printWorksTable(works []models.Work) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Title", "Original Language", "First Published", "Editions"})
+
+	for _, work := range works {
+		table.Append([]string{
+			strconv.Itoa(work.ID),
+			work.Title,
+			work.OriginalLanguage,
+			work.OriginalPublishedDate,
+			strconv.Itoa(work.EditionCount),
+		})
+	}
+
+	table.Render()
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{workGetCmd, workUpdateCmd, workDeleteCmd, workMergeCmd, workSplitCmd} {
+		cmd.Flags().String("id", "", "ID of the work")
+	}
+	for _, cmd := range []*cobra.Command{workCreateCmd, workUpdateCmd} {
+		cmd.Flags().String("title", "", "Title of the work, usually that of its first edition")
+		cmd.Flags().String("language", "", "Language the work was written in, e.g. de")
+		cmd.Flags().String("published", "", "Date the work was first published, YYYY-MM-DD")
+	}
+	workCreateCmd.Flags().IntSlice("books", nil, "IDs of the books that are editions of the work, comma separated")
+	workMergeCmd.Flags().IntSlice("books", nil, "IDs of the books, comma separated")
+	workSplitCmd.Flags().IntSlice("books", nil, "IDs of the editions to move, comma separated")
+
+	workCmd.AddCommand(workListCmd, workCreateCmd, workGetCmd, workUpdateCmd, workDeleteCmd, workMergeCmd, workSplitCmd)
+}
@@ -3,22 +3,80 @@ package main
 import (
 	"log"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/mayank-02/bookman/internal/api"
+	"github.com/mayank-02/bookman/internal/blob"
 	"github.com/mayank-02/bookman/internal/db"
+	"github.com/spf13/cobra"
 )
 
-func main() {
-	db, err := db.InitDB("./bookman.db")
-	if err != nil {
-		log.Fatal(err)
+var (
+	dataSourceName string
+	coversDir      string
+	purgeAfter     int
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "bookman-server",
+	Short: "Run the bookman REST API server",
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := db.InitDB(dataSourceName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+		covers, err := blob.NewFS(coversDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		router := mux.NewRouter()
+		api.RegisterHandlers(router, db, covers)
+		if purgeAfter > 0 {
+			go purgeTrash(db.WithActor("bookman-server"), covers)
+		}
+
+		log.Println("Server is running on http://localhost:8080")
+		log.Fatal(http.ListenAndServe(":8080", router))
+	},
+}
+
+// purgeTrash permanently deletes what has been in the trash for more than
+// purgeAfter days, at startup and then every hour. The audit log names the
+// actor of store.
+func purgeTrash(store db.Store, covers blob.Store) {
+	for {
+		items, err := api.PurgeTrash(store, covers, time.Now().AddDate(0, 0, -purgeAfter))
+		if err != nil {
+			log.Printf("purging the trash: %v", err)
+		} else if len(items) > 0 {
+			log.Printf("purged %d items that were deleted more than %d days ago", len(items), purgeAfter)
+		}
+		time.Sleep(time.Hour)
 	}
-	defer db.Close()
+}
 
-	router := mux.NewRouter()
-	api.RegisterHandlers(router, db)
+func main() {
+	defaultDSN := os.Getenv("BOOKMAN_DB")
+	if defaultDSN == "" {
+		defaultDSN = "./bookman.db"
+	}
+	rootCmd.PersistentFlags().StringVar(&dataSourceName, "db", defaultDSN,
+		"Path to a SQLite database or a postgres:// connection URL (defaults to $BOOKMAN_DB)")
+	defaultCovers := os.Getenv("BOOKMAN_COVERS")
+	if defaultCovers == "" {
+		defaultCovers = "./covers"
+	}
+	rootCmd.Flags().StringVar(&coversDir, "covers", defaultCovers,
+		"Directory to keep the cover images of books in (defaults to $BOOKMAN_COVERS)")
+	rootCmd.Flags().IntVar(&purgeAfter, "purge-after", 0,
+		"Days after which deleted books and collections are purged from the trash, 0 (the default) keeps them until purged by hand")
+	rootCmd.AddCommand(migrateCmd)
 
-	log.Println("Server is running on http://localhost:8080")
-	log.Fatal(http.ListenAndServe(":8080", router))
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
 }
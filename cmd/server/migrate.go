@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Manage database schema migrations",
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which migrations have been applied",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		database, err := db.Open(dataSourceName)
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		statuses, err := database.MigrationStatus()
+		if err != nil {
+			return err
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Version", "Name", "Applied At"})
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.Applied {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			table.Append([]string{strconv.Itoa(s.Version), s.Name, appliedAt})
+		}
+		table.Render()
+		return nil
+	},
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply pending migrations",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		database, err := db.Open(dataSourceName)
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		target, _ := cmd.Flags().GetInt("to")
+		if target == 0 {
+			if target, err = db.LatestVersion(database.Driver); err != nil {
+				return err
+			}
+		}
+		current, err := database.SchemaVersion()
+		if err != nil {
+			return err
+		}
+		if target < current {
+			return fmt.Errorf("database is at version %d, use migrate down to go back to %d", current, target)
+		}
+
+		if err := database.MigrateTo(target); err != nil {
+			return err
+		}
+		fmt.Printf("Database migrated from version %d to %d\n", current, target)
+		return nil
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Revert applied migrations",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		database, err := db.Open(dataSourceName)
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		current, err := database.SchemaVersion()
+		if err != nil {
+			return err
+		}
+		migrations, err := db.Migrations(database.Driver)
+		if err != nil {
+			return err
+		}
+
+		// Walk back the requested number of steps over the known migrations,
+		// since versions are not required to be contiguous
+		steps, _ := cmd.Flags().GetInt("steps")
+		target := current
+		for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+			if migrations[i].Version > current {
+				continue
+			}
+			target = 0
+			if i > 0 {
+				target = migrations[i-1].Version
+			}
+			steps--
+		}
+
+		if err := database.MigrateTo(target); err != nil {
+			return err
+		}
+		fmt.Printf("Database migrated from version %d to %d\n", current, target)
+		return nil
+	},
+}
+
+func init() {
+	migrateUpCmd.Flags().Int("to", 0, "Version to migrate to (defaults to the latest)")
+	migrateDownCmd.Flags().Int("steps", 1, "Number of migrations to revert")
+
+	migrateCmd.AddCommand(migrateStatusCmd, migrateUpCmd, migrateDownCmd)
+}
@@ -4,7 +4,9 @@ go 1.22.1
 
 require (
 	github.com/gorilla/mux v1.8.1
+	github.com/lib/pq v1.10.9
 	github.com/mattn/go-sqlite3 v1.14.22
+	golang.org/x/image v0.18.0
 )
 
 require (
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+const (
+	// ActorHeader names who makes a request, for the audit log. Requests
+	// without it are recorded as made by AnonymousActor.
+	ActorHeader    = "X-Actor"
+	AnonymousActor = "anonymous"
+)
+
+// asActor builds the handler h for every request, with a store that records
+// the actor of the request in the audit log.
+func asActor(store db.Store, h func(db.Store) http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor := strings.TrimSpace(r.Header.Get(ActorHeader))
+		if actor == "" {
+			actor = AnonymousActor
+		}
+		h(store.WithActor(actor))(w, r)
+	}
+}
+
+// getBookHistory lists the changes to a book and its memberships, also
+// after the book was deleted.
+func getBookHistory(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookID, err := parseID(r, "id", "book")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		opts, err := parseListOptions(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		filter, err := parseAuditFilter(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		filter.BookID = bookID
+
+		entries, page, err := db.GetAudit(filter, opts)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		// Books changed before there was an audit log have no history
+		if page.Total == 0 {
+			if _, err := db.GetBook(bookID); err != nil {
+				writeError(w, r, err)
+				return
+			}
+		}
+		writeAudit(w, r, entries, page)
+	}
+}
+
+// getAudit lists the audit log, optionally only the changes to one book or
+// collection.
+func getAudit(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, err := parseListOptions(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		filter, err := parseAuditFilter(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		for param, id := range map[string]*int{"book_id": &filter.BookID, "collection_id": &filter.CollectionID} {
+			if value := r.URL.Query().Get(param); value != "" {
+				if *id, err = strconv.Atoi(value); err != nil || *id < 1 {
+					badRequest(w, r, fmt.Errorf("Invalid %s", param))
+					return
+				}
+			}
+		}
+
+		entries, page, err := db.GetAudit(filter, opts)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		writeAudit(w, r, entries, page)
+	}
+}
+
+// parseAuditFilter reads the entity, action, actor, since and until
+// filters. since and until take RFC 3339 times or dates, until a date
+// includes that day.
+func parseAuditFilter(r *http.Request) (db.AuditFilter, error) {
+	filter := db.AuditFilter{
+		Entity: r.URL.Query().Get("entity"),
+		Action: r.URL.Query().Get("action"),
+		Actor:  r.URL.Query().Get("actor"),
+	}
+	if filter.Entity != "" && !slices.Contains(models.AuditEntities, filter.Entity) {
+		return db.AuditFilter{}, fmt.Errorf("Invalid entity: must be one of %s", strings.Join(models.AuditEntities, ", "))
+	}
+	if filter.Action != "" && !slices.Contains(models.AuditActions, filter.Action) {
+		return db.AuditFilter{}, fmt.Errorf("Invalid action: must be one of %s", strings.Join(models.AuditActions, ", "))
+	}
+	for _, param := range []struct {
+		name  string
+		value *time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		value := r.URL.Query().Get(param.name)
+		if value == "" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			*param.value = t
+			continue
+		}
+		day, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return db.AuditFilter{}, fmt.Errorf("Invalid %s: must be a date or an RFC 3339 time", param.name)
+		}
+		if param.name == "until" {
+			day = day.AddDate(0, 0, 1)
+		}
+		*param.value = day
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && !filter.Since.Before(filter.Until) {
+		return db.AuditFilter{}, errors.New("Invalid since: must be before until")
+	}
+	return filter, nil
+}
+
+func writeAudit(w http.ResponseWriter, r *http.Request, entries []models.AuditEntry, page db.Page) {
+	if entries == nil {
+		entries = []models.AuditEntry{}
+	}
+	writePageHeaders(w, r, page)
+	json.NewEncoder(w).Encode(entries)
+}
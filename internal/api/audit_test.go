@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAudit(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		router := setupTestRouter(store)
+		request := func(method, url, actor, body string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest(method, url, strings.NewReader(body))
+			assert.NoError(t, err)
+			req.Header.Set(ActorHeader, actor)
+			if method == "PATCH" {
+				req.Header.Set("Content-Type", MergePatchContentType)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr
+		}
+		audit := func(url string) []models.AuditEntry {
+			rr := request("GET", url, "", "")
+			assert.Equal(t, http.StatusOK, rr.Code, url)
+			var entries []models.AuditEntry
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&entries))
+			return entries
+		}
+
+		rr := request("POST", "/api/v1/books", "alice", `{"title": "Dune", "author": "Frank Herbert", "published_date": "1965-08-01"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, http.StatusOK, request("PATCH", "/api/v1/books/1", "bob", `{"genre": "Science Fiction"}`).Code)
+		assert.Equal(t, http.StatusCreated, request("POST", "/api/v1/collections", "", `{"name": "Shelf"}`).Code)
+		assert.Equal(t, http.StatusNoContent, request("POST", "/api/v1/collections/1/books/1", "alice", "").Code)
+		// Reading the book changes nothing
+		assert.Equal(t, http.StatusOK, request("GET", "/api/v1/books/1", "alice", "").Code)
+
+		entries := audit("/api/v1/books/1/history")
+		if assert.Len(t, entries, 3) {
+			assert.Equal(t, "alice", entries[0].Actor)
+			assert.Equal(t, models.AuditCreate, entries[0].Action)
+			assert.Equal(t, "bob", entries[1].Actor)
+			assert.Equal(t, []models.FieldChange{
+				{Field: "genre", Before: json.RawMessage("null"), After: json.RawMessage(`"Science Fiction"`)},
+			}, entries[1].Changes)
+			assert.Equal(t, models.AuditMembership, entries[2].Entity)
+			assert.Equal(t, 1, entries[2].CollectionID)
+		}
+		entries = audit("/api/v1/audit?entity=collection")
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, AnonymousActor, entries[0].Actor)
+		}
+		assert.Len(t, audit("/api/v1/audit?actor=alice"), 2)
+		assert.Len(t, audit("/api/v1/audit?collection_id=1"), 2)
+		assert.Len(t, audit("/api/v1/audit?book_id=1&action=update"), 1)
+		assert.Len(t, audit("/api/v1/audit?since="+time.Now().AddDate(0, 0, -1).Format("2006-01-02")), 4)
+		assert.Empty(t, audit("/api/v1/audit?until=2000-01-01"))
+
+		rr = request("GET", "/api/v1/audit?order=desc&limit=1", "", "")
+		assert.Equal(t, "4", rr.Header().Get("X-Total-Count"))
+		assert.Contains(t, rr.Header().Get("Link"), "rel=\"next\"")
+		assert.Contains(t, rr.Body.String(), `"entity":"membership"`)
+
+		// The history outlives the book
+		assert.Equal(t, http.StatusNoContent, request("DELETE", "/api/v1/books/1", "carol", "").Code)
+		entries = audit("/api/v1/books/1/history?action=delete")
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, "carol", entries[0].Actor)
+			assert.Contains(t, entries[0].Changes, models.FieldChange{Field: "title", Before: json.RawMessage(`"Dune"`), After: json.RawMessage(`null`)})
+		}
+
+		assert.Equal(t, http.StatusNotFound, request("GET", "/api/v1/books/2/history", "", "").Code)
+		for _, query := range []string{"entity=review", "action=read", "since=yesterday", "book_id=x", "since=2024-02-01&until=2024-01-01"} {
+			assert.Equal(t, http.StatusBadRequest, request("GET", "/api/v1/audit?"+query, "", "").Code, query)
+		}
+	})
+}
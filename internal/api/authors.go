@@ -0,0 +1,199 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+func getAuthors(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, err := parseListOptions(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+
+		authors, page, err := db.GetAuthors(opts)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if authors == nil {
+			authors = []models.Author{}
+		}
+		writePageHeaders(w, r, page)
+		json.NewEncoder(w).Encode(authors)
+	}
+}
+
+func getAuthor(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "author")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		author, err := db.GetAuthor(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(author)
+	}
+}
+
+// getAuthorBooks lists the books crediting the author in any role. It takes
+// the paging parameters of the book listing.
+func getAuthorBooks(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "author")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		opts, err := parseListOptions(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		if _, err := db.GetAuthor(id); err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		books, page, err := db.GetBooks(authorFilter(id), opts)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if books == nil {
+			books = []models.Book{}
+		}
+		writePageHeaders(w, r, page)
+		json.NewEncoder(w).Encode(books)
+	}
+}
+
+func authorFilter(id int) db.BookFilter {
+	return db.BookFilter{AuthorID: id}
+}
+
+func createAuthor(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var author models.Author
+		if err := decodeJSON(r, &author); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+
+		// Validation checks
+		if fieldErrors := validateAuthor(author); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		id, err := db.CreateAuthor(author)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		author.ID = id
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(author)
+	}
+}
+
+func updateAuthor(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "author")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		var author models.Author
+		if err := decodeJSON(r, &author); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		author.ID = id
+
+		// Validation checks
+		if fieldErrors := validateAuthor(author); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		err = db.UpdateAuthor(author)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(author)
+	}
+}
+
+func patchAuthor(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "author")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		if !isMergePatch(r) {
+			unsupportedMediaType(w, r, MergePatchContentType)
+			return
+		}
+
+		current, err := db.GetAuthor(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		author, err := applyMergePatch(r, current)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		author.ID = current.ID
+
+		// Validation checks
+		if fieldErrors := validateAuthor(author); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		err = db.UpdateAuthor(author)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(author)
+	}
+}
+
+func deleteAuthor(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "author")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		err = db.DeleteAuthor(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func validateAuthor(author models.Author) []models.FieldError {
+	if strings.TrimSpace(author.Name) == "" {
+		return []models.FieldError{{Field: "name", Message: "is required"}}
+	}
+	return nil
+}
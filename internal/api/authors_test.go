@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthors(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		router := setupTestRouter(store)
+		request := func(method, url, body string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest(method, url, strings.NewReader(body))
+			assert.NoError(t, err)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr
+		}
+
+		rr := request("POST", "/api/v1/authors", `{"name": "Frank Herbert"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var author models.Author
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&author))
+		assert.Equal(t, "Frank Herbert", author.Name)
+
+		rr = request("POST", "/api/v1/authors", `{"name": "Frank Herbert"}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		rr = request("POST", "/api/v1/authors", `{"name": " "}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		// Books credit authors by ID or name, in order and with a role
+		rr = request("POST", "/api/v1/books", `{"title": "Dune", "published_date": "1965-08-01",
+			"authors": [{"id": 1}, {"name": "Brian Herbert", "role": "editor"}]}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var book models.Book
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&book))
+		assert.Equal(t, "Frank Herbert", book.Author)
+		assert.Equal(t, []models.BookAuthor{
+			{ID: 1, Name: "Frank Herbert", Role: "author"},
+			{ID: 2, Name: "Brian Herbert", Role: "editor"},
+		}, book.Authors)
+
+		rr = request("POST", "/api/v1/books", `{"title": "Dune", "published_date": "1965-08-01", "authors": [{"role": "ghostwriter"}]}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `{"field":"authors[0]","message":"must have an id or a name"}`)
+		assert.Contains(t, rr.Body.String(), `{"field":"authors[0].role","message":"must be one of author, editor, translator, illustrator"}`)
+		rr = request("POST", "/api/v1/books", `{"title": "Dune", "published_date": "1965-08-01", "authors": [{"id": 42}]}`)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		// Patching the author line replaces the authors
+		rr = patchRequest(t, router, "/api/v1/books/1", `{"author": "Frank Herbert & Kevin J. Anderson"}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&book))
+		assert.Len(t, book.Authors, 2)
+		assert.Equal(t, "Kevin J. Anderson", book.Authors[1].Name)
+
+		rr = request("GET", "/api/v1/authors/1/books", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "1", rr.Header().Get("X-Total-Count"))
+		rr = request("GET", "/api/v1/authors/2/books", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "[]\n", rr.Body.String())
+		rr = request("GET", "/api/v1/authors/42/books", "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = request("GET", "/api/v1/authors?sort=name&limit=2", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "3", rr.Header().Get("X-Total-Count"))
+		var authors []models.Author
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&authors))
+		assert.Equal(t, "Brian Herbert", authors[0].Name)
+
+		rr = request("PUT", "/api/v1/authors/3", `{"name": "Kevin James Anderson"}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		rr = request("GET", "/api/v1/books/1", "")
+		assert.Contains(t, rr.Body.String(), `"author":"Frank Herbert, Kevin James Anderson"`)
+
+		rr = request("DELETE", "/api/v1/authors/1", "")
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		rr = request("DELETE", "/api/v1/authors/2", "")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = request("GET", "/api/v1/authors/2", "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
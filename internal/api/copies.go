@@ -0,0 +1,515 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+func getLocations(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, err := parseListOptions(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		locations, page, err := db.GetLocations(opts)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if locations == nil {
+			locations = []models.Location{}
+		}
+		writePageHeaders(w, r, page)
+		json.NewEncoder(w).Encode(locations)
+	}
+}
+
+func getLocation(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "location")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		location, err := db.GetLocation(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(location)
+	}
+}
+
+func createLocation(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var location models.Location
+		if err := decodeJSON(r, &location); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+
+		// Validation checks
+		if fieldErrors := validateLocation(location); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		id, err := db.CreateLocation(location)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		location, err = db.GetLocation(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(location)
+	}
+}
+
+// updateLocation renames a location, changes its kind or moves it to
+// another parent with everything inside it.
+func updateLocation(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "location")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		var location models.Location
+		if err := decodeJSON(r, &location); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		location.ID = id
+
+		// Validation checks
+		if fieldErrors := validateLocation(location); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		writeLocation(w, r, db, location)
+	}
+}
+
+func patchLocation(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "location")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		if !isMergePatch(r) {
+			unsupportedMediaType(w, r, MergePatchContentType)
+			return
+		}
+
+		current, err := db.GetLocation(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		location, err := applyMergePatch(r, current)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		location.ID = id
+
+		// Validation checks
+		if fieldErrors := validateLocation(location); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		writeLocation(w, r, db, location)
+	}
+}
+
+// writeLocation stores a changed location and responds with it as stored.
+func writeLocation(w http.ResponseWriter, r *http.Request, store db.Store, location models.Location) {
+	err := store.UpdateLocation(location)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	location, err = store.GetLocation(location.ID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(location)
+}
+
+// deleteLocation only deletes empty locations, 409 Conflict otherwise.
+func deleteLocation(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "location")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		err = db.DeleteLocation(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// getLocationCopies lists the copies at a location, with recursive=true
+// also those in the locations inside it.
+func getLocationCopies(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		locationID, err := parseID(r, "id", "location")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		opts, err := parseListOptions(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		filter, err := parseCopyFilter(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		if _, err := db.GetLocation(locationID); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		filter.LocationID = locationID
+		writeCopies(w, r, db, filter, opts)
+	}
+}
+
+// checkInventory compares the barcodes found at a location with the copies
+// recorded there.
+func checkInventory(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		locationID, err := parseID(r, "id", "location")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		var request models.InventoryRequest
+		if err := decodeJSON(r, &request); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+
+		// Validation checks
+		if fieldErrors := validateInventory(request); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		report, err := db.CheckInventory(locationID, request.Barcodes, request.Apply)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+// getCopies lists the copies of all books, filtered by barcode, format or
+// location.
+func getCopies(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, err := parseListOptions(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		filter, err := parseCopyFilter(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		if value := r.URL.Query().Get("location_id"); value != "" {
+			filter.LocationID, err = strconv.Atoi(value)
+			if err != nil || filter.LocationID <= 0 {
+				badRequest(w, r, fmt.Errorf("Invalid location_id: must be a positive integer"))
+				return
+			}
+		}
+		writeCopies(w, r, db, filter, opts)
+	}
+}
+
+func getBookCopies(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookID, err := parseID(r, "id", "book")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		opts, err := parseListOptions(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		filter, err := parseCopyFilter(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		if _, err := db.GetBook(bookID); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		filter.BookID = bookID
+		writeCopies(w, r, db, filter, opts)
+	}
+}
+
+// parseCopyFilter reads the filters all copy listings share.
+func parseCopyFilter(r *http.Request) (db.CopyFilter, error) {
+	filter := db.CopyFilter{Barcode: r.URL.Query().Get("barcode"), Format: r.URL.Query().Get("format")}
+	if value := r.URL.Query().Get("recursive"); value != "" {
+		recursive, err := strconv.ParseBool(value)
+		if err != nil {
+			return db.CopyFilter{}, fmt.Errorf("Invalid recursive: must be true or false")
+		}
+		filter.Recursive = recursive
+	}
+	return filter, nil
+}
+
+func writeCopies(w http.ResponseWriter, r *http.Request, store db.Store, filter db.CopyFilter, opts db.ListOptions) {
+	copies, page, err := store.GetCopies(filter, opts)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if copies == nil {
+		copies = []models.Copy{}
+	}
+	writePageHeaders(w, r, page)
+	json.NewEncoder(w).Encode(copies)
+}
+
+func getCopy(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "copy")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		copy, err := db.GetCopy(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(copy)
+	}
+}
+
+func createCopy(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookID, err := parseID(r, "id", "book")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		var copy models.Copy
+		if err := decodeJSON(r, &copy); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		copy.BookID = bookID
+
+		// Validation checks
+		if fieldErrors := validateCopy(copy); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		id, err := db.CreateCopy(copy)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		copy, err = db.GetCopy(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(copy)
+	}
+}
+
+// updateCopy replaces the details and location of a copy, the book stays
+// the same.
+func updateCopy(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "copy")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		var copy models.Copy
+		if err := decodeJSON(r, &copy); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		copy.ID = id
+
+		// Validation checks
+		if fieldErrors := validateCopy(copy); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		writeCopy(w, r, db, copy)
+	}
+}
+
+func patchCopy(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "copy")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		if !isMergePatch(r) {
+			unsupportedMediaType(w, r, MergePatchContentType)
+			return
+		}
+
+		current, err := db.GetCopy(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		copy, err := applyMergePatch(r, current)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		copy.ID = id
+
+		// Validation checks
+		if fieldErrors := validateCopy(copy); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		writeCopy(w, r, db, copy)
+	}
+}
+
+// writeCopy stores a changed copy and responds with it as stored.
+func writeCopy(w http.ResponseWriter, r *http.Request, store db.Store, copy models.Copy) {
+	err := store.UpdateCopy(copy)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	copy, err = store.GetCopy(copy.ID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(copy)
+}
+
+func deleteCopy(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "copy")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		err = db.DeleteCopy(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// moveCopies moves several copies to a location at once, or out of any
+// location. Either all of them move or none.
+func moveCopies(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request models.MoveCopiesRequest
+		if err := decodeJSON(r, &request); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+
+		// Validation checks
+		if fieldErrors := validateMoveCopies(request); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		err := db.MoveCopies(request.CopyIDs, request.LocationID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func validateLocation(location models.Location) []models.FieldError {
+	var fieldErrors []models.FieldError
+	if strings.TrimSpace(location.Name) == "" {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "name", Message: "is required"})
+	}
+	if !slices.Contains(models.LocationKinds, location.Kind) {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "kind", Message: "must be one of " + strings.Join(models.LocationKinds, ", ")})
+	}
+	return fieldErrors
+}
+
+func validateCopy(copy models.Copy) []models.FieldError {
+	var fieldErrors []models.FieldError
+	if !slices.Contains(models.CopyFormats, copy.Format) {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "format", Message: "must be one of " + strings.Join(models.CopyFormats, ", ")})
+	}
+	if copy.Condition != "" && !slices.Contains(models.CopyConditions, copy.Condition) {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "condition", Message: "must be one of " + strings.Join(models.CopyConditions, ", ")})
+	}
+	fieldErrors = append(fieldErrors, validateDate("acquired_on", copy.AcquiredOn)...)
+	if copy.Price != nil && *copy.Price < 0 {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "price", Message: "must be at least 0"})
+	}
+	return fieldErrors
+}
+
+func validateMoveCopies(request models.MoveCopiesRequest) []models.FieldError {
+	if len(request.CopyIDs) == 0 {
+		return []models.FieldError{{Field: "copy_ids", Message: "is required"}}
+	}
+	if len(request.CopyIDs) > MaxListLimit {
+		return []models.FieldError{{Field: "copy_ids", Message: fmt.Sprintf("must not list more than %d copies", MaxListLimit)}}
+	}
+	return nil
+}
+
+func validateInventory(request models.InventoryRequest) []models.FieldError {
+	if len(request.Barcodes) > MaxInventorySize {
+		return []models.FieldError{{Field: "barcodes", Message: fmt.Sprintf("must not list more than %d barcodes", MaxInventorySize)}}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopies(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		router := setupTestRouter(store)
+		request := func(method, url, body string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest(method, url, strings.NewReader(body))
+			assert.NoError(t, err)
+			if method == "PATCH" {
+				req.Header.Set("Content-Type", MergePatchContentType)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr
+		}
+		createTestBook(t, store, "1965-08-01")
+		createTestBook(t, store, "1937-09-21")
+
+		rr := request("POST", "/api/v1/locations", `{"name": "Study", "kind": "room"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		rr = request("POST", "/api/v1/locations", `{"parent_id": 1, "name": "Bookcase", "kind": "bookcase"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		rr = request("POST", "/api/v1/locations", `{"parent_id": 2, "name": "Top shelf", "kind": "shelf"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var location models.Location
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&location))
+		assert.Equal(t, "Study > Bookcase > Top shelf", location.Path)
+		rr = request("POST", "/api/v1/locations", `{"parent_id": 3, "name": "Box", "kind": "room"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		rr = request("POST", "/api/v1/locations", `{"kind": "drawer"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `{"field":"name","message":"is required"}`)
+		rr = request("POST", "/api/v1/locations", `{"parent_id": 1, "name": "Bookcase", "kind": "bookcase"}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		rr = request("POST", "/api/v1/locations", `{"name": "Hall", "kind": "room"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		rr = request("POST", "/api/v1/books/1/copies", `{"format": "hardcover", "condition": "good", "acquired_on": "2020-05-01", "price": 12.5, "barcode": "B1", "location_id": 3}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var copy models.Copy
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&copy))
+		assert.Equal(t, "Study > Bookcase > Top shelf", copy.Location)
+		assert.Equal(t, 12.5, *copy.Price)
+		rr = request("POST", "/api/v1/books/2/copies", `{"format": "vinyl", "price": -1}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"field":"format"`)
+		assert.Contains(t, rr.Body.String(), `{"field":"price","message":"must be at least 0"}`)
+		rr = request("POST", "/api/v1/books/2/copies", `{"format": "paperback", "barcode": "B1"}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		rr = request("POST", "/api/v1/books/42/copies", `{"format": "paperback"}`)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		rr = request("POST", "/api/v1/books/2/copies", `{"format": "paperback", "barcode": "B2", "location_id": 4}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		rr = request("POST", "/api/v1/books/2/copies", `{"format": "ebook"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		// The room holds the shelf's copies only when listed recursively
+		rr = request("GET", "/api/v1/locations/1/copies", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "0", rr.Header().Get("X-Total-Count"))
+		rr = request("GET", "/api/v1/locations/1/copies?recursive=true", "")
+		assert.Equal(t, "1", rr.Header().Get("X-Total-Count"))
+		rr = request("GET", "/api/v1/locations/42/copies", "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		rr = request("GET", "/api/v1/copies?barcode=B2", "")
+		assert.Equal(t, "1", rr.Header().Get("X-Total-Count"))
+		rr = request("GET", "/api/v1/books/2/copies", "")
+		assert.Equal(t, "2", rr.Header().Get("X-Total-Count"))
+
+		rr = request("PATCH", "/api/v1/copies/3", `{"condition": "fine", "book_id": 1}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"book_id":2`)
+		assert.Contains(t, rr.Body.String(), `"condition":"fine"`)
+
+		// Inventory of the study: B2 was found there although it belongs in the hall
+		rr = request("POST", "/api/v1/locations/1/inventory", `{"barcodes": ["B2", "X9"], "apply": true}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var report models.InventoryReport
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&report))
+		assert.Empty(t, report.Found)
+		assert.Len(t, report.Missing, 1)
+		assert.Equal(t, "B1", report.Missing[0].Barcode)
+		assert.Len(t, report.Misplaced, 1)
+		assert.Equal(t, []string{"X9"}, report.Unknown)
+		assert.True(t, report.Applied)
+		rr = request("GET", "/api/v1/copies/2", "")
+		assert.Contains(t, rr.Body.String(), `"location_id":1`)
+
+		rr = request("POST", "/api/v1/copies/move", `{"copy_ids": [1, 2], "location_id": 4}`)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = request("GET", "/api/v1/locations/4/copies", "")
+		assert.Equal(t, "2", rr.Header().Get("X-Total-Count"))
+		rr = request("POST", "/api/v1/copies/move", `{"copy_ids": [1, 42], "location_id": 3}`)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		rr = request("POST", "/api/v1/copies/move", `{"copy_ids": []}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = request("DELETE", "/api/v1/locations/4", "")
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		rr = request("DELETE", "/api/v1/copies/1", "")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = request("GET", "/api/v1/copies/1", "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/mayank-02/bookman/internal/blob"
+	"github.com/mayank-02/bookman/internal/cover"
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// maxCoverSize bounds the size of an uploaded cover in bytes.
+const maxCoverSize = 10 << 20
+
+// coverKey is the blob key of the cover of a book, or of one of its
+// thumbnails if size is set. Keys name the digest of the cover, so a new
+// cover never overwrites the files of the one that is being served.
+func coverKey(bookID int, c *models.Cover, size string) string {
+	name := "original"
+	if size != "" {
+		name = size + ".jpg"
+	}
+	return fmt.Sprintf("%d/%s/%s", bookID, c.Digest, name)
+}
+
+// deleteCoverFiles removes the files of a cover that is no longer recorded.
+// Failures are only logged, the cover is gone either way.
+func deleteCoverFiles(covers blob.Store, bookID int, c *models.Cover) {
+	keys := []string{coverKey(bookID, c, "")}
+	for size := range models.CoverSizes {
+		keys = append(keys, coverKey(bookID, c, size))
+	}
+	for _, key := range keys {
+		if err := covers.Delete(key); err != nil {
+			log.Printf("deleting cover of book %d: %v", bookID, err)
+		}
+	}
+}
+
+// putCover stores an uploaded cover for a book. The handler is built per
+// request by asActor.
+func putCover(covers blob.Store) func(db.Store) http.HandlerFunc {
+	return func(db db.Store) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			id, err := parseID(r, "id", "book")
+			if err != nil {
+				badRequest(w, r, err)
+				return
+			}
+			mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if !slices.Contains(cover.ContentTypes, mediaType) {
+				writeProblem(w, r, http.StatusUnsupportedMediaType, ProblemUnsupportedMedia,
+					fmt.Sprintf("Expected a request body of type %s", strings.Join(cover.ContentTypes, ", ")))
+				return
+			}
+			book, err := db.GetBook(id)
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+
+			data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCoverSize))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeProblem(w, r, http.StatusRequestEntityTooLarge, ProblemTooLarge,
+					fmt.Sprintf("Covers can be at most %d MiB", maxCoverSize>>20))
+				return
+			} else if err != nil {
+				badRequest(w, r, err)
+				return
+			}
+
+			// Validation checks
+			img, contentType, err := cover.Decode(data)
+			if errors.Is(err, cover.ErrUnsupported) {
+				badRequest(w, r, fmt.Errorf("The body is not a JPEG, PNG or WebP image"))
+				return
+			} else if err != nil {
+				badRequest(w, r, err)
+				return
+			}
+			if contentType != mediaType {
+				badRequest(w, r, fmt.Errorf("The body is a %s image, not %s", contentType, mediaType))
+				return
+			}
+
+			sum := sha256.Sum256(data)
+			c := models.Cover{
+				ContentType: contentType,
+				Width:       img.Bounds().Dx(),
+				Height:      img.Bounds().Dy(),
+				Size:        len(data),
+				Digest:      hex.EncodeToString(sum[:]),
+			}
+			sizes := make([]string, 0, len(models.CoverSizes))
+			for size := range models.CoverSizes {
+				sizes = append(sizes, size)
+			}
+			sort.Strings(sizes)
+			for _, size := range sizes {
+				thumbnail, err := cover.Thumbnail(img, models.CoverSizes[size])
+				if err == nil {
+					err = covers.Put(coverKey(id, &c, size), thumbnail)
+				}
+				if err != nil {
+					writeError(w, r, err)
+					return
+				}
+			}
+			if err := covers.Put(coverKey(id, &c, ""), data); err != nil {
+				writeError(w, r, err)
+				return
+			}
+			if err := db.SetBookCover(id, c); err != nil {
+				writeError(w, r, err)
+				return
+			}
+			if book.Cover != nil && book.Cover.Digest != c.Digest {
+				deleteCoverFiles(covers, id, book.Cover)
+			}
+
+			book, err = db.GetBook(id)
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+			json.NewEncoder(w).Encode(book.Cover)
+		}
+	}
+}
+
+// getCover serves the cover of a book or one of its thumbnails. Requests for
+// the versioned URLs listed in the book may be cached for good, others are
+// revalidated against the ETag, which is derived from the digest.
+func getCover(db db.Store, covers blob.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "book")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		size := mux.Vars(r)["size"]
+		if _, ok := models.CoverSizes[size]; size != "" && !ok {
+			writeProblem(w, r, http.StatusNotFound, ProblemNotFound,
+				fmt.Sprintf("No thumbnail size %q, expected small, medium or large", size))
+			return
+		}
+		book, err := db.GetBook(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if book.Cover == nil {
+			writeProblem(w, r, http.StatusNotFound, ProblemNotFound, fmt.Sprintf("Book %d has no cover", id))
+			return
+		}
+		data, err := covers.Get(coverKey(id, book.Cover, size))
+		if errors.Is(err, blob.ErrNotFound) {
+			// The cover is recorded but its file is gone, e.g. removed by hand
+			log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+			writeProblem(w, r, http.StatusNotFound, ProblemNotFound, fmt.Sprintf("The cover of book %d is missing", id))
+			return
+		} else if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		contentType, tag := book.Cover.ContentType, book.Cover.Digest
+		if size != "" {
+			contentType, tag = cover.ThumbnailType, tag+"-"+size
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("ETag", `"`+tag+`"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		if location, err := url.Parse(book.Cover.URL); err == nil && r.URL.Query().Get("v") == location.Query().Get("v") {
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		}
+		http.ServeContent(w, r, "", book.Cover.UpdatedAt, bytes.NewReader(data))
+	}
+}
+
+// deleteCover removes the cover of a book. The handler is built per request
+// by asActor.
+func deleteCover(covers blob.Store) func(db.Store) http.HandlerFunc {
+	return func(db db.Store) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			id, err := parseID(r, "id", "book")
+			if err != nil {
+				badRequest(w, r, err)
+				return
+			}
+			book, err := db.GetBook(id)
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+			if err := db.DeleteBookCover(id); err != nil {
+				writeError(w, r, err)
+				return
+			}
+			deleteCoverFiles(covers, id, book.Cover)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mayank-02/bookman/internal/blob"
+	"github.com/mayank-02/bookman/internal/cover"
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func testCover(t *testing.T, width, height int, fill color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, fill)
+		}
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestBookCovers(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		covers := blob.NewMemory()
+		router := mux.NewRouter()
+		RegisterHandlers(router, store, covers)
+		request := func(method, url, contentType string, body []byte, header ...string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest(method, url, bytes.NewReader(body))
+			assert.NoError(t, err)
+			if contentType != "" {
+				req.Header.Set("Content-Type", contentType)
+			}
+			for i := 0; i+1 < len(header); i += 2 {
+				req.Header.Set(header[i], header[i+1])
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr
+		}
+		id := createTestBook(t, store, "2015-10-26")
+
+		rr := request("GET", "/api/v1/books/1/cover", "", nil)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		red := testCover(t, 800, 1200, color.RGBA{R: 255, A: 255})
+		rr = request("PUT", "/api/v1/books/1/cover", "image/png", red)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var c models.Cover
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&c))
+		assert.Equal(t, "image/png", c.ContentType)
+		assert.Equal(t, 800, c.Width)
+		assert.Equal(t, 1200, c.Height)
+		assert.Equal(t, len(red), c.Size)
+		assert.Len(t, c.Thumbnails, 3)
+
+		book, err := store.GetBook(id)
+		assert.NoError(t, err)
+		assert.Equal(t, c.URL, book.Cover.URL)
+
+		// The versioned URL may be cached for good, the plain one is revalidated
+		rr = request("GET", c.URL, "", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "image/png", rr.Header().Get("Content-Type"))
+		assert.Equal(t, "public, max-age=31536000, immutable", rr.Header().Get("Cache-Control"))
+		assert.Equal(t, red, rr.Body.Bytes())
+		rr = request("GET", "/api/v1/books/1/cover", "", nil)
+		assert.Equal(t, "no-cache", rr.Header().Get("Cache-Control"))
+		etag := rr.Header().Get("ETag")
+		assert.Equal(t, `"`+c.Digest+`"`, etag)
+		assert.NotEmpty(t, rr.Header().Get("Last-Modified"))
+		rr = request("GET", "/api/v1/books/1/cover", "", nil, "If-None-Match", etag)
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+
+		rr = request("GET", c.Thumbnails["medium"], "", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, cover.ThumbnailType, rr.Header().Get("Content-Type"))
+		thumbnail, _, err := image.Decode(rr.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 300, 450), thumbnail.Bounds())
+		assert.Equal(t, http.StatusNotFound, request("GET", "/api/v1/books/1/cover/huge", "", nil).Code)
+
+		for _, tt := range []struct {
+			contentType string
+			body        []byte
+			status      int
+		}{
+			{"image/gif", []byte("GIF89a"), http.StatusUnsupportedMediaType},
+			{"", red, http.StatusUnsupportedMediaType},
+			{"image/jpeg", red, http.StatusBadRequest},
+			{"image/png", []byte("not an image"), http.StatusBadRequest},
+			{"image/png", red[:len(red)/2], http.StatusBadRequest},
+			{"image/png", append(append([]byte{}, red...), make([]byte, maxCoverSize)...), http.StatusRequestEntityTooLarge},
+		} {
+			rr = request("PUT", "/api/v1/books/1/cover", tt.contentType, tt.body)
+			assert.Equal(t, tt.status, rr.Code, tt.contentType)
+		}
+		assert.Equal(t, http.StatusNotFound, request("PUT", "/api/v1/books/99/cover", "image/png", red).Code)
+
+		// A new cover gets new URLs and the files of the old one are deleted
+		blue := testCover(t, 100, 150, color.RGBA{B: 255, A: 255})
+		rr = request("PUT", "/api/v1/books/1/cover", "image/png; charset=binary", blue)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var replaced models.Cover
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&replaced))
+		assert.NotEqual(t, c.URL, replaced.URL)
+		_, err = covers.Get(coverKey(id, &c, ""))
+		assert.ErrorIs(t, err, blob.ErrNotFound)
+		rr = request("GET", replaced.URL, "", nil)
+		assert.Equal(t, blue, rr.Body.Bytes())
+		rr = request("GET", c.URL, "", nil)
+		assert.Equal(t, "no-cache", rr.Header().Get("Cache-Control"))
+
+		rr = request("DELETE", "/api/v1/books/1/cover", "", nil)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Equal(t, http.StatusNotFound, request("DELETE", "/api/v1/books/1/cover", "", nil).Code)
+		_, err = covers.Get(coverKey(id, &replaced, "small"))
+		assert.ErrorIs(t, err, blob.ErrNotFound)
+
+		// The cover of a deleted book is kept until the book is purged
+		assert.Equal(t, http.StatusOK, request("PUT", "/api/v1/books/1/cover", "image/png", red).Code)
+		assert.Equal(t, http.StatusNoContent, request("DELETE", "/api/v1/books/1", "", nil).Code)
+		assert.Equal(t, http.StatusNotFound, request("GET", "/api/v1/books/1/cover", "", nil).Code)
+		_, err = covers.Get(coverKey(id, &c, ""))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, request("DELETE", "/api/v1/trash", "", nil).Code)
+		_, err = covers.Get(coverKey(id, &c, ""))
+		assert.ErrorIs(t, err, blob.ErrNotFound)
+	})
+}
+
+func TestMissingCoverFiles(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		router := setupTestRouter(store)
+		id := createTestBook(t, store, "2015-10-26")
+		// The cover is recorded, but its files were never stored
+		digest := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+		assert.NoError(t, store.SetBookCover(id, models.Cover{ContentType: "image/png", Width: 800, Height: 1200, Size: 51234, Digest: digest}))
+
+		for _, url := range []string{"/api/v1/books/1/cover", "/api/v1/books/1/cover/small"} {
+			req, err := http.NewRequest("GET", url, nil)
+			assert.NoError(t, err)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusNotFound, rr.Code, url)
+			var problem models.Problem
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+			assert.Equal(t, ProblemNotFound, problem.Type)
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+func getCustomFields(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, err := parseListOptions(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+
+		fields, page, err := db.GetCustomFields(opts)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if fields == nil {
+			fields = []models.CustomField{}
+		}
+		writePageHeaders(w, r, page)
+		json.NewEncoder(w).Encode(fields)
+	}
+}
+
+func getCustomField(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "custom field")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		field, err := db.GetCustomField(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(field)
+	}
+}
+
+func createCustomField(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var field models.CustomField
+		if err := decodeJSON(r, &field); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+
+		// Validation checks
+		if fieldErrors := validateCustomField(field); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		id, err := db.CreateCustomField(field)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		field, err = db.GetCustomField(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(field)
+	}
+}
+
+func updateCustomField(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "custom field")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		var field models.CustomField
+		if err := decodeJSON(r, &field); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		field.ID = id
+		writeCustomField(w, r, db, field)
+	}
+}
+
+func patchCustomField(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "custom field")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		if !isMergePatch(r) {
+			unsupportedMediaType(w, r, MergePatchContentType)
+			return
+		}
+
+		current, err := db.GetCustomField(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		field, err := applyMergePatch(r, current)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		field.ID = current.ID
+		writeCustomField(w, r, db, field)
+	}
+}
+
+// writeCustomField replaces the definition of a custom field and responds
+// with it as stored.
+func writeCustomField(w http.ResponseWriter, r *http.Request, store db.Store, field models.CustomField) {
+	// Validation checks
+	if fieldErrors := validateCustomField(field); fieldErrors != nil {
+		validationFailed(w, r, fieldErrors)
+		return
+	}
+
+	if err := store.UpdateCustomField(field); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	field, err := store.GetCustomField(field.ID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(field)
+}
+
+func deleteCustomField(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "custom field")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		err = db.DeleteCustomField(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// customFieldName matches the names the stores accept for custom fields.
+var customFieldName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+func validateCustomField(field models.CustomField) []models.FieldError {
+	var fieldErrors []models.FieldError
+	if strings.TrimSpace(field.Name) == "" {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "name", Message: "is required"})
+	} else if !customFieldName.MatchString(strings.TrimSpace(field.Name)) {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "name", Message: "must be up to 64 lower case letters, digits and underscores, starting with a letter"})
+	}
+	if !slices.Contains(models.CustomFieldTypes, field.Type) {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "type", Message: "must be one of " + strings.Join(models.CustomFieldTypes, ", ")})
+	}
+	if field.Type == "enum" && len(field.Choices) == 0 {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "choices", Message: "is required for enum fields"})
+	} else if field.Type != "enum" && len(field.Choices) > 0 {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "choices", Message: "is only allowed for enum fields"})
+	}
+	if field.Pattern != "" {
+		if field.Type != "string" {
+			fieldErrors = append(fieldErrors, models.FieldError{Field: "pattern", Message: "is only allowed for string fields"})
+		} else if _, err := regexp.Compile(field.Pattern); err != nil {
+			fieldErrors = append(fieldErrors, models.FieldError{Field: "pattern", Message: "must be a valid regular expression"})
+		}
+	}
+	return fieldErrors
+}
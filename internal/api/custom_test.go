@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomFields(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		router := setupTestRouter(store)
+		request := func(method, url, body string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest(method, url, strings.NewReader(body))
+			assert.NoError(t, err)
+			if method == "PATCH" {
+				req.Header.Set("Content-Type", MergePatchContentType)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr
+		}
+		createTestBook(t, store, "1965-08-01")
+
+		rr := request("POST", "/api/v1/custom-fields", `{"name": "po", "type": "string", "pattern": "PO-\\d+"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var field models.CustomField
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&field))
+		assert.Equal(t, models.CustomField{ID: 1, Name: "po", Type: "string", Choices: []string{}, Pattern: `PO-\d+`,
+			CreatedAt: field.CreatedAt, UpdatedAt: field.UpdatedAt}, field)
+		rr = request("POST", "/api/v1/custom-fields", `{"name": "Signed Copy", "type": "enum", "pattern": "("}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `{"field":"name","message":"must be up to 64 lower case letters, digits and underscores, starting with a letter"}`)
+		assert.Contains(t, rr.Body.String(), `{"field":"choices","message":"is required for enum fields"}`)
+		assert.Contains(t, rr.Body.String(), `{"field":"pattern","message":"is only allowed for string fields"}`)
+		rr = request("POST", "/api/v1/custom-fields", `{"name": "po", "type": "int"}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		rr = request("POST", "/api/v1/custom-fields", `{"name": "signed", "type": "bool"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		rr = request("PATCH", "/api/v1/books/1", `{"custom": {"po": "PO-42", "signed": true}}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"custom":{"po":"PO-42","signed":true}`)
+		rr = request("PATCH", "/api/v1/books/1", `{"custom": {"po": "42"}}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `custom field \"po\"`)
+		rr = request("PATCH", "/api/v1/books/1", `{"custom": {"lab": "north"}}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = request("GET", "/api/v1/books?custom.signed=true", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "1", rr.Header().Get("X-Total-Count"))
+		rr = request("GET", "/api/v1/books?custom.po=PO-7", "")
+		assert.Equal(t, "0", rr.Header().Get("X-Total-Count"))
+		rr = request("GET", "/api/v1/books?custom.lab=north", "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		// Null removes a value, like any member of a merge patch
+		rr = request("PATCH", "/api/v1/books/1", `{"custom": {"signed": null}}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"custom":{"po":"PO-42"}`)
+
+		rr = request("PATCH", "/api/v1/custom-fields/1", `{"pattern": "PO-\\d{3}"}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		rr = request("PATCH", "/api/v1/custom-fields/1", `{"name": "purchase_order"}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"name":"purchase_order"`)
+		assert.Contains(t, rr.Body.String(), `"book_count":1`)
+		rr = request("GET", "/api/v1/books/1", "")
+		assert.Contains(t, rr.Body.String(), `"custom":{"purchase_order":"PO-42"}`)
+
+		rr = request("GET", "/api/v1/custom-fields?sort=name", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "2", rr.Header().Get("X-Total-Count"))
+		rr = request("DELETE", "/api/v1/custom-fields/1", "")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = request("GET", "/api/v1/custom-fields/1", "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		rr = request("GET", "/api/v1/books/1", "")
+		assert.Contains(t, rr.Body.String(), `"custom":{}`)
+	})
+}
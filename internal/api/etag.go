@@ -0,0 +1,75 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Books and collections are tagged with their version, so an entity tag
+// changes exactly when the record does. Tags are strong, a version is never
+// reused for different content.
+
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", etag(version))
+}
+
+// matchETag reports whether a If-Match or If-None-Match header lists the tag
+// of version. Weak tags only match under the weak comparison used by
+// If-None-Match.
+func matchETag(header string, version int, weak bool) bool {
+	tag := etag(version)
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified sets the ETag of a record about to be returned and answers 304
+// Not Modified instead if the client's copy, named by If-None-Match, is
+// current.
+func notModified(w http.ResponseWriter, r *http.Request, version int) bool {
+	setETag(w, version)
+	header := r.Header.Get("If-None-Match")
+	if header == "" || !matchETag(header, version, true) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// ifMatch evaluates the If-Match header of a request that changes a record.
+// Without the header the change is unconditional and the version is 0.
+// Otherwise current loads the record's version, which is returned so the
+// store can make sure the record does not change before the request is
+// applied. If the tags do not match, the response is written and ok is false.
+func ifMatch(w http.ResponseWriter, r *http.Request, current func() (int, error)) (version int, ok bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return 0, true
+	}
+	version, err := current()
+	if err != nil {
+		writeError(w, r, err)
+		return 0, false
+	}
+	if !matchETag(header, version, false) {
+		preconditionFailed(w, r, fmt.Sprintf("The resource is at version %d, which does not match If-Match", version))
+		return 0, false
+	}
+	return version, true
+}
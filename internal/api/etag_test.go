@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchETag(t *testing.T) {
+	assert.True(t, matchETag(`"3"`, 3, false))
+	assert.True(t, matchETag(`"1", "3"`, 3, false))
+	assert.True(t, matchETag(`*`, 3, false))
+	assert.False(t, matchETag(`"2"`, 3, false))
+	assert.False(t, matchETag(`3`, 3, false))
+
+	// Weak tags only match under the weak comparison
+	assert.False(t, matchETag(`W/"3"`, 3, false))
+	assert.True(t, matchETag(`W/"3"`, 3, true))
+}
+
+func conditionalRequest(t *testing.T, router http.Handler, method, url, header, tag, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	assert.NoError(t, err)
+	if method == "PATCH" {
+		req.Header.Set("Content-Type", MergePatchContentType)
+	}
+	req.Header.Set(header, tag)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestBookETags(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		// Insert test data
+		createTestBook(t, store, "2022-01-01")
+		router := setupTestRouter(store)
+
+		rr := conditionalRequest(t, router, "GET", "/api/v1/books/1", "If-None-Match", `"0"`, "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"1"`, rr.Header().Get("ETag"))
+
+		// The client's copy is current
+		rr = conditionalRequest(t, router, "GET", "/api/v1/books/1", "If-None-Match", `W/"1"`, "")
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+		assert.Equal(t, `"1"`, rr.Header().Get("ETag"))
+		assert.Empty(t, rr.Body.String())
+
+		// Updates with the current tag go through and return the new one
+		body := `{"title": "Updated Book", "author": "Test Author", "published_date": "2022-01-01"}`
+		rr = conditionalRequest(t, router, "PUT", "/api/v1/books/1", "If-Match", `"1"`, body)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+		var book models.Book
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&book))
+		assert.Equal(t, 2, book.Version)
+
+		// Stale tags are rejected
+		rr = conditionalRequest(t, router, "PUT", "/api/v1/books/1", "If-Match", `"1"`, body)
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+		assert.Equal(t, ProblemContentType, rr.Header().Get("Content-Type"))
+		rr = conditionalRequest(t, router, "PATCH", "/api/v1/books/1", "If-Match", `"1"`, `{"genre": "Fiction"}`)
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+		rr = conditionalRequest(t, router, "DELETE", "/api/v1/books/1", "If-Match", `"1"`, "")
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+		stored, err := store.GetBook(1)
+		assert.NoError(t, err)
+		assert.Equal(t, "Updated Book", stored.Title)
+		assert.Equal(t, "", stored.Genre)
+
+		rr = conditionalRequest(t, router, "PATCH", "/api/v1/books/1", "If-Match", `"2"`, `{"genre": "Fiction", "version": 42}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
+
+		// A wildcard matches any version but not a missing book
+		rr = conditionalRequest(t, router, "DELETE", "/api/v1/books/1", "If-Match", `*`, "")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = conditionalRequest(t, router, "DELETE", "/api/v1/books/1", "If-Match", `*`, "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestCollectionETags(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		// Insert test data
+		createTestCollection(t, store)
+		bookID := createTestBook(t, store, "2022-01-01")
+		router := setupTestRouter(store)
+
+		rr := conditionalRequest(t, router, "GET", "/api/v1/collections/1", "If-None-Match", `"1"`, "")
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+
+		// Adding a book changes the collection
+		assert.NoError(t, store.AddBookToCollection(1, bookID))
+		rr = conditionalRequest(t, router, "GET", "/api/v1/collections/1", "If-None-Match", `"1"`, "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+
+		rr = conditionalRequest(t, router, "PUT", "/api/v1/collections/1", "If-Match", `"1"`, `{"name": "Renamed"}`)
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+		rr = conditionalRequest(t, router, "PATCH", "/api/v1/collections/1", "If-Match", `"2"`, `{"name": "Renamed"}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
+
+		rr = conditionalRequest(t, router, "DELETE", "/api/v1/collections/1", "If-Match", `"2"`, "")
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+		rr = conditionalRequest(t, router, "DELETE", "/api/v1/collections/1", "If-Match", `"3"`, "")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+}
@@ -2,12 +2,20 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/mayank-02/bookman/internal/bcp47"
+	"github.com/mayank-02/bookman/internal/blob"
 	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/isbn"
 	"github.com/mayank-02/bookman/internal/models"
+	"github.com/mayank-02/bookman/internal/pubdate"
 
 	"github.com/gorilla/mux"
 )
@@ -16,264 +24,895 @@ const (
 	APIVersion      = "v1"
 	BooksPath       = "/api/" + APIVersion + "/books"
 	CollectionsPath = "/api/" + APIVersion + "/collections"
+	AuthorsPath     = "/api/" + APIVersion + "/authors"
+	TagsPath        = "/api/" + APIVersion + "/tags"
+	SeriesPath      = "/api/" + APIVersion + "/series"
+	ReviewsPath     = "/api/" + APIVersion + "/reviews"
+	LoansPath       = "/api/" + APIVersion + "/loans"
+	LocationsPath   = "/api/" + APIVersion + "/locations"
+	CopiesPath      = "/api/" + APIVersion + "/copies"
+	WorksPath       = "/api/" + APIVersion + "/works"
+	PublishersPath  = "/api/" + APIVersion + "/publishers"
+	FieldsPath      = "/api/" + APIVersion + "/custom-fields"
+	TrashPath       = "/api/" + APIVersion + "/trash"
+	AuditPath       = "/api/" + APIVersion + "/audit"
+
+	DefaultListLimit   = 100
+	MaxListLimit       = 1000
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+	MaxInventorySize   = 10000
 )
 
-func RegisterHandlers(r *mux.Router, db *db.DB) {
+// RegisterHandlers registers the API on r. Books and everything else are
+// kept in db, the images of covers in covers. Changes to books and
+// collections are recorded in the audit log under the actor named by the
+// X-Actor header, and kept as revisions they can be restored to.
+func RegisterHandlers(r *mux.Router, db db.Store, covers blob.Store) {
+	r.NotFoundHandler = http.HandlerFunc(notFoundHandler)
+	r.MethodNotAllowedHandler = http.HandlerFunc(methodNotAllowedHandler)
+
 	r.HandleFunc(BooksPath, getBooks(db)).Methods("GET")
-	r.HandleFunc(BooksPath, createBook(db)).Methods("POST")
+	r.HandleFunc(BooksPath, asActor(db, createBook)).Methods("POST")
+	r.HandleFunc(BooksPath+"/search", searchBooks(db)).Methods("GET")
+	r.HandleFunc(BooksPath+"/tag", asActor(db, tagBooks)).Methods("POST")
+	r.HandleFunc(BooksPath+"/untag", asActor(db, untagBooks)).Methods("POST")
 	r.HandleFunc(BooksPath+"/{id}", getBook(db)).Methods("GET")
-	r.HandleFunc(BooksPath+"/{id}", updateBook(db)).Methods("PUT")
-	r.HandleFunc(BooksPath+"/{id}", deleteBook(db)).Methods("DELETE")
+	r.HandleFunc(BooksPath+"/{id}", asActor(db, updateBook)).Methods("PUT")
+	r.HandleFunc(BooksPath+"/{id}", asActor(db, patchBook)).Methods("PATCH")
+	r.HandleFunc(BooksPath+"/{id}", asActor(db, deleteBook)).Methods("DELETE")
+	r.HandleFunc(BooksPath+"/{id}/history", getBookHistory(db)).Methods("GET")
+	r.HandleFunc(BooksPath+"/{id}/revisions", getBookRevisions(db)).Methods("GET")
+	r.HandleFunc(BooksPath+"/{id}/revisions/diff", diffBookRevisions(db)).Methods("GET")
+	r.HandleFunc(BooksPath+"/{id}/revisions/{revision}", getBookRevision(db)).Methods("GET")
+	r.HandleFunc(BooksPath+"/{id}/revisions/{revision}/restore", asActor(db, restoreBookRevision)).Methods("POST")
+	r.HandleFunc(BooksPath+"/{id}/cover", getCover(db, covers)).Methods("GET", "HEAD")
+	r.HandleFunc(BooksPath+"/{id}/cover", asActor(db, putCover(covers))).Methods("PUT")
+	r.HandleFunc(BooksPath+"/{id}/cover", asActor(db, deleteCover(covers))).Methods("DELETE")
+	r.HandleFunc(BooksPath+"/{id}/cover/{size}", getCover(db, covers)).Methods("GET", "HEAD")
+	r.HandleFunc(BooksPath+"/{id}/reading", getReadingLog(db)).Methods("GET")
+	r.HandleFunc(BooksPath+"/{id}/reading", createReadingSession(db)).Methods("POST")
+	r.HandleFunc(BooksPath+"/{id}/reading/{sessionId}", getReadingSession(db)).Methods("GET")
+	r.HandleFunc(BooksPath+"/{id}/reading/{sessionId}", updateReadingSession(db)).Methods("PUT")
+	r.HandleFunc(BooksPath+"/{id}/reading/{sessionId}", patchReadingSession(db)).Methods("PATCH")
+	r.HandleFunc(BooksPath+"/{id}/reading/{sessionId}", deleteReadingSession(db)).Methods("DELETE")
+	r.HandleFunc(BooksPath+"/{id}/reading/{sessionId}/progress", addReadingProgress(db)).Methods("POST")
+	r.HandleFunc(BooksPath+"/{id}/reviews", getBookReviews(db)).Methods("GET")
+	r.HandleFunc(BooksPath+"/{id}/reviews", createReview(db)).Methods("POST")
+	r.HandleFunc(BooksPath+"/{id}/loans", getBookLoans(db)).Methods("GET")
+	r.HandleFunc(BooksPath+"/{id}/loans", createLoan(db)).Methods("POST")
+	r.HandleFunc(BooksPath+"/{id}/copies", getBookCopies(db)).Methods("GET")
+	r.HandleFunc(BooksPath+"/{id}/copies", createCopy(db)).Methods("POST")
 	r.HandleFunc(CollectionsPath, getCollections(db)).Methods("GET")
-	r.HandleFunc(CollectionsPath, createCollection(db)).Methods("POST")
+	r.HandleFunc(CollectionsPath, asActor(db, createCollection)).Methods("POST")
 	r.HandleFunc(CollectionsPath+"/{id}", getCollection(db)).Methods("GET")
-	r.HandleFunc(CollectionsPath+"/{id}", updateCollection(db)).Methods("PUT")
-	r.HandleFunc(CollectionsPath+"/{id}", deleteCollection(db)).Methods("DELETE")
-	r.HandleFunc(CollectionsPath+"/{id}/books/{bookId}", addBookToCollection(db)).Methods("POST")
-	r.HandleFunc(CollectionsPath+"/{id}/books/{bookId}", removeBookFromCollection(db)).Methods("DELETE")
+	r.HandleFunc(CollectionsPath+"/{id}", asActor(db, updateCollection)).Methods("PUT")
+	r.HandleFunc(CollectionsPath+"/{id}", asActor(db, patchCollection)).Methods("PATCH")
+	r.HandleFunc(CollectionsPath+"/{id}", asActor(db, deleteCollection)).Methods("DELETE")
+	r.HandleFunc(CollectionsPath+"/{id}/books/{bookId}", asActor(db, addBookToCollection)).Methods("POST")
+	r.HandleFunc(CollectionsPath+"/{id}/books/{bookId}", asActor(db, removeBookFromCollection)).Methods("DELETE")
+	r.HandleFunc(CollectionsPath+"/{id}/revisions", getCollectionRevisions(db)).Methods("GET")
+	r.HandleFunc(CollectionsPath+"/{id}/revisions/diff", diffCollectionRevisions(db)).Methods("GET")
+	r.HandleFunc(CollectionsPath+"/{id}/revisions/{revision}", getCollectionRevision(db)).Methods("GET")
+	r.HandleFunc(CollectionsPath+"/{id}/revisions/{revision}/restore", asActor(db, restoreCollectionRevision)).Methods("POST")
+	r.HandleFunc(AuthorsPath, getAuthors(db)).Methods("GET")
+	r.HandleFunc(AuthorsPath, createAuthor(db)).Methods("POST")
+	r.HandleFunc(AuthorsPath+"/{id}", getAuthor(db)).Methods("GET")
+	r.HandleFunc(AuthorsPath+"/{id}", asActor(db, updateAuthor)).Methods("PUT")
+	r.HandleFunc(AuthorsPath+"/{id}", asActor(db, patchAuthor)).Methods("PATCH")
+	r.HandleFunc(AuthorsPath+"/{id}", deleteAuthor(db)).Methods("DELETE")
+	r.HandleFunc(AuthorsPath+"/{id}/books", getAuthorBooks(db)).Methods("GET")
+	r.HandleFunc(FieldsPath, getCustomFields(db)).Methods("GET")
+	r.HandleFunc(FieldsPath, createCustomField(db)).Methods("POST")
+	r.HandleFunc(FieldsPath+"/{id}", getCustomField(db)).Methods("GET")
+	r.HandleFunc(FieldsPath+"/{id}", asActor(db, updateCustomField)).Methods("PUT")
+	r.HandleFunc(FieldsPath+"/{id}", asActor(db, patchCustomField)).Methods("PATCH")
+	r.HandleFunc(FieldsPath+"/{id}", asActor(db, deleteCustomField)).Methods("DELETE")
+	r.HandleFunc(PublishersPath, getPublishers(db)).Methods("GET")
+	r.HandleFunc(PublishersPath+"/{id}", getPublisher(db)).Methods("GET")
+	r.HandleFunc(PublishersPath+"/{id}", asActor(db, updatePublisher)).Methods("PUT")
+	r.HandleFunc(PublishersPath+"/{id}", asActor(db, patchPublisher)).Methods("PATCH")
+	r.HandleFunc(PublishersPath+"/{id}", deletePublisher(db)).Methods("DELETE")
+	r.HandleFunc(PublishersPath+"/{id}/books", getPublisherBooks(db)).Methods("GET")
+	r.HandleFunc(TagsPath, getTags(db)).Methods("GET")
+	r.HandleFunc(TagsPath, createTag(db)).Methods("POST")
+	r.HandleFunc(TagsPath+"/{id}", getTag(db)).Methods("GET")
+	r.HandleFunc(TagsPath+"/{id}", asActor(db, updateTag)).Methods("PUT")
+	r.HandleFunc(TagsPath+"/{id}", asActor(db, patchTag)).Methods("PATCH")
+	r.HandleFunc(TagsPath+"/{id}", asActor(db, deleteTag)).Methods("DELETE")
+	r.HandleFunc(TagsPath+"/{id}/merge/{targetId}", asActor(db, mergeTags)).Methods("POST")
+	r.HandleFunc(SeriesPath, listSeries(db)).Methods("GET")
+	r.HandleFunc(SeriesPath, createSeries(db)).Methods("POST")
+	r.HandleFunc(SeriesPath+"/{id}", getSeries(db)).Methods("GET")
+	r.HandleFunc(SeriesPath+"/{id}", asActor(db, updateSeries)).Methods("PUT")
+	r.HandleFunc(SeriesPath+"/{id}", asActor(db, patchSeries)).Methods("PATCH")
+	r.HandleFunc(SeriesPath+"/{id}", asActor(db, deleteSeries)).Methods("DELETE")
+	r.HandleFunc(SeriesPath+"/{id}/books/{bookId}", asActor(db, setSeriesBook)).Methods("PUT")
+	r.HandleFunc(SeriesPath+"/{id}/books/{bookId}", asActor(db, removeSeriesBook)).Methods("DELETE")
+	r.HandleFunc(ReviewsPath, getReviews(db)).Methods("GET")
+	r.HandleFunc(ReviewsPath+"/{id}", getReview(db)).Methods("GET")
+	r.HandleFunc(ReviewsPath+"/{id}", updateReview(db)).Methods("PUT")
+	r.HandleFunc(ReviewsPath+"/{id}", patchReview(db)).Methods("PATCH")
+	r.HandleFunc(ReviewsPath+"/{id}", deleteReview(db)).Methods("DELETE")
+	r.HandleFunc(LoansPath, getLoans(db)).Methods("GET")
+	r.HandleFunc(LoansPath+"/{id}", getLoan(db)).Methods("GET")
+	r.HandleFunc(LoansPath+"/{id}", updateLoan(db)).Methods("PUT")
+	r.HandleFunc(LoansPath+"/{id}", patchLoan(db)).Methods("PATCH")
+	r.HandleFunc(LoansPath+"/{id}", deleteLoan(db)).Methods("DELETE")
+	r.HandleFunc(LoansPath+"/{id}/return", returnLoan(db)).Methods("POST")
+	r.HandleFunc(LocationsPath, getLocations(db)).Methods("GET")
+	r.HandleFunc(LocationsPath, createLocation(db)).Methods("POST")
+	r.HandleFunc(LocationsPath+"/{id}", getLocation(db)).Methods("GET")
+	r.HandleFunc(LocationsPath+"/{id}", updateLocation(db)).Methods("PUT")
+	r.HandleFunc(LocationsPath+"/{id}", patchLocation(db)).Methods("PATCH")
+	r.HandleFunc(LocationsPath+"/{id}", deleteLocation(db)).Methods("DELETE")
+	r.HandleFunc(LocationsPath+"/{id}/copies", getLocationCopies(db)).Methods("GET")
+	r.HandleFunc(LocationsPath+"/{id}/inventory", checkInventory(db)).Methods("POST")
+	r.HandleFunc(CopiesPath, getCopies(db)).Methods("GET")
+	r.HandleFunc(CopiesPath+"/move", moveCopies(db)).Methods("POST")
+	r.HandleFunc(CopiesPath+"/{id}", getCopy(db)).Methods("GET")
+	r.HandleFunc(CopiesPath+"/{id}", updateCopy(db)).Methods("PUT")
+	r.HandleFunc(CopiesPath+"/{id}", patchCopy(db)).Methods("PATCH")
+	r.HandleFunc(CopiesPath+"/{id}", deleteCopy(db)).Methods("DELETE")
+	r.HandleFunc(WorksPath, getWorks(db)).Methods("GET")
+	r.HandleFunc(WorksPath, createWork(db)).Methods("POST")
+	r.HandleFunc(WorksPath+"/{id}", getWork(db)).Methods("GET")
+	r.HandleFunc(WorksPath+"/{id}", updateWork(db)).Methods("PUT")
+	r.HandleFunc(WorksPath+"/{id}", patchWork(db)).Methods("PATCH")
+	r.HandleFunc(WorksPath+"/{id}", asActor(db, deleteWork)).Methods("DELETE")
+	r.HandleFunc(WorksPath+"/{id}/editions", getWorkEditions(db)).Methods("GET")
+	r.HandleFunc(WorksPath+"/{id}/merge", asActor(db, mergeEditions)).Methods("POST")
+	r.HandleFunc(WorksPath+"/{id}/split", asActor(db, splitEditions)).Methods("POST")
+	r.HandleFunc(TrashPath, getTrash(db)).Methods("GET")
+	r.HandleFunc(TrashPath, asActor(db, purgeTrash(covers))).Methods("DELETE")
+	r.HandleFunc(TrashPath+"/books/{id}/restore", asActor(db, restoreBook)).Methods("POST")
+	r.HandleFunc(TrashPath+"/collections/{id}/restore", asActor(db, restoreCollection)).Methods("POST")
+	r.HandleFunc(AuditPath, getAudit(db)).Methods("GET")
+}
+
+// parseLimit reads the limit query parameter, which must lie between 1 and
+// max.
+func parseLimit(r *http.Request, defaultLimit, max int) (int, error) {
+	l := r.URL.Query().Get("limit")
+	if l == "" {
+		return defaultLimit, nil
+	}
+	limit, err := strconv.Atoi(l)
+	if err != nil || limit < 1 || limit > max {
+		return 0, fmt.Errorf("Invalid limit, expected a number between 1 and %d", max)
+	}
+	return limit, nil
+}
+
+// parseListOptions reads the paging and ordering query parameters shared by
+// the list endpoints: limit, after, sort and order.
+func parseListOptions(r *http.Request) (db.ListOptions, error) {
+	limit, err := parseLimit(r, DefaultListLimit, MaxListLimit)
+	if err != nil {
+		return db.ListOptions{}, err
+	}
+	opts := db.ListOptions{
+		Sort:  r.URL.Query().Get("sort"),
+		Limit: limit,
+		After: r.URL.Query().Get("after"),
+	}
+	switch r.URL.Query().Get("order") {
+	case "", "asc":
+	case "desc":
+		opts.Desc = true
+	default:
+		return db.ListOptions{}, errors.New("Invalid order, expected asc or desc")
+	}
+	return opts, nil
+}
+
+// parseID reads the numeric id in the path variable name. kind names the
+// resource in the error message.
+func parseID(r *http.Request, name, kind string) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)[name])
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid %s ID", kind)
+	}
+	return id, nil
 }
 
-func getBooks(db *db.DB) http.HandlerFunc {
+// writePageHeaders reports the total number of items in X-Total-Count and,
+// unless this is the last page, links to the next page in the Link header.
+func writePageHeaders(w http.ResponseWriter, r *http.Request, page db.Page) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if page.Next == "" {
+		return
+	}
+	next := *r.URL
+	query := next.Query()
+	query.Set("after", page.Next)
+	next.RawQuery = query.Encode()
+	w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.RequestURI()))
+}
+
+func getBooks(db db.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		author := r.URL.Query().Get("author")
-		genre := r.URL.Query().Get("genre")
-		from := r.URL.Query().Get("from")
-		to := r.URL.Query().Get("to")
+		opts, err := parseListOptions(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		filter, err := parseBookFilter(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
 
-		books, err := db.GetBooks(author, genre, from, to)
+		books, page, err := db.GetBooks(filter, opts)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeError(w, r, err)
 			return
 		}
+		if books == nil {
+			books = []models.Book{}
+		}
+		writePageHeaders(w, r, page)
 		json.NewEncoder(w).Encode(books)
 	}
 }
 
-func getBook(db *db.DB) http.HandlerFunc {
+// parseBookFilter reads the book filters from the query. The isbn filter
+// takes either form of an ISBN, the status filter one of the reading
+// statuses and min_rating a rating from 0 to 5. collapse=work lists one
+// edition per work.
+func parseBookFilter(r *http.Request) (db.BookFilter, error) {
+	filter := db.BookFilter{
+		Author: r.URL.Query().Get("author"),
+		Genre:  r.URL.Query().Get("genre"),
+		Tags:   parseTagFilter(r),
+		From:   r.URL.Query().Get("from"),
+		To:     r.URL.Query().Get("to"),
+		Status: r.URL.Query().Get("status"),
+	}
+	for _, param := range []string{"from", "to"} {
+		if value := r.URL.Query().Get(param); value != "" {
+			if _, err := pubdate.Parse(value); err != nil {
+				return db.BookFilter{}, fmt.Errorf("Invalid %s: %s", param, publishedDateFormat)
+			}
+		}
+	}
+	if filter.Status != "" && !slices.Contains(models.ReadingStatuses, filter.Status) {
+		return db.BookFilter{}, fmt.Errorf("Invalid status: must be one of %s", strings.Join(models.ReadingStatuses, ", "))
+	}
+	if value := r.URL.Query().Get("min_rating"); value != "" {
+		rating, err := strconv.ParseFloat(value, 64)
+		if err != nil || rating < 0 || rating > 5 {
+			return db.BookFilter{}, fmt.Errorf("Invalid min_rating: must be a number from 0 to 5")
+		}
+		filter.MinRating = rating
+	}
+	if value := r.URL.Query().Get("isbn"); value != "" {
+		_, isbn13, err := isbn.Parse(value)
+		if err != nil {
+			return db.BookFilter{}, fmt.Errorf("Invalid isbn: %v", err)
+		}
+		filter.ISBN = isbn13
+	}
+	if err := parseDetailFilter(r, &filter); err != nil {
+		return db.BookFilter{}, err
+	}
+	// Custom fields are filtered by custom.<name>=<value>, the store checks
+	// the names and values against the field definitions
+	for key, values := range r.URL.Query() {
+		if name, ok := strings.CutPrefix(key, "custom."); ok && values[0] != "" {
+			if filter.Custom == nil {
+				filter.Custom = map[string]string{}
+			}
+			filter.Custom[name] = values[0]
+		}
+	}
+	switch r.URL.Query().Get("collapse") {
+	case "":
+	case "work":
+		filter.CollapseWorks = true
+	default:
+		return db.BookFilter{}, fmt.Errorf("Invalid collapse: must be work")
+	}
+	return filter, nil
+}
+
+// parseDetailFilter reads the filters on the publishing details of books.
+func parseDetailFilter(r *http.Request, filter *db.BookFilter) error {
+	filter.Publisher = r.URL.Query().Get("publisher")
+	if value := r.URL.Query().Get("language"); value != "" {
+		language, err := bcp47.Canonical(value)
+		if err != nil {
+			return fmt.Errorf("Invalid language: must be a BCP 47 language tag")
+		}
+		filter.Language = language
+	}
+	filter.Format = r.URL.Query().Get("format")
+	if filter.Format != "" && !slices.Contains(models.BookFormats, filter.Format) {
+		return fmt.Errorf("Invalid format: must be one of %s", strings.Join(models.BookFormats, ", "))
+	}
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{{"min_pages", &filter.MinPages}, {"max_pages", &filter.MaxPages}} {
+		if value := r.URL.Query().Get(param.name); value != "" {
+			pages, err := strconv.Atoi(value)
+			if err != nil || pages < 0 {
+				return fmt.Errorf("Invalid %s: must be a non-negative integer", param.name)
+			}
+			*param.value = pages
+		}
+	}
+	for _, param := range []struct {
+		name  string
+		value *float64
+	}{{"max_height", &filter.MaxHeight}, {"max_width", &filter.MaxWidth}, {"max_thickness", &filter.MaxThickness}} {
+		if value := r.URL.Query().Get(param.name); value != "" {
+			millimetres, err := strconv.ParseFloat(value, 64)
+			if err != nil || millimetres < 0 {
+				return fmt.Errorf("Invalid %s: must be a non-negative number of millimetres", param.name)
+			}
+			*param.value = millimetres
+		}
+	}
+	return nil
+}
+
+func searchBooks(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("q")
+		if strings.TrimSpace(query) == "" {
+			validationFailed(w, r, []models.FieldError{{Field: "q", Message: "is required"}})
+			return
+		}
+
+		limit, err := parseLimit(r, DefaultSearchLimit, MaxSearchLimit)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+
+		results, err := db.SearchBooks(query, limit)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if results == nil {
+			results = []models.BookSearchResult{}
+		}
+		json.NewEncoder(w).Encode(results)
+	}
+}
+
+func getBook(db db.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id, _ := strconv.Atoi(mux.Vars(r)["id"])
+		id, err := parseID(r, "id", "book")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
 		book, err := db.GetBook(id)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusNotFound)
+			writeError(w, r, err)
+			return
+		}
+		if notModified(w, r, book.Version) {
 			return
 		}
 		json.NewEncoder(w).Encode(book)
 	}
 }
 
-func createBook(db *db.DB) http.HandlerFunc {
+func createBook(db db.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var book models.Book
-		if err := json.NewDecoder(r.Body).Decode(&book); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+		if err := decodeJSON(r, &book); err != nil {
+			badRequest(w, r, err)
 			return
 		}
 
 		// Validation checks
-		if book.Title == "" || book.Author == "" || book.PublishedDate == "" {
-			http.Error(w, "Title, author, and published date are required", http.StatusBadRequest)
+		if fieldErrors := validateBook(&book); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
 			return
 		}
 
-		if result, err := time.Parse("2006-01-02", book.PublishedDate); err != nil || result.IsZero() {
-			http.Error(w, "Invalid published date format, expected YYYY-MM-DD", http.StatusBadRequest)
+		id, err := db.CreateBook(book)
+		if err != nil {
+			writeError(w, r, err)
 			return
 		}
-
-		id, err := db.CreateBook(book)
+		book, err = db.GetBook(id)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeError(w, r, err)
 			return
 		}
-		book.ID = id
+		setETag(w, book.Version)
 		w.WriteHeader(http.StatusCreated)
 		json.NewEncoder(w).Encode(book)
 	}
 }
 
-func updateBook(db *db.DB) http.HandlerFunc {
+func updateBook(db db.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id, _ := strconv.Atoi(mux.Vars(r)["id"])
+		id, err := parseID(r, "id", "book")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
 		var book models.Book
-		json.NewDecoder(r.Body).Decode(&book)
+		if err := decodeJSON(r, &book); err != nil {
+			badRequest(w, r, err)
+			return
+		}
 		book.ID = id
 
 		// Validation checks
-		if book.Title == "" || book.Author == "" || book.PublishedDate == "" {
-			http.Error(w, "Title, author, and published date are required", http.StatusBadRequest)
+		if fieldErrors := validateBook(&book); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		// The version in the body is ignored, updates are only conditional
+		// with If-Match
+		var ok bool
+		book.Version, ok = ifMatch(w, r, func() (int, error) {
+			current, err := db.GetBook(id)
+			return current.Version, err
+		})
+		if !ok {
+			return
+		}
+
+		err = db.UpdateBook(book)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		book, err = db.GetBook(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		setETag(w, book.Version)
+		json.NewEncoder(w).Encode(book)
+	}
+}
+
+// patchBook applies a JSON merge patch, so only the fields present in the
+// body change. The result must still be a valid book.
+func patchBook(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "book")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		if !isMergePatch(r) {
+			unsupportedMediaType(w, r, MergePatchContentType)
+			return
+		}
+
+		current, err := db.GetBook(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		version, ok := ifMatch(w, r, func() (int, error) { return current.Version, nil })
+		if !ok {
+			return
+		}
+		book, err := applyMergePatch(r, current)
+		if err != nil {
+			badRequest(w, r, err)
 			return
 		}
+		// Fields managed by the server cannot be patched, and the patch only
+		// applies to the version it was merged with
+		book.ID, book.CreatedAt, book.UpdatedAt = current.ID, current.CreatedAt, current.UpdatedAt
+		book.Version = current.Version
+		// Changing the author line replaces the authors, which are split
+		// from it again by the store
+		if book.Author != current.Author && slices.Equal(book.Authors, current.Authors) {
+			book.Authors = nil
+		}
+		// Changing one form of the ISBN replaces the other, which is derived
+		// again during validation
+		if book.ISBN13 != current.ISBN13 && book.ISBN10 == current.ISBN10 {
+			book.ISBN10 = ""
+		} else if book.ISBN10 != current.ISBN10 && book.ISBN13 == current.ISBN13 {
+			book.ISBN13 = ""
+		}
 
-		if result, err := time.Parse("2006-01-02", book.PublishedDate); err != nil || result.IsZero() {
-			http.Error(w, "Invalid published date format, expected YYYY-MM-DD", http.StatusBadRequest)
+		// Validation checks
+		if fieldErrors := validateBook(&book); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
 			return
 		}
 
-		err := db.UpdateBook(book)
+		err = db.UpdateBook(book)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writePatchError(w, r, err, version)
 			return
 		}
+		book, err = db.GetBook(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		setETag(w, book.Version)
 		json.NewEncoder(w).Encode(book)
 	}
 }
 
-func deleteBook(db *db.DB) http.HandlerFunc {
+// deleteBook moves the book to the trash. The files of its cover are kept
+// until it is purged.
+func deleteBook(db db.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id, _ := strconv.Atoi(mux.Vars(r)["id"])
-		err := db.DeleteBook(id)
+		id, err := parseID(r, "id", "book")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		version, ok := ifMatch(w, r, func() (int, error) {
+			current, err := db.GetBook(id)
+			return current.Version, err
+		})
+		if !ok {
+			return
+		}
+		err = db.DeleteBook(id, version)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeError(w, r, err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
 
-func getCollections(db *db.DB) http.HandlerFunc {
+func getCollections(db db.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		collections, err := db.GetCollections()
+		opts, err := parseListOptions(r)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			badRequest(w, r, err)
 			return
 		}
+
+		collections, page, err := db.GetCollections(opts)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if collections == nil {
+			collections = []models.Collection{}
+		}
+		writePageHeaders(w, r, page)
 		json.NewEncoder(w).Encode(collections)
 	}
 }
 
-func getCollection(db *db.DB) http.HandlerFunc {
+func getCollection(db db.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id, _ := strconv.Atoi(mux.Vars(r)["id"])
+		id, err := parseID(r, "id", "collection")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
 		collection, err := db.GetCollection(id)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusNotFound)
+			writeError(w, r, err)
+			return
+		}
+		if notModified(w, r, collection.Version) {
 			return
 		}
 		json.NewEncoder(w).Encode(collection)
 	}
 }
 
-func createCollection(db *db.DB) http.HandlerFunc {
+func createCollection(db db.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var collection models.Collection
-		json.NewDecoder(r.Body).Decode(&collection)
+		if err := decodeJSON(r, &collection); err != nil {
+			badRequest(w, r, err)
+			return
+		}
 
 		// Validation checks
-		if collection.Name == "" {
-			http.Error(w, "Collection name is required", http.StatusBadRequest)
+		if fieldErrors := validateCollection(collection); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
 			return
 		}
 
 		id, err := db.CreateCollection(collection)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeError(w, r, err)
 			return
 		}
-		collection.ID = id
+		collection, err = db.GetCollection(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		setETag(w, collection.Version)
 		w.WriteHeader(http.StatusCreated)
 		json.NewEncoder(w).Encode(collection)
 	}
 }
 
-func updateCollection(db *db.DB) http.HandlerFunc {
+func updateCollection(db db.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id, _ := strconv.Atoi(mux.Vars(r)["id"])
+		id, err := parseID(r, "id", "collection")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
 		var collection models.Collection
-		json.NewDecoder(r.Body).Decode(&collection)
+		if err := decodeJSON(r, &collection); err != nil {
+			badRequest(w, r, err)
+			return
+		}
 		collection.ID = id
 
 		// Validation checks
-		if collection.Name == "" {
-			http.Error(w, "Collection name is required", http.StatusBadRequest)
+		if fieldErrors := validateCollection(collection); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		var ok bool
+		collection.Version, ok = ifMatch(w, r, func() (int, error) {
+			current, err := db.GetCollection(id)
+			return current.Version, err
+		})
+		if !ok {
 			return
 		}
 
-		err := db.UpdateCollection(collection)
+		err = db.UpdateCollection(collection)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		collection, err = db.GetCollection(id)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeError(w, r, err)
 			return
 		}
+		setETag(w, collection.Version)
 		json.NewEncoder(w).Encode(collection)
 	}
 }
 
-func deleteCollection(db *db.DB) http.HandlerFunc {
+// patchCollection applies a JSON merge patch to the collection. Its books
+// are managed through the membership endpoints and cannot be patched.
+func patchCollection(db db.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id, _ := strconv.Atoi(mux.Vars(r)["id"])
-		err := db.DeleteCollection(id)
+		id, err := parseID(r, "id", "collection")
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			badRequest(w, r, err)
 			return
 		}
-		w.WriteHeader(http.StatusNoContent)
-	}
-}
-func addBookToCollection(db *db.DB) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		vars := mux.Vars(r)
-		collectionID, err := strconv.Atoi(vars["id"])
+		if !isMergePatch(r) {
+			unsupportedMediaType(w, r, MergePatchContentType)
+			return
+		}
+
+		current, err := db.GetCollection(id)
 		if err != nil {
-			http.Error(w, "invalid collection ID", http.StatusBadRequest)
+			writeError(w, r, err)
 			return
 		}
-		bookID, err := strconv.Atoi(vars["bookId"])
+		version, ok := ifMatch(w, r, func() (int, error) { return current.Version, nil })
+		if !ok {
+			return
+		}
+		collection, err := applyMergePatch(r, current)
 		if err != nil {
-			http.Error(w, "invalid book ID", http.StatusBadRequest)
+			badRequest(w, r, err)
 			return
 		}
+		// The patch only applies to the version it was merged with
+		collection.ID, collection.Books, collection.Version = current.ID, current.Books, current.Version
 
-		// Check if the book is already in the collection
-		inCollection, err := db.IsBookInCollection(collectionID, bookID)
+		// Validation checks
+		if fieldErrors := validateCollection(collection); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		err = db.UpdateCollection(collection)
 		if err != nil {
-			http.Error(w, "error checking book in collection: "+err.Error(), http.StatusInternalServerError)
+			writePatchError(w, r, err, version)
 			return
 		}
-		if inCollection {
-			http.Error(w, "book is already in the collection", http.StatusConflict)
+		collection, err = db.GetCollection(id)
+		if err != nil {
+			writeError(w, r, err)
 			return
 		}
+		setETag(w, collection.Version)
+		json.NewEncoder(w).Encode(collection)
+	}
+}
 
-		// Add the book to the collection
-		err = db.AddBookToCollection(collectionID, bookID)
+func deleteCollection(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "collection")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		version, ok := ifMatch(w, r, func() (int, error) {
+			current, err := db.GetCollection(id)
+			return current.Version, err
+		})
+		if !ok {
+			return
+		}
+		err = db.DeleteCollection(id, version)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeError(w, r, err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
 
-func removeBookFromCollection(db *db.DB) http.HandlerFunc {
+func addBookToCollection(db db.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		vars := mux.Vars(r)
-		collectionID, err := strconv.Atoi(vars["id"])
+		collectionID, err := parseID(r, "id", "collection")
 		if err != nil {
-			http.Error(w, "invalid collection ID", http.StatusBadRequest)
+			badRequest(w, r, err)
 			return
 		}
-		bookID, err := strconv.Atoi(vars["bookId"])
+		bookID, err := parseID(r, "bookId", "book")
 		if err != nil {
-			http.Error(w, "invalid book ID", http.StatusBadRequest)
+			badRequest(w, r, err)
 			return
 		}
 
-		// Check if the book is in the collection
-		inCollection, err := db.IsBookInCollection(collectionID, bookID)
+		// The store reports missing collections and books as well as books
+		// that are already in the collection
+		err = db.AddBookToCollection(collectionID, bookID)
 		if err != nil {
-			http.Error(w, "error checking book in collection: "+err.Error(), http.StatusInternalServerError)
+			writeError(w, r, err)
 			return
 		}
-		if !inCollection {
-			http.Error(w, "book is not in the collection", http.StatusNotFound)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func removeBookFromCollection(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		collectionID, err := parseID(r, "id", "collection")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		bookID, err := parseID(r, "bookId", "book")
+		if err != nil {
+			badRequest(w, r, err)
 			return
 		}
 
-		// Remove the book from the collection
 		err = db.RemoveBookFromCollection(collectionID, bookID)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeError(w, r, err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+func decodeJSON(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return fmt.Errorf("Invalid JSON body: %v", err)
+	}
+	return nil
+}
+
+const publishedDateFormat = "must be a date in the format YYYY-MM-DD, YYYY-MM, YYYY or c. YYYY"
+
+// validateBook checks the fields of a book and normalizes its publication
+// date and ISBNs. Either form of the ISBN is enough, the other one is filled
+// in.
+func validateBook(book *models.Book) []models.FieldError {
+	var fieldErrors []models.FieldError
+	if book.Title == "" {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "title", Message: "is required"})
+	}
+	if book.Author == "" && len(book.Authors) == 0 {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "author", Message: "is required"})
+	}
+	for i, author := range book.Authors {
+		if author.ID == 0 && strings.TrimSpace(author.Name) == "" {
+			fieldErrors = append(fieldErrors, models.FieldError{Field: fmt.Sprintf("authors[%d]", i), Message: "must have an id or a name"})
+		}
+		if author.Role != "" && !slices.Contains(models.AuthorRoles, author.Role) {
+			fieldErrors = append(fieldErrors, models.FieldError{Field: fmt.Sprintf("authors[%d].role", i),
+				Message: "must be one of " + strings.Join(models.AuthorRoles, ", ")})
+		}
+	}
+	for i, tag := range book.Tags {
+		if message := tagNameError(tag); message != "" {
+			fieldErrors = append(fieldErrors, models.FieldError{Field: fmt.Sprintf("tags[%d]", i), Message: message})
+		}
+	}
+	if book.PublishedDate == "" {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "published_date", Message: "is required"})
+	} else if published, err := pubdate.Parse(book.PublishedDate); err != nil {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "published_date", Message: publishedDateFormat})
+	} else {
+		book.PublishedDate, book.PublishedPrecision = published.String(), published.Precision
+	}
+	fieldErrors = append(fieldErrors, normalizeDetails(book)...)
+	return append(fieldErrors, normalizeISBNs(book)...)
+}
+
+// normalizeDetails checks the publishing details of a book and brings the
+// publisher name and language tag into their stored form.
+func normalizeDetails(book *models.Book) []models.FieldError {
+	var fieldErrors []models.FieldError
+	book.Publisher = strings.TrimSpace(book.Publisher)
+	if book.Language != "" {
+		language, err := bcp47.Canonical(book.Language)
+		if err != nil {
+			fieldErrors = append(fieldErrors, models.FieldError{Field: "language", Message: "must be a BCP 47 language tag"})
+		}
+		book.Language = language
+	}
+	if book.PageCount < 0 {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "page_count", Message: "must be at least 0"})
+	}
+	if book.Format != "" && !slices.Contains(models.BookFormats, book.Format) {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "format", Message: "must be one of " + strings.Join(models.BookFormats, ", ")})
+	}
+	for _, dimension := range []struct {
+		field string
+		value float64
+	}{{"dimensions.height", book.Dimensions.Height}, {"dimensions.width", book.Dimensions.Width}, {"dimensions.thickness", book.Dimensions.Thickness}} {
+		if dimension.value < 0 {
+			fieldErrors = append(fieldErrors, models.FieldError{Field: dimension.field, Message: "must be at least 0"})
+		}
+	}
+	return fieldErrors
+}
+
+func normalizeISBNs(book *models.Book) []models.FieldError {
+	var isbn10, isbn13 string
+	if book.ISBN10 != "" {
+		value := isbn.Normalize(book.ISBN10)
+		if isbn.Validate10(value) != nil {
+			return []models.FieldError{{Field: "isbn_10", Message: "must be a valid ISBN-10"}}
+		}
+		isbn10, isbn13 = value, isbn.To13(value)
+	}
+	if book.ISBN13 != "" {
+		value := isbn.Normalize(book.ISBN13)
+		if isbn.Validate13(value) != nil {
+			return []models.FieldError{{Field: "isbn_13", Message: "must be a valid ISBN-13"}}
+		}
+		if isbn13 != "" && isbn13 != value {
+			return []models.FieldError{{Field: "isbn_13", Message: "must match isbn_10"}}
+		}
+		isbn13 = value
+		isbn10, _ = isbn.To10(value)
+	}
+	book.ISBN10, book.ISBN13 = isbn10, isbn13
+	return nil
+}
+
+func validateCollection(collection models.Collection) []models.FieldError {
+	if collection.Name == "" {
+		return []models.FieldError{{Field: "name", Message: "is required"}}
+	}
+	return nil
+}
+
+// validateDate checks an optional date field.
+func validateDate(field, value string) []models.FieldError {
+	if _, err := time.Parse("2006-01-02", value); value != "" && err != nil {
+		return []models.FieldError{{Field: field, Message: "must be a date in the format YYYY-MM-DD"}}
+	}
+	return nil
+}
@@ -5,463 +5,756 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/mayank-02/bookman/internal/blob"
 	"github.com/mayank-02/bookman/internal/db"
 	"github.com/mayank-02/bookman/internal/models"
 	"github.com/stretchr/testify/assert"
 )
 
-func setupTestRouter(db *db.DB) *mux.Router {
+func setupTestRouter(db db.Store) *mux.Router {
 	r := mux.NewRouter()
-	RegisterHandlers(r, db)
+	RegisterHandlers(r, db, blob.NewMemory())
 	return r
 }
 
-func setupTestDB(t *testing.T) *db.DB {
+func setupTestDB(t *testing.T, dataSourceName string) *db.DB {
 	t.Helper()
-	db, err := db.InitDB(":memory:")
+	db, err := db.Open(dataSourceName)
 	if err != nil {
 		t.Fatalf("Failed to initialize test database: %v", err)
 	}
-
-	schema := `
-	CREATE TABLE IF NOT EXISTS books (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		title TEXT NOT NULL,
-		author TEXT NOT NULL,
-		published_date TEXT NOT NULL,
-		edition TEXT,
-		description TEXT,
-		genre TEXT,
-		created_at TEXT DEFAULT (datetime('now')),
-		updated_at TEXT DEFAULT (datetime('now'))
-	);
-	CREATE TABLE IF NOT EXISTS collections (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL,
-		created_at TEXT DEFAULT (datetime('now')),
-		updated_at TEXT DEFAULT (datetime('now'))
-	);
-	CREATE TABLE IF NOT EXISTS collection_books (
-		collection_id INTEGER,
-		book_id INTEGER,
-		added_at TEXT DEFAULT (datetime('now')),
-		PRIMARY KEY (collection_id, book_id),
-		FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE,
-		FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
-	);`
-	_, err = db.Exec(schema)
-	if err != nil {
-		t.Fatalf("Failed to create schema: %v", err)
+	// Start from an empty schema, the PostgreSQL test database is shared
+	// between tests
+	if err := db.MigrateTo(0); err != nil {
+		t.Fatalf("Failed to reset test database: %v", err)
+	}
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
 	}
 
 	return db
 }
 
-func TestGetBooks(t *testing.T) {
-	db := setupTestDB(t)
-	defer db.Close()
+// forEachStore runs the test once against every Store implementation. The
+// PostgreSQL backend is only covered when BOOKMAN_TEST_POSTGRES_DSN points at
+// a throwaway database.
+func forEachStore(t *testing.T, test func(t *testing.T, store db.Store)) {
+	t.Run("sqlite", func(t *testing.T) {
+		store := setupTestDB(t, ":memory:")
+		defer store.Close()
+		test(t, store)
+	})
+	t.Run("postgres", func(t *testing.T) {
+		dsn := os.Getenv("BOOKMAN_TEST_POSTGRES_DSN")
+		if dsn == "" {
+			t.Skip("BOOKMAN_TEST_POSTGRES_DSN is not set")
+		}
+		store := setupTestDB(t, dsn)
+		defer store.Close()
+		test(t, store)
+	})
+	t.Run("memory", func(t *testing.T) {
+		test(t, db.NewMemoryStore())
+	})
+}
 
-	// Insert test data
-	_, err := db.Exec("INSERT INTO books (title, author, published_date, edition, description, genre) VALUES (?, ?, ?, ?, ?, ?)",
-		"Test Book", "Test Author", "2022-03-01", "1st", "A test book description", "Test Genre")
+func createTestBook(t *testing.T, store db.Store, publishedDate string) int {
+	t.Helper()
+	id, err := store.CreateBook(models.Book{
+		Title:         "Test Book",
+		Author:        "Test Author",
+		PublishedDate: publishedDate,
+		Edition:       "1st",
+		Description:   "A test book description",
+		Genre:         "Test Genre",
+	})
 	assert.NoError(t, err)
+	return id
+}
 
-	req, err := http.NewRequest("GET", "/api/v1/books", nil)
+func createTestCollection(t *testing.T, store db.Store) int {
+	t.Helper()
+	id, err := store.CreateCollection(models.Collection{Name: "Test Collection"})
 	assert.NoError(t, err)
+	return id
+}
 
-	rr := httptest.NewRecorder()
-	router := setupTestRouter(db)
-	router.ServeHTTP(rr, req)
+func TestGetBooks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		// Insert test data
+		createTestBook(t, store, "2022-03-01")
+
+		req, err := http.NewRequest("GET", "/api/v1/books", nil)
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router := setupTestRouter(store)
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var books []models.Book
+		err = json.NewDecoder(rr.Body).Decode(&books)
+		assert.NoError(t, err)
+		assert.Len(t, books, 1)
+		assert.Equal(t, "Test Book", books[0].Title)
+		assert.Equal(t, "Test Author", books[0].Author)
+		assert.Equal(t, "2022-03-01", books[0].PublishedDate)
+		assert.Equal(t, "1st", books[0].Edition)
+		assert.Equal(t, "A test book description", books[0].Description)
+		assert.Equal(t, "Test Genre", books[0].Genre)
+	})
+}
 
-	assert.Equal(t, http.StatusOK, rr.Code)
-	var books []models.Book
-	err = json.NewDecoder(rr.Body).Decode(&books)
-	assert.NoError(t, err)
-	assert.Len(t, books, 1)
-	assert.Equal(t, "Test Book", books[0].Title)
-	assert.Equal(t, "Test Author", books[0].Author)
-	assert.Equal(t, "2022-03-01", books[0].PublishedDate)
-	assert.Equal(t, "1st", books[0].Edition)
-	assert.Equal(t, "A test book description", books[0].Description)
-	assert.Equal(t, "Test Genre", books[0].Genre)
+func TestGetBooks_Pagination(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		// Insert test data
+		for _, date := range []string{"2022-03-01", "2021-03-01", "2023-03-01"} {
+			createTestBook(t, store, date)
+		}
+		router := setupTestRouter(store)
+
+		// Walk the pages by following the Link headers
+		var dates []string
+		url := "/api/v1/books?sort=published_date&order=desc&limit=2"
+		for url != "" {
+			req, err := http.NewRequest("GET", url, nil)
+			assert.NoError(t, err)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "3", rr.Header().Get("X-Total-Count"))
+			var books []models.Book
+			err = json.NewDecoder(rr.Body).Decode(&books)
+			assert.NoError(t, err)
+			for _, b := range books {
+				dates = append(dates, b.PublishedDate)
+			}
+
+			url = ""
+			if link := rr.Header().Get("Link"); link != "" {
+				assert.Regexp(t, `^<.*after=.*>; rel="next"$`, link)
+				url = link[1:strings.Index(link, ">")]
+			}
+		}
+		assert.Equal(t, []string{"2023-03-01", "2022-03-01", "2021-03-01"}, dates)
+
+		// Invalid paging parameters
+		for _, url := range []string{
+			"/api/v1/books?limit=0",
+			"/api/v1/books?limit=abc",
+			"/api/v1/books?sort=genre",
+			"/api/v1/books?order=sideways",
+			"/api/v1/books?after=garbage",
+		} {
+			req, err := http.NewRequest("GET", url, nil)
+			assert.NoError(t, err)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, url)
+		}
+	})
 }
 
 func TestGetBook(t *testing.T) {
-	db := setupTestDB(t)
-	defer db.Close()
-
-	// Insert test data
-	_, err := db.Exec("INSERT INTO books (title, author, published_date, edition, description, genre) VALUES (?, ?, ?, ?, ?, ?)",
-		"Test Book", "Test Author", "2022-03-01", "1st", "A test book description", "Test Genre")
-	assert.NoError(t, err)
-
-	req, err := http.NewRequest("GET", "/api/v1/books/1", nil)
-	assert.NoError(t, err)
-
-	rr := httptest.NewRecorder()
-	router := setupTestRouter(db)
-	router.ServeHTTP(rr, req)
-
-	assert.Equal(t, http.StatusOK, rr.Code)
-	var book models.Book
-	t.Log(rr.Body.String())
-	err = json.NewDecoder(rr.Body).Decode(&book)
-	assert.NoError(t, err)
-	assert.Equal(t, "Test Book", book.Title)
-	assert.Equal(t, "Test Author", book.Author)
-	assert.Equal(t, "2022-03-01", book.PublishedDate)
-	assert.Equal(t, "1st", book.Edition)
-	assert.Equal(t, "A test book description", book.Description)
-	assert.Equal(t, "Test Genre", book.Genre)
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		// Insert test data
+		createTestBook(t, store, "2022-03-01")
+
+		req, err := http.NewRequest("GET", "/api/v1/books/1", nil)
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router := setupTestRouter(store)
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var book models.Book
+		t.Log(rr.Body.String())
+		err = json.NewDecoder(rr.Body).Decode(&book)
+		assert.NoError(t, err)
+		assert.Equal(t, "Test Book", book.Title)
+		assert.Equal(t, "Test Author", book.Author)
+		assert.Equal(t, "2022-03-01", book.PublishedDate)
+		assert.Equal(t, "1st", book.Edition)
+		assert.Equal(t, "A test book description", book.Description)
+		assert.Equal(t, "Test Genre", book.Genre)
+	})
 }
 
 func TestCreateBook(t *testing.T) {
-	db := setupTestDB(t)
-	defer db.Close()
-
-	book := models.Book{
-		Title:         "New Book",
-		Author:        "New Author",
-		PublishedDate: "2022-01-01",
-		Edition:       "1st",
-		Description:   "A new book description",
-		Genre:         "New Genre",
-	}
-	bookJSON, _ := json.Marshal(book)
-
-	req, err := http.NewRequest("POST", "/api/v1/books", bytes.NewBuffer(bookJSON))
-	assert.NoError(t, err)
-	req.Header.Set("Content-Type", "application/json")
-
-	rr := httptest.NewRecorder()
-	router := setupTestRouter(db)
-	router.ServeHTTP(rr, req)
-
-	assert.Equal(t, http.StatusCreated, rr.Code)
-	var createdBook models.Book
-	err = json.NewDecoder(rr.Body).Decode(&createdBook)
-	t.Log(rr.Body.String())
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		book := models.Book{
+			Title:         "New Book",
+			Author:        "New Author",
+			PublishedDate: "2022-01-01",
+			Edition:       "1st",
+			Description:   "A new book description",
+			Genre:         "New Genre",
+		}
+		bookJSON, _ := json.Marshal(book)
+
+		req, err := http.NewRequest("POST", "/api/v1/books", bytes.NewBuffer(bookJSON))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		router := setupTestRouter(store)
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var createdBook models.Book
+		err = json.NewDecoder(rr.Body).Decode(&createdBook)
+		t.Log(rr.Body.String())
+
+		assert.NoError(t, err)
+		assert.Equal(t, "New Book", createdBook.Title)
+		assert.Equal(t, "New Author", createdBook.Author)
+		assert.Equal(t, "2022-01-01", createdBook.PublishedDate)
+		assert.Equal(t, "1st", createdBook.Edition)
+		assert.Equal(t, "A new book description", createdBook.Description)
+		assert.Equal(t, "New Genre", createdBook.Genre)
+
+		// Verify insertion count
+		books, _, err := store.GetBooks(db.BookFilter{}, db.ListOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, books, 1)
+
+		// Verify values
+		stored, err := store.GetBook(createdBook.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "New Book", stored.Title)
+		assert.Equal(t, "New Author", stored.Author)
+		assert.Equal(t, "2022-01-01", stored.PublishedDate)
+		assert.Equal(t, "1st", stored.Edition)
+		assert.Equal(t, "A new book description", stored.Description)
+		assert.Equal(t, "New Genre", stored.Genre)
+		assert.False(t, stored.CreatedAt.IsZero())
+		assert.False(t, stored.UpdatedAt.IsZero())
+	})
+}
 
-	assert.NoError(t, err)
-	assert.Equal(t, "New Book", createdBook.Title)
-	assert.Equal(t, "New Author", createdBook.Author)
-	assert.Equal(t, "2022-01-01", createdBook.PublishedDate)
-	assert.Equal(t, "1st", createdBook.Edition)
-	assert.Equal(t, "A new book description", createdBook.Description)
-	assert.Equal(t, "New Genre", createdBook.Genre)
-
-	// Verify insertion count
-	var count int
-	err = db.QueryRow("SELECT COUNT(*) FROM books WHERE title = ?", "New Book").Scan(&count)
-	assert.NoError(t, err)
-	assert.Equal(t, 1, count)
+func TestPartialPublishedDates(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		router := setupTestRouter(store)
+		request := func(method, url, body string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest(method, url, strings.NewReader(body))
+			assert.NoError(t, err)
+			if method == "PATCH" {
+				req.Header.Set("Content-Type", MergePatchContentType)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr
+		}
+
+		rr := request("POST", "/api/v1/books", `{"title": "Moby-Dick", "author": "Herman Melville", "published_date": "1851-10"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"published_date":"1851-10","published_precision":"month"`)
+		rr = request("POST", "/api/v1/books", `{"title": "Beowulf", "author": "Anonymous", "published_date": " Circa 1000"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"published_date":"c. 1000","published_precision":"circa"`)
+
+		rr = request("PATCH", "/api/v1/books/1", `{"published_date": "1851", "published_precision": "day"}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"published_date":"1851","published_precision":"year"`)
+		rr = request("PATCH", "/api/v1/books/1", `{"published_date": "c. 1851-10"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = request("GET", "/api/v1/books?from=1851-06&to=1851-06", "")
+		assert.Equal(t, "1", rr.Header().Get("X-Total-Count"))
+		rr = request("GET", "/api/v1/books?to=0998", "")
+		assert.Equal(t, "1", rr.Header().Get("X-Total-Count"))
+		rr = request("GET", "/api/v1/books?from=June+1851", "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Invalid from")
+	})
+}
 
-	// Verify values
-	var title, author, edition, description, genre string
-	var publishedDate, createdAt, updatedAt string
-	err = db.QueryRow("SELECT title, author, published_date, edition, description, genre, created_at, updated_at FROM books WHERE id = 1", "New Book").Scan(&title, &author, &publishedDate, &edition, &description, &genre, &createdAt, &updatedAt)
-	assert.NoError(t, err)
-	assert.Equal(t, "New Book", title)
-	assert.Equal(t, "New Author", author)
-	assert.Equal(t, "2022-01-01", publishedDate)
-	assert.Equal(t, "1st", edition)
-	assert.Equal(t, "A new book description", description)
-	assert.Equal(t, "New Genre", genre)
-	assert.NotEmpty(t, createdAt)
-	assert.NotEmpty(t, updatedAt)
-	assert.NotEqual(t, "0001-01-01T00:00:00Z", createdAt)
-	assert.NotEqual(t, "0001-01-01T00:00:00Z", updatedAt)
+func TestBookISBN(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		router := setupTestRouter(store)
+		post := func(body string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest("POST", "/api/v1/books", strings.NewReader(body))
+			assert.NoError(t, err)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr
+		}
+
+		// The ISBN is normalized and completed with its other form
+		rr := post(`{"title": "Effective Java", "author": "Joshua Bloch", "published_date": "2017-12-27", "isbn_10": "0-13-468599-7"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var book models.Book
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&book))
+		assert.Equal(t, "0134685997", book.ISBN10)
+		assert.Equal(t, "9780134685991", book.ISBN13)
+
+		// The same ISBN in its other form is a duplicate
+		rr = post(`{"title": "Effective Java", "author": "Joshua Bloch", "published_date": "2017-12-27", "isbn_13": "978-0134685991"}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		rr = post(`{"title": "Effective Java", "author": "Joshua Bloch", "published_date": "2017-12-27", "isbn_13": "978-0134685992"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `{"field":"isbn_13","message":"must be a valid ISBN-13"}`)
+		rr = post(`{"title": "Effective Java", "author": "Joshua Bloch", "published_date": "2017-12-27", "isbn_10": "0134685997", "isbn_13": "9780306406157"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `{"field":"isbn_13","message":"must match isbn_10"}`)
+
+		// Lookup by either form
+		for _, query := range []string{"isbn=0134685997", "isbn=978-0-13-468599-1"} {
+			req, err := http.NewRequest("GET", "/api/v1/books?"+query, nil)
+			assert.NoError(t, err)
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusOK, rr.Code)
+			var books []models.Book
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&books))
+			assert.Len(t, books, 1, query)
+		}
+		req, err := http.NewRequest("GET", "/api/v1/books?isbn=12345", nil)
+		assert.NoError(t, err)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		// Patching one form replaces the other
+		rr = patchRequest(t, router, "/api/v1/books/1", `{"isbn_13": "979-10-90636-07-1"}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&book))
+		assert.Equal(t, "", book.ISBN10)
+		assert.Equal(t, "9791090636071", book.ISBN13)
+	})
 }
 
 func TestUpdateBook(t *testing.T) {
-	db := setupTestDB(t)
-	defer db.Close()
-
-	// Insert test data
-	_, err := db.Exec("INSERT INTO books (title, author, published_date, edition, description, genre) VALUES (?, ?, ?, ?, ?, ?)",
-		"Test Book", "Test Author", "2022-01-01", "1st", "A test book description", "Test Genre")
-	assert.NoError(t, err)
-
-	// Ensure updated_at is different
-	time.Sleep(1 * time.Second)
-
-	book := models.Book{
-		Title:         "Updated Book",
-		Author:        "Updated Author",
-		PublishedDate: "2022-01-01",
-		Edition:       "2nd",
-		Description:   "An updated book description",
-		Genre:         "Updated Genre",
-	}
-	bookJSON, _ := json.Marshal(book)
-
-	req, err := http.NewRequest("PUT", "/api/v1/books/1", bytes.NewBuffer(bookJSON))
-	assert.NoError(t, err)
-	req.Header.Set("Content-Type", "application/json")
-
-	rr := httptest.NewRecorder()
-	router := setupTestRouter(db)
-	router.ServeHTTP(rr, req)
-
-	assert.Equal(t, http.StatusOK, rr.Code)
-	var updatedBook models.Book
-	err = json.NewDecoder(rr.Body).Decode(&updatedBook)
-	assert.NoError(t, err)
-	assert.Equal(t, "Updated Book", updatedBook.Title)
-	assert.Equal(t, "Updated Author", updatedBook.Author)
-	assert.Equal(t, "2022-01-01", updatedBook.PublishedDate)
-	assert.Equal(t, "2nd", updatedBook.Edition)
-	assert.Equal(t, "An updated book description", updatedBook.Description)
-	assert.Equal(t, "Updated Genre", updatedBook.Genre)
-
-	// Verify data
-	var title, author, edition, description, genre, publishedDate, createdAt, updatedAt string
-	err = db.QueryRow("SELECT title, author, published_date, edition, description, genre, created_at, updated_at FROM books WHERE id = 1", "Updated Book").Scan(&title, &author, &publishedDate, &edition, &description, &genre, &createdAt, &updatedAt)
-	assert.NoError(t, err)
-	assert.Equal(t, "Updated Book", title)
-	assert.Equal(t, "Updated Author", author)
-	assert.Equal(t, "2022-01-01", publishedDate)
-	assert.Equal(t, "2nd", edition)
-	assert.Equal(t, "An updated book description", description)
-	assert.Equal(t, "Updated Genre", genre)
-	assert.NotEmpty(t, createdAt)
-	assert.NotEmpty(t, updatedAt)
-	assert.NotEqual(t, "0001-01-01T00:00:00Z", createdAt)
-	assert.NotEqual(t, "0001-01-01T00:00:00Z", updatedAt)
-	assert.NotEqual(t, createdAt, updatedAt)
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		// Insert test data
+		createTestBook(t, store, "2022-01-01")
+
+		// Ensure updated_at is different
+		time.Sleep(1 * time.Second)
+
+		book := models.Book{
+			Title:         "Updated Book",
+			Author:        "Updated Author",
+			PublishedDate: "2022-01-01",
+			Edition:       "2nd",
+			Description:   "An updated book description",
+			Genre:         "Updated Genre",
+		}
+		bookJSON, _ := json.Marshal(book)
+
+		req, err := http.NewRequest("PUT", "/api/v1/books/1", bytes.NewBuffer(bookJSON))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		router := setupTestRouter(store)
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var updatedBook models.Book
+		err = json.NewDecoder(rr.Body).Decode(&updatedBook)
+		assert.NoError(t, err)
+		assert.Equal(t, "Updated Book", updatedBook.Title)
+		assert.Equal(t, "Updated Author", updatedBook.Author)
+		assert.Equal(t, "2022-01-01", updatedBook.PublishedDate)
+		assert.Equal(t, "2nd", updatedBook.Edition)
+		assert.Equal(t, "An updated book description", updatedBook.Description)
+		assert.Equal(t, "Updated Genre", updatedBook.Genre)
+
+		// Verify data
+		stored, err := store.GetBook(1)
+		assert.NoError(t, err)
+		assert.Equal(t, "Updated Book", stored.Title)
+		assert.Equal(t, "Updated Author", stored.Author)
+		assert.Equal(t, "2022-01-01", stored.PublishedDate)
+		assert.Equal(t, "2nd", stored.Edition)
+		assert.Equal(t, "An updated book description", stored.Description)
+		assert.Equal(t, "Updated Genre", stored.Genre)
+		assert.False(t, stored.CreatedAt.IsZero())
+		assert.False(t, stored.UpdatedAt.IsZero())
+		assert.NotEqual(t, stored.CreatedAt, stored.UpdatedAt)
+	})
 }
 
 func TestDeleteBook(t *testing.T) {
-	db := setupTestDB(t)
-	defer db.Close()
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		// Insert test data
+		createTestBook(t, store, "2022-01-01")
 
-	// Insert test data
-	_, err := db.Exec("INSERT INTO books (title, author, published_date, edition, description, genre) VALUES (?, ?, ?, ?, ?, ?)",
-		"Test Book", "Test Author", "2022-01-01", "1st", "A test book description", "Test Genre")
-	assert.NoError(t, err)
+		req, err := http.NewRequest("DELETE", "/api/v1/books/1", nil)
+		assert.NoError(t, err)
 
-	req, err := http.NewRequest("DELETE", "/api/v1/books/1", nil)
-	assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+		router := setupTestRouter(store)
+		router.ServeHTTP(rr, req)
 
-	rr := httptest.NewRecorder()
-	router := setupTestRouter(db)
-	router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
 
-	assert.Equal(t, http.StatusNoContent, rr.Code)
+		// Verify deletion
+		_, err = store.GetBook(1)
+		assert.Error(t, err)
+	})
+}
 
-	// Verify deletion
-	var count int
-	err = db.QueryRow("SELECT COUNT(*) FROM books WHERE id = ?", 1).Scan(&count)
-	assert.NoError(t, err)
-	assert.Equal(t, 0, count)
+func TestSearchBooks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		// Insert test data
+		createTestBook(t, store, "2022-01-01")
+		_, err := store.CreateBook(models.Book{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01", Genre: "Science Fiction"})
+		assert.NoError(t, err)
+
+		req, err := http.NewRequest("GET", "/api/v1/books/search?q=herb", nil)
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router := setupTestRouter(store)
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var results []models.BookSearchResult
+		err = json.NewDecoder(rr.Body).Decode(&results)
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+		assert.Equal(t, "Dune", results[0].Book.Title)
+		assert.Contains(t, results[0].Snippet, "<mark>Herbert</mark>")
+
+		// No matches is an empty list
+		req, err = http.NewRequest("GET", "/api/v1/books/search?q=xylophone", nil)
+		assert.NoError(t, err)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, "[]", rr.Body.String())
+
+		// Missing query and invalid limit
+		for _, url := range []string{"/api/v1/books/search", "/api/v1/books/search?q=%20", "/api/v1/books/search?q=dune&limit=0"} {
+			req, err = http.NewRequest("GET", url, nil)
+			assert.NoError(t, err)
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, url)
+		}
+	})
 }
 
 func TestGetCollections(t *testing.T) {
-	db := setupTestDB(t)
-	defer db.Close()
-
-	// Insert test data
-	_, err := db.Exec("INSERT INTO collections (name) VALUES (?)", "Test Collection")
-	assert.NoError(t, err)
-
-	req, err := http.NewRequest("GET", "/api/v1/collections", nil)
-	assert.NoError(t, err)
-
-	rr := httptest.NewRecorder()
-	router := setupTestRouter(db)
-	router.ServeHTTP(rr, req)
-
-	assert.Equal(t, http.StatusOK, rr.Code)
-	var collections []models.Collection
-	err = json.NewDecoder(rr.Body).Decode(&collections)
-	assert.NoError(t, err)
-	assert.Len(t, collections, 1)
-	assert.Equal(t, "Test Collection", collections[0].Name)
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		// Insert test data
+		createTestCollection(t, store)
+
+		req, err := http.NewRequest("GET", "/api/v1/collections", nil)
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router := setupTestRouter(store)
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var collections []models.Collection
+		err = json.NewDecoder(rr.Body).Decode(&collections)
+		assert.NoError(t, err)
+		assert.Len(t, collections, 1)
+		assert.Equal(t, "Test Collection", collections[0].Name)
+		assert.Equal(t, "1", rr.Header().Get("X-Total-Count"))
+		assert.Empty(t, rr.Header().Get("Link"))
+
+		// Paging
+		createTestCollection(t, store)
+		req, err = http.NewRequest("GET", "/api/v1/collections?limit=1&order=desc", nil)
+		assert.NoError(t, err)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "2", rr.Header().Get("X-Total-Count"))
+		assert.Contains(t, rr.Header().Get("Link"), `rel="next"`)
+		err = json.NewDecoder(rr.Body).Decode(&collections)
+		assert.NoError(t, err)
+		assert.Len(t, collections, 1)
+		assert.Equal(t, 2, collections[0].ID)
+	})
 }
 
 func TestGetCollection(t *testing.T) {
-	db := setupTestDB(t)
-	defer db.Close()
-
-	// Insert test data
-	_, err := db.Exec("INSERT INTO collections (name) VALUES (?)", "Test Collection")
-	assert.NoError(t, err)
-	_, err = db.Exec("INSERT INTO books (title, author, published_date, edition, description, genre) VALUES (?, ?, ?, ?, ?, ?)",
-		"Test Book", "Test Author", "2022-01-01", "1st", "A test book description", "Test Genre")
-	assert.NoError(t, err)
-	_, err = db.Exec("INSERT INTO collection_books (collection_id, book_id) VALUES (?, ?)", 1, 1)
-	assert.NoError(t, err)
-
-	req, err := http.NewRequest("GET", "/api/v1/collections/1", nil)
-	assert.NoError(t, err)
-
-	rr := httptest.NewRecorder()
-	router := setupTestRouter(db)
-	router.ServeHTTP(rr, req)
-
-	assert.Equal(t, http.StatusOK, rr.Code)
-	var collection models.Collection
-	err = json.NewDecoder(rr.Body).Decode(&collection)
-	assert.NoError(t, err)
-	assert.Equal(t, "Test Collection", collection.Name)
-	assert.Len(t, collection.Books, 1)
-	assert.Equal(t, "Test Book", collection.Books[0].Title)
-	assert.Equal(t, "Test Author", collection.Books[0].Author)
-	assert.Equal(t, "2022-01-01", collection.Books[0].PublishedDate)
-	assert.Equal(t, "1st", collection.Books[0].Edition)
-	assert.Equal(t, "A test book description", collection.Books[0].Description)
-	assert.Equal(t, "Test Genre", collection.Books[0].Genre)
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		// Insert test data
+		collectionID := createTestCollection(t, store)
+		bookID := createTestBook(t, store, "2022-01-01")
+		err := store.AddBookToCollection(collectionID, bookID)
+		assert.NoError(t, err)
+
+		req, err := http.NewRequest("GET", "/api/v1/collections/1", nil)
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router := setupTestRouter(store)
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var collection models.Collection
+		err = json.NewDecoder(rr.Body).Decode(&collection)
+		assert.NoError(t, err)
+		assert.Equal(t, "Test Collection", collection.Name)
+		assert.Len(t, collection.Books, 1)
+		assert.Equal(t, "Test Book", collection.Books[0].Title)
+		assert.Equal(t, "Test Author", collection.Books[0].Author)
+		assert.Equal(t, "2022-01-01", collection.Books[0].PublishedDate)
+		assert.Equal(t, "1st", collection.Books[0].Edition)
+		assert.Equal(t, "A test book description", collection.Books[0].Description)
+		assert.Equal(t, "Test Genre", collection.Books[0].Genre)
+	})
 }
 
 func TestCreateCollection(t *testing.T) {
-	db := setupTestDB(t)
-	defer db.Close()
-
-	collection := models.Collection{Name: "New Collection"}
-	collectionJSON, _ := json.Marshal(collection)
-
-	req, err := http.NewRequest("POST", "/api/v1/collections", bytes.NewBuffer(collectionJSON))
-	assert.NoError(t, err)
-	req.Header.Set("Content-Type", "application/json")
-
-	rr := httptest.NewRecorder()
-	router := setupTestRouter(db)
-	router.ServeHTTP(rr, req)
-
-	assert.Equal(t, http.StatusCreated, rr.Code)
-	var createdCollection models.Collection
-	err = json.NewDecoder(rr.Body).Decode(&createdCollection)
-	assert.NoError(t, err)
-	assert.Equal(t, "New Collection", createdCollection.Name)
-
-	// Verify insertion
-	var count int
-	err = db.QueryRow("SELECT COUNT(*) FROM collections WHERE name = ?", "New Collection").Scan(&count)
-	assert.NoError(t, err)
-	assert.Equal(t, 1, count)
-
-	var name, createdAt, updatedAt string
-	err = db.QueryRow("SELECT name, created_at, updated_at FROM collections WHERE id = 1").Scan(&name, &createdAt, &updatedAt)
-	assert.NoError(t, err)
-	assert.Equal(t, "New Collection", name)
-	assert.NotEmpty(t, createdAt)
-	assert.NotEmpty(t, updatedAt)
-	assert.NotEqual(t, "0001-01-01T00:00:00Z", createdAt)
-	assert.NotEqual(t, "0001-01-01T00:00:00Z", updatedAt)
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		collection := models.Collection{Name: "New Collection"}
+		collectionJSON, _ := json.Marshal(collection)
+
+		req, err := http.NewRequest("POST", "/api/v1/collections", bytes.NewBuffer(collectionJSON))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		router := setupTestRouter(store)
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var createdCollection models.Collection
+		err = json.NewDecoder(rr.Body).Decode(&createdCollection)
+		assert.NoError(t, err)
+		assert.Equal(t, "New Collection", createdCollection.Name)
+
+		// Verify insertion
+		collections, _, err := store.GetCollections(db.ListOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, collections, 1)
+		assert.Equal(t, "New Collection", collections[0].Name)
+
+		// Collection timestamps are not part of the model, check them in SQL
+		if sqlDB, ok := store.(*db.DB); ok {
+			var createdAt, updatedAt string
+			err = sqlDB.QueryRow("SELECT created_at, updated_at FROM collections WHERE id = 1").Scan(&createdAt, &updatedAt)
+			assert.NoError(t, err)
+			assert.NotEmpty(t, createdAt)
+			assert.NotEmpty(t, updatedAt)
+			assert.NotEqual(t, "0001-01-01T00:00:00Z", createdAt)
+			assert.NotEqual(t, "0001-01-01T00:00:00Z", updatedAt)
+		}
+	})
 }
 
 func TestUpdateCollection(t *testing.T) {
-	db := setupTestDB(t)
-	defer db.Close()
-
-	// Insert test data
-	_, err := db.Exec("INSERT INTO collections (name) VALUES (?)", "Test Collection")
-	assert.NoError(t, err)
-
-	collection := models.Collection{Name: "Updated Collection"}
-	collectionJSON, _ := json.Marshal(collection)
-
-	req, err := http.NewRequest("PUT", "/api/v1/collections/1", bytes.NewBuffer(collectionJSON))
-	assert.NoError(t, err)
-	req.Header.Set("Content-Type", "application/json")
-
-	rr := httptest.NewRecorder()
-	router := setupTestRouter(db)
-	router.ServeHTTP(rr, req)
-
-	assert.Equal(t, http.StatusOK, rr.Code)
-	var updatedCollection models.Collection
-	err = json.NewDecoder(rr.Body).Decode(&updatedCollection)
-	assert.NoError(t, err)
-	assert.Equal(t, "Updated Collection", updatedCollection.Name)
-
-	// Verify update
-	var updatedName string
-	err = db.QueryRow("SELECT name FROM collections WHERE id = ?", 1).Scan(&updatedName)
-	assert.NoError(t, err)
-	assert.Equal(t, "Updated Collection", updatedName)
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		// Insert test data
+		createTestCollection(t, store)
+
+		collection := models.Collection{Name: "Updated Collection"}
+		collectionJSON, _ := json.Marshal(collection)
+
+		req, err := http.NewRequest("PUT", "/api/v1/collections/1", bytes.NewBuffer(collectionJSON))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		router := setupTestRouter(store)
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var updatedCollection models.Collection
+		err = json.NewDecoder(rr.Body).Decode(&updatedCollection)
+		assert.NoError(t, err)
+		assert.Equal(t, "Updated Collection", updatedCollection.Name)
+
+		// Verify update
+		stored, err := store.GetCollection(1)
+		assert.NoError(t, err)
+		assert.Equal(t, "Updated Collection", stored.Name)
+	})
 }
 
 func TestDeleteCollection(t *testing.T) {
-	db := setupTestDB(t)
-	defer db.Close()
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		// Insert test data
+		createTestCollection(t, store)
 
-	// Insert test data
-	_, err := db.Exec("INSERT INTO collections (name) VALUES (?)", "Test Collection")
-	assert.NoError(t, err)
+		req, err := http.NewRequest("DELETE", "/api/v1/collections/1", nil)
+		assert.NoError(t, err)
 
-	req, err := http.NewRequest("DELETE", "/api/v1/collections/1", nil)
-	assert.NoError(t, err)
-
-	rr := httptest.NewRecorder()
-	router := setupTestRouter(db)
-	router.ServeHTTP(rr, req)
+		rr := httptest.NewRecorder()
+		router := setupTestRouter(store)
+		router.ServeHTTP(rr, req)
 
-	assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
 
-	// Verify deletion
-	var count int
-	err = db.QueryRow("SELECT COUNT(*) FROM collections WHERE id = ?", 1).Scan(&count)
-	assert.NoError(t, err)
-	assert.Equal(t, 0, count)
+		// Verify deletion
+		_, err = store.GetCollection(1)
+		assert.Error(t, err)
+	})
 }
 
 func TestAddBookToCollection(t *testing.T) {
-	db := setupTestDB(t)
-	defer db.Close()
-
-	// Insert test data
-	_, err := db.Exec("INSERT INTO collections (name) VALUES (?)", "Test Collection")
-	assert.NoError(t, err)
-	_, err = db.Exec("INSERT INTO books (title, author, published_date, edition, description, genre) VALUES (?, ?, ?, ?, ?, ?)",
-		"Test Book", "Test Author", "2022-01-01", "1st", "A test book description", "Test Genre")
-	assert.NoError(t, err)
-
-	req, err := http.NewRequest("POST", "/api/v1/collections/1/books/1", nil)
-	assert.NoError(t, err)
-
-	rr := httptest.NewRecorder()
-	router := setupTestRouter(db)
-	router.ServeHTTP(rr, req)
-
-	assert.Equal(t, http.StatusNoContent, rr.Code)
-
-	// Verify insertion
-	var count int
-	err = db.QueryRow("SELECT COUNT(*) FROM collection_books WHERE collection_id = ? AND book_id = ?", 1, 1).Scan(&count)
-	assert.NoError(t, err)
-	assert.Equal(t, 1, count)
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		// Insert test data
+		createTestCollection(t, store)
+		createTestBook(t, store, "2022-01-01")
+
+		req, err := http.NewRequest("POST", "/api/v1/collections/1/books/1", nil)
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router := setupTestRouter(store)
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+
+		// Verify insertion
+		inCollection, err := store.IsBookInCollection(1, 1)
+		assert.NoError(t, err)
+		assert.True(t, inCollection)
+
+		// Adding the same book again is a conflict
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
 }
 
 func TestRemoveBookFromCollection(t *testing.T) {
-	db := setupTestDB(t)
-	defer db.Close()
-
-	// Insert test data
-	_, err := db.Exec("INSERT INTO collections (name) VALUES (?)", "Test Collection")
-	assert.NoError(t, err)
-	_, err = db.Exec("INSERT INTO books (title, author, published_date, edition, description, genre) VALUES (?, ?, ?, ?, ?, ?)",
-		"Test Book", "Test Author", "2022-01-01", "1st", "A test book description", "Test Genre")
-	assert.NoError(t, err)
-	_, err = db.Exec("INSERT INTO collection_books (collection_id, book_id) VALUES (?, ?)", 1, 1)
-	assert.NoError(t, err)
-
-	req, err := http.NewRequest("DELETE", "/api/v1/collections/1/books/1", nil)
-	assert.NoError(t, err)
-
-	rr := httptest.NewRecorder()
-	router := setupTestRouter(db)
-	router.ServeHTTP(rr, req)
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		// Insert test data
+		collectionID := createTestCollection(t, store)
+		bookID := createTestBook(t, store, "2022-01-01")
+		err := store.AddBookToCollection(collectionID, bookID)
+		assert.NoError(t, err)
+
+		req, err := http.NewRequest("DELETE", "/api/v1/collections/1/books/1", nil)
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router := setupTestRouter(store)
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+
+		// Verify deletion
+		inCollection, err := store.IsBookInCollection(1, 1)
+		assert.NoError(t, err)
+		assert.False(t, inCollection)
+	})
+}
 
-	assert.Equal(t, http.StatusNoContent, rr.Code)
+func TestErrorStatusCodes(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		// Insert test data
+		createTestBook(t, store, "2022-01-01")
+		createTestCollection(t, store)
+		router := setupTestRouter(store)
+
+		book := `{"title": "Updated Book", "author": "Updated Author", "published_date": "2022-01-01"}`
+		tests := []struct {
+			method string
+			url    string
+			body   string
+			code   int
+		}{
+			{"GET", "/api/v1/books/42", "", http.StatusNotFound},
+			{"PUT", "/api/v1/books/42", book, http.StatusNotFound},
+			{"DELETE", "/api/v1/books/42", "", http.StatusNotFound},
+			{"GET", "/api/v1/books/abc", "", http.StatusBadRequest},
+			{"PUT", "/api/v1/books/abc", book, http.StatusBadRequest},
+			{"DELETE", "/api/v1/books/0", "", http.StatusBadRequest},
+			{"PUT", "/api/v1/books/1", "{", http.StatusBadRequest},
+			{"GET", "/api/v1/collections/42", "", http.StatusNotFound},
+			{"PUT", "/api/v1/collections/42", `{"name": "Updated Collection"}`, http.StatusNotFound},
+			{"DELETE", "/api/v1/collections/42", "", http.StatusNotFound},
+			{"GET", "/api/v1/collections/abc", "", http.StatusBadRequest},
+			{"POST", "/api/v1/collections/42/books/1", "", http.StatusNotFound},
+			{"POST", "/api/v1/collections/1/books/42", "", http.StatusNotFound},
+			{"POST", "/api/v1/collections/1/books/abc", "", http.StatusBadRequest},
+			{"DELETE", "/api/v1/collections/1/books/1", "", http.StatusNotFound},
+		}
+		for _, tt := range tests {
+			req, err := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, tt.code, rr.Code, tt.method+" "+tt.url)
+		}
+	})
+}
 
-	// Verify deletion
-	var count int
-	err = db.QueryRow("SELECT COUNT(*) FROM collection_books WHERE collection_id = ? AND book_id = ?", 1, 1).Scan(&count)
-	assert.NoError(t, err)
-	assert.Equal(t, 0, count)
+func TestProblemResponses(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		router := setupTestRouter(store)
+
+		decodeProblem := func(rr *httptest.ResponseRecorder) models.Problem {
+			t.Helper()
+			assert.Equal(t, ProblemContentType, rr.Header().Get("Content-Type"))
+			var problem models.Problem
+			err := json.NewDecoder(rr.Body).Decode(&problem)
+			assert.NoError(t, err)
+			assert.Equal(t, rr.Code, problem.Status)
+			assert.NotEmpty(t, problem.Title)
+			return problem
+		}
+
+		// Every invalid field is reported
+		req, err := http.NewRequest("POST", "/api/v1/books", strings.NewReader(`{"published_date": "01/02/2022"}`))
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		problem := decodeProblem(rr)
+		assert.Equal(t, ProblemValidation, problem.Type)
+		assert.Equal(t, "/api/v1/books", problem.Instance)
+		assert.Equal(t, []models.FieldError{
+			{Field: "title", Message: "is required"},
+			{Field: "author", Message: "is required"},
+			{Field: "published_date", Message: "must be a date in the format YYYY-MM-DD, YYYY-MM, YYYY or c. YYYY"},
+		}, problem.Errors)
+
+		// Malformed bodies
+		req, err = http.NewRequest("POST", "/api/v1/collections", strings.NewReader(`{"name": 42}`))
+		assert.NoError(t, err)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, ProblemInvalidRequest, decodeProblem(rr).Type)
+
+		// Missing resources
+		req, err = http.NewRequest("GET", "/api/v1/books/42", nil)
+		assert.NoError(t, err)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		problem = decodeProblem(rr)
+		assert.Equal(t, ProblemNotFound, problem.Type)
+		assert.Equal(t, "book 42: not found", problem.Detail)
+		assert.Equal(t, "/api/v1/books/42", problem.Instance)
+
+		// Unknown routes and methods
+		req, err = http.NewRequest("GET", "/api/v1/shelves", nil)
+		assert.NoError(t, err)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Equal(t, ProblemNotFound, decodeProblem(rr).Type)
+
+		req, err = http.NewRequest("PATCH", "/api/v1/collections", nil)
+		assert.NoError(t, err)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+		assert.Equal(t, ProblemMethodNotAllowed, decodeProblem(rr).Type)
+	})
 }
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// getLoans lists the loans of all books. overdue=true keeps the loans past
+// their due date, open=true those not returned yet.
+func getLoans(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, err := parseListOptions(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		filter, err := parseLoanFilter(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		writeLoans(w, r, db, filter, opts)
+	}
+}
+
+// getBookLoans lists the loan history of a book.
+func getBookLoans(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookID, err := parseID(r, "id", "book")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		opts, err := parseListOptions(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		filter, err := parseLoanFilter(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		if _, err := db.GetBook(bookID); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		filter.BookID = bookID
+		writeLoans(w, r, db, filter, opts)
+	}
+}
+
+func parseLoanFilter(r *http.Request) (db.LoanFilter, error) {
+	filter := db.LoanFilter{Borrower: r.URL.Query().Get("borrower")}
+	for name, value := range map[string]*bool{"open": &filter.Open, "overdue": &filter.Overdue} {
+		if param := r.URL.Query().Get(name); param != "" {
+			b, err := strconv.ParseBool(param)
+			if err != nil {
+				return db.LoanFilter{}, fmt.Errorf("Invalid %s: must be true or false", name)
+			}
+			*value = b
+		}
+	}
+	return filter, nil
+}
+
+func writeLoans(w http.ResponseWriter, r *http.Request, store db.Store, filter db.LoanFilter, opts db.ListOptions) {
+	loans, page, err := store.GetLoans(filter, opts)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if loans == nil {
+		loans = []models.Loan{}
+	}
+	writePageHeaders(w, r, page)
+	json.NewEncoder(w).Encode(loans)
+}
+
+func getLoan(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "loan")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		loan, err := db.GetLoan(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(loan)
+	}
+}
+
+// createLoan lends a book, which fails with 409 Conflict while it is out on
+// another loan.
+func createLoan(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookID, err := parseID(r, "id", "book")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		var loan models.Loan
+		if err := decodeJSON(r, &loan); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		loan.BookID = bookID
+
+		// Validation checks
+		if fieldErrors := validateLoan(loan); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		id, err := db.CreateLoan(loan)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		loan, err = db.GetLoan(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(loan)
+	}
+}
+
+// updateLoan replaces the borrower, contact and dates of a loan, the book
+// stays the same.
+func updateLoan(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "loan")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		current, err := db.GetLoan(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		var loan models.Loan
+		if err := decodeJSON(r, &loan); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		loan.ID, loan.BookID = current.ID, current.BookID
+
+		// Validation checks
+		if fieldErrors := validateLoan(loan); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		writeLoan(w, r, db, loan)
+	}
+}
+
+func patchLoan(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "loan")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		if !isMergePatch(r) {
+			unsupportedMediaType(w, r, MergePatchContentType)
+			return
+		}
+
+		current, err := db.GetLoan(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		loan, err := applyMergePatch(r, current)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		loan.ID, loan.BookID = current.ID, current.BookID
+
+		// Validation checks
+		if fieldErrors := validateLoan(loan); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		writeLoan(w, r, db, loan)
+	}
+}
+
+// writeLoan stores a changed loan and responds with it as stored.
+func writeLoan(w http.ResponseWriter, r *http.Request, store db.Store, loan models.Loan) {
+	err := store.UpdateLoan(loan)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	loan, err = store.GetLoan(loan.ID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(loan)
+}
+
+// loanReturn is the optional body of a request returning a loan.
+type loanReturn struct {
+	ReturnedAt string `json:"returned_at"`
+}
+
+// returnLoan records that the book of a loan came back, on the date in the
+// body or today. Returning a loan twice fails with 409 Conflict.
+func returnLoan(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "loan")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		var body loanReturn
+		if r.ContentLength != 0 {
+			if err := decodeJSON(r, &body); err != nil {
+				badRequest(w, r, err)
+				return
+			}
+		}
+
+		// Validation checks
+		if fieldErrors := validateDate("returned_at", body.ReturnedAt); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		err = db.ReturnLoan(id, body.ReturnedAt)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		loan, err := db.GetLoan(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(loan)
+	}
+}
+
+func deleteLoan(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "loan")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		err = db.DeleteLoan(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func validateLoan(loan models.Loan) []models.FieldError {
+	var fieldErrors []models.FieldError
+	if strings.TrimSpace(loan.Borrower) == "" {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "borrower", Message: "is required"})
+	}
+	fieldErrors = append(fieldErrors, validateDate("loaned_at", loan.LoanedAt)...)
+	if loan.DueAt == "" {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "due_at", Message: "is required"})
+	} else {
+		fieldErrors = append(fieldErrors, validateDate("due_at", loan.DueAt)...)
+	}
+	return append(fieldErrors, validateDate("returned_at", loan.ReturnedAt)...)
+}
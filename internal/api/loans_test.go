@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoans(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		router := setupTestRouter(store)
+		request := func(method, url, body string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest(method, url, strings.NewReader(body))
+			assert.NoError(t, err)
+			if method == "PATCH" {
+				req.Header.Set("Content-Type", MergePatchContentType)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr
+		}
+		createTestBook(t, store, "1965-08-01")
+		createTestBook(t, store, "1937-09-21")
+
+		rr := request("POST", "/api/v1/books/1/loans", `{"borrower": "ada", "contact": "ada@example.com", "loaned_at": "2024-03-01", "due_at": "2024-03-15"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var loan models.Loan
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&loan))
+		assert.Equal(t, models.Loan{ID: 1, BookID: 1, Borrower: "ada", Contact: "ada@example.com", LoanedAt: "2024-03-01", DueAt: "2024-03-15",
+			Overdue: true, CreatedAt: loan.CreatedAt, UpdatedAt: loan.UpdatedAt}, loan)
+
+		rr = request("POST", "/api/v1/books/1/loans", `{"borrower": "grace", "due_at": "2099-01-01"}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		rr = request("POST", "/api/v1/books/2/loans", `{"due_at": "soon"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `{"field":"borrower","message":"is required"}`)
+		assert.Contains(t, rr.Body.String(), `{"field":"due_at","message":"must be a date in the format YYYY-MM-DD"}`)
+		rr = request("POST", "/api/v1/books/2/loans", `{"borrower": "grace", "loaned_at": "2024-03-10", "due_at": "2024-03-01"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		rr = request("POST", "/api/v1/books/42/loans", `{"borrower": "grace", "due_at": "2099-01-01"}`)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		rr = request("POST", "/api/v1/books/2/loans", `{"borrower": "grace", "due_at": "2099-01-01"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		rr = request("GET", "/api/v1/loans?overdue=true", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "1", rr.Header().Get("X-Total-Count"))
+		assert.Contains(t, rr.Body.String(), `"borrower":"ada"`)
+		rr = request("GET", "/api/v1/loans?overdue=maybe", "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		rr = request("GET", "/api/v1/loans?borrower=grace&open=true", "")
+		assert.Equal(t, "1", rr.Header().Get("X-Total-Count"))
+
+		rr = request("PATCH", "/api/v1/loans/1", `{"due_at": "2024-03-31", "book_id": 2}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"book_id":1,"borrower":"ada"`)
+		assert.Contains(t, rr.Body.String(), `"due_at":"2024-03-31"`)
+
+		rr = request("POST", "/api/v1/loans/1/return", `{"returned_at": "2024-03-20"}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"returned_at":"2024-03-20","overdue":false`)
+		rr = request("POST", "/api/v1/loans/1/return", "")
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		rr = request("POST", "/api/v1/loans/42/return", "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		// A returned book can be lent again, the loan history keeps both
+		rr = request("POST", "/api/v1/books/1/loans", `{"borrower": "grace", "due_at": "2099-01-01"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		rr = request("GET", "/api/v1/books/1/loans", "")
+		var loans []models.Loan
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&loans))
+		assert.Len(t, loans, 2)
+		rr = request("POST", "/api/v1/loans/3/return", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		rr = request("GET", "/api/v1/books/42/loans", "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = request("DELETE", "/api/v1/loans/3", "")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = request("GET", "/api/v1/loans/3", "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/mayank-02/bookman/internal/db"
+)
+
+const MergePatchContentType = "application/merge-patch+json"
+
+// mergePatch applies an RFC 7386 JSON merge patch to target. Objects are
+// merged recursively, null removes a member and any other value replaces it.
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+		} else {
+			targetObject[name] = mergePatch(targetObject[name], value)
+		}
+	}
+	return targetObject
+}
+
+// applyMergePatch reads a merge patch from the request body and applies it
+// to the JSON form of current. Members that T does not have are rejected and
+// members removed by the patch come back as zero values.
+func applyMergePatch[T any](r *http.Request, current T) (T, error) {
+	var patched T
+	var patch interface{}
+	if err := decodeJSON(r, &patch); err != nil {
+		return patched, err
+	}
+	if _, ok := patch.(map[string]interface{}); !ok {
+		return patched, errors.New("Invalid merge patch, expected a JSON object")
+	}
+
+	data, err := json.Marshal(current)
+	if err != nil {
+		return patched, err
+	}
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return patched, err
+	}
+	merged, err := json.Marshal(mergePatch(document, patch))
+	if err != nil {
+		return patched, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patched); err != nil {
+		return patched, fmt.Errorf("Invalid merge patch: %v", err)
+	}
+	return patched, nil
+}
+
+// isMergePatch accepts application/merge-patch+json as well as plain JSON,
+// which is what most clients send by default.
+func isMergePatch(r *http.Request) bool {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == MergePatchContentType || mediaType == "application/json")
+}
+
+// writePatchError reports a merge patch that could not be stored. Patches
+// are stored against the version they were applied to, so a concurrent
+// change is not reverted. Without If-Match the client named no version and
+// the patch conflicts with the change instead of failing a precondition.
+func writePatchError(w http.ResponseWriter, r *http.Request, err error, version int) {
+	if version == 0 && errors.Is(err, db.ErrVersionMismatch) {
+		writeProblem(w, r, http.StatusConflict, ProblemConflict, "The resource changed while the patch was applied, apply it again")
+		return
+	}
+	writeError(w, r, err)
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatch(t *testing.T) {
+	// Test cases from RFC 7386, appendix A
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		var target, patch interface{}
+		assert.NoError(t, json.Unmarshal([]byte(tt.target), &target))
+		assert.NoError(t, json.Unmarshal([]byte(tt.patch), &patch))
+		got, err := json.Marshal(mergePatch(target, patch))
+		assert.NoError(t, err)
+		assert.JSONEq(t, tt.want, string(got), tt.target+" + "+tt.patch)
+	}
+}
+
+func patchRequest(t *testing.T, router http.Handler, url, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req, err := http.NewRequest("PATCH", url, strings.NewReader(body))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", MergePatchContentType)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestPatchBook(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		// Insert test data
+		createTestBook(t, store, "2022-01-01")
+		router := setupTestRouter(store)
+
+		// Only the fields in the patch change, removed fields are cleared
+		rr := patchRequest(t, router, "/api/v1/books/1", `{"genre": "Fiction", "edition": null, "id": 7}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var patched models.Book
+		err := json.NewDecoder(rr.Body).Decode(&patched)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, patched.ID)
+		assert.Equal(t, "Fiction", patched.Genre)
+		assert.Equal(t, "", patched.Edition)
+		assert.False(t, patched.CreatedAt.IsZero())
+
+		stored, err := store.GetBook(1)
+		assert.NoError(t, err)
+		assert.Equal(t, "Test Book", stored.Title)
+		assert.Equal(t, "Test Author", stored.Author)
+		assert.Equal(t, "2022-01-01", stored.PublishedDate)
+		assert.Equal(t, "A test book description", stored.Description)
+		assert.Equal(t, "Fiction", stored.Genre)
+		assert.Equal(t, "", stored.Edition)
+
+		// The patched book must still be valid
+		rr = patchRequest(t, router, "/api/v1/books/1", `{"title": null, "published_date": "yesterday"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		var problem models.Problem
+		err = json.NewDecoder(rr.Body).Decode(&problem)
+		assert.NoError(t, err)
+		assert.Equal(t, ProblemValidation, problem.Type)
+		assert.Len(t, problem.Errors, 2)
+
+		// Malformed patches
+		for _, body := range []string{`{"shelf": "A1"}`, `{"title": 42}`, `["title"]`, `{`} {
+			rr = patchRequest(t, router, "/api/v1/books/1", body)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+		}
+
+		rr = patchRequest(t, router, "/api/v1/books/42", `{"genre": "Fiction"}`)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		req, err := http.NewRequest("PATCH", "/api/v1/books/1", strings.NewReader(`[{"op": "remove", "path": "/genre"}]`))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json-patch+json")
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+		assert.Equal(t, MergePatchContentType, rr.Header().Get("Accept-Patch"))
+	})
+}
+
+// racingStore runs race once, right after the handler under test loaded a
+// book, as if another client changed it at that moment.
+type racingStore struct {
+	db.Store
+	race *func()
+}
+
+func (s racingStore) WithActor(actor string) db.Store {
+	return racingStore{s.Store.WithActor(actor), s.race}
+}
+
+func (s racingStore) GetBook(id int) (models.Book, error) {
+	b, err := s.Store.GetBook(id)
+	if race := *s.race; race != nil {
+		*s.race = nil
+		race()
+	}
+	return b, err
+}
+
+func TestPatchBookRace(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		createTestBook(t, store, "2022-01-01")
+		var race func()
+		router := setupTestRouter(racingStore{store, &race})
+		changeGenre := func() {
+			book, err := store.GetBook(1)
+			assert.NoError(t, err)
+			book.Genre = "Mystery"
+			assert.NoError(t, store.UpdateBook(book))
+		}
+
+		// A concurrent change is not reverted by a patch of other fields
+		race = changeGenre
+		rr := patchRequest(t, router, "/api/v1/books/1", `{"edition": "Second"}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		book, err := store.GetBook(1)
+		assert.NoError(t, err)
+		assert.Equal(t, "Mystery", book.Genre)
+		assert.Equal(t, 2, book.Version)
+
+		// Conditional patches fail their precondition instead
+		race = changeGenre
+		req, err := http.NewRequest("PATCH", "/api/v1/books/1", strings.NewReader(`{"edition": "Second"}`))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", MergePatchContentType)
+		req.Header.Set("If-Match", `"2"`)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+		rr = patchRequest(t, router, "/api/v1/books/1", `{"edition": "Second"}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		book, err = store.GetBook(1)
+		assert.NoError(t, err)
+		assert.Equal(t, "Mystery", book.Genre)
+		assert.Equal(t, "Second", book.Edition)
+	})
+}
+
+func TestPatchCollection(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		// Insert test data
+		collectionID := createTestCollection(t, store)
+		bookID := createTestBook(t, store, "2022-01-01")
+		err := store.AddBookToCollection(collectionID, bookID)
+		assert.NoError(t, err)
+		router := setupTestRouter(store)
+
+		// Books cannot be patched
+		rr := patchRequest(t, router, "/api/v1/collections/1", `{"name": "Renamed", "books": []}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var patched models.Collection
+		err = json.NewDecoder(rr.Body).Decode(&patched)
+		assert.NoError(t, err)
+		assert.Equal(t, "Renamed", patched.Name)
+		assert.Len(t, patched.Books, 1)
+
+		collection, err := store.GetCollection(collectionID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Renamed", collection.Name)
+		assert.Len(t, collection.Books, 1)
+
+		rr = patchRequest(t, router, "/api/v1/collections/1", `{"name": null}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = patchRequest(t, router, "/api/v1/collections/42", `{"name": "Missing"}`)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem types. They are relative URIs documented in the README.
+const (
+	ProblemInvalidRequest   = "/problems/invalid-request"
+	ProblemValidation       = "/problems/validation"
+	ProblemNotFound         = "/problems/not-found"
+	ProblemConflict         = "/problems/conflict"
+	ProblemMethodNotAllowed = "/problems/method-not-allowed"
+	ProblemUnsupportedMedia = "/problems/unsupported-media-type"
+	ProblemPrecondition     = "/problems/precondition-failed"
+	ProblemTooLarge         = "/problems/payload-too-large"
+	ProblemInternal         = "/problems/internal"
+)
+
+var problemTitles = map[string]string{
+	ProblemInvalidRequest:   "Invalid request",
+	ProblemValidation:       "Validation failed",
+	ProblemNotFound:         "Resource not found",
+	ProblemConflict:         "Conflict with the current state of the resource",
+	ProblemMethodNotAllowed: "Method not allowed",
+	ProblemUnsupportedMedia: "Unsupported media type",
+	ProblemPrecondition:     "Precondition failed",
+	ProblemTooLarge:         "Payload too large",
+	ProblemInternal:         "Internal server error",
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, problemType, detail string, fieldErrors ...models.FieldError) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(models.Problem{
+		Type:     problemType,
+		Title:    problemTitles[problemType],
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Errors:   fieldErrors,
+	})
+}
+
+// badRequest reports a request that could not be understood, such as a
+// malformed body or query parameter.
+func badRequest(w http.ResponseWriter, r *http.Request, err error) {
+	writeProblem(w, r, http.StatusBadRequest, ProblemInvalidRequest, err.Error())
+}
+
+// validationFailed reports the fields of an otherwise well-formed request
+// that were rejected.
+func validationFailed(w http.ResponseWriter, r *http.Request, fieldErrors []models.FieldError) {
+	writeProblem(w, r, http.StatusBadRequest, ProblemValidation, "One or more fields are invalid", fieldErrors...)
+}
+
+// writeError reports an error returned by the store. Errors the store does
+// not classify are logged and hidden from the client, since they may carry
+// database internals.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		writeProblem(w, r, http.StatusNotFound, ProblemNotFound, err.Error())
+	case errors.Is(err, db.ErrVersionMismatch):
+		preconditionFailed(w, r, err.Error())
+	case errors.Is(err, db.ErrConflict):
+		writeProblem(w, r, http.StatusConflict, ProblemConflict, err.Error())
+	case errors.Is(err, db.ErrInvalid):
+		writeProblem(w, r, http.StatusBadRequest, ProblemInvalidRequest, err.Error())
+	default:
+		log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+		writeProblem(w, r, http.StatusInternalServerError, ProblemInternal, "The request could not be completed")
+	}
+}
+
+// preconditionFailed reports a conditional request for a record that has
+// changed since the client last saw it.
+func preconditionFailed(w http.ResponseWriter, r *http.Request, detail string) {
+	writeProblem(w, r, http.StatusPreconditionFailed, ProblemPrecondition, detail)
+}
+
+// unsupportedMediaType rejects a request body of the wrong type and names
+// the accepted one in the Accept-Patch header.
+func unsupportedMediaType(w http.ResponseWriter, r *http.Request, accepted string) {
+	w.Header().Set("Accept-Patch", accepted)
+	writeProblem(w, r, http.StatusUnsupportedMediaType, ProblemUnsupportedMedia,
+		fmt.Sprintf("Expected a request body of type %s", accepted))
+}
+
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusNotFound, ProblemNotFound, "No resource matches the URL")
+}
+
+func methodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusMethodNotAllowed, ProblemMethodNotAllowed, fmt.Sprintf("%s is not supported for this resource", r.Method))
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+func getPublishers(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, err := parseListOptions(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+
+		publishers, page, err := db.GetPublishers(opts)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if publishers == nil {
+			publishers = []models.Publisher{}
+		}
+		writePageHeaders(w, r, page)
+		json.NewEncoder(w).Encode(publishers)
+	}
+}
+
+func getPublisher(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "publisher")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		publisher, err := db.GetPublisher(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(publisher)
+	}
+}
+
+// getPublisherBooks lists the books of the publisher. It takes the paging
+// parameters of the book listing.
+func getPublisherBooks(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "publisher")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		opts, err := parseListOptions(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		publisher, err := db.GetPublisher(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		books, page, err := db.GetBooks(publisherFilter(publisher), opts)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if books == nil {
+			books = []models.Book{}
+		}
+		writePageHeaders(w, r, page)
+		json.NewEncoder(w).Encode(books)
+	}
+}
+
+func publisherFilter(publisher models.Publisher) db.BookFilter {
+	return db.BookFilter{Publisher: publisher.Name}
+}
+
+func updatePublisher(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "publisher")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		var publisher models.Publisher
+		if err := decodeJSON(r, &publisher); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		publisher.ID = id
+		writePublisher(w, r, db, publisher)
+	}
+}
+
+func patchPublisher(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "publisher")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		if !isMergePatch(r) {
+			unsupportedMediaType(w, r, MergePatchContentType)
+			return
+		}
+
+		current, err := db.GetPublisher(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		publisher, err := applyMergePatch(r, current)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		publisher.ID = current.ID
+		writePublisher(w, r, db, publisher)
+	}
+}
+
+// writePublisher renames a publisher and responds with it as stored.
+func writePublisher(w http.ResponseWriter, r *http.Request, store db.Store, publisher models.Publisher) {
+	// Validation checks
+	if fieldErrors := validatePublisher(&publisher); fieldErrors != nil {
+		validationFailed(w, r, fieldErrors)
+		return
+	}
+
+	if err := store.UpdatePublisher(publisher); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	publisher, err := store.GetPublisher(publisher.ID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(publisher)
+}
+
+func deletePublisher(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "publisher")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		err = db.DeletePublisher(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func validatePublisher(publisher *models.Publisher) []models.FieldError {
+	publisher.Name = strings.TrimSpace(publisher.Name)
+	if publisher.Name == "" {
+		return []models.FieldError{{Field: "name", Message: "is required"}}
+	}
+	return nil
+}
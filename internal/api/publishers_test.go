@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookDetails(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		router := setupTestRouter(store)
+		request := func(method, url, body string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest(method, url, strings.NewReader(body))
+			assert.NoError(t, err)
+			if method == "PATCH" {
+				req.Header.Set("Content-Type", MergePatchContentType)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr
+		}
+
+		rr := request("POST", "/api/v1/books", `{"title": "Dune", "author": "Frank Herbert", "published_date": "1965-08-01",
+			"publisher": " Chilton Books ", "language": "EN-us", "page_count": 412, "format": "hardcover",
+			"dimensions": {"height": 235, "width": 157, "thickness": 38}}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var book models.Book
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&book))
+		assert.Equal(t, "Chilton Books", book.Publisher)
+		assert.Equal(t, "en-US", book.Language)
+		assert.Equal(t, models.Dimensions{Height: 235, Width: 157, Thickness: 38}, book.Dimensions)
+
+		rr = request("POST", "/api/v1/books", `{"title": "Dune", "author": "Frank Herbert", "published_date": "1965-08-01",
+			"language": "en_US", "page_count": -1, "format": "scroll", "dimensions": {"height": -1}}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `{"field":"language","message":"must be a BCP 47 language tag"}`)
+		assert.Contains(t, rr.Body.String(), `{"field":"page_count","message":"must be at least 0"}`)
+		assert.Contains(t, rr.Body.String(), `{"field":"format","message":"must be one of`)
+		assert.Contains(t, rr.Body.String(), `{"field":"dimensions.height","message":"must be at least 0"}`)
+
+		rr = request("PATCH", "/api/v1/books/1", `{"language": "de", "page_count": 300}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"publisher":"Chilton Books","language":"de","page_count":300`)
+
+		for _, tt := range []struct {
+			query string
+			total string
+		}{
+			{"publisher=Chilton+Books", "1"},
+			{"publisher=Ace", "0"},
+			{"language=DE", "1"},
+			{"format=hardcover&min_pages=200&max_pages=400", "1"},
+			{"max_height=200", "0"},
+			{"max_thickness=40", "1"},
+		} {
+			rr = request("GET", "/api/v1/books?"+tt.query, "")
+			assert.Equal(t, http.StatusOK, rr.Code, tt.query)
+			assert.Equal(t, tt.total, rr.Header().Get("X-Total-Count"), tt.query)
+		}
+		for _, query := range []string{"language=x", "format=scroll", "min_pages=-1", "max_width=wide"} {
+			rr = request("GET", "/api/v1/books?"+query, "")
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+
+		rr = request("GET", "/api/v1/publishers", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `[{"id":1,"name":"Chilton Books","book_count":1}]`, strings.TrimSpace(rr.Body.String()))
+		rr = request("GET", "/api/v1/publishers/1/books", "")
+		assert.Equal(t, "1", rr.Header().Get("X-Total-Count"))
+		rr = request("PATCH", "/api/v1/publishers/1", `{"name": " Chilton "}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `{"id":1,"name":"Chilton","book_count":1}`, strings.TrimSpace(rr.Body.String()))
+		rr = request("PUT", "/api/v1/publishers/1", `{"name": ""}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		rr = request("GET", "/api/v1/books/1", "")
+		assert.Contains(t, rr.Body.String(), `"publisher":"Chilton"`)
+
+		rr = request("DELETE", "/api/v1/publishers/1", "")
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		rr = request("PATCH", "/api/v1/books/1", `{"publisher": ""}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		rr = request("DELETE", "/api/v1/publishers/1", "")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = request("GET", "/api/v1/publishers/1", "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
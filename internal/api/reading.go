@@ -0,0 +1,249 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// getReadingLog returns the reading sessions of a book, oldest first.
+func getReadingLog(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookID, err := parseID(r, "id", "book")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		log, err := db.GetReadingLog(bookID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(log)
+	}
+}
+
+func getReadingSession(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookID, sessionID, err := parseSessionID(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		session, err := db.GetReadingSession(bookID, sessionID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(session)
+	}
+}
+
+// createReadingSession starts a new read of a book, which is rejected while
+// the previous one is still open.
+func createReadingSession(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookID, err := parseID(r, "id", "book")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		var session models.ReadingSession
+		if err := decodeJSON(r, &session); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		session.BookID = bookID
+
+		// Validation checks
+		if fieldErrors := validateReadingSession(session); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		id, err := db.CreateReadingSession(session)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		session, err = db.GetReadingSession(bookID, id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(session)
+	}
+}
+
+// updateReadingSession replaces the status and dates of a session. Its
+// progress is kept.
+func updateReadingSession(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookID, sessionID, err := parseSessionID(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		var session models.ReadingSession
+		if err := decodeJSON(r, &session); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		session.ID, session.BookID = sessionID, bookID
+
+		// Validation checks
+		if fieldErrors := validateReadingSession(session); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		writeReadingSession(w, r, db, session)
+	}
+}
+
+func patchReadingSession(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookID, sessionID, err := parseSessionID(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		if !isMergePatch(r) {
+			unsupportedMediaType(w, r, MergePatchContentType)
+			return
+		}
+
+		current, err := db.GetReadingSession(bookID, sessionID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		session, err := applyMergePatch(r, current)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		session.ID, session.BookID = current.ID, current.BookID
+
+		// Validation checks
+		if fieldErrors := validateReadingSession(session); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		writeReadingSession(w, r, db, session)
+	}
+}
+
+// writeReadingSession stores a changed session and responds with it as
+// stored.
+func writeReadingSession(w http.ResponseWriter, r *http.Request, store db.Store, session models.ReadingSession) {
+	err := store.UpdateReadingSession(session)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	session, err = store.GetReadingSession(session.BookID, session.ID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(session)
+}
+
+func deleteReadingSession(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookID, sessionID, err := parseSessionID(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		err = db.DeleteReadingSession(bookID, sessionID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// addReadingProgress records how far a session got and responds with the
+// session including the new entry.
+func addReadingProgress(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookID, sessionID, err := parseSessionID(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		var progress models.ReadingProgress
+		if err := decodeJSON(r, &progress); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+
+		// Validation checks
+		if fieldErrors := validateReadingProgress(progress); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		_, err = db.AddReadingProgress(bookID, sessionID, progress)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		session, err := db.GetReadingSession(bookID, sessionID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(session)
+	}
+}
+
+func parseSessionID(r *http.Request) (int, int, error) {
+	bookID, err := parseID(r, "id", "book")
+	if err != nil {
+		return 0, 0, err
+	}
+	sessionID, err := parseID(r, "sessionId", "reading session")
+	return bookID, sessionID, err
+}
+
+func validateReadingSession(session models.ReadingSession) []models.FieldError {
+	var fieldErrors []models.FieldError
+	if session.Status == "" {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "status", Message: "is required"})
+	} else if !slices.Contains(models.ReadingStatuses, session.Status) {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "status", Message: "must be one of " + strings.Join(models.ReadingStatuses, ", ")})
+	}
+	if _, err := time.Parse("2006-01-02", session.StartedOn); session.StartedOn != "" && err != nil {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "started_on", Message: "must be a date in the format YYYY-MM-DD"})
+	}
+	if _, err := time.Parse("2006-01-02", session.FinishedOn); session.FinishedOn != "" && err != nil {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "finished_on", Message: "must be a date in the format YYYY-MM-DD"})
+	}
+	return fieldErrors
+}
+
+func validateReadingProgress(progress models.ReadingProgress) []models.FieldError {
+	var fieldErrors []models.FieldError
+	if progress.Page == nil && progress.Percent == nil {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "page", Message: "or percent is required"})
+	}
+	if progress.Page != nil && *progress.Page < 0 {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "page", Message: "must be at least 0"})
+	}
+	if progress.Percent != nil && (*progress.Percent < 0 || *progress.Percent > 100) {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "percent", Message: "must be between 0 and 100"})
+	}
+	return fieldErrors
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReading(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		router := setupTestRouter(store)
+		request := func(method, url, body string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest(method, url, strings.NewReader(body))
+			assert.NoError(t, err)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr
+		}
+		createTestBook(t, store, "1965-08-01")
+		createTestBook(t, store, "1937-09-21")
+
+		rr := request("GET", "/api/v1/books/1/reading", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"book_id": 1, "status": "", "sessions": []}`, rr.Body.String())
+
+		rr = request("POST", "/api/v1/books/1/reading", `{"status": "reading", "started_on": "2024-03-01"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var session models.ReadingSession
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&session))
+		assert.Equal(t, models.ReadingSession{ID: 1, BookID: 1, Status: "reading", StartedOn: "2024-03-01",
+			Progress: []models.ReadingProgress{}, CreatedAt: session.CreatedAt, UpdatedAt: session.UpdatedAt}, session)
+
+		rr = request("POST", "/api/v1/books/1/reading", `{"status": "reading"}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		rr = request("POST", "/api/v1/books/2/reading", `{"status": "skimming", "started_on": "March"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `{"field":"status","message":"must be one of want-to-read, reading, finished, abandoned"}`)
+		assert.Contains(t, rr.Body.String(), `{"field":"started_on","message":"must be a date in the format YYYY-MM-DD"}`)
+		rr = request("POST", "/api/v1/books/42/reading", `{"status": "reading"}`)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = request("POST", "/api/v1/books/1/reading/1/progress", `{"page": 50, "percent": 12.5}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"page":50,"percent":12.5`)
+		rr = request("POST", "/api/v1/books/1/reading/1/progress", `{}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		rr = request("POST", "/api/v1/books/1/reading/1/progress", `{"percent": 120}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		rr = request("POST", "/api/v1/books/2/reading/1/progress", `{"page": 1}`)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		// Books embed and are filtered by their reading status
+		rr = request("GET", "/api/v1/books?status=reading", "")
+		assert.Equal(t, "1", rr.Header().Get("X-Total-Count"))
+		assert.Contains(t, rr.Body.String(), `"reading_status":"reading"`)
+		rr = request("GET", "/api/v1/books?status=done", "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = request("PATCH", "/api/v1/books/1/reading/1", `{"status": "finished", "finished_on": "2024-03-20"}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"started_on":"2024-03-01","finished_on":"2024-03-20"`)
+		rr = request("PUT", "/api/v1/books/1/reading/1", `{"status": "finished", "started_on": "2024-03-21", "finished_on": "2024-03-20"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		// A re-read is a new session
+		rr = request("POST", "/api/v1/books/1/reading", `{"status": "want-to-read"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		rr = request("GET", "/api/v1/books/1/reading", "")
+		var log models.ReadingLog
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&log))
+		assert.Equal(t, "want-to-read", log.Status)
+		assert.Len(t, log.Sessions, 2)
+		assert.Len(t, log.Sessions[0].Progress, 1)
+
+		rr = request("DELETE", "/api/v1/books/1/reading/2", "")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = request("GET", "/api/v1/books/1/reading/2", "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		rr = request("GET", "/api/v1/books/1", "")
+		assert.Contains(t, rr.Body.String(), `"reading_status":"finished"`)
+	})
+}
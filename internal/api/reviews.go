@@ -0,0 +1,220 @@
+package api
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// getReviews lists the reviews of all books, optionally only those of the
+// reviewer given in the query.
+func getReviews(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, err := parseListOptions(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		writeReviews(w, r, db, reviewFilter(0, r.URL.Query().Get("reviewer")), opts)
+	}
+}
+
+func getBookReviews(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookID, err := parseID(r, "id", "book")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		opts, err := parseListOptions(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		if _, err := db.GetBook(bookID); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		writeReviews(w, r, db, reviewFilter(bookID, r.URL.Query().Get("reviewer")), opts)
+	}
+}
+
+func reviewFilter(bookID int, reviewer string) db.ReviewFilter {
+	return db.ReviewFilter{BookID: bookID, Reviewer: reviewer}
+}
+
+func writeReviews(w http.ResponseWriter, r *http.Request, store db.Store, filter db.ReviewFilter, opts db.ListOptions) {
+	reviews, page, err := store.GetReviews(filter, opts)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if reviews == nil {
+		reviews = []models.Review{}
+	}
+	writePageHeaders(w, r, page)
+	json.NewEncoder(w).Encode(reviews)
+}
+
+// getReview returns the review with its earlier versions.
+func getReview(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "review")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		review, err := db.GetReview(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(review)
+	}
+}
+
+func createReview(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookID, err := parseID(r, "id", "book")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		var review models.Review
+		if err := decodeJSON(r, &review); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		review.BookID = bookID
+
+		// Validation checks
+		if fieldErrors := validateReview(review); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		id, err := db.CreateReview(review)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		review, err = db.GetReview(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(review)
+	}
+}
+
+// updateReview replaces the rating and body of a review, keeping the
+// version it replaces in the history. The book and reviewer stay the same.
+func updateReview(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "review")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		current, err := db.GetReview(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		var review models.Review
+		if err := decodeJSON(r, &review); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		review.ID, review.BookID, review.Reviewer = current.ID, current.BookID, current.Reviewer
+
+		// Validation checks
+		if fieldErrors := validateReview(review); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		writeReview(w, r, db, review)
+	}
+}
+
+func patchReview(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "review")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		if !isMergePatch(r) {
+			unsupportedMediaType(w, r, MergePatchContentType)
+			return
+		}
+
+		current, err := db.GetReview(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		review, err := applyMergePatch(r, current)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		review.ID, review.BookID, review.Reviewer = current.ID, current.BookID, current.Reviewer
+
+		// Validation checks
+		if fieldErrors := validateReview(review); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		writeReview(w, r, db, review)
+	}
+}
+
+// writeReview stores a changed review and responds with it as stored.
+func writeReview(w http.ResponseWriter, r *http.Request, store db.Store, review models.Review) {
+	err := store.UpdateReview(review)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	review, err = store.GetReview(review.ID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(review)
+}
+
+func deleteReview(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "review")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		err = db.DeleteReview(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func validateReview(review models.Review) []models.FieldError {
+	var fieldErrors []models.FieldError
+	if strings.TrimSpace(review.Reviewer) == "" {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "reviewer", Message: "is required"})
+	}
+	if review.Rating < 1 || review.Rating > 5 || review.Rating*2 != math.Round(review.Rating*2) {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "rating", Message: "must be from 1 to 5 in steps of 0.5"})
+	}
+	return fieldErrors
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReviews(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		router := setupTestRouter(store)
+		request := func(method, url, body string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest(method, url, strings.NewReader(body))
+			assert.NoError(t, err)
+			if method == "PATCH" {
+				req.Header.Set("Content-Type", MergePatchContentType)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr
+		}
+		createTestBook(t, store, "1965-08-01")
+		createTestBook(t, store, "1937-09-21")
+
+		rr := request("GET", "/api/v1/books/1/reviews", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[]`, rr.Body.String())
+
+		rr = request("POST", "/api/v1/books/1/reviews", `{"reviewer": "ana", "rating": 4.5, "body": "*Great* read"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var review models.Review
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&review))
+		assert.Equal(t, models.Review{ID: 1, BookID: 1, Reviewer: "ana", Rating: 4.5, Body: "*Great* read",
+			History: []models.ReviewRevision{}, CreatedAt: review.CreatedAt, UpdatedAt: review.UpdatedAt}, review)
+
+		rr = request("POST", "/api/v1/books/1/reviews", `{"reviewer": "ana", "rating": 3}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		rr = request("POST", "/api/v1/books/2/reviews", `{"rating": 4.2}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `{"field":"reviewer","message":"is required"}`)
+		assert.Contains(t, rr.Body.String(), `{"field":"rating","message":"must be from 1 to 5 in steps of 0.5"}`)
+		rr = request("POST", "/api/v1/books/42/reviews", `{"reviewer": "ana", "rating": 3}`)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = request("POST", "/api/v1/books/1/reviews", `{"reviewer": "ben", "rating": 2}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		rr = request("POST", "/api/v1/books/2/reviews", `{"reviewer": "ben", "rating": 5}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		// Books embed their average rating and are filtered and sorted by it
+		rr = request("GET", "/api/v1/books/1", "")
+		assert.Contains(t, rr.Body.String(), `"average_rating":3.25,"rating_count":2`)
+		rr = request("GET", "/api/v1/books?min_rating=4", "")
+		assert.Equal(t, "1", rr.Header().Get("X-Total-Count"))
+		rr = request("GET", "/api/v1/books?min_rating=6", "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		rr = request("GET", "/api/v1/books?sort=rating&order=desc", "")
+		var books []models.Book
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&books))
+		assert.Equal(t, []int{2, 1}, []int{books[0].ID, books[1].ID})
+
+		rr = request("GET", "/api/v1/reviews?reviewer=ben", "")
+		assert.Equal(t, "2", rr.Header().Get("X-Total-Count"))
+
+		// Edits keep the earlier versions
+		rr = request("PATCH", "/api/v1/reviews/1", `{"rating": 3.5, "reviewer": "eve"}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&review))
+		assert.Equal(t, "ana", review.Reviewer)
+		assert.Equal(t, 3.5, review.Rating)
+		assert.Len(t, review.History, 1)
+		assert.Equal(t, 4.5, review.History[0].Rating)
+		rr = request("PUT", "/api/v1/reviews/1", `{"rating": 7}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		rr = request("PUT", "/api/v1/reviews/1", `{"rating": 4, "body": "Better the second time"}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		rr = request("GET", "/api/v1/reviews/1", "")
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&review))
+		assert.Equal(t, "Better the second time", review.Body)
+		assert.Len(t, review.History, 2)
+
+		rr = request("DELETE", "/api/v1/reviews/2", "")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = request("GET", "/api/v1/reviews/2", "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		rr = request("GET", "/api/v1/books/1", "")
+		assert.Contains(t, rr.Body.String(), `"average_rating":4,"rating_count":1`)
+	})
+}
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/mayank-02/bookman/internal/db"
+)
+
+func getBookRevisions(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "book")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		revisions, err := db.GetBookRevisions(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(revisions)
+	}
+}
+
+func getBookRevision(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "book")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		revision, err := parseID(r, "revision", "revision")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		rev, err := db.GetBookRevision(id, revision)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(rev)
+	}
+}
+
+// diffBookRevisions compares the revisions given by from and to, either of
+// which defaults to the book as it is now.
+func diffBookRevisions(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "book")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		from, to, err := parseRevisionRange(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		diff, err := db.DiffBookRevisions(store, id, from, to)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(diff)
+	}
+}
+
+// restoreBookRevision sets the fields of a book back to those of one of its
+// revisions and responds with the book. It honours If-Match like updateBook.
+func restoreBookRevision(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "book")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		revision, err := parseID(r, "revision", "revision")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		version, ok := ifMatch(w, r, func() (int, error) {
+			current, err := db.GetBook(id)
+			return current.Version, err
+		})
+		if !ok {
+			return
+		}
+
+		if err := db.RestoreBookRevision(id, revision, version); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		book, err := db.GetBook(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		setETag(w, book.Version)
+		json.NewEncoder(w).Encode(book)
+	}
+}
+
+func getCollectionRevisions(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "collection")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		revisions, err := db.GetCollectionRevisions(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(revisions)
+	}
+}
+
+func getCollectionRevision(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "collection")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		revision, err := parseID(r, "revision", "revision")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		rev, err := db.GetCollectionRevision(id, revision)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(rev)
+	}
+}
+
+// diffCollectionRevisions is diffBookRevisions for collections.
+func diffCollectionRevisions(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "collection")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		from, to, err := parseRevisionRange(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		diff, err := db.DiffCollectionRevisions(store, id, from, to)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(diff)
+	}
+}
+
+// restoreCollectionRevision sets the name and the books of a collection back
+// to those of one of its revisions and responds with the collection.
+func restoreCollectionRevision(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "collection")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		revision, err := parseID(r, "revision", "revision")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		version, ok := ifMatch(w, r, func() (int, error) {
+			current, err := db.GetCollection(id)
+			return current.Version, err
+		})
+		if !ok {
+			return
+		}
+
+		if err := db.RestoreCollectionRevision(id, revision, version); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		collection, err := db.GetCollection(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		setETag(w, collection.Version)
+		json.NewEncoder(w).Encode(collection)
+	}
+}
+
+// parseRevisionRange reads the from and to revisions of a diff. At least
+// one of them is required, the other one defaults to 0 for the current
+// state.
+func parseRevisionRange(r *http.Request) (from, to int, err error) {
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{{"from", &from}, {"to", &to}} {
+		value := r.URL.Query().Get(param.name)
+		if value == "" {
+			continue
+		}
+		if *param.value, err = strconv.Atoi(value); err != nil || *param.value < 1 {
+			return 0, 0, fmt.Errorf("Invalid %s: must be a revision number", param.name)
+		}
+	}
+	if from == 0 && to == 0 {
+		return 0, 0, errors.New("Invalid diff: from or to is required")
+	}
+	return from, to, nil
+}
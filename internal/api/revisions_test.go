@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevisions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		router := setupTestRouter(store)
+		request := func(method, url, ifMatch, body string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest(method, url, strings.NewReader(body))
+			assert.NoError(t, err)
+			req.Header.Set(ActorHeader, "alice")
+			if method == "PATCH" {
+				req.Header.Set("Content-Type", MergePatchContentType)
+			}
+			if ifMatch != "" {
+				req.Header.Set("If-Match", ifMatch)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr
+		}
+
+		assert.Equal(t, http.StatusCreated, request("POST", "/api/v1/books", "", `{"title": "Dune", "author": "Frank Herbert", "published_date": "1965-08-01"}`).Code)
+		assert.Equal(t, http.StatusOK, request("PATCH", "/api/v1/books/1", "", `{"genre": "Science Fiction", "edition": "First"}`).Code)
+
+		rr := request("GET", "/api/v1/books/1/revisions", "", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var revisions []models.BookRevision
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&revisions))
+		if assert.Len(t, revisions, 2) {
+			assert.Equal(t, "alice", revisions[1].Actor)
+			assert.Equal(t, "Science Fiction", revisions[1].Book.Genre)
+		}
+		rr = request("GET", "/api/v1/books/1/revisions/1", "", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"title":"Dune"`)
+
+		var diff models.RevisionDiff
+		rr = request("GET", "/api/v1/books/1/revisions/diff?from=1&to=2", "", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&diff))
+		assert.Equal(t, models.RevisionDiff{From: 1, To: 2, Changes: []models.FieldChange{
+			{Field: "edition", Before: json.RawMessage("null"), After: json.RawMessage(`"First"`)},
+			{Field: "genre", Before: json.RawMessage("null"), After: json.RawMessage(`"Science Fiction"`)},
+		}}, diff)
+		// From the book as it is now
+		rr = request("GET", "/api/v1/books/1/revisions/diff?to=1", "", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&diff))
+		assert.Equal(t, 0, diff.From)
+		assert.Len(t, diff.Changes, 2)
+
+		rr = request("POST", "/api/v1/books/1/revisions/1/restore", `"1"`, "")
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+		rr = request("POST", "/api/v1/books/1/revisions/1/restore", `"2"`, "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
+		var book models.Book
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&book))
+		assert.Empty(t, book.Genre)
+		assert.Empty(t, book.Edition)
+		assert.Equal(t, "Dune", book.Title)
+
+		assert.Equal(t, http.StatusCreated, request("POST", "/api/v1/collections", "", `{"name": "Shelf"}`).Code)
+		assert.Equal(t, http.StatusNoContent, request("POST", "/api/v1/collections/1/books/1", "", "").Code)
+		rr = request("GET", "/api/v1/collections/1/revisions/diff?from=1", "", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `{"field":"book_ids","before":null,"after":[1]}`)
+		rr = request("POST", "/api/v1/collections/1/revisions/1/restore", "", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var collection models.Collection
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&collection))
+		assert.Empty(t, collection.Books)
+		rr = request("GET", "/api/v1/collections/1/revisions", "", "")
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&revisions))
+		assert.Len(t, revisions, 3)
+
+		for url, code := range map[string]int{
+			"/api/v1/books/1/revisions/9":                 http.StatusNotFound,
+			"/api/v1/books/2/revisions":                   http.StatusNotFound,
+			"/api/v1/books/1/revisions/first":             http.StatusBadRequest,
+			"/api/v1/books/1/revisions/diff":              http.StatusBadRequest,
+			"/api/v1/books/1/revisions/diff?from=0":       http.StatusBadRequest,
+			"/api/v1/books/1/revisions/diff?from=1&to=9":  http.StatusNotFound,
+			"/api/v1/collections/1/revisions/diff?to=x":   http.StatusBadRequest,
+			"/api/v1/collections/2/revisions/diff?from=1": http.StatusNotFound,
+		} {
+			assert.Equal(t, code, request("GET", url, "", "").Code, url)
+		}
+		assert.Equal(t, http.StatusNotFound, request("POST", "/api/v1/collections/1/revisions/9/restore", "", "").Code)
+	})
+}
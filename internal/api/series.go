@@ -0,0 +1,251 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+func listSeries(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, err := parseListOptions(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+
+		series, page, err := db.ListSeries(opts)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if series == nil {
+			series = []models.Series{}
+		}
+		writePageHeaders(w, r, page)
+		json.NewEncoder(w).Encode(series)
+	}
+}
+
+// getSeries returns the series with its books in order and the positions
+// missing between them.
+func getSeries(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "series")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		series, err := db.GetSeries(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(series)
+	}
+}
+
+func createSeries(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var series models.Series
+		if err := decodeJSON(r, &series); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+
+		// Validation checks
+		if fieldErrors := validateSeries(series); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		id, err := db.CreateSeries(series)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		series, err = db.GetSeries(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(series)
+	}
+}
+
+// updateSeries changes the name and description of a series. Its books are
+// managed through the membership endpoints.
+func updateSeries(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "series")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		var series models.Series
+		if err := decodeJSON(r, &series); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		series.ID = id
+
+		// Validation checks
+		if fieldErrors := validateSeries(series); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		writeSeries(w, r, db, series)
+	}
+}
+
+func patchSeries(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "series")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		if !isMergePatch(r) {
+			unsupportedMediaType(w, r, MergePatchContentType)
+			return
+		}
+
+		current, err := db.GetSeries(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		series, err := applyMergePatch(r, current)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		series.ID = current.ID
+
+		// Validation checks
+		if fieldErrors := validateSeries(series); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		writeSeries(w, r, db, series)
+	}
+}
+
+// writeSeries stores a changed series and responds with it as stored.
+func writeSeries(w http.ResponseWriter, r *http.Request, store db.Store, series models.Series) {
+	err := store.UpdateSeries(series)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	series, err = store.GetSeries(series.ID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(series)
+}
+
+// deleteSeries deletes the series but keeps its books.
+func deleteSeries(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "series")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		err = db.DeleteSeries(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// seriesPosition is the body of a request placing a book in a series.
+type seriesPosition struct {
+	Position *float64 `json:"position"`
+}
+
+// setSeriesBook adds the book to the series at the position in the body, or
+// moves it there if it is already in the series.
+func setSeriesBook(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		seriesID, err := parseID(r, "id", "series")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		bookID, err := parseID(r, "bookId", "book")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		var body seriesPosition
+		if err := decodeJSON(r, &body); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+
+		// Validation checks
+		if fieldErrors := validatePosition(body.Position); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		err = db.SetSeriesBook(seriesID, bookID, *body.Position)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func removeSeriesBook(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		seriesID, err := parseID(r, "id", "series")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		bookID, err := parseID(r, "bookId", "book")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+
+		err = db.RemoveSeriesBook(seriesID, bookID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func validateSeries(series models.Series) []models.FieldError {
+	if strings.TrimSpace(series.Name) == "" {
+		return []models.FieldError{{Field: "name", Message: "is required"}}
+	}
+	return nil
+}
+
+// validatePosition checks the position of a book in a series, which may be
+// fractional but not negative.
+func validatePosition(position *float64) []models.FieldError {
+	if position == nil {
+		return []models.FieldError{{Field: "position", Message: "is required"}}
+	}
+	if *position < 0 {
+		return []models.FieldError{{Field: "position", Message: "must be at least 0"}}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeries(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		router := setupTestRouter(store)
+		request := func(method, url, body string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest(method, url, strings.NewReader(body))
+			assert.NoError(t, err)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr
+		}
+		for i := 0; i < 3; i++ {
+			createTestBook(t, store, "1968-01-01")
+		}
+
+		rr := request("POST", "/api/v1/series", `{"name": "The Art of Computer Programming", "description": "Knuth's monograph"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.JSONEq(t, `{"id": 1, "name": "The Art of Computer Programming", "description": "Knuth's monograph", "books": [], "missing": []}`, rr.Body.String())
+		rr = request("POST", "/api/v1/series", `{"description": "no name"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = request("PUT", "/api/v1/series/1/books/1", `{"position": 1}`)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = request("PUT", "/api/v1/series/1/books/2", `{"position": 3}`)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = request("PUT", "/api/v1/series/1/books/3", `{"position": 3}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		rr = request("PUT", "/api/v1/series/1/books/3", `{"position": 1.5}`)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = request("PUT", "/api/v1/series/1/books/3", `{}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `{"field":"position","message":"is required"}`)
+		rr = request("PUT", "/api/v1/series/1/books/3", `{"position": -2}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		rr = request("PUT", "/api/v1/series/1/books/42", `{"position": 2}`)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = request("GET", "/api/v1/series/1", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var series models.Series
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&series))
+		assert.Len(t, series.Books, 3)
+		assert.Equal(t, 1.5, series.Books[1].Position)
+		assert.Equal(t, 2, series.Books[2].Book.ID)
+		assert.Equal(t, []int{2}, series.Missing)
+
+		// Books embed their series
+		rr = request("GET", "/api/v1/books/2", "")
+		assert.Contains(t, rr.Body.String(), `"series":[{"id":1,"name":"The Art of Computer Programming","position":3}]`)
+
+		rr = request("PATCH", "/api/v1/series/1", `{"name": "TAOCP"}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"description":"Knuth's monograph"`)
+		rr = request("GET", "/api/v1/series", "")
+		assert.Equal(t, "1", rr.Header().Get("X-Total-Count"))
+
+		rr = request("DELETE", "/api/v1/series/1/books/3", "")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = request("DELETE", "/api/v1/series/1/books/3", "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		rr = request("DELETE", "/api/v1/series/1", "")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = request("GET", "/api/v1/books/2", "")
+		assert.Contains(t, rr.Body.String(), `"series":[]`)
+	})
+}
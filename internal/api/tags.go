@@ -0,0 +1,295 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+func getTags(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, err := parseListOptions(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+
+		tags, page, err := db.GetTags(opts)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if tags == nil {
+			tags = []models.Tag{}
+		}
+		writePageHeaders(w, r, page)
+		json.NewEncoder(w).Encode(tags)
+	}
+}
+
+func getTag(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "tag")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		tag, err := db.GetTag(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(tag)
+	}
+}
+
+func createTag(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var tag models.Tag
+		if err := decodeJSON(r, &tag); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+
+		// Validation checks
+		if fieldErrors := validateTag(tag); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		id, err := db.CreateTag(tag)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		tag, err = db.GetTag(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(tag)
+	}
+}
+
+// updateTag renames a tag. Renaming it to the name of another tag is a
+// conflict, the tags have to be merged instead.
+func updateTag(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "tag")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		var tag models.Tag
+		if err := decodeJSON(r, &tag); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		tag.ID = id
+
+		// Validation checks
+		if fieldErrors := validateTag(tag); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		writeTag(w, r, db, tag)
+	}
+}
+
+func patchTag(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "tag")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		if !isMergePatch(r) {
+			unsupportedMediaType(w, r, MergePatchContentType)
+			return
+		}
+
+		current, err := db.GetTag(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		tag, err := applyMergePatch(r, current)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		tag.ID = current.ID
+
+		// Validation checks
+		if fieldErrors := validateTag(tag); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		writeTag(w, r, db, tag)
+	}
+}
+
+// writeTag stores a renamed tag and responds with it as stored.
+func writeTag(w http.ResponseWriter, r *http.Request, store db.Store, tag models.Tag) {
+	err := store.UpdateTag(tag)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	tag, err = store.GetTag(tag.ID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(tag)
+}
+
+// deleteTag deletes the tag and removes it from its books.
+func deleteTag(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "tag")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		err = db.DeleteTag(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// mergeTags moves the books of the tag in the path to the target tag,
+// deletes the tag and responds with the target tag.
+func mergeTags(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sourceID, err := parseID(r, "id", "tag")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		targetID, err := parseID(r, "targetId", "tag")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+
+		err = db.MergeTags(sourceID, targetID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		tag, err := db.GetTag(targetID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(tag)
+	}
+}
+
+// tagBooks adds tags to several books at once. Either all books are tagged
+// or, if one of them does not exist, none.
+func tagBooks(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request models.BulkTagRequest
+		if err := decodeJSON(r, &request); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+
+		// Validation checks
+		if fieldErrors := validateBulkTag(request); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		err := db.TagBooks(request.BookIDs, request.Tags)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// untagBooks removes tags from several books at once, like tagBooks.
+func untagBooks(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request models.BulkTagRequest
+		if err := decodeJSON(r, &request); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+
+		// Validation checks
+		if fieldErrors := validateBulkTag(request); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		err := db.UntagBooks(request.BookIDs, request.Tags)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// parseTagFilter reads the tag query parameters. Every tag parameter must
+// match, the comma separated tags within one are alternatives:
+// tag=fantasy,science+fiction&tag=classics finds classics of either genre.
+func parseTagFilter(r *http.Request) [][]string {
+	var groups [][]string
+	for _, value := range r.URL.Query()["tag"] {
+		groups = append(groups, strings.Split(value, ","))
+	}
+	return groups
+}
+
+func validateTag(tag models.Tag) []models.FieldError {
+	if message := tagNameError(tag.Name); message != "" {
+		return []models.FieldError{{Field: "name", Message: message}}
+	}
+	return nil
+}
+
+func validateBulkTag(request models.BulkTagRequest) []models.FieldError {
+	var fieldErrors []models.FieldError
+	if len(request.BookIDs) == 0 {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "book_ids", Message: "is required"})
+	} else if len(request.BookIDs) > MaxListLimit {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "book_ids", Message: fmt.Sprintf("must not list more than %d books", MaxListLimit)})
+	}
+	if len(request.Tags) == 0 {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "tags", Message: "is required"})
+	}
+	for i, tag := range request.Tags {
+		if message := tagNameError(tag); message != "" {
+			fieldErrors = append(fieldErrors, models.FieldError{Field: fmt.Sprintf("tags[%d]", i), Message: message})
+		}
+	}
+	return fieldErrors
+}
+
+// tagNameError explains why a tag name is rejected. Commas separate
+// alternatives in tag filters, so names cannot contain them.
+func tagNameError(name string) string {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return "is required"
+	case strings.Contains(name, ","):
+		return "must not contain commas"
+	}
+	return ""
+}
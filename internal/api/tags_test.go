@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTags(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		router := setupTestRouter(store)
+		request := func(method, url, body string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest(method, url, strings.NewReader(body))
+			assert.NoError(t, err)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr
+		}
+		bookIDs := func(rr *httptest.ResponseRecorder) []int {
+			assert.Equal(t, http.StatusOK, rr.Code)
+			var books []models.Book
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&books))
+			ids := []int{}
+			for _, b := range books {
+				ids = append(ids, b.ID)
+			}
+			return ids
+		}
+
+		rr := request("POST", "/api/v1/books", `{"title": "Dune", "author": "Frank Herbert", "published_date": "1965-08-01", "tags": ["Science Fiction", "classics"]}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var book models.Book
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&book))
+		assert.Equal(t, []string{"classics", "science fiction"}, book.Tags)
+		rr = request("POST", "/api/v1/books", `{"title": "The Hobbit", "author": "J. R. R. Tolkien", "published_date": "1937-09-21", "tags": ["fantasy"]}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		rr = request("POST", "/api/v1/books", `{"title": "Emma", "author": "Jane Austen", "published_date": "1815-12-23", "tags": ["", "a,b"]}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `{"field":"tags[0]","message":"is required"}`)
+		assert.Contains(t, rr.Body.String(), `{"field":"tags[1]","message":"must not contain commas"}`)
+
+		// Repeated tag parameters must all match, commas separate
+		// alternatives
+		assert.Equal(t, []int{1, 2}, bookIDs(request("GET", "/api/v1/books?tag=fantasy,science+fiction", "")))
+		assert.Equal(t, []int{1}, bookIDs(request("GET", "/api/v1/books?tag=fantasy,science+fiction&tag=classics", "")))
+		assert.Equal(t, []int{}, bookIDs(request("GET", "/api/v1/books?tag=fantasy&tag=classics", "")))
+
+		// Bulk changes apply to all books or to none
+		rr = request("POST", "/api/v1/books/tag", `{"book_ids": [1, 2], "tags": ["to-read"]}`)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Equal(t, []int{1, 2}, bookIDs(request("GET", "/api/v1/books?tag=to-read", "")))
+		rr = request("POST", "/api/v1/books/untag", `{"book_ids": [2, 42], "tags": ["to-read"]}`)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		rr = request("POST", "/api/v1/books/untag", `{"book_ids": [2], "tags": ["to-read"]}`)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Equal(t, []int{1}, bookIDs(request("GET", "/api/v1/books?tag=to-read", "")))
+		rr = request("POST", "/api/v1/books/tag", `{"tags": []}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `{"field":"book_ids","message":"is required"}`)
+
+		rr = request("GET", "/api/v1/tags?sort=name", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var tags []models.Tag
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&tags))
+		assert.Equal(t, []models.Tag{
+			{ID: 1, Name: "classics", Books: 1},
+			{ID: 3, Name: "fantasy", Books: 1},
+			{ID: 2, Name: "science fiction", Books: 1},
+			{ID: 4, Name: "to-read", Books: 1},
+		}, tags)
+
+		// Renaming to an existing name is a conflict, merging combines tags
+		rr = request("PATCH", "/api/v1/tags/2", `{"name": "fantasy"}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		rr = request("PUT", "/api/v1/tags/2", `{"name": "SF"}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"name":"sf"`)
+		rr = request("POST", "/api/v1/tags/2/merge/3", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var tag models.Tag
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&tag))
+		assert.Equal(t, models.Tag{ID: 3, Name: "fantasy", Books: 2}, tag)
+		rr = request("GET", "/api/v1/tags/2", "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		rr = request("POST", "/api/v1/tags/3/merge/3", "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = request("DELETE", "/api/v1/tags/3", "")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = request("GET", "/api/v1/books/2", "")
+		assert.Contains(t, rr.Body.String(), `"tags":[]`)
+
+		rr = request("POST", "/api/v1/tags", `{"name": "Classics"}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		rr = request("POST", "/api/v1/tags", `{"name": "Favorites"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"name":"favorites","books":0`)
+	})
+}
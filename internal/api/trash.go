@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mayank-02/bookman/internal/blob"
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// PurgeTrash permanently deletes the books and collections that were moved
+// to the trash before the given time, along with the cover files of the
+// books, and returns what was purged. The server runs it to keep the trash
+// from growing forever.
+func PurgeTrash(store db.Store, covers blob.Store, before time.Time) ([]models.TrashItem, error) {
+	items, err := store.PurgeTrash(before)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if item.Book != nil && item.Book.Cover != nil {
+			deleteCoverFiles(covers, item.ID, item.Book.Cover)
+		}
+	}
+	return items, nil
+}
+
+// getTrash lists the books and collections in the trash, optionally only
+// those of one type. The trash is not paged.
+func getTrash(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		kind := r.URL.Query().Get("type")
+		if kind != "" && kind != models.TrashBook && kind != models.TrashCollection {
+			badRequest(w, r, errors.New("Invalid type, expected book or collection"))
+			return
+		}
+
+		items, err := db.GetTrash()
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		filtered := []models.TrashItem{}
+		for _, item := range items {
+			if kind == "" || item.Type == kind {
+				filtered = append(filtered, item)
+			}
+		}
+		json.NewEncoder(w).Encode(filtered)
+	}
+}
+
+// restoreBook takes a book out of the trash and responds with it.
+func restoreBook(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "book")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		if err := db.RestoreBook(id); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		book, err := db.GetBook(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		setETag(w, book.Version)
+		json.NewEncoder(w).Encode(book)
+	}
+}
+
+// restoreCollection takes a collection out of the trash and responds with
+// it.
+func restoreCollection(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "collection")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		if err := db.RestoreCollection(id); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		collection, err := db.GetCollection(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		setETag(w, collection.Version)
+		json.NewEncoder(w).Encode(collection)
+	}
+}
+
+// purgeTrash permanently deletes the items that have been in the trash for
+// at least older_than days, all of them by default, and responds with what
+// was purged. The handler is built per request by asActor.
+func purgeTrash(covers blob.Store) func(db.Store) http.HandlerFunc {
+	return func(db db.Store) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			days := 0
+			if param := r.URL.Query().Get("older_than"); param != "" {
+				var err error
+				days, err = strconv.Atoi(param)
+				if err != nil || days < 0 {
+					badRequest(w, r, errors.New("Invalid older_than, expected a number of days"))
+					return
+				}
+			}
+
+			// Everything deleted up to now is older than 0 days
+			before := time.Now().Add(time.Second)
+			if days > 0 {
+				before = time.Now().AddDate(0, 0, -days)
+			}
+			items, err := PurgeTrash(db, covers, before)
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+			if items == nil {
+				items = []models.TrashItem{}
+			}
+			json.NewEncoder(w).Encode(items)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrash(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		router := setupTestRouter(store)
+		request := func(method, url, body string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest(method, url, strings.NewReader(body))
+			assert.NoError(t, err)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr
+		}
+		createTestBook(t, store, "1965-08-01")
+		createTestBook(t, store, "1937-09-21")
+		assert.Equal(t, http.StatusCreated, request("POST", "/api/v1/collections", `{"name": "Shelf"}`).Code)
+		assert.Equal(t, http.StatusNoContent, request("POST", "/api/v1/collections/1/books/1", "").Code)
+
+		assert.Equal(t, http.StatusNoContent, request("DELETE", "/api/v1/books/1", "").Code)
+		assert.Equal(t, http.StatusNotFound, request("GET", "/api/v1/books/1", "").Code)
+		assert.Equal(t, http.StatusNotFound, request("DELETE", "/api/v1/books/1", "").Code)
+		assert.Equal(t, "1", request("GET", "/api/v1/books", "").Header().Get("X-Total-Count"))
+		assert.Equal(t, http.StatusNoContent, request("DELETE", "/api/v1/collections/1", "").Code)
+
+		rr := request("GET", "/api/v1/trash", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var items []models.TrashItem
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&items))
+		if assert.Len(t, items, 2) {
+			assert.ElementsMatch(t, []string{"book", "collection"}, []string{items[0].Type, items[1].Type})
+		}
+		rr = request("GET", "/api/v1/trash?type=collection", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		items = nil
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&items))
+		if assert.Len(t, items, 1) {
+			assert.Equal(t, models.TrashItem{Type: "collection", ID: 1, Name: "Shelf", Memberships: 1, DeletedAt: items[0].DeletedAt}, items[0])
+		}
+		assert.Equal(t, http.StatusBadRequest, request("GET", "/api/v1/trash?type=review", "").Code)
+
+		// Restoring brings back the memberships
+		rr = request("POST", "/api/v1/trash/collections/1/restore", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"books":null`)
+		rr = request("POST", "/api/v1/trash/books/1/restore", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotEmpty(t, rr.Header().Get("ETag"))
+		assert.Contains(t, rr.Body.String(), `"published_date":"1965-08-01"`)
+		assert.Equal(t, http.StatusNotFound, request("POST", "/api/v1/trash/books/1/restore", "").Code)
+		assert.Equal(t, http.StatusNotFound, request("POST", "/api/v1/trash/books/2/restore", "").Code)
+		assert.Equal(t, http.StatusBadRequest, request("POST", "/api/v1/trash/books/x/restore", "").Code)
+		rr = request("GET", "/api/v1/collections/1", "")
+		assert.Contains(t, rr.Body.String(), `"published_date":"1965-08-01"`)
+
+		// Purging
+		assert.Equal(t, http.StatusNoContent, request("DELETE", "/api/v1/books/2", "").Code)
+		rr = request("DELETE", "/api/v1/trash?older_than=30", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "[]\n", rr.Body.String())
+		assert.Equal(t, http.StatusBadRequest, request("DELETE", "/api/v1/trash?older_than=-1", "").Code)
+		rr = request("DELETE", "/api/v1/trash", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		items = nil
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&items))
+		if assert.Len(t, items, 1) {
+			assert.Equal(t, 2, items[0].ID)
+		}
+		assert.Equal(t, http.StatusNotFound, request("POST", "/api/v1/trash/books/2/restore", "").Code)
+	})
+}
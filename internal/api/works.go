@@ -0,0 +1,290 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+func getWorks(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, err := parseListOptions(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		works, page, err := db.GetWorks(opts)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if works == nil {
+			works = []models.Work{}
+		}
+		writePageHeaders(w, r, page)
+		json.NewEncoder(w).Encode(works)
+	}
+}
+
+func getWork(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "work")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		work, err := db.GetWork(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(work)
+	}
+}
+
+// getWorkEditions lists the books that are editions of the work, like
+// GET /books.
+func getWorkEditions(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "work")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		opts, err := parseListOptions(r)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		if _, err := db.GetWork(id); err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		books, page, err := db.GetBooks(workFilter(id), opts)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if books == nil {
+			books = []models.Book{}
+		}
+		writePageHeaders(w, r, page)
+		json.NewEncoder(w).Encode(books)
+	}
+}
+
+func workFilter(id int) db.BookFilter {
+	return db.BookFilter{WorkID: id}
+}
+
+func createWork(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var work models.Work
+		if err := decodeJSON(r, &work); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+
+		// Validation checks
+		if fieldErrors := validateWork(work); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		id, err := db.CreateWork(work)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		work, err = db.GetWork(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(work)
+	}
+}
+
+func updateWork(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "work")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		var work models.Work
+		if err := decodeJSON(r, &work); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		work.ID = id
+
+		// Validation checks
+		if fieldErrors := validateWork(work); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		writeWork(w, r, db, work)
+	}
+}
+
+func patchWork(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "work")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		if !isMergePatch(r) {
+			unsupportedMediaType(w, r, MergePatchContentType)
+			return
+		}
+
+		current, err := db.GetWork(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		work, err := applyMergePatch(r, current)
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		work.ID = id
+
+		// Validation checks
+		if fieldErrors := validateWork(work); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		writeWork(w, r, db, work)
+	}
+}
+
+// writeWork stores a changed work and responds with it as stored.
+func writeWork(w http.ResponseWriter, r *http.Request, store db.Store, work models.Work) {
+	err := store.UpdateWork(work)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	work, err = store.GetWork(work.ID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(work)
+}
+
+// deleteWork deletes a work and keeps its editions as books of no work.
+func deleteWork(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "work")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		err = db.DeleteWork(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// mergeEditions makes books editions of the work, responding with the
+// work. Works the books leave without editions are deleted.
+func mergeEditions(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "work")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		var request models.EditionsRequest
+		if err := decodeJSON(r, &request); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+
+		// Validation checks
+		if fieldErrors := validateEditions(request); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		if err := db.MergeEditions(id, request.BookIDs); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		work, err := db.GetWork(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(work)
+	}
+}
+
+// splitEditions moves editions of the work into a new work, responding with
+// the new work.
+func splitEditions(db db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id", "work")
+		if err != nil {
+			badRequest(w, r, err)
+			return
+		}
+		var request models.EditionsRequest
+		if err := decodeJSON(r, &request); err != nil {
+			badRequest(w, r, err)
+			return
+		}
+
+		// Validation checks
+		if fieldErrors := validateEditions(request); fieldErrors != nil {
+			validationFailed(w, r, fieldErrors)
+			return
+		}
+
+		splitID, err := db.SplitEditions(id, request.BookIDs)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		work, err := db.GetWork(splitID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(work)
+	}
+}
+
+func validateWork(work models.Work) []models.FieldError {
+	var fieldErrors []models.FieldError
+	if strings.TrimSpace(work.Title) == "" {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "title", Message: "is required"})
+	}
+	return append(fieldErrors, validateDate("original_published_date", work.OriginalPublishedDate)...)
+}
+
+func validateEditions(request models.EditionsRequest) []models.FieldError {
+	if len(request.BookIDs) == 0 {
+		return []models.FieldError{{Field: "book_ids", Message: "is required"}}
+	}
+	if len(request.BookIDs) > MaxListLimit {
+		return []models.FieldError{{Field: "book_ids", Message: fmt.Sprintf("must not list more than %d books", MaxListLimit)}}
+	}
+	return nil
+}
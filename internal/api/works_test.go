@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/db"
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		router := setupTestRouter(store)
+		request := func(method, url, body string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest(method, url, strings.NewReader(body))
+			assert.NoError(t, err)
+			if method == "PATCH" {
+				req.Header.Set("Content-Type", MergePatchContentType)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr
+		}
+		createTestBook(t, store, "1925-04-26")
+		createTestBook(t, store, "1937-01-01")
+		createTestBook(t, store, "1965-08-01")
+
+		rr := request("POST", "/api/v1/works", `{"title": "The Trial", "original_language": "de", "original_published_date": "1925-04-26"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var work models.Work
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&work))
+		assert.Equal(t, models.Work{ID: 1, Title: "The Trial", OriginalLanguage: "de", OriginalPublishedDate: "1925-04-26",
+			CreatedAt: work.CreatedAt, UpdatedAt: work.UpdatedAt}, work)
+		rr = request("POST", "/api/v1/works", `{"original_published_date": "1925"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `{"field":"title","message":"is required"}`)
+		assert.Contains(t, rr.Body.String(), `{"field":"original_published_date","message":"must be a date in the format YYYY-MM-DD"}`)
+
+		rr = request("POST", "/api/v1/works/1/merge", `{"book_ids": [1, 2]}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"edition_count":2`)
+		rr = request("POST", "/api/v1/works/1/merge", `{"book_ids": [42]}`)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		rr = request("POST", "/api/v1/works/42/merge", `{"book_ids": [1]}`)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		rr = request("POST", "/api/v1/works/1/merge", `{}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = request("GET", "/api/v1/works/1/editions", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "2", rr.Header().Get("X-Total-Count"))
+		rr = request("GET", "/api/v1/books/2", "")
+		assert.Contains(t, rr.Body.String(), `"work_id":1`)
+		rr = request("GET", "/api/v1/books?collapse=work", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "2", rr.Header().Get("X-Total-Count"))
+		var books []models.Book
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&books))
+		assert.Equal(t, 1, books[0].ID)
+		assert.Equal(t, 3, books[1].ID)
+		rr = request("GET", "/api/v1/books?collapse=series", "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		// Writing a book leaves its work alone
+		rr = request("PATCH", "/api/v1/books/2", `{"work_id": null, "edition": "2nd"}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"work_id":1`)
+
+		rr = request("POST", "/api/v1/works/1/split", `{"book_ids": [2]}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&work))
+		assert.Equal(t, 2, work.ID)
+		assert.Equal(t, "1937-01-01", work.OriginalPublishedDate)
+		assert.Equal(t, 1, work.EditionCount)
+		rr = request("POST", "/api/v1/works/1/split", `{"book_ids": [1]}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = request("PATCH", "/api/v1/works/2", `{"title": "The Trial (translation)", "edition_count": 7}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"title":"The Trial (translation)"`)
+		assert.Contains(t, rr.Body.String(), `"edition_count":1`)
+		rr = request("GET", "/api/v1/works?sort=title&order=desc", "")
+		assert.Equal(t, "2", rr.Header().Get("X-Total-Count"))
+
+		rr = request("DELETE", "/api/v1/works/2", "")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = request("GET", "/api/v1/books/2", "")
+		assert.Contains(t, rr.Body.String(), `"work_id":null`)
+		rr = request("GET", "/api/v1/works/2/editions", "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,127 @@
+// Package bcp47 checks that language tags such as "en", "pt-BR" or
+// "zh-Hant-TW" are well-formed according to BCP 47 (RFC 5646) and puts
+// their subtags into the canonical case. It does not check the subtags
+// against the IANA registry, and does not accept the grandfathered tags.
+package bcp47
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalid = errors.New("not a well-formed BCP 47 language tag")
+
+// Canonical validates tag and returns it with the language in lower case,
+// the script in title case, the region in upper case and everything else in
+// lower case, e.g. "zh-hant-tw" becomes "zh-Hant-TW". Underscores are not
+// accepted as separators.
+func Canonical(tag string) (string, error) {
+	subtags := strings.Split(strings.ToLower(strings.TrimSpace(tag)), "-")
+	for _, s := range subtags {
+		if s == "" || len(s) > 8 || !alphanum(s) {
+			return "", ErrInvalid
+		}
+	}
+
+	i := 0
+	// A tag may consist of private use subtags only
+	if subtags[0] != "x" {
+		if i = language(subtags); i == 0 {
+			return "", ErrInvalid
+		}
+		if i < len(subtags) && len(subtags[i]) == 4 && alpha(subtags[i]) {
+			subtags[i] = strings.ToUpper(subtags[i][:1]) + subtags[i][1:]
+			i++
+		}
+		if i < len(subtags) && (len(subtags[i]) == 2 && alpha(subtags[i]) || len(subtags[i]) == 3 && digits(subtags[i])) {
+			subtags[i] = strings.ToUpper(subtags[i])
+			i++
+		}
+		variants := map[string]bool{}
+		for i < len(subtags) && variant(subtags[i]) {
+			if variants[subtags[i]] {
+				return "", ErrInvalid
+			}
+			variants[subtags[i]] = true
+			i++
+		}
+		singletons := map[string]bool{}
+		for i < len(subtags) && len(subtags[i]) == 1 && subtags[i] != "x" {
+			if singletons[subtags[i]] {
+				return "", ErrInvalid
+			}
+			singletons[subtags[i]] = true
+			i++
+			start := i
+			for i < len(subtags) && len(subtags[i]) >= 2 {
+				i++
+			}
+			if i == start {
+				return "", ErrInvalid
+			}
+		}
+	}
+	if i < len(subtags) && subtags[i] == "x" {
+		if i == len(subtags)-1 {
+			return "", ErrInvalid
+		}
+		i = len(subtags)
+	}
+	if i < len(subtags) {
+		return "", ErrInvalid
+	}
+	return strings.Join(subtags, "-"), nil
+}
+
+// Valid reports whether tag is a well-formed language tag.
+func Valid(tag string) bool {
+	_, err := Canonical(tag)
+	return err == nil
+}
+
+// language returns the number of subtags taken by the primary language and
+// its extended language subtags, 0 if there is no valid language.
+func language(subtags []string) int {
+	switch first := subtags[0]; {
+	case len(first) >= 4 && alpha(first):
+		return 1
+	case len(first) < 2 || !alpha(first):
+		return 0
+	}
+	i := 1
+	for i < len(subtags) && i <= 3 && len(subtags[i]) == 3 && alpha(subtags[i]) {
+		i++
+	}
+	return i
+}
+
+func variant(s string) bool {
+	return len(s) >= 5 || len(s) == 4 && s[0] >= '0' && s[0] <= '9'
+}
+
+func alpha(s string) bool {
+	for _, r := range s {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+func digits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func alphanum(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
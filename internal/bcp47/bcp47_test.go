@@ -0,0 +1,38 @@
+package bcp47
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		input, tag string
+	}{
+		{"en", "en"},
+		{" EN-gb ", "en-GB"},
+		{"zh-hant-tw", "zh-Hant-TW"},
+		{"es-419", "es-419"},
+		{"sr-Latn-RS", "sr-Latn-RS"},
+		{"zh-yue-HK", "zh-yue-HK"},
+		{"de-CH-1996", "de-CH-1996"},
+		{"sl-rozaj-biske", "sl-rozaj-biske"},
+		{"en-US-u-ca-gregory", "en-US-u-ca-gregory"},
+		{"en-a-bbb-x-a-ccc", "en-a-bbb-x-a-ccc"},
+		{"x-whatever", "x-whatever"},
+		{"de-Qaaa-x-private", "de-Qaaa-x-private"},
+	}
+	for _, tt := range tests {
+		tag, err := Canonical(tt.input)
+		assert.NoError(t, err, tt.input)
+		assert.Equal(t, tt.tag, tag, tt.input)
+	}
+
+	for _, input := range []string{
+		"", "e", "en_GB", "en-", "-en", "english-and-more", "en-GB-GB", "de-419-DE",
+		"de-DE-1901-1901", "en-a-bbb-a-ccc", "en-a", "en-x", "ab-abc-abc-abc-abc", "123", "en-ü",
+	} {
+		assert.False(t, Valid(input), input)
+	}
+}
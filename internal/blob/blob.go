@@ -0,0 +1,32 @@
+// Package blob stores binary objects, such as the cover images of books, by
+// key. FS keeps them in a directory and Memory in memory; other backends only
+// have to implement Store.
+package blob
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// ErrNotFound is returned for keys without an object.
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps objects by key. Keys are slash separated paths such as
+// "covers/1/original", without empty, "." or ".." elements.
+type Store interface {
+	// Put stores data under key, replacing any object stored there.
+	Put(key string, data []byte) error
+	// Get returns the object stored under key.
+	Get(key string) ([]byte, error)
+	// Delete removes the object stored under key. Deleting a missing object
+	// is not an error.
+	Delete(key string) error
+}
+
+func checkKey(key string) error {
+	if !fs.ValidPath(key) || key == "." {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package blob
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStores(t *testing.T) {
+	dir := t.TempDir()
+	fsStore, err := NewFS(filepath.Join(dir, "blobs"))
+	assert.NoError(t, err)
+
+	for name, store := range map[string]Store{"fs": fsStore, "memory": NewMemory()} {
+		t.Run(name, func(t *testing.T) {
+			_, err := store.Get("covers/1/original")
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			assert.NoError(t, store.Put("covers/1/original", []byte("first")))
+			assert.NoError(t, store.Put("covers/1/original", []byte("second")))
+			data, err := store.Get("covers/1/original")
+			assert.NoError(t, err)
+			assert.Equal(t, "second", string(data))
+
+			assert.NoError(t, store.Delete("covers/1/original"))
+			assert.NoError(t, store.Delete("covers/1/original"))
+			_, err = store.Get("covers/1/original")
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			for _, key := range []string{"", ".", "../escape", "/absolute", "covers//1", "covers/./1"} {
+				assert.Error(t, store.Put(key, []byte("data")), key)
+				_, err := store.Get(key)
+				assert.Error(t, err, key)
+			}
+		})
+	}
+
+	// Nothing is left behind outside the directory or as temporary files
+	entries, err := os.ReadDir(filepath.Join(dir, "blobs", "covers", "1"))
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+	_, err = os.Stat(filepath.Join(dir, "escape"))
+	assert.True(t, os.IsNotExist(err))
+}
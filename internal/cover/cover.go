@@ -0,0 +1,81 @@
+// Package cover decodes uploaded cover images and scales them down to
+// thumbnails.
+package cover
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+	"slices"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// ContentTypes are the media types of the images accepted as covers.
+var ContentTypes = []string{"image/jpeg", "image/png", "image/webp"}
+
+// ThumbnailType is the media type of thumbnails, which are always JPEGs.
+const ThumbnailType = "image/jpeg"
+
+// MaxPixels bounds the size of a cover, so a small file cannot make the
+// server decode a huge image.
+const MaxPixels = 40_000_000
+
+// ErrUnsupported is returned for data that is not an image of one of the
+// ContentTypes.
+var ErrUnsupported = errors.New("unsupported image format")
+
+// Decode checks that data is a JPEG, PNG or WebP image and decodes it. It
+// returns the media type the data was detected as, regardless of what the
+// client claimed.
+func Decode(data []byte) (image.Image, string, error) {
+	contentType := http.DetectContentType(data)
+	if !slices.Contains(ContentTypes, contentType) {
+		return nil, "", ErrUnsupported
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid %s image: %v", contentType, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, "", fmt.Errorf("invalid %s image: it is empty", contentType)
+	}
+	if config.Width*config.Height > MaxPixels {
+		return nil, "", fmt.Errorf("the image is %dx%d pixels, more than the %d megapixels allowed", config.Width, config.Height, MaxPixels/1_000_000)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid %s image: %v", contentType, err)
+	}
+	return img, contentType, nil
+}
+
+// Thumbnail scales img to width pixels, keeping its aspect ratio, and encodes
+// it as a JPEG. Images no wider than width keep their size. Transparent parts
+// become white.
+func Thumbnail(img image.Image, width int) ([]byte, error) {
+	bounds := img.Bounds()
+	height := bounds.Dy()
+	if bounds.Dx() > width {
+		height = max(1, (bounds.Dy()*width+bounds.Dx()/2)/bounds.Dx())
+	} else {
+		width = bounds.Dx()
+	}
+
+	thumbnail := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(thumbnail, thumbnail.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(thumbnail, thumbnail.Bounds(), img, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
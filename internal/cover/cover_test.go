@@ -0,0 +1,81 @@
+package cover
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// A 1x1 lossless WebP image
+const webpPixel = "UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA=="
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, 0, color.NRGBA{R: 200, A: 255})
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	img, contentType, err := Decode(encodePNG(t, 80, 120))
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+	assert.Equal(t, 80, img.Bounds().Dx())
+
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, img, nil))
+	_, contentType, err = Decode(buf.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, "image/jpeg", contentType)
+
+	data, _ := base64.StdEncoding.DecodeString(webpPixel)
+	img, contentType, err = Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/webp", contentType)
+	assert.Equal(t, image.Rect(0, 0, 1, 1), img.Bounds())
+
+	_, _, err = Decode([]byte("GIF89a not a cover"))
+	assert.ErrorIs(t, err, ErrUnsupported)
+	_, _, err = Decode([]byte("<svg></svg>"))
+	assert.ErrorIs(t, err, ErrUnsupported)
+
+	// Truncated images are rejected, as are huge ones before being decoded
+	data = encodePNG(t, 80, 120)
+	_, _, err = Decode(data[:len(data)/2])
+	assert.Error(t, err)
+	_, _, err = Decode(encodePNG(t, 8000, 6000))
+	assert.ErrorContains(t, err, "8000x6000")
+}
+
+func TestThumbnail(t *testing.T) {
+	img, _, err := Decode(encodePNG(t, 800, 1200))
+	assert.NoError(t, err)
+
+	data, err := Thumbnail(img, 300)
+	assert.NoError(t, err)
+	thumbnail, contentType, err := Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, ThumbnailType, contentType)
+	assert.Equal(t, image.Rect(0, 0, 300, 450), thumbnail.Bounds())
+
+	// Small images are not scaled up, and transparency turns white
+	img, _, err = Decode(encodePNG(t, 40, 60))
+	assert.NoError(t, err)
+	data, err = Thumbnail(img, 300)
+	assert.NoError(t, err)
+	thumbnail, _, err = Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 40, 60), thumbnail.Bounds())
+	r, g, b, _ := thumbnail.At(20, 30).RGBA()
+	assert.Greater(t, min(r, g, b), uint32(0xf000))
+}
@@ -0,0 +1,202 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// AuditSortFields are the fields audit log listings can be ordered by. The
+// log is in the order of the changes, so ordering by id is chronological.
+var AuditSortFields = []string{"id"}
+
+// AuditFilter restricts GetAudit to the entries matching all of its
+// non-empty fields. BookID matches the entries of the book and of its
+// memberships, CollectionID those of the collection and its memberships.
+// Since and Until bound the time of the change, Since inclusively and Until
+// exclusively.
+type AuditFilter struct {
+	BookID       int
+	CollectionID int
+	Entity       string
+	Action       string
+	Actor        string
+	Since        time.Time
+	Until        time.Time
+}
+
+// auditIgnored are the fields that change with every change, and the books
+// of a collection, whose changes are recorded as memberships.
+var auditIgnored = map[string]bool{"id": true, "version": true, "created_at": true, "updated_at": true, "books": true}
+
+// diffFields compares the JSON of two records field by field and returns the
+// fields that differ in alphabetical order. Empty values, such as "", 0,
+// null or [], are all alike and reported as null. A nil record has no
+// fields.
+func diffFields(before, after interface{}) ([]models.FieldChange, error) {
+	old, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for name := range old {
+		names = append(names, name)
+	}
+	for name := range updated {
+		if _, ok := old[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []models.FieldChange{}
+	for _, name := range names {
+		b, a := nonEmpty(old[name]), nonEmpty(updated[name])
+		if auditIgnored[name] || bytes.Equal(b, a) {
+			continue
+		}
+		changes = append(changes, models.FieldChange{Field: name, Before: b, After: a})
+	}
+	return changes, nil
+}
+
+func jsonFields(v interface{}) (map[string]json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	return fields, json.Unmarshal(data, &fields)
+}
+
+// nonEmpty returns null for empty JSON values.
+func nonEmpty(value json.RawMessage) json.RawMessage {
+	switch string(value) {
+	case "", "null", `""`, "0", "false", "[]", "{}":
+		return json.RawMessage("null")
+	}
+	return value
+}
+
+// WithActor returns a DB that names actor in the audit log entries of the
+// changes made through it. It shares the connections of db.
+func (db *DB) WithActor(actor string) Store {
+	c := *db
+	c.actor = actor
+	return &c
+}
+
+// audit appends an entry with the changes from before to after to the audit
+// log, in the transaction of the change it records.
+func (db *DB) audit(q querier, entry models.AuditEntry, before, after interface{}) error {
+	changes, err := diffFields(before, after)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec("INSERT INTO audit_log (actor, action, entity, book_id, collection_id, changes) VALUES (?, ?, ?, ?, ?, ?)",
+		db.actor, entry.Action, entry.Entity, nullID(entry.BookID), nullID(entry.CollectionID), string(data))
+	return err
+}
+
+// nullID stores the absent IDs of audit log entries as NULL.
+func nullID(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+// GetAudit returns a page of the audit log entries matching the filter,
+// oldest first unless opts says otherwise.
+func (db *DB) GetAudit(filter AuditFilter, opts ListOptions) ([]models.AuditEntry, Page, error) {
+	sort, err := opts.sortField(AuditSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	where := " WHERE 1=1"
+	args := []interface{}{}
+	for _, condition := range []struct {
+		column string
+		value  interface{}
+		set    bool
+	}{
+		{"book_id = ?", filter.BookID, filter.BookID != 0},
+		{"collection_id = ?", filter.CollectionID, filter.CollectionID != 0},
+		{"entity = ?", filter.Entity, filter.Entity != ""},
+		{"action = ?", filter.Action, filter.Action != ""},
+		{"actor = ?", filter.Actor, filter.Actor != ""},
+		{"at >= ?", db.timeValue(filter.Since), !filter.Since.IsZero()},
+		{"at < ?", db.timeValue(filter.Until), !filter.Until.IsZero()},
+	} {
+		if condition.set {
+			where += " AND " + condition.column
+			args = append(args, condition.value)
+		}
+	}
+
+	var page Page
+	err = db.QueryRow("SELECT COUNT(*) FROM audit_log"+where, args...).Scan(&page.Total)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	condition, keysetArgs, order := keyset(sort, "id", opts.Desc, after)
+	if condition != "" {
+		where += " AND " + condition
+		args = append(args, keysetArgs...)
+	}
+	query := "SELECT id, at, actor, action, entity, COALESCE(book_id, 0), COALESCE(collection_id, 0), changes FROM audit_log" +
+		where + " ORDER BY " + order
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit+1)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var e models.AuditEntry
+		var changes string
+		err := rows.Scan(&e.ID, timestamp{&e.At}, &e.Actor, &e.Action, &e.Entity, &e.BookID, &e.CollectionID, &changes)
+		if err != nil {
+			return nil, Page{}, err
+		}
+		if err := json.Unmarshal([]byte(changes), &e.Changes); err != nil {
+			return nil, Page{}, err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, Page{}, err
+	}
+
+	entries, page.Next = paginate(entries, opts.Limit, func(e models.AuditEntry) cursor {
+		return cursor{Sort: sort, Desc: opts.Desc, ID: e.ID}
+	})
+	return entries, page, nil
+}
@@ -0,0 +1,180 @@
+package db
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffFields(t *testing.T) {
+	null := json.RawMessage("null")
+	changes, err := diffFields(
+		models.Book{ID: 1, Title: "Dune", Genre: "Science Fiction", Version: 1},
+		models.Book{ID: 1, Title: "Dune Messiah", Tags: []string{}, Edition: "First", Version: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.FieldChange{
+		{Field: "edition", Before: null, After: json.RawMessage(`"First"`)},
+		{Field: "genre", Before: json.RawMessage(`"Science Fiction"`), After: null},
+		{Field: "title", Before: json.RawMessage(`"Dune"`), After: json.RawMessage(`"Dune Messiah"`)},
+	}, changes)
+
+	changes, err = diffFields(nil, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestStore_Audit(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		null := json.RawMessage("null")
+		alice, bob := store.WithActor("alice"), store.WithActor("bob")
+		start := time.Now().Add(-time.Second)
+
+		id, err := alice.CreateBook(models.Book{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01"})
+		assert.NoError(t, err)
+		book, err := store.GetBook(id)
+		assert.NoError(t, err)
+		book.Title, book.Genre = "Dune Messiah", "Science Fiction"
+		assert.NoError(t, bob.UpdateBook(book))
+		// Failed changes are not recorded
+		book.Version = 1
+		assert.ErrorIs(t, bob.UpdateBook(book), ErrVersionMismatch)
+
+		shelfID, err := alice.CreateCollection(models.Collection{Name: "Shelf"})
+		assert.NoError(t, err)
+		assert.NoError(t, alice.UpdateCollection(models.Collection{ID: shelfID, Name: "Favourites"}))
+		assert.NoError(t, alice.AddBookToCollection(shelfID, id))
+		assert.NoError(t, bob.RemoveBookFromCollection(shelfID, id))
+		assert.NoError(t, bob.DeleteBook(id, 0))
+		assert.NoError(t, alice.RestoreBook(id))
+		assert.NoError(t, alice.DeleteBook(id, 0))
+		_, err = store.WithActor("system").PurgeTrash(time.Now().Add(time.Minute))
+		assert.NoError(t, err)
+
+		// The history of the book outlives it
+		entries, page, err := store.GetAudit(AuditFilter{BookID: id}, ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, 8, page.Total)
+		type step struct{ actor, action, entity string }
+		var steps []step
+		for _, e := range entries {
+			steps = append(steps, step{e.Actor, e.Action, e.Entity})
+			assert.Equal(t, id, e.BookID)
+			assert.WithinDuration(t, time.Now(), e.At, time.Minute)
+		}
+		assert.Equal(t, []step{
+			{"alice", models.AuditCreate, models.AuditBook},
+			{"bob", models.AuditUpdate, models.AuditBook},
+			{"alice", models.AuditCreate, models.AuditMembership},
+			{"bob", models.AuditDelete, models.AuditMembership},
+			{"bob", models.AuditDelete, models.AuditBook},
+			{"alice", models.AuditRestore, models.AuditBook},
+			{"alice", models.AuditDelete, models.AuditBook},
+			{"system", models.AuditPurge, models.AuditBook},
+		}, steps)
+		if len(entries) == 8 {
+			assert.Contains(t, entries[0].Changes, models.FieldChange{Field: "title", Before: null, After: json.RawMessage(`"Dune"`)})
+			assert.Equal(t, []models.FieldChange{
+				{Field: "genre", Before: null, After: json.RawMessage(`"Science Fiction"`)},
+				{Field: "title", Before: json.RawMessage(`"Dune"`), After: json.RawMessage(`"Dune Messiah"`)},
+			}, entries[1].Changes)
+			assert.Equal(t, shelfID, entries[2].CollectionID)
+			assert.Contains(t, entries[4].Changes, models.FieldChange{Field: "title", Before: json.RawMessage(`"Dune Messiah"`), After: null})
+			assert.Contains(t, entries[5].Changes, models.FieldChange{Field: "title", Before: null, After: json.RawMessage(`"Dune Messiah"`)})
+			assert.Contains(t, entries[7].Changes, models.FieldChange{Field: "title", Before: json.RawMessage(`"Dune Messiah"`), After: null})
+		}
+
+		entries, _, err = store.GetAudit(AuditFilter{CollectionID: shelfID, Action: models.AuditUpdate}, ListOptions{})
+		assert.NoError(t, err)
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, []models.FieldChange{
+				{Field: "name", Before: json.RawMessage(`"Shelf"`), After: json.RawMessage(`"Favourites"`)},
+			}, entries[0].Changes)
+		}
+		entries, _, err = store.GetAudit(AuditFilter{Entity: models.AuditMembership, Actor: "bob"}, ListOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+		entries, _, err = store.GetAudit(AuditFilter{Since: start, Until: time.Now().Add(time.Minute)}, ListOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, entries, 10)
+		entries, _, err = store.GetAudit(AuditFilter{Until: start}, ListOptions{})
+		assert.NoError(t, err)
+		assert.Empty(t, entries)
+
+		// Newest first, a page at a time
+		entries, page, err = store.GetAudit(AuditFilter{}, ListOptions{Desc: true, Limit: 3})
+		assert.NoError(t, err)
+		assert.Equal(t, 10, page.Total)
+		if assert.Len(t, entries, 3) {
+			assert.Equal(t, models.AuditPurge, entries[0].Action)
+		}
+		next, _, err := store.GetAudit(AuditFilter{}, ListOptions{Desc: true, Limit: 3, After: page.Next})
+		assert.NoError(t, err)
+		if assert.Len(t, next, 3) {
+			assert.Less(t, next[0].ID, entries[2].ID)
+		}
+	})
+}
+
+func TestStore_AuditBookRelations(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		id, err := store.CreateBook(models.Book{Title: "Dune", Author: "Frank Herbert", Publisher: "Chilton Books", PublishedDate: "1965-08-01"})
+		assert.NoError(t, err)
+		book, err := store.GetBook(id)
+		assert.NoError(t, err)
+		seriesID, err := store.CreateSeries(models.Series{Name: "Dune Chronicles"})
+		assert.NoError(t, err)
+		workID, err := store.CreateWork(models.Work{Title: "Dune"})
+		assert.NoError(t, err)
+		publishers, _, err := store.GetPublishers(ListOptions{})
+		assert.NoError(t, err)
+
+		// Changes to a book made through its relations are part of its history
+		assert.NoError(t, store.WithActor("alice").TagBooks([]int{id}, []string{"classics"}))
+		assert.NoError(t, store.WithActor("bob").SetSeriesBook(seriesID, id, 1))
+		assert.NoError(t, store.WithActor("carol").SetBookCover(id, models.Cover{ContentType: "image/png", Width: 800, Height: 1200, Size: 51234, Digest: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"}))
+		assert.NoError(t, store.WithActor("dave").MergeEditions(workID, []int{id}))
+		assert.NoError(t, store.WithActor("erin").UpdateAuthor(models.Author{ID: book.Authors[0].ID, Name: "Frank P. Herbert"}))
+		assert.NoError(t, store.WithActor("frank").UpdatePublisher(models.Publisher{ID: publishers[0].ID, Name: "Chilton"}))
+		// Renaming a tag to itself changes no book
+		tags, _, err := store.GetTags(ListOptions{})
+		assert.NoError(t, err)
+		assert.NoError(t, store.WithActor("grace").UpdateTag(tags[0]))
+
+		entries, _, err := store.GetAudit(AuditFilter{BookID: id, Action: models.AuditUpdate}, ListOptions{})
+		assert.NoError(t, err)
+		var actors []string
+		for _, e := range entries {
+			actors = append(actors, e.Actor)
+		}
+		assert.Equal(t, []string{"alice", "bob", "carol", "dave", "erin", "frank"}, actors)
+		if len(entries) == 6 {
+			assert.Equal(t, []models.FieldChange{
+				{Field: "tags", Before: json.RawMessage("null"), After: json.RawMessage(`["classics"]`)},
+			}, entries[0].Changes)
+			for i, field := range []string{"series", "cover", "work_id", "author", "publisher"} {
+				var fields []string
+				for _, c := range entries[i+1].Changes {
+					fields = append(fields, c.Field)
+				}
+				assert.Contains(t, fields, field)
+			}
+		}
+		book, err = store.GetBook(id)
+		assert.NoError(t, err)
+		assert.Equal(t, 7, book.Version)
+	})
+}
+
+func TestDB_AuditLogIsAppendOnly(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *DB) {
+		_, err := db.CreateCollection(models.Collection{Name: "Shelf"})
+		assert.NoError(t, err)
+		_, err = db.Exec("UPDATE audit_log SET actor = 'mallory'")
+		assert.Error(t, err)
+		_, err = db.Exec("DELETE FROM audit_log")
+		assert.Error(t, err)
+	})
+}
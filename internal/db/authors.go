@@ -0,0 +1,318 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// AuthorSortFields are the fields the author list can be ordered by.
+var AuthorSortFields = []string{"id", "name"}
+
+// authorSeparators split an author string into names. The migration that
+// introduced the authors table splits existing books the same way.
+var authorSeparators = strings.NewReplacer(" & ", ",", " and ", ",")
+
+// splitAuthors turns a free-text author string like "Alan A. A. Donovan,
+// Brian W. Kernighan" into authors credited in that order.
+func splitAuthors(s string) []models.BookAuthor {
+	var authors []models.BookAuthor
+	for _, name := range strings.Split(authorSeparators.Replace(s), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			authors = append(authors, models.BookAuthor{Name: name, Role: "author"})
+		}
+	}
+	return authors
+}
+
+// authorLine is the display form of a book's authors stored in
+// books.author: the names credited as author, or all names if there are
+// none, e.g. for an anthology that only has editors.
+func authorLine(authors []models.BookAuthor) string {
+	var names, all []string
+	for _, a := range authors {
+		if a.Role == "author" {
+			names = append(names, a.Name)
+		}
+		all = append(all, a.Name)
+	}
+	if len(names) == 0 {
+		names = all
+	}
+	return strings.Join(names, ", ")
+}
+
+// bookAuthors returns the authors of a book about to be written, split from
+// b.Author if b.Authors is empty. Roles default to "author" and each author
+// may only be credited once per role.
+func bookAuthors(b models.Book) ([]models.BookAuthor, error) {
+	authors := slices.Clone(b.Authors)
+	if len(authors) == 0 {
+		authors = splitAuthors(b.Author)
+	}
+	for i, a := range authors {
+		a.Name = strings.TrimSpace(a.Name)
+		if a.Role == "" {
+			a.Role = "author"
+		}
+		if !slices.Contains(models.AuthorRoles, a.Role) {
+			return nil, fmt.Errorf("%w author role %q, expected one of %v", ErrInvalid, a.Role, models.AuthorRoles)
+		}
+		if a.ID == 0 && a.Name == "" {
+			return nil, fmt.Errorf("%w author, an ID or a name is required", ErrInvalid)
+		}
+		authors[i] = a
+	}
+	return authors, nil
+}
+
+// checkCredits rejects an author credited twice in the same role, once the
+// authors have their IDs.
+func checkCredits(authors []models.BookAuthor) error {
+	for i, a := range authors {
+		for _, other := range authors[:i] {
+			if a.ID == other.ID && a.Role == other.Role {
+				return fmt.Errorf("author %d is credited twice as %s: %w", a.ID, a.Role, ErrConflict)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveAuthors looks up the authors given by ID and finds or creates those
+// given by name.
+func resolveAuthors(q querier, authors []models.BookAuthor) error {
+	for i, a := range authors {
+		if a.ID != 0 {
+			err := q.QueryRow("SELECT name FROM authors WHERE id = ?", a.ID).Scan(&authors[i].Name)
+			if err != nil {
+				return translateError(err, "author", a.ID)
+			}
+			continue
+		}
+		err := q.QueryRow("SELECT id FROM authors WHERE name = ?", a.Name).Scan(&authors[i].ID)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		err = q.QueryRow("INSERT INTO authors (name) VALUES (?) RETURNING id", a.Name).Scan(&authors[i].ID)
+		if err != nil {
+			return translateError(err, "author", 0)
+		}
+	}
+	return checkCredits(authors)
+}
+
+// writeBookAuthors replaces the credits of a book.
+func writeBookAuthors(q querier, bookID int, authors []models.BookAuthor) error {
+	if _, err := q.Exec("DELETE FROM book_authors WHERE book_id = ?", bookID); err != nil {
+		return err
+	}
+	for i, a := range authors {
+		_, err := q.Exec("INSERT INTO book_authors (book_id, author_id, role, position) VALUES (?, ?, ?, ?)", bookID, a.ID, a.Role, i+1)
+		if err != nil {
+			return translateError(err, "book", bookID)
+		}
+	}
+	return nil
+}
+
+// loadAuthors fills in the authors of the books in a single query.
+func loadAuthors(q querier, books ...*models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+	byID := map[int][]*models.Book{}
+	args := make([]interface{}, 0, len(books))
+	for _, b := range books {
+		if _, ok := byID[b.ID]; !ok {
+			args = append(args, b.ID)
+		}
+		byID[b.ID] = append(byID[b.ID], b)
+		b.Authors = []models.BookAuthor{}
+	}
+
+	rows, err := q.Query(`
+		SELECT ba.book_id, a.id, a.name, ba.role
+		FROM book_authors ba
+		JOIN authors a ON a.id = ba.author_id
+		WHERE ba.book_id IN (?`+strings.Repeat(", ?", len(args)-1)+`)
+		ORDER BY ba.book_id, ba.position`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bookID int
+		var a models.BookAuthor
+		if err := rows.Scan(&bookID, &a.ID, &a.Name, &a.Role); err != nil {
+			return err
+		}
+		for _, b := range byID[bookID] {
+			b.Authors = append(b.Authors, a)
+		}
+	}
+	return rows.Err()
+}
+
+// loadRelations fills in the authors, tags, custom values, cover, series
+// and reading status of the books.
+func loadRelations(q querier, books ...*models.Book) error {
+	if err := loadAuthors(q, books...); err != nil {
+		return err
+	}
+	if err := loadTags(q, books...); err != nil {
+		return err
+	}
+	if err := loadCustom(q, books...); err != nil {
+		return err
+	}
+	if err := loadCover(q, books...); err != nil {
+		return err
+	}
+	if err := loadSeries(q, books...); err != nil {
+		return err
+	}
+	return loadReadingStatus(q, books...)
+}
+
+// loadRelationsOf is loadRelations for a slice of books.
+func loadRelationsOf(q querier, books []models.Book) error {
+	pointers := make([]*models.Book, len(books))
+	for i := range books {
+		pointers[i] = &books[i]
+	}
+	return loadRelations(q, pointers...)
+}
+
+// GetAuthors returns a page of authors.
+func (db *DB) GetAuthors(opts ListOptions) ([]models.Author, Page, error) {
+	sort, err := opts.sortField(AuthorSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	var page Page
+	err = db.QueryRow("SELECT COUNT(*) FROM authors").Scan(&page.Total)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	query := "SELECT id, name FROM authors"
+	condition, args, order := keyset(sort, "id", opts.Desc, after)
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+	query += " ORDER BY " + order
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit+1)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	defer rows.Close()
+
+	var authors []models.Author
+	for rows.Next() {
+		var a models.Author
+		if err := rows.Scan(&a.ID, &a.Name); err != nil {
+			return nil, Page{}, err
+		}
+		authors = append(authors, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, Page{}, err
+	}
+
+	authors, page.Next = paginate(authors, opts.Limit, func(a models.Author) cursor {
+		if sort == "name" {
+			return cursor{Sort: sort, Desc: opts.Desc, Value: a.Name, ID: a.ID}
+		}
+		return cursor{Sort: sort, Desc: opts.Desc, ID: a.ID}
+	})
+	return authors, page, nil
+}
+
+func (db *DB) GetAuthor(id int) (models.Author, error) {
+	var a models.Author
+	err := db.QueryRow("SELECT id, name FROM authors WHERE id = ?", id).Scan(&a.ID, &a.Name)
+	if err != nil {
+		return models.Author{}, translateError(err, "author", id)
+	}
+	return a, nil
+}
+
+// CreateAuthor fails with ErrConflict if an author with the name exists.
+func (db *DB) CreateAuthor(a models.Author) (int, error) {
+	var id int
+	err := db.QueryRow("INSERT INTO authors (name) VALUES (?) RETURNING id", a.Name).Scan(&id)
+	if err != nil {
+		return 0, translateError(err, "author", 0)
+	}
+	return id, nil
+}
+
+// UpdateAuthor renames the author. The books crediting the author change
+// with it, so their author line is rewritten and their version bumped.
+func (db *DB) UpdateAuthor(a models.Author) error {
+	return db.inTx(func(tx *Tx) error {
+		ids, err := queryIDs(tx, "SELECT DISTINCT book_id FROM book_authors WHERE author_id = ? ORDER BY book_id", a.ID)
+		if err != nil {
+			return err
+		}
+		return db.changeBooks(tx, ids, func() error {
+			res, err := tx.Exec("UPDATE authors SET name = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", a.Name, a.ID)
+			if err := expectVersion(tx, res, err, "author", a.ID, 0); err != nil {
+				return err
+			}
+			return refreshAuthorLines(tx, ids)
+		})
+	})
+}
+
+// DeleteAuthor fails with ErrConflict while books still credit the author.
+func (db *DB) DeleteAuthor(id int) error {
+	return db.inTx(func(tx *Tx) error {
+		var books int
+		err := tx.QueryRow("SELECT COUNT(*) FROM book_authors WHERE author_id = ?", id).Scan(&books)
+		if err != nil {
+			return err
+		}
+		if books > 0 {
+			return fmt.Errorf("author %d is credited on %d books: %w", id, books, ErrConflict)
+		}
+		res, err := tx.Exec("DELETE FROM authors WHERE id = ?", id)
+		return expectVersion(tx, res, err, "author", id, 0)
+	})
+}
+
+// refreshAuthorLines rewrites books.author for the books with the ids.
+func refreshAuthorLines(tx *Tx, ids []int) error {
+	books := make([]*models.Book, len(ids))
+	for i, id := range ids {
+		books[i] = &models.Book{ID: id}
+	}
+	if err := loadAuthors(tx, books...); err != nil {
+		return err
+	}
+	for _, b := range books {
+		if _, err := tx.Exec("UPDATE books SET author = ? WHERE id = ?", authorLine(b.Authors), b.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
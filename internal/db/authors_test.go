@@ -0,0 +1,136 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitAuthors(t *testing.T) {
+	assert.Equal(t, []models.BookAuthor{
+		{Name: "Alan A. A. Donovan", Role: "author"},
+		{Name: "Brian W. Kernighan", Role: "author"},
+	}, splitAuthors("Alan A. A. Donovan, Brian W. Kernighan"))
+	assert.Len(t, splitAuthors("William Strunk Jr. and E. B. White"), 2)
+	assert.Len(t, splitAuthors("Kernighan & Ritchie,"), 2)
+	assert.Empty(t, splitAuthors(" "))
+
+	assert.Equal(t, "A, C", authorLine([]models.BookAuthor{{Name: "A", Role: "author"}, {Name: "B", Role: "translator"}, {Name: "C", Role: "author"}}))
+	assert.Equal(t, "B", authorLine([]models.BookAuthor{{Name: "B", Role: "editor"}}))
+}
+
+func TestStore_Authors(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		// Author strings are split into authors
+		goID, err := store.CreateBook(models.Book{Title: "The Go Programming Language", Author: "Alan A. A. Donovan, Brian W. Kernighan", PublishedDate: "2015-10-26"})
+		assert.NoError(t, err)
+		book, err := store.GetBook(goID)
+		assert.NoError(t, err)
+		assert.Len(t, book.Authors, 2)
+		assert.Equal(t, "Alan A. A. Donovan", book.Authors[0].Name)
+		assert.Equal(t, "author", book.Authors[0].Role)
+		kernighan := book.Authors[1]
+
+		// Known authors are reused, by ID or by name, and roles are kept
+		cID, err := store.CreateBook(models.Book{Title: "The C Programming Language", PublishedDate: "1978-02-22", Authors: []models.BookAuthor{
+			{ID: kernighan.ID}, {Name: "Dennis M. Ritchie"}, {Name: "Alan A. A. Donovan", Role: "editor"},
+		}})
+		assert.NoError(t, err)
+		book, err = store.GetBook(cID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Brian W. Kernighan, Dennis M. Ritchie", book.Author)
+		assert.Equal(t, []models.BookAuthor{
+			{ID: kernighan.ID, Name: "Brian W. Kernighan", Role: "author"},
+			{ID: book.Authors[1].ID, Name: "Dennis M. Ritchie", Role: "author"},
+			{ID: book.Authors[2].ID, Name: "Alan A. A. Donovan", Role: "editor"},
+		}, book.Authors)
+
+		authors, page, err := store.GetAuthors(ListOptions{Sort: "name"})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, page.Total)
+		assert.Equal(t, "Alan A. A. Donovan", authors[0].Name)
+
+		// Books can be filtered by a single author
+		books, _, err := store.GetBooks(BookFilter{Author: "Brian W. Kernighan"}, ListOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, books, 2)
+		books, _, err = store.GetBooks(BookFilter{Author: "Alan A. A. Donovan, Brian W. Kernighan"}, ListOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, books, 1)
+		books, _, err = store.GetBooks(BookFilter{AuthorID: book.Authors[1].ID}, ListOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, books, 1)
+
+		// Renaming an author changes the books
+		err = store.UpdateAuthor(models.Author{ID: kernighan.ID, Name: "Brian Kernighan"})
+		assert.NoError(t, err)
+		book, err = store.GetBook(goID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Alan A. A. Donovan, Brian Kernighan", book.Author)
+		assert.Equal(t, "Brian Kernighan", book.Authors[1].Name)
+		assert.Equal(t, 2, book.Version)
+		err = store.UpdateAuthor(models.Author{ID: kernighan.ID, Name: "Dennis M. Ritchie"})
+		assert.ErrorIs(t, err, ErrConflict)
+
+		// Credited authors cannot be deleted
+		err = store.DeleteAuthor(kernighan.ID)
+		assert.ErrorIs(t, err, ErrConflict)
+		assert.NoError(t, store.DeleteBook(goID, 0))
+		assert.NoError(t, store.DeleteBook(cID, 0))
+		emptyTrash(t, store)
+		assert.NoError(t, store.DeleteAuthor(kernighan.ID))
+		_, err = store.GetAuthor(kernighan.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestStore_InvalidAuthors(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		book := models.Book{Title: "Dune", PublishedDate: "1965-08-01"}
+
+		book.Authors = []models.BookAuthor{{ID: 42}}
+		_, err := store.CreateBook(book)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		book.Authors = []models.BookAuthor{{Name: "Frank Herbert", Role: "ghostwriter"}}
+		_, err = store.CreateBook(book)
+		assert.ErrorIs(t, err, ErrInvalid)
+
+		book.Authors = []models.BookAuthor{{Name: "Frank Herbert"}, {Name: "Frank Herbert"}}
+		_, err = store.CreateBook(book)
+		assert.ErrorIs(t, err, ErrConflict)
+
+		// Rejected books leave no authors behind
+		authors, _, err := store.GetAuthors(ListOptions{})
+		assert.NoError(t, err)
+		assert.Empty(t, authors)
+	})
+}
+
+func TestDB_AuthorsMigration(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, driver string) {
+		db := openTestDB(t, driver)
+		defer db.Close()
+
+		// Books written before the authors table get their author strings
+		// split
+		err := db.MigrateTo(5)
+		assert.NoError(t, err)
+		for _, author := range []string{"Alan A. A. Donovan, Brian W. Kernighan", "Brian W. Kernighan & Dennis M. Ritchie"} {
+			_, err = db.Exec("INSERT INTO books (title, author, published_date, edition, description, genre) VALUES (?, ?, ?, '', '', '')",
+				"Test Book", author, "2015-10-26")
+			assert.NoError(t, err)
+		}
+		err = db.Migrate()
+		assert.NoError(t, err)
+
+		authors, _, err := db.GetAuthors(ListOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, authors, 3)
+		book, err := db.GetBook(2)
+		assert.NoError(t, err)
+		assert.Equal(t, "Brian W. Kernighan", book.Authors[0].Name)
+		assert.Equal(t, "Dennis M. Ritchie", book.Authors[1].Name)
+	})
+}
@@ -0,0 +1,571 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// LocationSortFields and CopySortFields are the fields location and copy
+// lists can be ordered by.
+var (
+	LocationSortFields = []string{"id", "name"}
+	CopySortFields     = []string{"id", "title"}
+)
+
+// CopyFilter restricts GetCopies to the copies of a book, at a location, with
+// a barcode or in a format. With Recursive the copies in the locations
+// inside the location are included.
+type CopyFilter struct {
+	BookID     int
+	LocationID int
+	Recursive  bool
+	Barcode    string
+	Format     string
+}
+
+// locationPath names the location with its parents, outermost first.
+func locationPath(all map[int]models.Location, id int) string {
+	var names []string
+	// The depth is bounded in case the parents were stored in a loop
+	for len(names) <= len(all) {
+		l, ok := all[id]
+		if !ok {
+			break
+		}
+		names = append(names, l.Name)
+		id = derefID(l.ParentID)
+	}
+	slices.Reverse(names)
+	return strings.Join(names, " > ")
+}
+
+func derefID(id *int) int {
+	if id == nil {
+		return 0
+	}
+	return *id
+}
+
+// subtree returns the IDs of the location and of all locations inside it.
+func subtree(all map[int]models.Location, id int) map[int]bool {
+	ids := map[int]bool{id: true}
+	for changed := true; changed; {
+		changed = false
+		for _, l := range all {
+			if l.ParentID != nil && ids[*l.ParentID] && !ids[l.ID] {
+				ids[l.ID] = true
+				changed = true
+			}
+		}
+	}
+	return ids
+}
+
+// checkLocation validates a location about to be written against the other
+// locations: the parent has to exist, must not be the location or inside it,
+// and kinds go from rooms to shelves inwards.
+func checkLocation(all map[int]models.Location, l *models.Location) error {
+	l.Name = strings.TrimSpace(l.Name)
+	if l.Name == "" {
+		return fmt.Errorf("%w location, the name is empty", ErrInvalid)
+	}
+	kind := slices.Index(models.LocationKinds, l.Kind)
+	if kind < 0 {
+		return fmt.Errorf("%w location kind %q, expected one of %v", ErrInvalid, l.Kind, models.LocationKinds)
+	}
+	if l.ParentID != nil {
+		parent, ok := all[*l.ParentID]
+		if !ok {
+			return notFound("location", *l.ParentID)
+		}
+		if l.ID != 0 && subtree(all, l.ID)[parent.ID] {
+			return fmt.Errorf("%w location, %d cannot be put inside itself", ErrInvalid, l.ID)
+		}
+		if slices.Index(models.LocationKinds, parent.Kind) >= kind {
+			return fmt.Errorf("%w location, a %s cannot be inside a %s", ErrInvalid, l.Kind, parent.Kind)
+		}
+	}
+	for _, child := range all {
+		if l.ID != 0 && derefID(child.ParentID) == l.ID && slices.Index(models.LocationKinds, child.Kind) <= kind {
+			return fmt.Errorf("%w location, a %s cannot contain the %s %q", ErrInvalid, l.Kind, child.Kind, child.Name)
+		}
+	}
+	return nil
+}
+
+// duplicateLocation names the location in conflicts caused by its name.
+func duplicateLocation(err error, name string) error {
+	if errors.Is(err, ErrConflict) {
+		return fmt.Errorf("location %q already exists in its parent: %w", name, ErrConflict)
+	}
+	return err
+}
+
+// checkCopy validates a copy about to be written and trims its barcode.
+func checkCopy(c *models.Copy) error {
+	if !slices.Contains(models.CopyFormats, c.Format) {
+		return fmt.Errorf("%w copy format %q, expected one of %v", ErrInvalid, c.Format, models.CopyFormats)
+	}
+	if c.Condition != "" && !slices.Contains(models.CopyConditions, c.Condition) {
+		return fmt.Errorf("%w copy condition %q, expected one of %v", ErrInvalid, c.Condition, models.CopyConditions)
+	}
+	if _, err := time.Parse(dateLayout, c.AcquiredOn); c.AcquiredOn != "" && err != nil {
+		return fmt.Errorf("%w date %q, expected YYYY-MM-DD", ErrInvalid, c.AcquiredOn)
+	}
+	if c.Price != nil && !(*c.Price >= 0 && !math.IsInf(*c.Price, 1)) {
+		return fmt.Errorf("%w price %v, expected at least 0", ErrInvalid, *c.Price)
+	}
+	c.Barcode = strings.TrimSpace(c.Barcode)
+	return nil
+}
+
+// duplicateBarcode names the barcode in conflicts caused by the copy's,
+// which is its only unique value besides its ID.
+func duplicateBarcode(err error, barcode string) error {
+	if errors.Is(err, ErrConflict) && barcode != "" {
+		return fmt.Errorf("a copy with barcode %q already exists: %w", barcode, ErrConflict)
+	}
+	return err
+}
+
+// scannedBarcodes trims the barcodes of an inventory and drops empty and
+// repeated ones.
+func scannedBarcodes(barcodes []string) []string {
+	var scanned []string
+	for _, b := range barcodes {
+		if b = strings.TrimSpace(b); b != "" && !slices.Contains(scanned, b) {
+			scanned = append(scanned, b)
+		}
+	}
+	return scanned
+}
+
+// inventory compares the scanned barcodes with the copies recorded in the
+// locations. candidates are the copies recorded there and those with a
+// scanned barcode, copies without a barcode cannot be checked.
+func inventory(locationID int, locations map[int]bool, scanned []string, candidates []models.Copy) models.InventoryReport {
+	report := models.InventoryReport{LocationID: locationID, Found: []models.Copy{}, Missing: []models.Copy{},
+		Misplaced: []models.Copy{}, Unknown: []string{}}
+	byBarcode := map[string]models.Copy{}
+	for _, c := range candidates {
+		if c.Barcode != "" {
+			byBarcode[c.Barcode] = c
+		}
+	}
+	for _, barcode := range scanned {
+		c, ok := byBarcode[barcode]
+		switch {
+		case !ok:
+			report.Unknown = append(report.Unknown, barcode)
+		case c.LocationID != nil && locations[*c.LocationID]:
+			report.Found = append(report.Found, c)
+		default:
+			report.Misplaced = append(report.Misplaced, c)
+		}
+	}
+	for _, c := range candidates {
+		if c.Barcode != "" && c.LocationID != nil && locations[*c.LocationID] && !slices.Contains(scanned, c.Barcode) {
+			report.Missing = append(report.Missing, c)
+		}
+	}
+	return report
+}
+
+func copyIDs(copies []models.Copy) []int {
+	ids := make([]int, len(copies))
+	for i, c := range copies {
+		ids[i] = c.ID
+	}
+	return ids
+}
+
+// placeholders returns n comma separated parameters.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// allLocations returns every location by ID, with its path.
+func allLocations(q querier) (map[int]models.Location, error) {
+	rows, err := q.Query("SELECT id, parent_id, name, kind, created_at, updated_at FROM locations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	all := map[int]models.Location{}
+	for rows.Next() {
+		var l models.Location
+		if err := rows.Scan(&l.ID, &l.ParentID, &l.Name, &l.Kind, timestamp{&l.CreatedAt}, timestamp{&l.UpdatedAt}); err != nil {
+			return nil, err
+		}
+		all[l.ID] = l
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for id, l := range all {
+		l.Path = locationPath(all, id)
+		all[id] = l
+	}
+	return all, nil
+}
+
+// GetLocations returns a page of locations with their paths.
+func (db *DB) GetLocations(opts ListOptions) ([]models.Location, Page, error) {
+	sort, err := opts.sortField(LocationSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	all, err := allLocations(db)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	page := Page{Total: len(all)}
+
+	query := "SELECT id FROM locations"
+	condition, args, order := keyset(sort, "id", opts.Desc, after)
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+	query += " ORDER BY " + order
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit+1)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	defer rows.Close()
+
+	var locations []models.Location
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, Page{}, err
+		}
+		locations = append(locations, all[id])
+	}
+	if err := rows.Err(); err != nil {
+		return nil, Page{}, err
+	}
+
+	locations, page.Next = paginate(locations, opts.Limit, func(l models.Location) cursor {
+		if sort == "name" {
+			return cursor{Sort: sort, Desc: opts.Desc, Value: l.Name, ID: l.ID}
+		}
+		return cursor{Sort: sort, Desc: opts.Desc, ID: l.ID}
+	})
+	return locations, page, nil
+}
+
+func (db *DB) GetLocation(id int) (models.Location, error) {
+	all, err := allLocations(db)
+	if err != nil {
+		return models.Location{}, err
+	}
+	l, ok := all[id]
+	if !ok {
+		return models.Location{}, notFound("location", id)
+	}
+	return l, nil
+}
+
+// CreateLocation fails with ErrConflict if the parent already contains a
+// location with the name.
+func (db *DB) CreateLocation(l models.Location) (int, error) {
+	var id int
+	err := db.inTx(func(tx *Tx) error {
+		all, err := allLocations(tx)
+		if err != nil {
+			return err
+		}
+		l.ID = 0
+		if err := checkLocation(all, &l); err != nil {
+			return err
+		}
+		err = tx.QueryRow("INSERT INTO locations (parent_id, name, kind) VALUES (?, ?, ?) RETURNING id", l.ParentID, l.Name, l.Kind).Scan(&id)
+		return duplicateLocation(translateError(err, "location", 0), l.Name)
+	})
+	return id, err
+}
+
+// UpdateLocation renames the location, changes its kind or moves it with
+// everything inside it to another parent.
+func (db *DB) UpdateLocation(l models.Location) error {
+	return db.inTx(func(tx *Tx) error {
+		all, err := allLocations(tx)
+		if err != nil {
+			return err
+		}
+		if _, ok := all[l.ID]; !ok {
+			return notFound("location", l.ID)
+		}
+		if err := checkLocation(all, &l); err != nil {
+			return err
+		}
+		_, err = tx.Exec("UPDATE locations SET parent_id = ?, name = ?, kind = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", l.ParentID, l.Name, l.Kind, l.ID)
+		return duplicateLocation(translateError(err, "location", l.ID), l.Name)
+	})
+}
+
+// DeleteLocation fails with ErrConflict while the location contains other
+// locations or copies.
+func (db *DB) DeleteLocation(id int) error {
+	return db.inTx(func(tx *Tx) error {
+		var locations, copies int
+		err := tx.QueryRow("SELECT (SELECT COUNT(*) FROM locations WHERE parent_id = ?), (SELECT COUNT(*) FROM copies WHERE location_id = ?)", id, id).Scan(&locations, &copies)
+		if err != nil {
+			return err
+		}
+		if locations > 0 || copies > 0 {
+			return fmt.Errorf("location %d still contains %d locations and %d copies: %w", id, locations, copies, ErrConflict)
+		}
+		res, err := tx.Exec("DELETE FROM locations WHERE id = ?", id)
+		return expectVersion(tx, res, err, "location", id, 0)
+	})
+}
+
+const copyColumns = "c.id, c.book_id, b.title, c.format, c.condition, c.acquired_on, c.price, COALESCE(c.barcode, ''), c.location_id, c.created_at, c.updated_at"
+
+// queryCopies returns the copies matching the condition, with their
+// locations named after all.
+func queryCopies(q querier, all map[int]models.Location, condition string, args ...interface{}) ([]models.Copy, error) {
+	rows, err := q.Query("SELECT "+copyColumns+" FROM copies c JOIN books b ON b.id = c.book_id WHERE "+condition, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var copies []models.Copy
+	for rows.Next() {
+		var c models.Copy
+		err := rows.Scan(&c.ID, &c.BookID, &c.Title, &c.Format, &c.Condition, &c.AcquiredOn, &c.Price, &c.Barcode, &c.LocationID,
+			timestamp{&c.CreatedAt}, timestamp{&c.UpdatedAt})
+		if err != nil {
+			return nil, err
+		}
+		if c.LocationID != nil {
+			c.Location = all[*c.LocationID].Path
+		}
+		copies = append(copies, c)
+	}
+	return copies, rows.Err()
+}
+
+// GetCopies returns a page of the copies matching the filter.
+func (db *DB) GetCopies(filter CopyFilter, opts ListOptions) ([]models.Copy, Page, error) {
+	sort, err := opts.sortField(CopySortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	all, err := allLocations(db)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	// Copies of books in the trash are hidden with their books
+	where := "b.deleted_at IS NULL"
+	args := []interface{}{}
+	if filter.BookID != 0 {
+		where += " AND c.book_id = ?"
+		args = append(args, filter.BookID)
+	}
+	if filter.LocationID != 0 {
+		ids := []interface{}{filter.LocationID}
+		if filter.Recursive {
+			ids = ids[:0]
+			for id := range subtree(all, filter.LocationID) {
+				ids = append(ids, id)
+			}
+		}
+		where += " AND c.location_id IN (" + placeholders(len(ids)) + ")"
+		args = append(args, ids...)
+	}
+	if filter.Barcode != "" {
+		where += " AND c.barcode = ?"
+		args = append(args, strings.TrimSpace(filter.Barcode))
+	}
+	if filter.Format != "" {
+		where += " AND c.format = ?"
+		args = append(args, filter.Format)
+	}
+
+	var page Page
+	err = db.QueryRow("SELECT COUNT(*) FROM copies c JOIN books b ON b.id = c.book_id WHERE "+where, args...).Scan(&page.Total)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	column := "c.id"
+	if sort == "title" {
+		column = "b.title"
+	}
+	condition, keysetArgs, order := keyset(column, "c.id", opts.Desc, after)
+	if condition != "" {
+		where += " AND " + condition
+		args = append(args, keysetArgs...)
+	}
+	where += " ORDER BY " + order
+	if opts.Limit > 0 {
+		where += " LIMIT ?"
+		args = append(args, opts.Limit+1)
+	}
+
+	copies, err := queryCopies(db, all, where, args...)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	copies, page.Next = paginate(copies, opts.Limit, func(c models.Copy) cursor {
+		if sort == "title" {
+			return cursor{Sort: sort, Desc: opts.Desc, Value: c.Title, ID: c.ID}
+		}
+		return cursor{Sort: sort, Desc: opts.Desc, ID: c.ID}
+	})
+	return copies, page, nil
+}
+
+func getCopy(q querier, id int) (models.Copy, error) {
+	all, err := allLocations(q)
+	if err != nil {
+		return models.Copy{}, err
+	}
+	copies, err := queryCopies(q, all, "c.id = ?", id)
+	if err != nil {
+		return models.Copy{}, err
+	}
+	if len(copies) == 0 {
+		return models.Copy{}, notFound("copy", id)
+	}
+	return copies[0], nil
+}
+
+func (db *DB) GetCopy(id int) (models.Copy, error) {
+	return getCopy(db, id)
+}
+
+// CreateCopy fails with ErrConflict if another copy has the barcode.
+func (db *DB) CreateCopy(c models.Copy) (int, error) {
+	if err := checkCopy(&c); err != nil {
+		return 0, err
+	}
+	var id int
+	err := db.inTx(func(tx *Tx) error {
+		if err := exists(tx, "books", c.BookID); err != nil {
+			return translateError(err, "book", c.BookID)
+		}
+		if c.LocationID != nil {
+			if err := exists(tx, "locations", *c.LocationID); err != nil {
+				return translateError(err, "location", *c.LocationID)
+			}
+		}
+		err := tx.QueryRow("INSERT INTO copies (book_id, format, condition, acquired_on, price, barcode, location_id) VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id",
+			c.BookID, c.Format, c.Condition, c.AcquiredOn, c.Price, nullString(c.Barcode), c.LocationID).Scan(&id)
+		return duplicateBarcode(translateError(err, "copy", 0), c.Barcode)
+	})
+	return id, err
+}
+
+// UpdateCopy replaces the details and location of the copy, the book stays
+// the same.
+func (db *DB) UpdateCopy(c models.Copy) error {
+	if err := checkCopy(&c); err != nil {
+		return err
+	}
+	return db.inTx(func(tx *Tx) error {
+		if c.LocationID != nil {
+			if err := exists(tx, "locations", *c.LocationID); err != nil {
+				return translateError(err, "location", *c.LocationID)
+			}
+		}
+		res, err := tx.Exec("UPDATE copies SET format = ?, condition = ?, acquired_on = ?, price = ?, barcode = ?, location_id = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+			c.Format, c.Condition, c.AcquiredOn, c.Price, nullString(c.Barcode), c.LocationID, c.ID)
+		return duplicateBarcode(expectVersion(tx, res, err, "copy", c.ID, 0), c.Barcode)
+	})
+}
+
+func (db *DB) DeleteCopy(id int) error {
+	res, err := db.Exec("DELETE FROM copies WHERE id = ?", id)
+	return expectVersion(db, res, err, "copy", id, 0)
+}
+
+// MoveCopies moves the copies to the location, or out of any location if it
+// is nil. Nothing is moved if a copy or the location does not exist.
+func (db *DB) MoveCopies(ids []int, locationID *int) error {
+	return db.inTx(func(tx *Tx) error {
+		if locationID != nil {
+			if err := exists(tx, "locations", *locationID); err != nil {
+				return translateError(err, "location", *locationID)
+			}
+		}
+		return moveCopies(tx, ids, locationID)
+	})
+}
+
+func moveCopies(tx *Tx, ids []int, locationID *int) error {
+	for _, id := range ids {
+		res, err := tx.Exec("UPDATE copies SET location_id = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", locationID, id)
+		if err := expectVersion(tx, res, err, "copy", id, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckInventory compares the barcodes found at a location and the
+// locations inside it with the copies recorded there. With apply, the
+// misplaced copies are moved to the location.
+func (db *DB) CheckInventory(locationID int, barcodes []string, apply bool) (models.InventoryReport, error) {
+	var report models.InventoryReport
+	err := db.inTx(func(tx *Tx) error {
+		all, err := allLocations(tx)
+		if err != nil {
+			return err
+		}
+		if _, ok := all[locationID]; !ok {
+			return notFound("location", locationID)
+		}
+		locations := subtree(all, locationID)
+		scanned := scannedBarcodes(barcodes)
+
+		var args []interface{}
+		for id := range locations {
+			args = append(args, id)
+		}
+		condition := "c.location_id IN (" + placeholders(len(args)) + ")"
+		if len(scanned) > 0 {
+			condition += " OR c.barcode IN (" + placeholders(len(scanned)) + ")"
+			for _, b := range scanned {
+				args = append(args, b)
+			}
+		}
+		candidates, err := queryCopies(tx, all, "b.deleted_at IS NULL AND ("+condition+") ORDER BY c.id", args...)
+		if err != nil {
+			return err
+		}
+
+		report = inventory(locationID, locations, scanned, candidates)
+		report.Applied = apply
+		if !apply {
+			return nil
+		}
+		return moveCopies(tx, copyIDs(report.Misplaced), &locationID)
+	})
+	return report, err
+}
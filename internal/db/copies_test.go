@@ -0,0 +1,168 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckLocation(t *testing.T) {
+	parent := func(id int) *int { return &id }
+	all := map[int]models.Location{
+		1: {ID: 1, Name: "Study", Kind: "room"},
+		2: {ID: 2, ParentID: parent(1), Name: "Bookcase", Kind: "bookcase"},
+		3: {ID: 3, ParentID: parent(2), Name: "Top shelf", Kind: "shelf"},
+	}
+	assert.Equal(t, "Study > Bookcase > Top shelf", locationPath(all, 3))
+	assert.Equal(t, map[int]bool{2: true, 3: true}, subtree(all, 2))
+
+	l := models.Location{ParentID: parent(1), Name: " Shelf ", Kind: "shelf"}
+	assert.NoError(t, checkLocation(all, &l))
+	assert.Equal(t, "Shelf", l.Name)
+
+	for _, l := range []models.Location{
+		{Name: " ", Kind: "room"},
+		{Name: "Drawer", Kind: "drawer"},
+		{ParentID: parent(3), Name: "Box", Kind: "shelf"},
+		{ParentID: parent(2), Name: "Hall", Kind: "room"},
+		{ID: 1, ParentID: parent(3), Name: "Study", Kind: "room"},
+		{ID: 2, Name: "Bookcase", Kind: "shelf"},
+	} {
+		assert.ErrorIs(t, checkLocation(all, &l), ErrInvalid, l)
+	}
+	assert.ErrorIs(t, checkLocation(all, &models.Location{ParentID: parent(42), Name: "Shelf", Kind: "shelf"}), ErrNotFound)
+}
+
+func TestInventory(t *testing.T) {
+	at := func(id int) *int { return &id }
+	candidates := []models.Copy{
+		{ID: 1, Barcode: "A", LocationID: at(1)},
+		{ID: 2, Barcode: "B", LocationID: at(2)},
+		{ID: 3, Barcode: "C", LocationID: at(3)},
+		{ID: 4, LocationID: at(1)},
+		{ID: 5, Barcode: "E"},
+	}
+	report := inventory(1, map[int]bool{1: true, 2: true}, scannedBarcodes([]string{"A", " C", "E", "X", "A", ""}), candidates)
+	assert.Equal(t, []int{1}, copyIDs(report.Found))
+	assert.Equal(t, []int{2}, copyIDs(report.Missing))
+	assert.Equal(t, []int{3, 5}, copyIDs(report.Misplaced))
+	assert.Equal(t, []string{"X"}, report.Unknown)
+}
+
+func TestStore_Copies(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		duneID, err := store.CreateBook(models.Book{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01"})
+		assert.NoError(t, err)
+		hobbitID, err := store.CreateBook(models.Book{Title: "The Hobbit", Author: "J. R. R. Tolkien", PublishedDate: "1937-09-21"})
+		assert.NoError(t, err)
+
+		studyID, err := store.CreateLocation(models.Location{Name: "Study", Kind: "room"})
+		assert.NoError(t, err)
+		caseID, err := store.CreateLocation(models.Location{ParentID: &studyID, Name: "Bookcase", Kind: "bookcase"})
+		assert.NoError(t, err)
+		topID, err := store.CreateLocation(models.Location{ParentID: &caseID, Name: "Top", Kind: "shelf"})
+		assert.NoError(t, err)
+		bottomID, err := store.CreateLocation(models.Location{ParentID: &caseID, Name: "Bottom", Kind: "shelf"})
+		assert.NoError(t, err)
+		_, err = store.CreateLocation(models.Location{ParentID: &caseID, Name: "Top", Kind: "shelf"})
+		assert.ErrorIs(t, err, ErrConflict)
+		_, err = store.CreateLocation(models.Location{ParentID: &topID, Name: "Box", Kind: "shelf"})
+		assert.ErrorIs(t, err, ErrInvalid)
+
+		location, err := store.GetLocation(bottomID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Study > Bookcase > Bottom", location.Path)
+		locations, page, err := store.GetLocations(ListOptions{Sort: "name", Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, 4, page.Total)
+		assert.Equal(t, []string{"Bookcase", "Bottom"}, []string{locations[0].Name, locations[1].Name})
+
+		price := 9.5
+		paperbackID, err := store.CreateCopy(models.Copy{BookID: duneID, Format: "paperback", Condition: "good", Price: &price, Barcode: " 0001 ", LocationID: &topID})
+		assert.NoError(t, err)
+		secondID, err := store.CreateCopy(models.Copy{BookID: duneID, Format: "paperback", Barcode: "0002", LocationID: &bottomID})
+		assert.NoError(t, err)
+		_, err = store.CreateCopy(models.Copy{BookID: duneID, Format: "ebook"})
+		assert.NoError(t, err)
+		hobbitCopyID, err := store.CreateCopy(models.Copy{BookID: hobbitID, Format: "hardcover", Barcode: "0003", LocationID: &topID})
+		assert.NoError(t, err)
+		_, err = store.CreateCopy(models.Copy{BookID: hobbitID, Format: "hardcover", Barcode: "0001"})
+		assert.ErrorIs(t, err, ErrConflict)
+		_, err = store.CreateCopy(models.Copy{BookID: hobbitID, Format: "scroll"})
+		assert.ErrorIs(t, err, ErrInvalid)
+		_, err = store.CreateCopy(models.Copy{BookID: hobbitID, Format: "hardcover", LocationID: &[]int{42}[0]})
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		copy, err := store.GetCopy(paperbackID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Dune", copy.Title)
+		assert.Equal(t, "0001", copy.Barcode)
+		assert.Equal(t, "Study > Bookcase > Top", copy.Location)
+		assert.Equal(t, 9.5, *copy.Price)
+
+		ids := func(filter CopyFilter, opts ListOptions) []int {
+			copies, _, err := store.GetCopies(filter, opts)
+			assert.NoError(t, err)
+			var ids []int
+			for _, c := range copies {
+				ids = append(ids, c.ID)
+			}
+			return ids
+		}
+		assert.Equal(t, []int{hobbitCopyID, paperbackID}, ids(CopyFilter{LocationID: topID}, ListOptions{Sort: "title", Desc: true}))
+		assert.Equal(t, []int{paperbackID, secondID, hobbitCopyID}, ids(CopyFilter{LocationID: studyID, Recursive: true}, ListOptions{}))
+		assert.Empty(t, ids(CopyFilter{LocationID: studyID}, ListOptions{}))
+		assert.Equal(t, []int{secondID}, ids(CopyFilter{Barcode: "0002"}, ListOptions{}))
+
+		assert.NoError(t, store.MoveCopies([]int{secondID}, &topID))
+		assert.ErrorIs(t, store.MoveCopies([]int{secondID, 42}, nil), ErrNotFound)
+		assert.Equal(t, []int{paperbackID, secondID, hobbitCopyID}, ids(CopyFilter{LocationID: topID}, ListOptions{}))
+
+		// The second paperback is back on the bottom shelf and the Hobbit
+		// went missing
+		report, err := store.CheckInventory(bottomID, []string{"0002", "9999"}, false)
+		assert.NoError(t, err)
+		assert.Empty(t, report.Found)
+		assert.Empty(t, report.Missing)
+		assert.Equal(t, []int{secondID}, copyIDs(report.Misplaced))
+		assert.Equal(t, []string{"9999"}, report.Unknown)
+		report, err = store.CheckInventory(bottomID, []string{"0002"}, true)
+		assert.NoError(t, err)
+		assert.True(t, report.Applied)
+		report, err = store.CheckInventory(caseID, []string{"0001", "0002"}, false)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{paperbackID, secondID}, copyIDs(report.Found))
+		assert.Equal(t, []int{hobbitCopyID}, copyIDs(report.Missing))
+		_, err = store.CheckInventory(42, nil, false)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		// Moving a bookcase moves what is on it
+		hallID, err := store.CreateLocation(models.Location{Name: "Hall", Kind: "room"})
+		assert.NoError(t, err)
+		assert.NoError(t, store.UpdateLocation(models.Location{ID: caseID, ParentID: &hallID, Name: "Bookcase", Kind: "bookcase"}))
+		assert.ErrorIs(t, store.UpdateLocation(models.Location{ID: caseID, ParentID: &topID, Name: "Bookcase", Kind: "bookcase"}), ErrInvalid)
+		copy, err = store.GetCopy(secondID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Hall > Bookcase > Bottom", copy.Location)
+
+		assert.ErrorIs(t, store.DeleteLocation(bottomID), ErrConflict)
+		assert.NoError(t, store.DeleteLocation(studyID))
+		assert.NoError(t, store.DeleteCopy(secondID))
+		assert.NoError(t, store.DeleteLocation(bottomID))
+		assert.ErrorIs(t, store.DeleteLocation(bottomID), ErrNotFound)
+
+		copy, err = store.GetCopy(paperbackID)
+		assert.NoError(t, err)
+		copy.Condition, copy.LocationID = "fair", nil
+		assert.NoError(t, store.UpdateCopy(copy))
+		copy, err = store.GetCopy(paperbackID)
+		assert.NoError(t, err)
+		assert.Equal(t, "fair", copy.Condition)
+		assert.Empty(t, copy.Location)
+
+		assert.NoError(t, store.DeleteBook(duneID, 0))
+		emptyTrash(t, store)
+		assert.Equal(t, []int{hobbitCopyID}, ids(CopyFilter{}, ListOptions{}))
+	})
+}
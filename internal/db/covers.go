@@ -0,0 +1,90 @@
+package db
+
+import (
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// loadCover sets the cover of books, leaving it nil for books without one.
+func loadCover(q querier, books ...*models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+	byID := map[int][]*models.Book{}
+	args := make([]interface{}, 0, len(books))
+	for _, b := range books {
+		if _, ok := byID[b.ID]; !ok {
+			args = append(args, b.ID)
+		}
+		byID[b.ID] = append(byID[b.ID], b)
+		b.Cover = nil
+	}
+
+	rows, err := q.Query(`
+		SELECT book_id, content_type, width, height, size, digest, updated_at
+		FROM book_covers
+		WHERE book_id IN (`+placeholders(len(args))+`)`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bookID int
+		var c models.Cover
+		if err := rows.Scan(&bookID, &c.ContentType, &c.Width, &c.Height, &c.Size, &c.Digest, timestamp{&c.UpdatedAt}); err != nil {
+			return err
+		}
+		c.SetURLs(bookID)
+		for _, b := range byID[bookID] {
+			cover := c
+			b.Cover = &cover
+		}
+	}
+	return rows.Err()
+}
+
+// SetBookCover records the cover of a book, replacing any earlier one.
+func (db *DB) SetBookCover(bookID int, c models.Cover) error {
+	return db.inTx(func(tx *Tx) error {
+		if err := exists(tx, "books", bookID); err != nil {
+			return translateError(err, "book", bookID)
+		}
+		return db.changeBooks(tx, []int{bookID}, func() error {
+			res, err := tx.Exec(`
+				UPDATE book_covers SET content_type = ?, width = ?, height = ?, size = ?, digest = ?, updated_at = CURRENT_TIMESTAMP
+				WHERE book_id = ?`, c.ContentType, c.Width, c.Height, c.Size, c.Digest, bookID)
+			if err != nil {
+				return err
+			}
+			if n, err := res.RowsAffected(); err != nil {
+				return err
+			} else if n == 0 {
+				_, err = tx.Exec("INSERT INTO book_covers (book_id, content_type, width, height, size, digest) VALUES (?, ?, ?, ?, ?, ?)",
+					bookID, c.ContentType, c.Width, c.Height, c.Size, c.Digest)
+				return err
+			}
+			return nil
+		})
+	})
+}
+
+// DeleteBookCover fails with ErrNotFound if the book has no cover.
+func (db *DB) DeleteBookCover(bookID int) error {
+	return db.inTx(func(tx *Tx) error {
+		if err := exists(tx, "books", bookID); err != nil {
+			return translateError(err, "book", bookID)
+		}
+		return db.changeBooks(tx, []int{bookID}, func() error {
+			res, err := tx.Exec("DELETE FROM book_covers WHERE book_id = ?", bookID)
+			if err != nil {
+				return err
+			}
+			if n, err := res.RowsAffected(); err != nil {
+				return err
+			} else if n == 0 {
+				return notFound("cover of book", bookID)
+			}
+			return nil
+		})
+	})
+}
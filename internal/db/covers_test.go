@@ -0,0 +1,67 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore_BookCovers(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		id, err := store.CreateBook(models.Book{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01"})
+		assert.NoError(t, err)
+		book, err := store.GetBook(id)
+		assert.NoError(t, err)
+		assert.Nil(t, book.Cover)
+
+		digest := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+		cover := models.Cover{ContentType: "image/png", Width: 800, Height: 1200, Size: 51234, Digest: digest}
+		assert.NoError(t, store.SetBookCover(id, cover))
+		book, err = store.GetBook(id)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, book.Version)
+		if assert.NotNil(t, book.Cover) {
+			assert.Equal(t, "image/png", book.Cover.ContentType)
+			assert.Equal(t, 800, book.Cover.Width)
+			assert.Equal(t, 51234, book.Cover.Size)
+			assert.Equal(t, digest, book.Cover.Digest)
+			assert.False(t, book.Cover.UpdatedAt.IsZero())
+			assert.Equal(t, "/api/v1/books/1/cover?v=9f86d081884c7d65", book.Cover.URL)
+			assert.Equal(t, "/api/v1/books/1/cover/small?v=9f86d081884c7d65", book.Cover.Thumbnails["small"])
+		}
+
+		// Replacing the cover, which lists and later updates keep
+		cover.ContentType, cover.Digest = "image/jpeg", "60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752"
+		assert.NoError(t, store.SetBookCover(id, cover))
+		book.Genre = "Science Fiction"
+		book.Cover = nil
+		book.Version = 0
+		assert.NoError(t, store.UpdateBook(book))
+		books, _, err := store.GetBooks(BookFilter{}, ListOptions{})
+		assert.NoError(t, err)
+		if assert.Len(t, books, 1) && assert.NotNil(t, books[0].Cover) {
+			assert.Equal(t, "image/jpeg", books[0].Cover.ContentType)
+			assert.Equal(t, 4, books[0].Version)
+		}
+
+		assert.NoError(t, store.DeleteBookCover(id))
+		assert.ErrorIs(t, store.DeleteBookCover(id), ErrNotFound)
+		book, err = store.GetBook(id)
+		assert.NoError(t, err)
+		assert.Nil(t, book.Cover)
+		assert.Equal(t, 5, book.Version)
+
+		assert.ErrorIs(t, store.SetBookCover(99, cover), ErrNotFound)
+		assert.ErrorIs(t, store.DeleteBookCover(99), ErrNotFound)
+
+		// Deleting the book deletes its cover, which a new book does not inherit
+		assert.NoError(t, store.SetBookCover(id, cover))
+		assert.NoError(t, store.DeleteBook(id, 0))
+		id, err = store.CreateBook(models.Book{Title: "Dune Messiah", Author: "Frank Herbert", PublishedDate: "1969-01-01"})
+		assert.NoError(t, err)
+		book, err = store.GetBook(id)
+		assert.NoError(t, err)
+		assert.Nil(t, book.Cover)
+	})
+}
@@ -0,0 +1,527 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// CustomFieldSortFields are the fields the custom field list can be ordered
+// by.
+var CustomFieldSortFields = []string{"id", "name"}
+
+// customFieldName is what names of custom fields look like, so they can be
+// used as keys on the command line and in custom.<name> filters.
+var customFieldName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// checkCustomField normalizes the choices of a custom field and rejects
+// invalid definitions.
+func checkCustomField(f *models.CustomField) error {
+	f.Name = strings.TrimSpace(f.Name)
+	if !customFieldName.MatchString(f.Name) {
+		return fmt.Errorf("%w custom field %q, names are up to 64 lower case letters, digits and underscores, starting with a letter", ErrInvalid, f.Name)
+	}
+	if !slices.Contains(models.CustomFieldTypes, f.Type) {
+		return fmt.Errorf("%w custom field type %q, expected one of %v", ErrInvalid, f.Type, models.CustomFieldTypes)
+	}
+	choices := []string{}
+	for _, choice := range f.Choices {
+		choice = strings.TrimSpace(choice)
+		if choice == "" {
+			return fmt.Errorf("%w custom field %q, a choice is empty", ErrInvalid, f.Name)
+		}
+		if slices.Contains(choices, choice) {
+			return fmt.Errorf("%w custom field %q, the choice %q is listed twice", ErrInvalid, f.Name, choice)
+		}
+		choices = append(choices, choice)
+	}
+	f.Choices = choices
+	if f.Type == "enum" && len(choices) == 0 {
+		return fmt.Errorf("%w custom field %q, an enum needs choices", ErrInvalid, f.Name)
+	}
+	if f.Type != "enum" && len(choices) > 0 {
+		return fmt.Errorf("%w custom field %q, only enum fields have choices", ErrInvalid, f.Name)
+	}
+	if f.Pattern != "" {
+		if f.Type != "string" {
+			return fmt.Errorf("%w custom field %q, only string fields have a pattern", ErrInvalid, f.Name)
+		}
+		if _, err := regexp.Compile(f.Pattern); err != nil {
+			return fmt.Errorf("%w custom field %q pattern: %v", ErrInvalid, f.Name, err)
+		}
+	}
+	return nil
+}
+
+// customText converts the value of a custom field to the text it is stored
+// as. Nil and the empty string leave the field unset, for which ok is false.
+func customText(f models.CustomField, value interface{}) (text string, ok bool, err error) {
+	if s, isString := value.(string); value == nil || isString && s == "" {
+		return "", false, nil
+	}
+	invalid := func(expected string) error {
+		return fmt.Errorf("%w value %v of custom field %q, expected %s", ErrInvalid, value, f.Name, expected)
+	}
+	switch f.Type {
+	case "int":
+		switch v := value.(type) {
+		case int:
+			return strconv.Itoa(v), true, nil
+		case int64:
+			return strconv.FormatInt(v, 10), true, nil
+		case float64:
+			// JSON numbers decode as float64, which is exact up to 2^53
+			if v == math.Trunc(v) && math.Abs(v) <= 1<<53 {
+				return strconv.FormatInt(int64(v), 10), true, nil
+			}
+		}
+		return "", false, invalid("an integer")
+	case "bool":
+		if v, isBool := value.(bool); isBool {
+			return strconv.FormatBool(v), true, nil
+		}
+		return "", false, invalid("true or false")
+	}
+
+	s, isString := value.(string)
+	if !isString {
+		return "", false, invalid("a string")
+	}
+	switch f.Type {
+	case "date":
+		if _, err := time.Parse(dateLayout, s); err != nil {
+			return "", false, invalid("a date in the format YYYY-MM-DD")
+		}
+	case "enum":
+		if !slices.Contains(f.Choices, s) {
+			return "", false, invalid("one of " + strings.Join(f.Choices, ", "))
+		}
+	case "string":
+		// The pattern has to match the whole value
+		if f.Pattern != "" && !regexp.MustCompile(`^(?:`+f.Pattern+`)$`).MatchString(s) {
+			return "", false, invalid("a value matching " + f.Pattern)
+		}
+	}
+	return s, true, nil
+}
+
+// customValue converts the stored text of a custom field back to its value.
+func customValue(fieldType, text string) interface{} {
+	switch fieldType {
+	case "int":
+		i, _ := strconv.ParseInt(text, 10, 64)
+		return i
+	case "bool":
+		return text == "true"
+	}
+	return text
+}
+
+// bookCustom checks the custom values of a book about to be written against
+// the field definitions and returns their stored text by field name.
+func bookCustom(fields map[string]models.CustomField, values map[string]interface{}) (map[string]string, error) {
+	texts := map[string]string{}
+	for _, name := range sortedKeys(values) {
+		f, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("%w custom field %q, no such field is defined", ErrInvalid, name)
+		}
+		text, ok, err := customText(f, values[name])
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			texts[name] = text
+		}
+	}
+	return texts, nil
+}
+
+// customMatch is a custom field and the stored text a book filter wants it
+// to have.
+type customMatch struct {
+	Field models.CustomField
+	Text  string
+}
+
+// customFilter converts the values of a custom field filter, given as text
+// like in a query string, to the stored text they match.
+func customFilter(fields map[string]models.CustomField, filter map[string]string) ([]customMatch, error) {
+	var matches []customMatch
+	for _, name := range sortedKeys(filter) {
+		f, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("%w custom field %q, no such field is defined", ErrInvalid, name)
+		}
+		var value interface{} = filter[name]
+		switch f.Type {
+		case "int":
+			i, err := strconv.ParseInt(filter[name], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w value %q of custom field %q, expected an integer", ErrInvalid, filter[name], name)
+			}
+			value = i
+		case "bool":
+			b, err := strconv.ParseBool(filter[name])
+			if err != nil {
+				return nil, fmt.Errorf("%w value %q of custom field %q, expected true or false", ErrInvalid, filter[name], name)
+			}
+			value = b
+		}
+		text, _, err := customText(f, value)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, customMatch{Field: f, Text: text})
+	}
+	return matches, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+const customFieldColumns = "f.id, f.name, f.type, f.pattern, " +
+	"(SELECT COUNT(*) FROM book_custom_values v JOIN books b ON b.id = v.book_id WHERE v.field_id = f.id AND b.deleted_at IS NULL), f.created_at, f.updated_at"
+
+func scanCustomField(row scanner) (models.CustomField, error) {
+	var f models.CustomField
+	err := row.Scan(&f.ID, &f.Name, &f.Type, &f.Pattern, &f.BookCount, timestamp{&f.CreatedAt}, timestamp{&f.UpdatedAt})
+	return f, err
+}
+
+// loadChoices fills in the choices of the custom fields in a single query.
+func loadChoices(q querier, fields ...*models.CustomField) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	byID := map[int]*models.CustomField{}
+	args := make([]interface{}, 0, len(fields))
+	for _, f := range fields {
+		byID[f.ID] = f
+		args = append(args, f.ID)
+		f.Choices = []string{}
+	}
+
+	rows, err := q.Query("SELECT field_id, value FROM custom_field_choices WHERE field_id IN ("+placeholders(len(args))+") ORDER BY field_id, position", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var fieldID int
+		var choice string
+		if err := rows.Scan(&fieldID, &choice); err != nil {
+			return err
+		}
+		byID[fieldID].Choices = append(byID[fieldID].Choices, choice)
+	}
+	return rows.Err()
+}
+
+// loadCustomFields returns the definitions of all custom fields by name.
+func loadCustomFields(q querier) (map[string]models.CustomField, error) {
+	rows, err := q.Query("SELECT " + customFieldColumns + " FROM custom_fields f")
+	if err != nil {
+		return nil, err
+	}
+	var fields []*models.CustomField
+	for rows.Next() {
+		f, err := scanCustomField(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		fields = append(fields, &f)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := loadChoices(q, fields...); err != nil {
+		return nil, err
+	}
+	byName := map[string]models.CustomField{}
+	for _, f := range fields {
+		byName[f.Name] = *f
+	}
+	return byName, nil
+}
+
+// writeBookCustom replaces the custom values of a book.
+func writeBookCustom(q querier, bookID int, fields map[string]models.CustomField, texts map[string]string) error {
+	if _, err := q.Exec("DELETE FROM book_custom_values WHERE book_id = ?", bookID); err != nil {
+		return err
+	}
+	for _, name := range sortedKeys(texts) {
+		_, err := q.Exec("INSERT INTO book_custom_values (book_id, field_id, value) VALUES (?, ?, ?)", bookID, fields[name].ID, texts[name])
+		if err != nil {
+			return translateError(err, "book", bookID)
+		}
+	}
+	return nil
+}
+
+// loadCustom fills in the custom values of the books in a single query.
+func loadCustom(q querier, books ...*models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+	byID := map[int][]*models.Book{}
+	args := make([]interface{}, 0, len(books))
+	for _, b := range books {
+		if _, ok := byID[b.ID]; !ok {
+			args = append(args, b.ID)
+		}
+		byID[b.ID] = append(byID[b.ID], b)
+		b.Custom = map[string]interface{}{}
+	}
+
+	rows, err := q.Query(`
+		SELECT v.book_id, f.name, f.type, v.value
+		FROM book_custom_values v
+		JOIN custom_fields f ON f.id = v.field_id
+		WHERE v.book_id IN (`+placeholders(len(args))+`)`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bookID int
+		var name, fieldType, text string
+		if err := rows.Scan(&bookID, &name, &fieldType, &text); err != nil {
+			return err
+		}
+		for _, b := range byID[bookID] {
+			b.Custom[name] = customValue(fieldType, text)
+		}
+	}
+	return rows.Err()
+}
+
+// GetCustomFields returns a page of custom fields with the number of books
+// having a value for each.
+func (db *DB) GetCustomFields(opts ListOptions) ([]models.CustomField, Page, error) {
+	sort, err := opts.sortField(CustomFieldSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	var page Page
+	err = db.QueryRow("SELECT COUNT(*) FROM custom_fields").Scan(&page.Total)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	query := "SELECT " + customFieldColumns + " FROM custom_fields f"
+	condition, args, order := keyset("f."+sort, "f.id", opts.Desc, after)
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+	query += " ORDER BY " + order
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit+1)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	defer rows.Close()
+
+	var fields []models.CustomField
+	for rows.Next() {
+		f, err := scanCustomField(rows)
+		if err != nil {
+			return nil, Page{}, err
+		}
+		fields = append(fields, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, Page{}, err
+	}
+
+	fields, page.Next = paginate(fields, opts.Limit, func(f models.CustomField) cursor {
+		if sort == "name" {
+			return cursor{Sort: sort, Desc: opts.Desc, Value: f.Name, ID: f.ID}
+		}
+		return cursor{Sort: sort, Desc: opts.Desc, ID: f.ID}
+	})
+	pointers := make([]*models.CustomField, len(fields))
+	for i := range fields {
+		pointers[i] = &fields[i]
+	}
+	return fields, page, loadChoices(db, pointers...)
+}
+
+func (db *DB) GetCustomField(id int) (models.CustomField, error) {
+	return getCustomField(db, id)
+}
+
+func getCustomField(q querier, id int) (models.CustomField, error) {
+	f, err := scanCustomField(q.QueryRow("SELECT "+customFieldColumns+" FROM custom_fields f WHERE f.id = ?", id))
+	if err != nil {
+		return models.CustomField{}, translateError(err, "custom field", id)
+	}
+	return f, loadChoices(q, &f)
+}
+
+// CreateCustomField fails with ErrConflict if a field with the name exists.
+func (db *DB) CreateCustomField(f models.CustomField) (int, error) {
+	if err := checkCustomField(&f); err != nil {
+		return 0, err
+	}
+	var id int
+	err := db.inTx(func(tx *Tx) error {
+		err := tx.QueryRow("INSERT INTO custom_fields (name, type, pattern) VALUES (?, ?, ?) RETURNING id", f.Name, f.Type, f.Pattern).Scan(&id)
+		if err != nil {
+			return duplicateCustomField(translateError(err, "custom field", 0), f.Name)
+		}
+		return writeChoices(tx, id, f.Choices)
+	})
+	return id, err
+}
+
+// UpdateCustomField changes the definition of a custom field. The values
+// books already have must still be valid under it, so the type can only
+// change while no book has a value, and choices in use cannot be dropped.
+// Renaming the field changes its key in the books having a value, so their
+// version is bumped.
+func (db *DB) UpdateCustomField(f models.CustomField) error {
+	if err := checkCustomField(&f); err != nil {
+		return err
+	}
+	return db.inTx(func(tx *Tx) error {
+		current, err := getCustomField(tx, f.ID)
+		if err != nil {
+			return err
+		}
+		texts, err := customTexts(tx, f.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkCustomValues(current, f, texts); err != nil {
+			return err
+		}
+
+		ids, err := customBooks(tx, f.ID)
+		if err != nil {
+			return err
+		}
+		return db.changeBooks(tx, ids, func() error {
+			res, err := tx.Exec("UPDATE custom_fields SET name = ?, type = ?, pattern = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", f.Name, f.Type, f.Pattern, f.ID)
+			if err := duplicateCustomField(expectVersion(tx, res, err, "custom field", f.ID, 0), f.Name); err != nil {
+				return err
+			}
+			return writeChoices(tx, f.ID, f.Choices)
+		})
+	})
+}
+
+// checkCustomValues fails with ErrConflict if the stored values of a custom
+// field are invalid under its new definition.
+func checkCustomValues(current, f models.CustomField, texts []string) error {
+	if len(texts) == 0 {
+		return nil
+	}
+	if f.Type != current.Type {
+		return fmt.Errorf("custom field %q has values on %d books, its type cannot change: %w", current.Name, len(texts), ErrConflict)
+	}
+	for _, text := range texts {
+		if _, _, err := customText(f, customValue(current.Type, text)); err != nil {
+			return fmt.Errorf("custom field %q has the value %q on a book, which the new definition rejects: %w", current.Name, text, ErrConflict)
+		}
+	}
+	return nil
+}
+
+// customTexts returns the distinct stored values of a custom field.
+func customTexts(q querier, fieldID int) ([]string, error) {
+	rows, err := q.Query("SELECT DISTINCT value FROM book_custom_values WHERE field_id = ? ORDER BY value", fieldID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var texts []string
+	for rows.Next() {
+		var text string
+		if err := rows.Scan(&text); err != nil {
+			return nil, err
+		}
+		texts = append(texts, text)
+	}
+	return texts, rows.Err()
+}
+
+// DeleteCustomField deletes the custom field and its values on books.
+func (db *DB) DeleteCustomField(id int) error {
+	return db.inTx(func(tx *Tx) error {
+		if err := exists(tx, "custom_fields", id); err != nil {
+			return translateError(err, "custom field", id)
+		}
+		ids, err := customBooks(tx, id)
+		if err != nil {
+			return err
+		}
+		return db.changeBooks(tx, ids, func() error {
+			// SQLite does not enforce the cascades
+			if _, err := tx.Exec("DELETE FROM book_custom_values WHERE field_id = ?", id); err != nil {
+				return err
+			}
+			if _, err := tx.Exec("DELETE FROM custom_field_choices WHERE field_id = ?", id); err != nil {
+				return err
+			}
+			_, err := tx.Exec("DELETE FROM custom_fields WHERE id = ?", id)
+			return err
+		})
+	})
+}
+
+// writeChoices replaces the choices of a custom field.
+func writeChoices(q querier, fieldID int, choices []string) error {
+	if _, err := q.Exec("DELETE FROM custom_field_choices WHERE field_id = ?", fieldID); err != nil {
+		return err
+	}
+	for i, choice := range choices {
+		if _, err := q.Exec("INSERT INTO custom_field_choices (field_id, value, position) VALUES (?, ?, ?)", fieldID, choice, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// customBooks returns the ids of the books with a value for the custom
+// field.
+func customBooks(q querier, fieldID int) ([]int, error) {
+	return queryIDs(q, "SELECT book_id FROM book_custom_values WHERE field_id = ? ORDER BY book_id", fieldID)
+}
+
+// duplicateCustomField names the field in conflicts caused by its name.
+func duplicateCustomField(err error, name string) error {
+	if errors.Is(err, ErrConflict) {
+		return fmt.Errorf("custom field %q already exists: %w", name, ErrConflict)
+	}
+	return err
+}
@@ -0,0 +1,153 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomText(t *testing.T) {
+	fields := map[string]models.CustomField{
+		"po":     {Name: "po", Type: "string", Pattern: `PO-\d+`},
+		"copies": {Name: "copies", Type: "int"},
+		"signed": {Name: "signed", Type: "bool"},
+		"bought": {Name: "bought", Type: "date"},
+		"lab":    {Name: "lab", Type: "enum", Choices: []string{"north", "south"}},
+	}
+	for _, tt := range []struct {
+		name  string
+		value interface{}
+		want  string
+		ok    bool
+		err   bool
+	}{
+		{"po", "PO-17", "PO-17", true, false},
+		{"po", "xPO-17", "", false, true},
+		{"po", "", "", false, false},
+		{"copies", float64(3), "3", true, false},
+		{"copies", int64(-2), "-2", true, false},
+		{"copies", 2.5, "", false, true},
+		{"copies", "3", "", false, true},
+		{"signed", false, "false", true, false},
+		{"signed", "yes", "", false, true},
+		{"bought", "2024-02-29", "2024-02-29", true, false},
+		{"bought", "2023-02-29", "", false, true},
+		{"lab", "south", "south", true, false},
+		{"lab", "east", "", false, true},
+		{"lab", nil, "", false, false},
+		{"lab", []interface{}{"north"}, "", false, true},
+	} {
+		text, ok, err := customText(fields[tt.name], tt.value)
+		assert.Equal(t, tt.want, text, "%s %v", tt.name, tt.value)
+		assert.Equal(t, tt.ok, ok, "%s %v", tt.name, tt.value)
+		if tt.err {
+			assert.ErrorIs(t, err, ErrInvalid, "%s %v", tt.name, tt.value)
+		} else {
+			assert.NoError(t, err, "%s %v", tt.name, tt.value)
+		}
+	}
+}
+
+func TestStore_CustomFields(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		_, err := store.CreateCustomField(models.CustomField{Name: "Lab", Type: "string"})
+		assert.ErrorIs(t, err, ErrInvalid)
+		_, err = store.CreateCustomField(models.CustomField{Name: "lab", Type: "enum"})
+		assert.ErrorIs(t, err, ErrInvalid)
+		labID, err := store.CreateCustomField(models.CustomField{Name: "lab", Type: "enum", Choices: []string{" north", "south"}})
+		assert.NoError(t, err)
+		_, err = store.CreateCustomField(models.CustomField{Name: "lab", Type: "string"})
+		assert.ErrorIs(t, err, ErrConflict)
+		signedID, err := store.CreateCustomField(models.CustomField{Name: "signed", Type: "bool"})
+		assert.NoError(t, err)
+		copiesID, err := store.CreateCustomField(models.CustomField{Name: "copies", Type: "int"})
+		assert.NoError(t, err)
+
+		book := models.Book{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01",
+			Custom: map[string]interface{}{"lab": "north", "signed": true, "copies": float64(2)}}
+		duneID, err := store.CreateBook(book)
+		assert.NoError(t, err)
+		hobbitID, err := store.CreateBook(models.Book{Title: "The Hobbit", Author: "J.R.R. Tolkien", PublishedDate: "1937-09-21",
+			Custom: map[string]interface{}{"lab": "south", "signed": false}})
+		assert.NoError(t, err)
+		_, err = store.CreateBook(models.Book{Title: "Emma", Author: "Jane Austen", PublishedDate: "1815-12-23",
+			Custom: map[string]interface{}{"shelf": "A"}})
+		assert.ErrorIs(t, err, ErrInvalid)
+		_, err = store.CreateBook(models.Book{Title: "Emma", Author: "Jane Austen", PublishedDate: "1815-12-23",
+			Custom: map[string]interface{}{"lab": "east"}})
+		assert.ErrorIs(t, err, ErrInvalid)
+
+		dune, err := store.GetBook(duneID)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"lab": "north", "signed": true, "copies": int64(2)}, dune.Custom)
+
+		for _, tt := range []struct {
+			filter map[string]string
+			want   []int
+		}{
+			{map[string]string{"lab": "north"}, []int{duneID}},
+			{map[string]string{"signed": "false"}, []int{hobbitID}},
+			{map[string]string{"copies": "2", "signed": "1"}, []int{duneID}},
+			{map[string]string{"copies": "3"}, nil},
+		} {
+			books, _, err := store.GetBooks(BookFilter{Custom: tt.filter}, ListOptions{})
+			assert.NoError(t, err)
+			var ids []int
+			for _, b := range books {
+				ids = append(ids, b.ID)
+			}
+			assert.Equal(t, tt.want, ids, "%v", tt.filter)
+		}
+		_, _, err = store.GetBooks(BookFilter{Custom: map[string]string{"shelf": "A"}}, ListOptions{})
+		assert.ErrorIs(t, err, ErrInvalid)
+		_, _, err = store.GetBooks(BookFilter{Custom: map[string]string{"copies": "two"}}, ListOptions{})
+		assert.ErrorIs(t, err, ErrInvalid)
+
+		// Unsetting a value
+		dune.Custom["copies"] = nil
+		assert.NoError(t, store.UpdateBook(dune))
+		dune, err = store.GetBook(duneID)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"lab": "north", "signed": true}, dune.Custom)
+
+		// Definitions must keep accepting the values books have
+		field, err := store.GetCustomField(labID)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"north", "south"}, field.Choices)
+		assert.Equal(t, 2, field.BookCount)
+		field.Choices = []string{"north"}
+		assert.ErrorIs(t, store.UpdateCustomField(field), ErrConflict)
+		field.Type, field.Choices = "string", nil
+		assert.ErrorIs(t, store.UpdateCustomField(field), ErrConflict)
+		assert.NoError(t, store.UpdateCustomField(models.CustomField{ID: copiesID, Name: "copies", Type: "string"}))
+
+		// Renaming a field renames its values on books
+		field = models.CustomField{ID: labID, Name: "site", Type: "enum", Choices: []string{"south", "north", "west"}}
+		assert.NoError(t, store.UpdateCustomField(field))
+		hobbit, err := store.GetBook(hobbitID)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"site": "south", "signed": false}, hobbit.Custom)
+		assert.Equal(t, 2, hobbit.Version)
+
+		assert.NoError(t, store.DeleteCustomField(signedID))
+		hobbit, err = store.GetBook(hobbitID)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"site": "south"}, hobbit.Custom)
+		assert.Equal(t, 3, hobbit.Version)
+		assert.ErrorIs(t, store.DeleteCustomField(signedID), ErrNotFound)
+
+		fields, page, err := store.GetCustomFields(ListOptions{Sort: "name"})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, page.Total)
+		assert.Equal(t, "copies", fields[0].Name)
+		assert.Equal(t, "site", fields[1].Name)
+		assert.Equal(t, []string{"south", "north", "west"}, fields[1].Choices)
+
+		assert.NoError(t, store.DeleteBook(hobbitID, 0))
+		emptyTrash(t, store)
+		field, err = store.GetCustomField(labID)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, field.BookCount)
+	})
+}
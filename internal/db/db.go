@@ -2,206 +2,960 @@ package db
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/mayank-02/bookman/internal/models"
+	"github.com/mayank-02/bookman/internal/pubdate"
+)
+
+const (
+	SQLite   = "sqlite3"
+	Postgres = "postgres"
 )
 
 type DB struct {
 	*sql.DB
+	Driver string
+	actor  string // named in the audit log, see WithActor
+}
+
+// DriverFor picks the database driver for a data source name. PostgreSQL
+// connection URLs select the Postgres backend, anything else is treated as a
+// path to a SQLite database.
+func DriverFor(dataSourceName string) string {
+	if strings.HasPrefix(dataSourceName, "postgres://") || strings.HasPrefix(dataSourceName, "postgresql://") {
+		return Postgres
+	}
+	return SQLite
 }
 
+// InitDB opens the database and applies any pending migrations, so callers
+// always get a database at the latest schema version.
 func InitDB(dataSourceName string) (*DB, error) {
-	db, err := sql.Open("sqlite3", dataSourceName)
+	db, err := Open(dataSourceName)
 	if err != nil {
 		return nil, err
 	}
-	if err = db.Ping(); err != nil {
+	if err = db.Migrate(); err != nil {
+		db.Close()
 		return nil, err
 	}
-	return &DB{db}, nil
+	return db, nil
 }
 
-func (db *DB) GetBook(id int) (models.Book, error) {
-	var b models.Book
-	var createdAt, updatedAt string
-	err := db.QueryRow("SELECT id, title, author, published_date, edition, description, genre, created_at, updated_at FROM books WHERE id = ?", id).
-		Scan(&b.ID, &b.Title, &b.Author, &b.PublishedDate, &b.Edition, &b.Description, &b.Genre, &createdAt, &updatedAt)
+// Open opens the database without touching its schema.
+func Open(dataSourceName string) (*DB, error) {
+	driver := DriverFor(dataSourceName)
+	db, err := sql.Open(driver, dataSourceName)
 	if err != nil {
-		return models.Book{}, err
+		return nil, err
+	}
+	// Every connection to an in-memory database gets its own empty database,
+	// so the pool must be limited to a single connection.
+	if driver == SQLite && strings.Contains(dataSourceName, ":memory:") {
+		db.SetMaxOpenConns(1)
+	}
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
 	}
+	return &DB{DB: db, Driver: driver}, nil
+}
+
+// Exec, Query and QueryRow accept queries written with ? placeholders and
+// rewrite them for the underlying driver.
+
+func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.DB.Exec(db.rebind(query), args...)
+}
 
-	b.CreatedAt, err = time.Parse("2006-01-02 15:04:05", createdAt)
+func (db *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return db.DB.Query(db.rebind(query), args...)
+}
+
+func (db *DB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return db.DB.QueryRow(db.rebind(query), args...)
+}
+
+// querier is implemented by DB and Tx, so helpers can run inside or outside
+// of a transaction.
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Tx is a transaction that rewrites placeholders like DB does.
+type Tx struct {
+	*sql.Tx
+	db *DB
+}
+
+func (tx *Tx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.Tx.Exec(tx.db.rebind(query), args...)
+}
+
+func (tx *Tx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.Tx.Query(tx.db.rebind(query), args...)
+}
+
+func (tx *Tx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return tx.Tx.QueryRow(tx.db.rebind(query), args...)
+}
+
+// inTx runs fn in a transaction, which is committed if fn succeeds and
+// rolled back otherwise. All queries of fn must go through tx, an in-memory
+// SQLite database has a single connection.
+func (db *DB) inTx(fn func(tx *Tx) error) error {
+	sqlTx, err := db.Begin()
 	if err != nil {
-		return models.Book{}, err
+		return err
+	}
+	defer sqlTx.Rollback()
+
+	if err := fn(&Tx{Tx: sqlTx, db: db}); err != nil {
+		return err
+	}
+	return sqlTx.Commit()
+}
+
+// rebind replaces ? placeholders with the $1, $2, ... form used by
+// PostgreSQL. Question marks inside quoted literals are left alone.
+func (db *DB) rebind(query string) string {
+	if db.Driver != Postgres || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	var quote rune
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '?':
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// sqliteTimeLayout is the format of the timestamps written by SQLite's
+// datetime('now') and CURRENT_TIMESTAMP.
+const sqliteTimeLayout = "2006-01-02 15:04:05"
+
+// timestamp scans both the TEXT timestamps written by SQLite and the native
+// timestamptz values returned by PostgreSQL into a UTC time.Time.
+type timestamp struct {
+	t *time.Time
+}
+
+func (ts timestamp) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*ts.t = time.Time{}
+	case time.Time:
+		*ts.t = v.UTC()
+	case string:
+		return ts.parse(v)
+	case []byte:
+		return ts.parse(string(v))
+	default:
+		return fmt.Errorf("cannot scan %T into timestamp", src)
+	}
+	return nil
+}
+
+// timeValue formats t the way the database compares timestamps.
+func (db *DB) timeValue(t time.Time) string {
+	if db.Driver == Postgres {
+		return t.UTC().Format(time.RFC3339Nano)
 	}
-	b.UpdatedAt, err = time.Parse("2006-01-02 15:04:05", updatedAt)
+	return t.UTC().Format(sqliteTimeLayout)
+}
+
+func (ts timestamp) parse(s string) error {
+	t, err := time.Parse(sqliteTimeLayout, s)
 	if err != nil {
+		return err
+	}
+	*ts.t = t
+	return nil
+}
+
+const bookColumns = "b.id, b.title, b.author, b.published_date, b.edition, b.description, b.genre, " +
+	"COALESCE(b.isbn_10, ''), COALESCE(b.isbn_13, ''), b.average_rating, b.rating_count, b.created_at, b.updated_at, b.version, b.work_id, " +
+	"COALESCE((SELECT p.name FROM publishers p WHERE p.id = b.publisher_id), ''), b.language, b.page_count, b.format, b.height_mm, b.width_mm, b.thickness_mm, b.published_precision"
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanBook scans the bookColumns of a row, followed by any extra columns the
+// query selected.
+func scanBook(row scanner, extra ...interface{}) (models.Book, error) {
+	var b models.Book
+	dest := []interface{}{&b.ID, &b.Title, &b.Author, &b.PublishedDate, &b.Edition, &b.Description, &b.Genre,
+		&b.ISBN10, &b.ISBN13, &b.AverageRating, &b.RatingCount, timestamp{&b.CreatedAt}, timestamp{&b.UpdatedAt}, &b.Version, &b.WorkID,
+		&b.Publisher, &b.Language, &b.PageCount, &b.Format, &b.Dimensions.Height, &b.Dimensions.Width, &b.Dimensions.Thickness,
+		&b.PublishedPrecision}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
 		return models.Book{}, err
 	}
+	b.PublishedDate = pubdate.Format(b.PublishedDate, b.PublishedPrecision)
 	return b, nil
 }
 
-func (db *DB) GetBooks(author, genre, from, to string) ([]models.Book, error) {
-	query := "SELECT id, title, author, published_date, edition, description, genre, created_at, updated_at FROM books WHERE 1=1"
+func (db *DB) GetBook(id int) (models.Book, error) {
+	return getBook(db, id)
+}
+
+// getBook loads a book that is not in the trash with all its relations.
+func getBook(q querier, id int) (models.Book, error) {
+	b, err := scanBook(q.QueryRow("SELECT "+bookColumns+" FROM books b WHERE b.id = ? AND b.deleted_at IS NULL", id))
+	if err != nil {
+		return models.Book{}, translateError(err, "book", id)
+	}
+	return b, loadRelations(q, &b)
+}
+
+// GetBooks returns the page of books matching the filter described by opts.
+func (db *DB) GetBooks(filter BookFilter, opts ListOptions) ([]models.Book, Page, error) {
+	sort, err := opts.sortField(BookSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	where := " WHERE b.deleted_at IS NULL"
 	args := []interface{}{}
 
-	if author != "" {
-		query += " AND author = ?"
-		args = append(args, author)
+	if filter.Author != "" {
+		where += " AND (b.author = ? OR EXISTS (SELECT 1 FROM book_authors ba JOIN authors a ON a.id = ba.author_id WHERE ba.book_id = b.id AND a.name = ?))"
+		args = append(args, filter.Author, filter.Author)
+	}
+	if filter.AuthorID != 0 {
+		where += " AND EXISTS (SELECT 1 FROM book_authors ba WHERE ba.book_id = b.id AND ba.author_id = ?)"
+		args = append(args, filter.AuthorID)
 	}
-	if genre != "" {
-		query += " AND genre = ?"
-		args = append(args, genre)
+	if filter.Genre != "" {
+		where += " AND b.genre = ?"
+		args = append(args, filter.Genre)
 	}
+	for _, group := range tagFilter(filter.Tags) {
+		where += " AND EXISTS (SELECT 1 FROM book_tags bt JOIN tags t ON t.id = bt.tag_id WHERE bt.book_id = b.id AND t.name IN (?" + strings.Repeat(", ?", len(group)-1) + "))"
+		for _, name := range group {
+			args = append(args, name)
+		}
+	}
+	from, to, err := publishedRange(filter)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	// Books are stored with the first day of their date, compare it with the
+	// first and last one a date of the book's precision may start on
 	if from != "" {
-		query += " AND published_date >= ?"
-		args = append(args, from)
+		where += " AND CASE b.published_precision"
+		for _, precision := range pubdate.Precisions {
+			where += " WHEN '" + precision + "' THEN b.published_date >= ?"
+			args = append(args, pubdate.MinStart(from, precision))
+		}
+		where += " END"
 	}
 	if to != "" {
-		query += " AND published_date <= ?"
-		args = append(args, to)
+		where += " AND CASE b.published_precision"
+		for _, precision := range pubdate.Precisions {
+			where += " WHEN '" + precision + "' THEN b.published_date <= ?"
+			args = append(args, pubdate.MaxStart(to, precision))
+		}
+		where += " END"
+	}
+	if filter.ISBN != "" {
+		where += " AND b.isbn_13 = ?"
+		args = append(args, filter.ISBN)
+	}
+	if filter.MinRating > 0 {
+		where += " AND b.average_rating >= ?"
+		args = append(args, filter.MinRating)
+	}
+	if filter.Status != "" {
+		where += " AND (SELECT rs.status FROM reading_sessions rs WHERE rs.book_id = b.id ORDER BY rs.id DESC LIMIT 1) = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.Publisher != "" {
+		where += " AND b.publisher_id = (SELECT p.id FROM publishers p WHERE p.name = ?)"
+		args = append(args, filter.Publisher)
+	}
+	if filter.Language != "" {
+		// A language also matches its regional and script variants
+		where += " AND (b.language = ? OR b.language LIKE ?)"
+		args = append(args, filter.Language, filter.Language+"-%")
+	}
+	if filter.Format != "" {
+		where += " AND b.format = ?"
+		args = append(args, filter.Format)
+	}
+	if filter.MinPages > 0 {
+		where += " AND b.page_count >= ?"
+		args = append(args, filter.MinPages)
+	}
+	if filter.MaxPages > 0 {
+		where += " AND b.page_count > 0 AND b.page_count <= ?"
+		args = append(args, filter.MaxPages)
+	}
+	for _, dimension := range []struct {
+		column string
+		limit  float64
+	}{{"b.height_mm", filter.MaxHeight}, {"b.width_mm", filter.MaxWidth}, {"b.thickness_mm", filter.MaxThickness}} {
+		if dimension.limit > 0 {
+			where += " AND " + dimension.column + " > 0 AND " + dimension.column + " <= ?"
+			args = append(args, dimension.limit)
+		}
+	}
+	if len(filter.Custom) > 0 {
+		fields, err := loadCustomFields(db)
+		if err != nil {
+			return nil, Page{}, err
+		}
+		matches, err := customFilter(fields, filter.Custom)
+		if err != nil {
+			return nil, Page{}, err
+		}
+		for _, match := range matches {
+			where += " AND b.id IN (SELECT v.book_id FROM book_custom_values v WHERE v.field_id = ? AND v.value = ?)"
+			args = append(args, match.Field.ID, match.Text)
+		}
+	}
+	if filter.WorkID != 0 {
+		where += " AND b.work_id = ?"
+		args = append(args, filter.WorkID)
+	}
+	if filter.CollapseWorks {
+		// Books of no work get a key of their own, ids are positive
+		where += " AND b.id IN (SELECT MIN(b.id) FROM books b" + where + " GROUP BY COALESCE(b.work_id, -b.id))"
+		args = append(args, args...)
+	}
+
+	var page Page
+	err = db.QueryRow("SELECT COUNT(*) FROM books b"+where, args...).Scan(&page.Total)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	column := "b." + sort
+	if sort == "rating" {
+		column = "b.average_rating"
+	}
+	condition, keysetArgs, order := keyset(column, "b.id", opts.Desc, after)
+	if condition != "" {
+		where += " AND " + condition
+		args = append(args, keysetArgs...)
+	}
+	query := "SELECT " + bookColumns + " FROM books b" + where + " ORDER BY " + order
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit+1)
 	}
 
 	rows, err := db.Query(query, args...)
 	if err != nil {
-		return nil, err
+		return nil, Page{}, err
 	}
 	defer rows.Close()
 
 	var books []models.Book
 	for rows.Next() {
-		var b models.Book
-		var createdAt, updatedAt string
-		err := rows.Scan(&b.ID, &b.Title, &b.Author, &b.PublishedDate, &b.Edition, &b.Description, &b.Genre, &createdAt, &updatedAt)
-		if err != nil {
-			return nil, err
-		}
-		b.CreatedAt, err = time.Parse("2006-01-02 15:04:05", createdAt)
-		if err != nil {
-			return nil, err
-		}
-		b.UpdatedAt, err = time.Parse("2006-01-02 15:04:05", updatedAt)
+		b, err := scanBook(rows)
 		if err != nil {
-			return nil, err
+			return nil, Page{}, err
 		}
 		books = append(books, b)
 	}
-	return books, nil
+	if err := rows.Err(); err != nil {
+		return nil, Page{}, err
+	}
+
+	books, page.Next = paginate(books, opts.Limit, func(b models.Book) cursor {
+		return cursor{Sort: sort, Desc: opts.Desc, Value: db.bookSortValue(b, sort), ID: b.ID}
+	})
+	return books, page, loadRelationsOf(db, books)
 }
 
+// CreateBook credits the authors in b.Authors, or those named in b.Author if
+// there are none. Authors given by name are created if they do not exist,
+// and so are the tags.
 func (db *DB) CreateBook(b models.Book) (int, error) {
-	result, err := db.Exec("INSERT INTO books (title, author, published_date, edition, description, genre) VALUES (?, ?, ?, ?, ?, ?)",
-		b.Title, b.Author, b.PublishedDate, b.Edition, b.Description, b.Genre)
+	authors, err := bookAuthors(b)
 	if err != nil {
 		return 0, err
 	}
-	id, err := result.LastInsertId()
+	tags, err := bookTags(b.Tags)
 	if err != nil {
 		return 0, err
 	}
-	return int(id), nil
+	published, err := publishedDate(b.PublishedDate)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = db.inTx(func(tx *Tx) error {
+		if err := resolveAuthors(tx, authors); err != nil {
+			return err
+		}
+		publisherID, err := resolvePublisher(tx, b.Publisher)
+		if err != nil {
+			return err
+		}
+		fields, err := loadCustomFields(tx)
+		if err != nil {
+			return err
+		}
+		custom, err := bookCustom(fields, b.Custom)
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRow("INSERT INTO books (title, author, published_date, published_precision, "+
+			"edition, description, genre, isbn_10, isbn_13, publisher_id, language, page_count, format, height_mm, width_mm, thickness_mm) "+
+			"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id",
+			b.Title, authorLine(authors), published.Start, published.Precision,
+			b.Edition, b.Description, b.Genre, nullString(b.ISBN10), nullString(b.ISBN13),
+			publisherID, b.Language, b.PageCount, b.Format, b.Dimensions.Height, b.Dimensions.Width, b.Dimensions.Thickness).Scan(&id)
+		if err != nil {
+			return duplicateISBN(translateError(err, "book", 0), b)
+		}
+		if err := writeBookAuthors(tx, id, authors); err != nil {
+			return err
+		}
+		if err := writeBookTags(tx, id, tags); err != nil {
+			return err
+		}
+		if err := writeBookCustom(tx, id, fields, custom); err != nil {
+			return err
+		}
+		created, err := getBook(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := db.addBookRevision(tx, created); err != nil {
+			return err
+		}
+		return db.audit(tx, models.AuditEntry{Action: models.AuditCreate, Entity: models.AuditBook, BookID: id}, models.Book{}, created)
+	})
+	return id, err
 }
 
+// UpdateBook replaces the book, including its authors and tags like
+// CreateBook, and bumps its version. If b.Version is set the update only happens while the
+// book is still at that version, and fails with ErrVersionMismatch
+// otherwise.
 func (db *DB) UpdateBook(b models.Book) error {
-	_, err := db.Exec("UPDATE books SET title = ?, author = ?, published_date = ?, edition = ?, description = ?, genre = ?, updated_at = datetime('now') WHERE id = ?",
-		b.Title, b.Author, b.PublishedDate, b.Edition, b.Description, b.Genre, b.ID)
-	return err
+	return db.inTx(func(tx *Tx) error {
+		return db.updateBook(tx, b)
+	})
 }
 
-func (db *DB) DeleteBook(id int) error {
-	_, err := db.Exec("DELETE FROM books WHERE id = ?", id)
-	return err
+// updateBook is UpdateBook in a transaction. It records the book as it was
+// before as its first revision if it has none, and the result as its next.
+func (db *DB) updateBook(tx *Tx, b models.Book) error {
+	authors, err := bookAuthors(b)
+	if err != nil {
+		return err
+	}
+	tags, err := bookTags(b.Tags)
+	if err != nil {
+		return err
+	}
+	published, err := publishedDate(b.PublishedDate)
+	if err != nil {
+		return err
+	}
+
+	before, err := getBook(tx, b.ID)
+	if err != nil {
+		return err
+	}
+	if err := resolveAuthors(tx, authors); err != nil {
+		return err
+	}
+	publisherID, err := resolvePublisher(tx, b.Publisher)
+	if err != nil {
+		return err
+	}
+	fields, err := loadCustomFields(tx)
+	if err != nil {
+		return err
+	}
+	custom, err := bookCustom(fields, b.Custom)
+	if err != nil {
+		return err
+	}
+	query := "UPDATE books SET title = ?, author = ?, published_date = ?, published_precision = ?, " +
+		"edition = ?, description = ?, genre = ?, isbn_10 = ?, isbn_13 = ?, publisher_id = ?, language = ?, page_count = ?, format = ?, " +
+		"height_mm = ?, width_mm = ?, thickness_mm = ?, updated_at = CURRENT_TIMESTAMP, version = version + 1 WHERE id = ? AND deleted_at IS NULL"
+	args := []interface{}{b.Title, authorLine(authors), published.Start, published.Precision,
+		b.Edition, b.Description, b.Genre, nullString(b.ISBN10), nullString(b.ISBN13),
+		publisherID, b.Language, b.PageCount, b.Format, b.Dimensions.Height, b.Dimensions.Width, b.Dimensions.Thickness, b.ID}
+	if b.Version != 0 {
+		query += " AND version = ?"
+		args = append(args, b.Version)
+	}
+	res, err := tx.Exec(query, args...)
+	if err := duplicateISBN(expectVersion(tx, res, err, "book", b.ID, b.Version), b); err != nil {
+		return err
+	}
+	// The update locks the book, so revisions are numbered one at a time
+	if err := db.startBookRevisions(tx, before); err != nil {
+		return err
+	}
+	if err := writeBookAuthors(tx, b.ID, authors); err != nil {
+		return err
+	}
+	if err := writeBookTags(tx, b.ID, tags); err != nil {
+		return err
+	}
+	if err := writeBookCustom(tx, b.ID, fields, custom); err != nil {
+		return err
+	}
+	after, err := getBook(tx, b.ID)
+	if err != nil {
+		return err
+	}
+	if err := db.addBookRevision(tx, after); err != nil {
+		return err
+	}
+	if err := touchCollectionsOf(tx, b.ID); err != nil {
+		return err
+	}
+	return db.audit(tx, models.AuditEntry{Action: models.AuditUpdate, Entity: models.AuditBook, BookID: b.ID}, before, after)
 }
 
-func (db *DB) GetCollections() ([]models.Collection, error) {
-	rows, err := db.Query("SELECT id, name FROM collections")
+// publishedDate parses the publication date of a book.
+func publishedDate(s string) (pubdate.Date, error) {
+	d, err := pubdate.Parse(s)
 	if err != nil {
-		return nil, err
+		return pubdate.Date{}, fmt.Errorf("%w published date %q: %v", ErrInvalid, s, err)
+	}
+	return d, nil
+}
+
+// publishedStart is the first day of the publication date of a book, which
+// books sort by.
+func publishedStart(b models.Book) string {
+	d, err := pubdate.Parse(b.PublishedDate)
+	if err != nil {
+		return b.PublishedDate
+	}
+	return d.Start
+}
+
+// publishedRange returns the first and the last day of the From and To
+// filters, either of which may be partial or approximate. Books match if
+// they may have been published between the two days: To "1850" takes in
+// books of December 1850, and From "1850" books published c. 1846.
+func publishedRange(filter BookFilter) (from, to string, err error) {
+	if filter.From != "" {
+		d, err := publishedDate(filter.From)
+		if err != nil {
+			return "", "", err
+		}
+		from = d.Earliest
+	}
+	if filter.To != "" {
+		d, err := publishedDate(filter.To)
+		if err != nil {
+			return "", "", err
+		}
+		to = d.Latest
+	}
+	return from, to, nil
+}
+
+// nullString stores empty optional values that must stay unique as NULL.
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// DeleteBook moves the book to the trash, where it keeps its memberships
+// and everything else until it is restored or purged. A non-zero version
+// makes the delete conditional, like for UpdateBook.
+func (db *DB) DeleteBook(id, version int) error {
+	query := "UPDATE books SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP, version = version + 1 " +
+		"WHERE id = ? AND deleted_at IS NULL"
+	args := []interface{}{id}
+	if version != 0 {
+		query += " AND version = ?"
+		args = append(args, version)
+	}
+	return db.inTx(func(tx *Tx) error {
+		before, err := getBook(tx, id)
+		if err != nil {
+			return err
+		}
+		res, err := tx.Exec(query, args...)
+		if err := expectVersion(tx, res, err, "book", id, version); err != nil {
+			return err
+		}
+		if err := touchCollectionsOf(tx, id); err != nil {
+			return err
+		}
+		return db.audit(tx, models.AuditEntry{Action: models.AuditDelete, Entity: models.AuditBook, BookID: id}, before, models.Book{})
+	})
+}
+
+// GetCollections returns a page of collections, without their books.
+func (db *DB) GetCollections(opts ListOptions) ([]models.Collection, Page, error) {
+	sort, err := opts.sortField(CollectionSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	var page Page
+	err = db.QueryRow("SELECT COUNT(*) FROM collections WHERE deleted_at IS NULL").Scan(&page.Total)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	query := "SELECT id, name, version FROM collections WHERE deleted_at IS NULL"
+	condition, args, order := keyset(sort, "id", opts.Desc, after)
+	if condition != "" {
+		query += " AND " + condition
+	}
+	query += " ORDER BY " + order
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit+1)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, Page{}, err
 	}
 	defer rows.Close()
 
 	var collections []models.Collection
 	for rows.Next() {
 		var c models.Collection
-		err := rows.Scan(&c.ID, &c.Name)
+		err := rows.Scan(&c.ID, &c.Name, &c.Version)
 		if err != nil {
-			return nil, err
+			return nil, Page{}, err
 		}
 		collections = append(collections, c)
 	}
-	return collections, nil
+	if err := rows.Err(); err != nil {
+		return nil, Page{}, err
+	}
+
+	collections, page.Next = paginate(collections, opts.Limit, func(c models.Collection) cursor {
+		if sort == "name" {
+			return cursor{Sort: sort, Desc: opts.Desc, Value: c.Name, ID: c.ID}
+		}
+		return cursor{Sort: sort, Desc: opts.Desc, ID: c.ID}
+	})
+	return collections, page, nil
 }
 
 func (db *DB) GetCollection(id int) (models.Collection, error) {
 	var c models.Collection
-	err := db.QueryRow("SELECT id, name FROM collections WHERE id = ?", id).Scan(&c.ID, &c.Name)
+	err := db.QueryRow("SELECT id, name, version FROM collections WHERE id = ? AND deleted_at IS NULL", id).Scan(&c.ID, &c.Name, &c.Version)
 	if err != nil {
-		return models.Collection{}, err
+		return models.Collection{}, translateError(err, "collection", id)
 	}
 
 	rows, err := db.Query(`
-		SELECT b.id, b.title, b.author, b.published_date, b.edition, b.description, b.genre, b.created_at, b.updated_at
+		SELECT `+bookColumns+`
 		FROM books b
 		JOIN collection_books cb ON b.id = cb.book_id
-		WHERE cb.collection_id = ?`, id)
+		WHERE cb.collection_id = ? AND b.deleted_at IS NULL
+		ORDER BY cb.added_at, b.id`, id)
 	if err != nil {
 		return models.Collection{}, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var b models.Book
-		var createdAt, updatedAt string
-		err := rows.Scan(&b.ID, &b.Title, &b.Author, &b.PublishedDate, &b.Edition, &b.Description, &b.Genre, &createdAt, &updatedAt)
+		b, err := scanBook(rows)
 		if err != nil {
 			return models.Collection{}, err
 		}
-		b.CreatedAt, err = time.Parse("2006-01-02 15:04:05", createdAt)
+		c.Books = append(c.Books, b)
+	}
+	if err := rows.Err(); err != nil {
+		return models.Collection{}, err
+	}
+
+	return c, loadRelationsOf(db, c.Books)
+}
+
+func (db *DB) CreateCollection(c models.Collection) (int, error) {
+	var id int
+	err := db.inTx(func(tx *Tx) error {
+		err := tx.QueryRow("INSERT INTO collections (name) VALUES (?) RETURNING id", c.Name).Scan(&id)
 		if err != nil {
-			return models.Collection{}, err
+			return translateError(err, "collection", 0)
+		}
+		if err := db.addCollectionRevision(tx, id); err != nil {
+			return err
 		}
-		b.UpdatedAt, err = time.Parse("2006-01-02 15:04:05", updatedAt)
+		return db.audit(tx, models.AuditEntry{Action: models.AuditCreate, Entity: models.AuditCollection, CollectionID: id},
+			models.Collection{}, models.Collection{Name: c.Name})
+	})
+	return id, err
+}
+
+// UpdateCollection renames the collection and bumps its version. A non-zero
+// c.Version makes the update conditional, like for UpdateBook.
+func (db *DB) UpdateCollection(c models.Collection) error {
+	query := "UPDATE collections SET name = ?, updated_at = CURRENT_TIMESTAMP, version = version + 1 WHERE id = ? AND deleted_at IS NULL"
+	args := []interface{}{c.Name, c.ID}
+	if c.Version != 0 {
+		query += " AND version = ?"
+		args = append(args, c.Version)
+	}
+	return db.inTx(func(tx *Tx) error {
+		before, err := collectionState(tx, c.ID)
 		if err != nil {
-			return models.Collection{}, err
+			return err
 		}
-		c.Books = append(c.Books, b)
+		res, err := tx.Exec(query, args...)
+		if err := expectVersion(tx, res, err, "collection", c.ID, c.Version); err != nil {
+			return err
+		}
+		if err := db.startCollectionRevisions(tx, c.ID, before); err != nil {
+			return err
+		}
+		if err := db.addCollectionRevision(tx, c.ID); err != nil {
+			return err
+		}
+		return db.audit(tx, models.AuditEntry{Action: models.AuditUpdate, Entity: models.AuditCollection, CollectionID: c.ID},
+			models.Collection{Name: before.Name}, models.Collection{Name: c.Name})
+	})
+}
+
+// DeleteCollection moves the collection to the trash along with its
+// memberships. A non-zero version makes the delete conditional, like for
+// UpdateBook.
+func (db *DB) DeleteCollection(id, version int) error {
+	query := "UPDATE collections SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP, version = version + 1 " +
+		"WHERE id = ? AND deleted_at IS NULL"
+	args := []interface{}{id}
+	if version != 0 {
+		query += " AND version = ?"
+		args = append(args, version)
 	}
+	return db.inTx(func(tx *Tx) error {
+		before, err := collectionState(tx, id)
+		if err != nil {
+			return err
+		}
+		res, err := tx.Exec(query, args...)
+		if err := expectVersion(tx, res, err, "collection", id, version); err != nil {
+			return err
+		}
+		return db.audit(tx, models.AuditEntry{Action: models.AuditDelete, Entity: models.AuditCollection, CollectionID: id},
+			models.Collection{Name: before.Name}, models.Collection{})
+	})
+}
+
+// AddBookToCollection fails with ErrNotFound if the collection or the book
+// does not exist and with ErrConflict if the book is already in the
+// collection. Membership is part of the collection, so its version is
+// bumped.
+func (db *DB) AddBookToCollection(collectionID, bookID int) error {
+	return db.inTx(func(tx *Tx) error {
+		if err := exists(tx, "collections", collectionID); err != nil {
+			return translateError(err, "collection", collectionID)
+		}
+		if err := exists(tx, "books", bookID); err != nil {
+			return translateError(err, "book", bookID)
+		}
+		before, err := collectionState(tx, collectionID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("INSERT INTO collection_books (collection_id, book_id) VALUES (?, ?)", collectionID, bookID)
+		if err = translateError(err, "book", bookID); errors.Is(err, ErrConflict) {
+			return fmt.Errorf("book %d is already in collection %d: %w", bookID, collectionID, ErrConflict)
+		} else if err != nil {
+			return err
+		}
+		if err := touchCollection(tx, collectionID); err != nil {
+			return err
+		}
+		if err := db.startCollectionRevisions(tx, collectionID, before); err != nil {
+			return err
+		}
+		if err := db.addCollectionRevision(tx, collectionID); err != nil {
+			return err
+		}
+		return db.audit(tx, models.AuditEntry{Action: models.AuditCreate, Entity: models.AuditMembership,
+			BookID: bookID, CollectionID: collectionID}, nil, nil)
+	})
+}
 
-	return c, nil
+// RemoveBookFromCollection fails with ErrNotFound if the book is not in the
+// collection. It bumps the version of the collection.
+func (db *DB) RemoveBookFromCollection(collectionID, bookID int) error {
+	return db.inTx(func(tx *Tx) error {
+		before, err := collectionState(tx, collectionID)
+		if err != nil {
+			return err
+		}
+		res, err := tx.Exec("DELETE FROM collection_books WHERE collection_id = ? AND book_id = ?", collectionID, bookID)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("book %d is not in collection %d: %w", bookID, collectionID, ErrNotFound)
+		}
+		if err := touchCollection(tx, collectionID); err != nil {
+			return err
+		}
+		if err := db.startCollectionRevisions(tx, collectionID, before); err != nil {
+			return err
+		}
+		if err := db.addCollectionRevision(tx, collectionID); err != nil {
+			return err
+		}
+		return db.audit(tx, models.AuditEntry{Action: models.AuditDelete, Entity: models.AuditMembership,
+			BookID: bookID, CollectionID: collectionID}, nil, nil)
+	})
 }
 
-func (db *DB) CreateCollection(c models.Collection) (int, error) {
-	result, err := db.Exec("INSERT INTO collections (name) VALUES (?)", c.Name)
+// loadBook loads a book with all its relations, also while it is in the
+// trash.
+func loadBook(q querier, id int) (models.Book, error) {
+	b, err := scanBook(q.QueryRow("SELECT "+bookColumns+" FROM books b WHERE b.id = ?", id))
 	if err != nil {
-		return 0, err
+		return models.Book{}, translateError(err, "book", id)
 	}
-	id, err := result.LastInsertId()
-	if err != nil {
-		return 0, err
+	return b, loadRelations(q, &b)
+}
+
+// changeBooks runs fn, which changes the books with the ids other than
+// through UpdateBook, such as their tags, series or cover. The books that
+// fn changed get a new version, an entry in the audit log and a revision,
+// and the collections they are in a new version.
+func (db *DB) changeBooks(tx *Tx, ids []int, fn func() error) error {
+	var before []models.Book
+	seen := map[int]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		b, err := loadBook(tx, id)
+		if err != nil {
+			return err
+		}
+		before = append(before, b)
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+
+	for _, b := range before {
+		after, err := loadBook(tx, b.ID)
+		if err != nil {
+			return err
+		}
+		changes, err := diffFields(b, after)
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		if err := touchBook(tx, b.ID); err != nil {
+			return err
+		}
+		if err := touchCollectionsOf(tx, b.ID); err != nil {
+			return err
+		}
+		if err := db.audit(tx, models.AuditEntry{Action: models.AuditUpdate, Entity: models.AuditBook, BookID: b.ID}, b, after); err != nil {
+			return err
+		}
+		if err := db.startBookRevisions(tx, b); err != nil {
+			return err
+		}
+		if err := db.addBookRevision(tx, after); err != nil {
+			return err
+		}
 	}
-	return int(id), nil
+	return nil
 }
 
-func (db *DB) UpdateCollection(c models.Collection) error {
-	_, err := db.Exec("UPDATE collections SET name = ? WHERE id = ?", c.Name, c.ID)
+// touchBook bumps the version of a book changed by changeBooks.
+func touchBook(q querier, id int) error {
+	_, err := q.Exec("UPDATE books SET updated_at = CURRENT_TIMESTAMP, version = version + 1 WHERE id = ?", id)
 	return err
 }
 
-func (db *DB) DeleteCollection(id int) error {
-	_, err := db.Exec("DELETE FROM collections WHERE id = ?", id)
+func touchCollection(q querier, id int) error {
+	_, err := q.Exec("UPDATE collections SET updated_at = CURRENT_TIMESTAMP, version = version + 1 WHERE id = ?", id)
 	return err
 }
 
-func (db *DB) AddBookToCollection(collectionID, bookID int) error {
-	_, err := db.Exec("INSERT INTO collection_books (collection_id, book_id) VALUES (?, ?)", collectionID, bookID)
+// touchCollectionsOf bumps the version of the collections a book is in,
+// whose books change when it is deleted or restored.
+func touchCollectionsOf(q querier, bookID int) error {
+	_, err := q.Exec("UPDATE collections SET updated_at = CURRENT_TIMESTAMP, version = version + 1 "+
+		"WHERE id IN (SELECT collection_id FROM collection_books WHERE book_id = ?)", bookID)
 	return err
 }
 
-func (db *DB) RemoveBookFromCollection(collectionID, bookID int) error {
-	_, err := db.Exec("DELETE FROM collection_books WHERE collection_id = ? AND book_id = ?", collectionID, bookID)
-	return err
+// queryIDs returns the ids selected by query, in the order of the rows.
+func queryIDs(q querier, query string, args ...interface{}) ([]int, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// exists returns sql.ErrNoRows unless table has a row with the id. Books and
+// collections in the trash do not count.
+func exists(q querier, table string, id int) error {
+	var found int
+	return q.QueryRow("SELECT 1 FROM "+table+" WHERE id = ?"+notTrashed(table), id).Scan(&found)
 }
 
 func (db *DB) IsBookInCollection(collectionID, bookID int) (bool, error) {
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// Errors returned by the stores wrap one of these, so callers can tell them
+// apart with errors.Is.
+var (
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("conflict")
+	ErrInvalid  = errors.New("invalid")
+
+	// ErrVersionMismatch is returned by conditional updates and deletes of
+	// records that were changed in the meantime.
+	ErrVersionMismatch = fmt.Errorf("version mismatch: %w", ErrConflict)
+)
+
+func notFound(kind string, id int) error {
+	return fmt.Errorf("%s %d: %w", kind, id, ErrNotFound)
+}
+
+// translateError maps driver errors for missing rows and constraint
+// violations to the errors above. The driver's message is left out, it talks
+// about tables and constraints rather than the request. Other errors are
+// returned unchanged.
+func translateError(err error, kind string, id int) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(kind, id)
+	}
+
+	var violation string
+	var sqliteErr sqlite3.Error
+	var pqErr *pq.Error
+	switch {
+	case errors.As(err, &sqliteErr):
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			violation = "unique"
+		case sqlite3.ErrConstraintNotNull, sqlite3.ErrConstraintCheck:
+			violation = "invalid"
+		case sqlite3.ErrConstraintForeignKey:
+			violation = "reference"
+		}
+	case errors.As(err, &pqErr):
+		switch pqErr.Code.Name() {
+		case "unique_violation":
+			violation = "unique"
+		case "not_null_violation", "check_violation":
+			violation = "invalid"
+		case "foreign_key_violation":
+			violation = "reference"
+		}
+	}
+
+	switch violation {
+	case "unique":
+		return fmt.Errorf("%s already exists: %w", kind, ErrConflict)
+	case "invalid":
+		return fmt.Errorf("%s has a missing or invalid value: %w", kind, ErrInvalid)
+	case "reference":
+		return fmt.Errorf("%s refers to a record that does not exist: %w", kind, ErrNotFound)
+	}
+	return err
+}
+
+// duplicateISBN names the ISBN in conflicts caused by the book's, which is
+// the only unique value of a book besides its ID.
+func duplicateISBN(err error, b models.Book) error {
+	if errors.Is(err, ErrConflict) && !errors.Is(err, ErrVersionMismatch) && b.ISBN13 != "" {
+		return fmt.Errorf("a book with ISBN %s already exists: %w", b.ISBN13, ErrConflict)
+	}
+	return err
+}
+
+// expectVersion turns an update or delete that matched no rows into an
+// error. If the statement was conditional on the version of the record, it
+// looks the record up to tell a missing one from a stale version.
+func expectVersion(q querier, res sql.Result, err error, kind string, id, version int) error {
+	if err != nil {
+		return translateError(err, kind, id)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	if version == 0 {
+		return notFound(kind, id)
+	}
+
+	var current int
+	err = q.QueryRow("SELECT version FROM "+kind+"s WHERE id = ?"+notTrashed(kind+"s"), id).Scan(&current)
+	if err != nil {
+		return translateError(err, kind, id)
+	}
+	return fmt.Errorf("%s %d is at version %d, not %d: %w", kind, id, current, version, ErrVersionMismatch)
+}
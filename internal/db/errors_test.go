@@ -0,0 +1,86 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore_NotFound(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		_, err := store.GetBook(42)
+		assert.ErrorIs(t, err, ErrNotFound)
+		err = store.UpdateBook(models.Book{ID: 42, Title: "Missing", Author: "Nobody", PublishedDate: "2022-01-01"})
+		assert.ErrorIs(t, err, ErrNotFound)
+		err = store.DeleteBook(42, 0)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		_, err = store.GetCollection(42)
+		assert.ErrorIs(t, err, ErrNotFound)
+		err = store.UpdateCollection(models.Collection{ID: 42, Name: "Missing"})
+		assert.ErrorIs(t, err, ErrNotFound)
+		err = store.DeleteCollection(42, 0)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestStore_Memberships(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		bookID, err := store.CreateBook(models.Book{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01"})
+		assert.NoError(t, err)
+		collectionID, err := store.CreateCollection(models.Collection{Name: "Favourites"})
+		assert.NoError(t, err)
+
+		// Both sides of the membership must exist
+		err = store.AddBookToCollection(42, bookID)
+		assert.ErrorIs(t, err, ErrNotFound)
+		err = store.AddBookToCollection(collectionID, 42)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		// A book can only be added once
+		err = store.AddBookToCollection(collectionID, bookID)
+		assert.NoError(t, err)
+		err = store.AddBookToCollection(collectionID, bookID)
+		assert.ErrorIs(t, err, ErrConflict)
+
+		// Only books in the collection can be removed
+		err = store.RemoveBookFromCollection(collectionID, bookID)
+		assert.NoError(t, err)
+		err = store.RemoveBookFromCollection(collectionID, bookID)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestErrorKinds(t *testing.T) {
+	assert.ErrorIs(t, ErrInvalidCursor, ErrInvalid)
+	assert.ErrorIs(t, ErrInvalidSort, ErrInvalid)
+	assert.EqualError(t, notFound("book", 7), "book 7: not found")
+}
+
+func TestStore_ISBN(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		book := models.Book{Title: "Effective Java", Author: "Joshua Bloch", PublishedDate: "2017-12-27",
+			ISBN10: "0134685997", ISBN13: "9780134685991"}
+		id, err := store.CreateBook(book)
+		assert.NoError(t, err)
+
+		// Books without an ISBN do not collide
+		_, err = store.CreateBook(models.Book{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01"})
+		assert.NoError(t, err)
+		otherID, err := store.CreateBook(models.Book{Title: "Emma", Author: "Jane Austen", PublishedDate: "1815-12-23"})
+		assert.NoError(t, err)
+
+		_, err = store.CreateBook(book)
+		assert.ErrorIs(t, err, ErrConflict)
+		assert.EqualError(t, err, "a book with ISBN 9780134685991 already exists: conflict")
+		err = store.UpdateBook(models.Book{ID: otherID, Title: "Emma", Author: "Jane Austen", PublishedDate: "1815-12-23", ISBN13: "9780134685991"})
+		assert.ErrorIs(t, err, ErrConflict)
+
+		books, page, err := store.GetBooks(BookFilter{ISBN: "9780134685991"}, ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, page.Total)
+		assert.Equal(t, id, books[0].ID)
+		assert.Equal(t, "0134685997", books[0].ISBN10)
+	})
+}
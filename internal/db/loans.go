@@ -0,0 +1,237 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// LoanSortFields are the fields loan lists can be ordered by.
+var LoanSortFields = []string{"id", "loaned_at", "due_at"}
+
+// LoanFilter restricts GetLoans to the loans of a book or a borrower. Open
+// keeps the books that are still out and Overdue those of them past their
+// due date.
+type LoanFilter struct {
+	BookID   int
+	Borrower string
+	Open     bool
+	Overdue  bool
+}
+
+// checkLoan validates a loan about to be written. A loan without a date was
+// made today, the borrower and contact are trimmed.
+func checkLoan(l *models.Loan) error {
+	l.Borrower, l.Contact = strings.TrimSpace(l.Borrower), strings.TrimSpace(l.Contact)
+	if l.Borrower == "" {
+		return fmt.Errorf("%w loan, the borrower is empty", ErrInvalid)
+	}
+	if l.LoanedAt == "" {
+		l.LoanedAt = time.Now().Format(dateLayout)
+	}
+	if l.DueAt == "" {
+		return fmt.Errorf("%w loan, the due date is empty", ErrInvalid)
+	}
+	for _, date := range []string{l.LoanedAt, l.DueAt, l.ReturnedAt} {
+		if _, err := time.Parse(dateLayout, date); date != "" && err != nil {
+			return fmt.Errorf("%w date %q, expected YYYY-MM-DD", ErrInvalid, date)
+		}
+	}
+	if l.DueAt < l.LoanedAt {
+		return fmt.Errorf("%w loan, due on %s before being loaned on %s", ErrInvalid, l.DueAt, l.LoanedAt)
+	}
+	if l.ReturnedAt != "" && l.ReturnedAt < l.LoanedAt {
+		return fmt.Errorf("%w loan, returned on %s before being loaned on %s", ErrInvalid, l.ReturnedAt, l.LoanedAt)
+	}
+	return nil
+}
+
+// overdue tells whether the loan is past its due date and not returned. A
+// book due today is not overdue yet.
+func overdue(l models.Loan, today string) bool {
+	return l.ReturnedAt == "" && l.DueAt < today
+}
+
+// duplicateLoan explains the conflict caused by lending a book that is
+// already out.
+func duplicateLoan(err error, bookID int) error {
+	if errors.Is(err, ErrConflict) {
+		return fmt.Errorf("book %d is already on loan, it has to be returned first: %w", bookID, ErrConflict)
+	}
+	return err
+}
+
+const loanColumns = "l.id, l.book_id, l.borrower, l.contact, l.loaned_at, l.due_at, l.returned_at, l.created_at, l.updated_at"
+
+func scanLoan(row scanner, today string) (models.Loan, error) {
+	var l models.Loan
+	err := row.Scan(&l.ID, &l.BookID, &l.Borrower, &l.Contact, &l.LoanedAt, &l.DueAt, &l.ReturnedAt, timestamp{&l.CreatedAt}, timestamp{&l.UpdatedAt})
+	l.Overdue = overdue(l, today)
+	return l, err
+}
+
+// GetLoans returns a page of the loans matching the filter.
+func (db *DB) GetLoans(filter LoanFilter, opts ListOptions) ([]models.Loan, Page, error) {
+	sort, err := opts.sortField(LoanSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	today := time.Now().Format(dateLayout)
+	// Loans of books in the trash are hidden with their books
+	where := " JOIN books b ON b.id = l.book_id WHERE b.deleted_at IS NULL"
+	args := []interface{}{}
+	if filter.BookID != 0 {
+		where += " AND l.book_id = ?"
+		args = append(args, filter.BookID)
+	}
+	if filter.Borrower != "" {
+		where += " AND l.borrower = ?"
+		args = append(args, strings.TrimSpace(filter.Borrower))
+	}
+	if filter.Open || filter.Overdue {
+		where += " AND l.returned_at = ''"
+	}
+	if filter.Overdue {
+		where += " AND l.due_at < ?"
+		args = append(args, today)
+	}
+
+	var page Page
+	err = db.QueryRow("SELECT COUNT(*) FROM loans l"+where, args...).Scan(&page.Total)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	condition, keysetArgs, order := keyset("l."+sort, "l.id", opts.Desc, after)
+	if condition != "" {
+		where += " AND " + condition
+		args = append(args, keysetArgs...)
+	}
+	query := "SELECT " + loanColumns + " FROM loans l" + where + " ORDER BY " + order
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit+1)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	defer rows.Close()
+
+	var loans []models.Loan
+	for rows.Next() {
+		l, err := scanLoan(rows, today)
+		if err != nil {
+			return nil, Page{}, err
+		}
+		loans = append(loans, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, Page{}, err
+	}
+
+	loans, page.Next = paginate(loans, opts.Limit, func(l models.Loan) cursor {
+		return cursor{Sort: sort, Desc: opts.Desc, Value: loanSortValue(l, sort), ID: l.ID}
+	})
+	return loans, page, nil
+}
+
+func loanSortValue(l models.Loan, sort string) string {
+	switch sort {
+	case "loaned_at":
+		return l.LoanedAt
+	case "due_at":
+		return l.DueAt
+	}
+	return ""
+}
+
+func getLoan(q querier, id int) (models.Loan, error) {
+	l, err := scanLoan(q.QueryRow("SELECT "+loanColumns+" FROM loans l WHERE l.id = ?", id), time.Now().Format(dateLayout))
+	if err != nil {
+		return models.Loan{}, translateError(err, "loan", id)
+	}
+	return l, nil
+}
+
+func (db *DB) GetLoan(id int) (models.Loan, error) {
+	return getLoan(db, id)
+}
+
+// CreateLoan lends the book. It fails with ErrConflict while the book is out
+// on another loan.
+func (db *DB) CreateLoan(l models.Loan) (int, error) {
+	if err := checkLoan(&l); err != nil {
+		return 0, err
+	}
+	var id int
+	err := db.inTx(func(tx *Tx) error {
+		if err := exists(tx, "books", l.BookID); err != nil {
+			return translateError(err, "book", l.BookID)
+		}
+		err := tx.QueryRow("INSERT INTO loans (book_id, borrower, contact, loaned_at, due_at, returned_at) VALUES (?, ?, ?, ?, ?, ?) RETURNING id",
+			l.BookID, l.Borrower, l.Contact, l.LoanedAt, l.DueAt, l.ReturnedAt).Scan(&id)
+		return duplicateLoan(translateError(err, "loan", 0), l.BookID)
+	})
+	return id, err
+}
+
+// UpdateLoan replaces the borrower, contact and dates of the loan. Clearing
+// the return date of a book that was lent again since fails with
+// ErrConflict.
+func (db *DB) UpdateLoan(l models.Loan) error {
+	if err := checkLoan(&l); err != nil {
+		return err
+	}
+	return db.inTx(func(tx *Tx) error {
+		var bookID int
+		if err := tx.QueryRow("SELECT book_id FROM loans WHERE id = ?", l.ID).Scan(&bookID); err != nil {
+			return translateError(err, "loan", l.ID)
+		}
+		_, err := tx.Exec("UPDATE loans SET borrower = ?, contact = ?, loaned_at = ?, due_at = ?, returned_at = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+			l.Borrower, l.Contact, l.LoanedAt, l.DueAt, l.ReturnedAt, l.ID)
+		return duplicateLoan(translateError(err, "loan", l.ID), bookID)
+	})
+}
+
+// ReturnLoan records that the book came back on the date, today if it is
+// empty. It fails with ErrConflict if the loan was already returned.
+func (db *DB) ReturnLoan(id int, returnedAt string) error {
+	return db.inTx(func(tx *Tx) error {
+		l, err := getLoan(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := returnLoan(&l, returnedAt); err != nil {
+			return err
+		}
+		_, err = tx.Exec("UPDATE loans SET returned_at = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", l.ReturnedAt, id)
+		return err
+	})
+}
+
+// returnLoan sets the return date of an open loan and checks it.
+func returnLoan(l *models.Loan, returnedAt string) error {
+	if l.ReturnedAt != "" {
+		return fmt.Errorf("loan %d was already returned on %s: %w", l.ID, l.ReturnedAt, ErrConflict)
+	}
+	if returnedAt == "" {
+		returnedAt = time.Now().Format(dateLayout)
+	}
+	l.ReturnedAt = returnedAt
+	return checkLoan(l)
+}
+
+func (db *DB) DeleteLoan(id int) error {
+	res, err := db.Exec("DELETE FROM loans WHERE id = ?", id)
+	return expectVersion(db, res, err, "loan", id, 0)
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckLoan(t *testing.T) {
+	l := models.Loan{Borrower: " ada ", Contact: " ada@example.com", DueAt: "2099-01-01"}
+	assert.NoError(t, checkLoan(&l))
+	assert.Equal(t, "ada", l.Borrower)
+	assert.Equal(t, "ada@example.com", l.Contact)
+	assert.Equal(t, time.Now().Format(dateLayout), l.LoanedAt)
+
+	for _, l := range []models.Loan{
+		{Borrower: " ", DueAt: "2024-03-01"},
+		{Borrower: "ada"},
+		{Borrower: "ada", DueAt: "March"},
+		{Borrower: "ada", LoanedAt: "2024-03-10", DueAt: "2024-03-01"},
+		{Borrower: "ada", LoanedAt: "2024-03-10", DueAt: "2024-03-20", ReturnedAt: "2024-03-09"},
+	} {
+		assert.ErrorIs(t, checkLoan(&l), ErrInvalid, l)
+	}
+
+	assert.True(t, overdue(models.Loan{DueAt: "2024-03-01"}, "2024-03-02"))
+	assert.False(t, overdue(models.Loan{DueAt: "2024-03-01"}, "2024-03-01"))
+	assert.False(t, overdue(models.Loan{DueAt: "2024-03-01", ReturnedAt: "2024-03-05"}, "2024-03-06"))
+}
+
+func TestStore_Loans(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		duneID, err := store.CreateBook(models.Book{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01"})
+		assert.NoError(t, err)
+		hobbitID, err := store.CreateBook(models.Book{Title: "The Hobbit", Author: "J. R. R. Tolkien", PublishedDate: "1937-09-21"})
+		assert.NoError(t, err)
+
+		lateID, err := store.CreateLoan(models.Loan{BookID: duneID, Borrower: "ada", LoanedAt: "2024-03-01", DueAt: "2024-03-15"})
+		assert.NoError(t, err)
+		_, err = store.CreateLoan(models.Loan{BookID: duneID, Borrower: "grace", DueAt: "2099-01-01"})
+		assert.ErrorIs(t, err, ErrConflict)
+		_, err = store.CreateLoan(models.Loan{BookID: 42, Borrower: "grace", DueAt: "2099-01-01"})
+		assert.ErrorIs(t, err, ErrNotFound)
+		hobbitLoanID, err := store.CreateLoan(models.Loan{BookID: hobbitID, Borrower: "grace", Contact: "grace@example.com", DueAt: "2099-01-01"})
+		assert.NoError(t, err)
+
+		loan, err := store.GetLoan(lateID)
+		assert.NoError(t, err)
+		assert.Equal(t, "ada", loan.Borrower)
+		assert.True(t, loan.Overdue)
+
+		ids := func(filter LoanFilter, opts ListOptions) []int {
+			loans, _, err := store.GetLoans(filter, opts)
+			assert.NoError(t, err)
+			var ids []int
+			for _, l := range loans {
+				ids = append(ids, l.ID)
+			}
+			return ids
+		}
+		assert.Equal(t, []int{lateID}, ids(LoanFilter{Overdue: true}, ListOptions{}))
+		assert.Equal(t, []int{hobbitLoanID, lateID}, ids(LoanFilter{Open: true}, ListOptions{Sort: "due_at", Desc: true}))
+		assert.Equal(t, []int{hobbitLoanID}, ids(LoanFilter{Borrower: "grace"}, ListOptions{}))
+
+		// Once returned the book can be lent again and the loan stays in
+		// its history
+		assert.NoError(t, store.ReturnLoan(lateID, "2024-03-20"))
+		assert.ErrorIs(t, store.ReturnLoan(lateID, ""), ErrConflict)
+		assert.ErrorIs(t, store.ReturnLoan(42, ""), ErrNotFound)
+		loan, err = store.GetLoan(lateID)
+		assert.NoError(t, err)
+		assert.Equal(t, "2024-03-20", loan.ReturnedAt)
+		assert.False(t, loan.Overdue)
+		assert.Empty(t, ids(LoanFilter{Overdue: true}, ListOptions{}))
+
+		againID, err := store.CreateLoan(models.Loan{BookID: duneID, Borrower: "grace", DueAt: "2099-01-01"})
+		assert.NoError(t, err)
+		assert.Equal(t, []int{lateID, againID}, ids(LoanFilter{BookID: duneID}, ListOptions{}))
+
+		// The first loan cannot be reopened while the book is out again
+		loan.ReturnedAt = ""
+		assert.ErrorIs(t, store.UpdateLoan(loan), ErrConflict)
+		loan.ReturnedAt, loan.Contact = "2024-03-18", "ada@example.com"
+		assert.NoError(t, store.UpdateLoan(loan))
+		loan, err = store.GetLoan(lateID)
+		assert.NoError(t, err)
+		assert.Equal(t, "2024-03-18", loan.ReturnedAt)
+		assert.Equal(t, "ada@example.com", loan.Contact)
+
+		assert.NoError(t, store.DeleteLoan(againID))
+		assert.ErrorIs(t, store.DeleteLoan(againID), ErrNotFound)
+		assert.NoError(t, store.DeleteBook(duneID, 0))
+		emptyTrash(t, store)
+		assert.Empty(t, ids(LoanFilter{BookID: duneID}, ListOptions{}))
+	})
+}
@@ -0,0 +1,2849 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/mayank-02/bookman/internal/pubdate"
+)
+
+// MemoryStore is a Store that keeps all data in memory. It is safe for
+// concurrent use and mirrors the semantics of the SQL schema, which makes it
+// suitable for tests and for embedding bookman without a database.
+type MemoryStore struct {
+	*memoryData
+	actor string // named in the audit log, see WithActor
+}
+
+// memoryData is the data of a MemoryStore, which the stores returned by
+// WithActor share.
+type memoryData struct {
+	mu               sync.RWMutex
+	books            map[int]models.Book
+	collections      map[int]models.Collection
+	collectionBooks  map[int][]int
+	authors          map[int]models.Author
+	tags             map[int]models.Tag
+	series           map[int]models.Series
+	seriesBooks      map[int]map[int]float64 // positions of books by series and book ID
+	readingSessions  map[int]models.ReadingSession
+	reviews          map[int]models.Review
+	loans            map[int]models.Loan
+	locations        map[int]models.Location
+	copies           map[int]models.Copy
+	works            map[int]models.Work
+	publishers       map[int]models.Publisher
+	customFields     map[int]models.CustomField
+	covers           map[int]models.Cover // by book ID
+	deletedBooks     map[int]time.Time    // books in the trash and when they were deleted
+	deletedCols      map[int]time.Time    // collections in the trash
+	auditLog         []models.AuditEntry
+	bookRevisions    map[int][]models.BookRevision // by book ID, oldest first
+	colRevisions     map[int][]models.CollectionRevision
+	nextBookID       int
+	nextCollectionID int
+	nextAuthorID     int
+	nextTagID        int
+	nextSeriesID     int
+	nextSessionID    int
+	nextProgressID   int
+	nextReviewID     int
+	nextLoanID       int
+	nextLocationID   int
+	nextCopyID       int
+	nextWorkID       int
+	nextPublisherID  int
+	nextFieldID      int
+	nextAuditID      int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{memoryData: &memoryData{
+		books:            map[int]models.Book{},
+		collections:      map[int]models.Collection{},
+		collectionBooks:  map[int][]int{},
+		authors:          map[int]models.Author{},
+		tags:             map[int]models.Tag{},
+		series:           map[int]models.Series{},
+		seriesBooks:      map[int]map[int]float64{},
+		readingSessions:  map[int]models.ReadingSession{},
+		reviews:          map[int]models.Review{},
+		loans:            map[int]models.Loan{},
+		locations:        map[int]models.Location{},
+		copies:           map[int]models.Copy{},
+		works:            map[int]models.Work{},
+		publishers:       map[int]models.Publisher{},
+		customFields:     map[int]models.CustomField{},
+		covers:           map[int]models.Cover{},
+		deletedBooks:     map[int]time.Time{},
+		deletedCols:      map[int]time.Time{},
+		bookRevisions:    map[int][]models.BookRevision{},
+		colRevisions:     map[int][]models.CollectionRevision{},
+		nextBookID:       1,
+		nextCollectionID: 1,
+		nextAuthorID:     1,
+		nextTagID:        1,
+		nextSeriesID:     1,
+		nextSessionID:    1,
+		nextProgressID:   1,
+		nextReviewID:     1,
+		nextLoanID:       1,
+		nextLocationID:   1,
+		nextCopyID:       1,
+		nextWorkID:       1,
+		nextPublisherID:  1,
+		nextFieldID:      1,
+		nextAuditID:      1,
+	}}
+}
+
+// now matches the second precision of the timestamps stored by SQLite.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+func (m *MemoryStore) GetBook(id int) (models.Book, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, ok := m.liveBook(id)
+	if !ok {
+		return models.Book{}, notFound("book", id)
+	}
+	return m.book(b), nil
+}
+
+// book returns a copy of a stored book that the caller may modify, with the
+// series it belongs to, its reading status and its rating.
+func (m *MemoryStore) book(b models.Book) models.Book {
+	b.Authors = append([]models.BookAuthor{}, b.Authors...)
+	b.Tags = append([]string{}, b.Tags...)
+	custom := b.Custom
+	b.Custom = map[string]interface{}{}
+	for name, value := range custom {
+		b.Custom[name] = value
+	}
+	b.Series = []models.BookSeries{}
+	for seriesID, positions := range m.seriesBooks {
+		if position, ok := positions[b.ID]; ok {
+			b.Series = append(b.Series, models.BookSeries{ID: seriesID, Name: m.series[seriesID].Name, Position: position})
+		}
+	}
+	sort.Slice(b.Series, func(i, j int) bool { return b.Series[i].Name < b.Series[j].Name })
+	b.Cover = nil
+	if c, ok := m.covers[b.ID]; ok {
+		c.SetURLs(b.ID)
+		b.Cover = &c
+	}
+	latest, _ := m.latestSession(b.ID)
+	b.ReadingStatus = latest.Status
+	b.AverageRating, b.RatingCount = m.rating(b.ID)
+	return b
+}
+
+func (m *MemoryStore) GetBooks(filter BookFilter, opts ListOptions) ([]models.Book, Page, error) {
+	sort, err := opts.sortField(BookSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	matches, err := customFilter(m.customFieldsByName(), filter.Custom)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	from, to, err := publishedRange(filter)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	var books []models.Book
+	for _, b := range m.books {
+		if _, ok := m.deletedBooks[b.ID]; ok {
+			continue
+		}
+		if filter.Author != "" && b.Author != filter.Author && !slices.ContainsFunc(b.Authors, func(a models.BookAuthor) bool { return a.Name == filter.Author }) {
+			continue
+		}
+		if filter.AuthorID != 0 && !slices.ContainsFunc(b.Authors, func(a models.BookAuthor) bool { return a.ID == filter.AuthorID }) {
+			continue
+		}
+		if filter.Genre != "" && b.Genre != filter.Genre {
+			continue
+		}
+		if !hasTags(b, tagFilter(filter.Tags)) {
+			continue
+		}
+		published, _ := pubdate.Parse(b.PublishedDate)
+		if from != "" && published.Latest < from {
+			continue
+		}
+		if to != "" && published.Earliest > to {
+			continue
+		}
+		if filter.ISBN != "" && b.ISBN13 != filter.ISBN {
+			continue
+		}
+		if latest, _ := m.latestSession(b.ID); filter.Status != "" && latest.Status != filter.Status {
+			continue
+		}
+		if average, _ := m.rating(b.ID); average < filter.MinRating {
+			continue
+		}
+		if filter.Publisher != "" && b.Publisher != filter.Publisher {
+			continue
+		}
+		if filter.Language != "" && b.Language != filter.Language && !strings.HasPrefix(b.Language, filter.Language+"-") {
+			continue
+		}
+		if filter.Format != "" && b.Format != filter.Format {
+			continue
+		}
+		if b.PageCount < filter.MinPages || !withinLimit(float64(b.PageCount), float64(filter.MaxPages)) {
+			continue
+		}
+		if !withinLimit(b.Dimensions.Height, filter.MaxHeight) || !withinLimit(b.Dimensions.Width, filter.MaxWidth) ||
+			!withinLimit(b.Dimensions.Thickness, filter.MaxThickness) {
+			continue
+		}
+		if slices.ContainsFunc(matches, func(match customMatch) bool {
+			text, _, _ := customText(match.Field, b.Custom[match.Field.Name])
+			return text != match.Text
+		}) {
+			continue
+		}
+		if filter.WorkID != 0 && derefID(b.WorkID) != filter.WorkID {
+			continue
+		}
+		books = append(books, m.book(b))
+	}
+	if filter.CollapseWorks {
+		books = collapseWorks(books)
+	}
+
+	value := func(b models.Book) string { return memorySortValue(b, sort) }
+	books, page := memoryPage(books, sort, opts, after, value, func(b models.Book) int { return b.ID })
+	return books, page, nil
+}
+
+func (m *MemoryStore) CreateBook(b models.Book) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkISBN(b); err != nil {
+		return 0, err
+	}
+	tags, err := bookTags(b.Tags)
+	if err != nil {
+		return 0, err
+	}
+	custom, err := m.bookCustom(b.Custom)
+	if err != nil {
+		return 0, err
+	}
+	published, err := publishedDate(b.PublishedDate)
+	if err != nil {
+		return 0, err
+	}
+	if err := m.setAuthors(&b); err != nil {
+		return 0, err
+	}
+	b.Custom = custom
+	b.PublishedDate, b.PublishedPrecision = published.String(), published.Precision
+	b.Tags = m.ensureTags(tags)
+	b.Publisher = m.ensurePublisher(b.Publisher)
+	b.WorkID = nil
+	b.ID = m.nextBookID
+	b.CreatedAt = now()
+	b.UpdatedAt = b.CreatedAt
+	b.Version = 1
+	m.books[b.ID] = b
+	m.nextBookID++
+	m.addBookRevision(m.book(b))
+	if err := m.audit(models.AuditEntry{Action: models.AuditCreate, Entity: models.AuditBook, BookID: b.ID}, models.Book{}, m.book(b)); err != nil {
+		return 0, err
+	}
+	return b.ID, nil
+}
+
+func (m *MemoryStore) UpdateBook(b models.Book) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.updateBook(b)
+}
+
+// updateBook is UpdateBook with the write lock held.
+func (m *MemoryStore) updateBook(b models.Book) error {
+	existing, ok := m.liveBook(b.ID)
+	if !ok {
+		return notFound("book", b.ID)
+	}
+	if err := checkVersion("book", b.ID, existing.Version, b.Version); err != nil {
+		return err
+	}
+	before := m.book(existing)
+	if err := m.checkISBN(b); err != nil {
+		return err
+	}
+	tags, err := bookTags(b.Tags)
+	if err != nil {
+		return err
+	}
+	custom, err := m.bookCustom(b.Custom)
+	if err != nil {
+		return err
+	}
+	published, err := publishedDate(b.PublishedDate)
+	if err != nil {
+		return err
+	}
+	if err := m.setAuthors(&b); err != nil {
+		return err
+	}
+	b.Custom = custom
+	b.PublishedDate, b.PublishedPrecision = published.String(), published.Precision
+	b.Tags = m.ensureTags(tags)
+	b.Publisher = m.ensurePublisher(b.Publisher)
+	b.WorkID = existing.WorkID
+	b.CreatedAt = existing.CreatedAt
+	b.UpdatedAt = now()
+	b.Version = existing.Version + 1
+	m.books[b.ID] = b
+	after := m.book(b)
+	m.startBookRevisions(before)
+	m.addBookRevision(after)
+	m.touchCollectionsOf(b.ID)
+	return m.audit(models.AuditEntry{Action: models.AuditUpdate, Entity: models.AuditBook, BookID: b.ID}, before, after)
+}
+
+func (m *MemoryStore) DeleteBook(id, version int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.liveBook(id)
+	if !ok {
+		return notFound("book", id)
+	}
+	if err := checkVersion("book", id, existing.Version, version); err != nil {
+		return err
+	}
+	before := m.book(existing)
+	m.deletedBooks[id] = now()
+	m.touchBook(id)
+	m.touchCollectionsOf(id)
+	return m.audit(models.AuditEntry{Action: models.AuditDelete, Entity: models.AuditBook, BookID: id}, before, models.Book{})
+}
+
+func (m *MemoryStore) SetBookCover(bookID int, c models.Cover) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.liveBook(bookID); !ok {
+		return notFound("book", bookID)
+	}
+	c.URL, c.Thumbnails = "", nil
+	c.UpdatedAt = now()
+	return m.changeBooks([]int{bookID}, func() error {
+		m.covers[bookID] = c
+		return nil
+	})
+}
+
+func (m *MemoryStore) DeleteBookCover(bookID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.liveBook(bookID); !ok {
+		return notFound("book", bookID)
+	}
+	if _, ok := m.covers[bookID]; !ok {
+		return notFound("cover of book", bookID)
+	}
+	return m.changeBooks([]int{bookID}, func() error {
+		delete(m.covers, bookID)
+		return nil
+	})
+}
+
+func (m *MemoryStore) GetCollections(opts ListOptions) ([]models.Collection, Page, error) {
+	sort, err := opts.sortField(CollectionSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var collections []models.Collection
+	for _, c := range m.collections {
+		if _, ok := m.deletedCols[c.ID]; ok {
+			continue
+		}
+		collections = append(collections, models.Collection{ID: c.ID, Name: c.Name, Version: c.Version})
+	}
+
+	value := func(c models.Collection) string {
+		if sort == "name" {
+			return c.Name
+		}
+		return ""
+	}
+	collections, page := memoryPage(collections, sort, opts, after, value, func(c models.Collection) int { return c.ID })
+	return collections, page, nil
+}
+
+func (m *MemoryStore) GetCollection(id int) (models.Collection, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, ok := m.liveCollection(id)
+	if !ok {
+		return models.Collection{}, notFound("collection", id)
+	}
+	collection := models.Collection{ID: c.ID, Name: c.Name, Version: c.Version}
+	for _, bookID := range m.collectionBooks[id] {
+		if b, ok := m.liveBook(bookID); ok {
+			collection.Books = append(collection.Books, m.book(b))
+		}
+	}
+	return collection, nil
+}
+
+func (m *MemoryStore) CreateCollection(c models.Collection) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c.ID = m.nextCollectionID
+	c.Books = nil
+	c.Version = 1
+	m.collections[c.ID] = c
+	m.nextCollectionID++
+	m.addCollectionRevision(c.ID)
+	err := m.audit(models.AuditEntry{Action: models.AuditCreate, Entity: models.AuditCollection, CollectionID: c.ID},
+		models.Collection{}, models.Collection{Name: c.Name})
+	if err != nil {
+		return 0, err
+	}
+	return c.ID, nil
+}
+
+func (m *MemoryStore) UpdateCollection(c models.Collection) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.liveCollection(c.ID)
+	if !ok {
+		return notFound("collection", c.ID)
+	}
+	if err := checkVersion("collection", c.ID, existing.Version, c.Version); err != nil {
+		return err
+	}
+	before := m.collectionState(c.ID)
+	existing.Name = c.Name
+	existing.Version++
+	m.collections[c.ID] = existing
+	m.startCollectionRevisions(c.ID, before)
+	m.addCollectionRevision(c.ID)
+	return m.audit(models.AuditEntry{Action: models.AuditUpdate, Entity: models.AuditCollection, CollectionID: c.ID},
+		models.Collection{Name: before.Name}, models.Collection{Name: c.Name})
+}
+
+func (m *MemoryStore) DeleteCollection(id, version int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.liveCollection(id)
+	if !ok {
+		return notFound("collection", id)
+	}
+	if err := checkVersion("collection", id, existing.Version, version); err != nil {
+		return err
+	}
+	m.deletedCols[id] = now()
+	m.touchCollection(id)
+	return m.audit(models.AuditEntry{Action: models.AuditDelete, Entity: models.AuditCollection, CollectionID: id},
+		models.Collection{Name: existing.Name}, models.Collection{})
+}
+
+func (m *MemoryStore) AddBookToCollection(collectionID, bookID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.liveCollection(collectionID); !ok {
+		return notFound("collection", collectionID)
+	}
+	if _, ok := m.liveBook(bookID); !ok {
+		return notFound("book", bookID)
+	}
+	for _, id := range m.collectionBooks[collectionID] {
+		if id == bookID {
+			return fmt.Errorf("book %d is already in collection %d: %w", bookID, collectionID, ErrConflict)
+		}
+	}
+	before := m.collectionState(collectionID)
+	m.collectionBooks[collectionID] = append(m.collectionBooks[collectionID], bookID)
+	m.touchCollection(collectionID)
+	m.startCollectionRevisions(collectionID, before)
+	m.addCollectionRevision(collectionID)
+	return m.audit(models.AuditEntry{Action: models.AuditCreate, Entity: models.AuditMembership,
+		BookID: bookID, CollectionID: collectionID}, nil, nil)
+}
+
+func (m *MemoryStore) RemoveBookFromCollection(collectionID, bookID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.liveCollection(collectionID); !ok {
+		return notFound("collection", collectionID)
+	}
+	before := m.collectionState(collectionID)
+	if !m.removeMembership(collectionID, bookID) {
+		return fmt.Errorf("book %d is not in collection %d: %w", bookID, collectionID, ErrNotFound)
+	}
+	m.touchCollection(collectionID)
+	m.startCollectionRevisions(collectionID, before)
+	m.addCollectionRevision(collectionID)
+	return m.audit(models.AuditEntry{Action: models.AuditDelete, Entity: models.AuditMembership,
+		BookID: bookID, CollectionID: collectionID}, nil, nil)
+}
+
+func (m *MemoryStore) IsBookInCollection(collectionID, bookID int) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, id := range m.collectionBooks[collectionID] {
+		if id == bookID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SearchBooks scores books like the SQL backends do: every word of a field
+// that starts with one of the terms counts as a hit, weighted by field.
+func (m *MemoryStore) SearchBooks(query string, limit int) ([]models.BookSearchResult, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var results []models.BookSearchResult
+	for _, b := range m.books {
+		if _, ok := m.deletedBooks[b.ID]; ok {
+			continue
+		}
+		fields := []string{b.Title, b.Author, b.Description, b.Genre}
+		var score, best float64
+		var snippet string
+		for i, field := range fields {
+			hits := 0
+			for _, word := range searchTerms(field) {
+				if matchesAnyTerm(word, terms) {
+					hits++
+				}
+			}
+			fieldScore := searchColumns[i].weight * float64(hits)
+			score += fieldScore
+			if fieldScore > best {
+				best = fieldScore
+				snippet = highlight(field, terms)
+			}
+		}
+		if score > 0 {
+			results = append(results, models.BookSearchResult{Book: m.book(b), Score: score, Snippet: snippet})
+		}
+	}
+
+	sortSearchResults(results)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// memorySortValue renders a book's sort field so that comparing the strings
+// orders the books like the SQL backends do.
+func memorySortValue(b models.Book, field string) string {
+	switch field {
+	case "title":
+		return b.Title
+	case "author":
+		return b.Author
+	case "published_date":
+		return publishedStart(b)
+	case "created_at":
+		return b.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000000Z")
+	case "rating":
+		return strconv.FormatFloat(b.AverageRating, 'f', -1, 64)
+	}
+	return ""
+}
+
+// memoryPage sorts items by their sort value and id and cuts out the page
+// after the cursor.
+func memoryPage[T any](items []T, field string, opts ListOptions, after *cursor, value func(T) string, id func(T) int) ([]T, Page) {
+	less := func(a, b T) bool {
+		if va, vb := value(a), value(b); va != vb {
+			return va < vb
+		}
+		return id(a) < id(b)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if opts.Desc {
+			return less(items[j], items[i])
+		}
+		return less(items[i], items[j])
+	})
+
+	page := Page{Total: len(items)}
+	if after != nil {
+		start := sort.Search(len(items), func(i int) bool {
+			if v := value(items[i]); v != after.Value {
+				return (v > after.Value) != opts.Desc
+			}
+			if opts.Desc {
+				return id(items[i]) < after.ID
+			}
+			return id(items[i]) > after.ID
+		})
+		items = items[start:]
+	}
+	items, page.Next = paginate(items, opts.Limit, func(item T) cursor {
+		return cursor{Sort: field, Desc: opts.Desc, Value: value(item), ID: id(item)}
+	})
+	return items, page
+}
+
+// removeMembership reports whether the book was in the collection. It must
+// be called with the write lock held.
+func (m *MemoryStore) removeMembership(collectionID, bookID int) bool {
+	ids := m.collectionBooks[collectionID]
+	for i, id := range ids {
+		if id == bookID {
+			m.collectionBooks[collectionID] = append(ids[:i:i], ids[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MemoryStore) touchCollection(id int) {
+	c := m.collections[id]
+	c.Version++
+	m.collections[id] = c
+}
+
+// touchCollectionsOf bumps the version of the collections a book is in.
+func (m *MemoryStore) touchCollectionsOf(bookID int) {
+	for collectionID, ids := range m.collectionBooks {
+		if slices.Contains(ids, bookID) {
+			m.touchCollection(collectionID)
+		}
+	}
+}
+
+// checkVersion mirrors the conditional statements of DB: a zero version
+// matches any record.
+func checkVersion(kind string, id, current, version int) error {
+	if version == 0 || version == current {
+		return nil
+	}
+	return fmt.Errorf("%s %d is at version %d, not %d: %w", kind, id, current, version, ErrVersionMismatch)
+}
+
+// checkISBN mirrors the unique index on the ISBN-13 of books.
+func (m *MemoryStore) checkISBN(b models.Book) error {
+	if b.ISBN13 == "" {
+		return nil
+	}
+	for _, other := range m.books {
+		if _, ok := m.deletedBooks[other.ID]; ok {
+			continue
+		}
+		if other.ID != b.ID && other.ISBN13 == b.ISBN13 {
+			return fmt.Errorf("a book with ISBN %s already exists: %w", b.ISBN13, ErrConflict)
+		}
+	}
+	return nil
+}
+
+// setAuthors resolves the authors of a book about to be stored and derives
+// its author line, creating authors given by unknown names. Nothing is
+// created if the authors are rejected.
+func (m *MemoryStore) setAuthors(b *models.Book) error {
+	authors, err := bookAuthors(*b)
+	if err != nil {
+		return err
+	}
+	var created []int
+	for i, a := range authors {
+		if a.ID != 0 {
+			author, ok := m.authors[a.ID]
+			if !ok {
+				err = notFound("author", a.ID)
+				break
+			}
+			authors[i].Name = author.Name
+			continue
+		}
+		if id, ok := m.authorID(a.Name); ok {
+			authors[i].ID = id
+			continue
+		}
+		authors[i].ID = m.nextAuthorID
+		m.authors[m.nextAuthorID] = models.Author{ID: m.nextAuthorID, Name: a.Name}
+		created = append(created, m.nextAuthorID)
+		m.nextAuthorID++
+	}
+	if err == nil {
+		err = checkCredits(authors)
+	}
+	if err != nil {
+		for _, id := range created {
+			delete(m.authors, id)
+		}
+		return err
+	}
+	b.Authors, b.Author = authors, authorLine(authors)
+	return nil
+}
+
+func (m *MemoryStore) authorID(name string) (int, bool) {
+	for _, a := range m.authors {
+		if a.Name == name {
+			return a.ID, true
+		}
+	}
+	return 0, false
+}
+
+func (m *MemoryStore) GetAuthors(opts ListOptions) ([]models.Author, Page, error) {
+	sort, err := opts.sortField(AuthorSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var authors []models.Author
+	for _, a := range m.authors {
+		authors = append(authors, a)
+	}
+
+	value := func(a models.Author) string {
+		if sort == "name" {
+			return a.Name
+		}
+		return ""
+	}
+	authors, page := memoryPage(authors, sort, opts, after, value, func(a models.Author) int { return a.ID })
+	return authors, page, nil
+}
+
+func (m *MemoryStore) GetAuthor(id int) (models.Author, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	a, ok := m.authors[id]
+	if !ok {
+		return models.Author{}, notFound("author", id)
+	}
+	return a, nil
+}
+
+func (m *MemoryStore) CreateAuthor(a models.Author) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.authorID(a.Name); ok {
+		return 0, fmt.Errorf("author already exists: %w", ErrConflict)
+	}
+	a.ID = m.nextAuthorID
+	m.authors[a.ID] = a
+	m.nextAuthorID++
+	return a.ID, nil
+}
+
+func (m *MemoryStore) UpdateAuthor(a models.Author) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.authors[a.ID]; !ok {
+		return notFound("author", a.ID)
+	}
+	if id, ok := m.authorID(a.Name); ok && id != a.ID {
+		return fmt.Errorf("author already exists: %w", ErrConflict)
+	}
+	m.authors[a.ID] = a
+
+	ids := m.bookIDsWhere(func(b models.Book) bool {
+		return slices.ContainsFunc(b.Authors, func(credit models.BookAuthor) bool { return credit.ID == a.ID })
+	})
+	return m.changeBooks(ids, func() error {
+		for _, id := range ids {
+			b := m.books[id]
+			for i := range b.Authors {
+				if b.Authors[i].ID == a.ID {
+					b.Authors[i].Name = a.Name
+				}
+			}
+			b.Author = authorLine(b.Authors)
+			m.books[id] = b
+		}
+		return nil
+	})
+}
+
+func (m *MemoryStore) DeleteAuthor(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.authors[id]; !ok {
+		return notFound("author", id)
+	}
+	books := 0
+	for _, b := range m.books {
+		if slices.ContainsFunc(b.Authors, func(a models.BookAuthor) bool { return a.ID == id }) {
+			books++
+		}
+	}
+	if books > 0 {
+		return fmt.Errorf("author %d is credited on %d books: %w", id, books, ErrConflict)
+	}
+	delete(m.authors, id)
+	return nil
+}
+
+// hasTags reports whether the book has a tag of every group of a
+// normalized tag filter.
+func hasTags(b models.Book, groups [][]string) bool {
+	for _, group := range groups {
+		if !slices.ContainsFunc(group, func(name string) bool { return slices.Contains(b.Tags, name) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// ensureTags creates the tags of normalized names that do not exist yet and
+// returns the names.
+func (m *MemoryStore) ensureTags(names []string) []string {
+	for _, name := range names {
+		if _, ok := m.tagID(name); !ok {
+			m.tags[m.nextTagID] = models.Tag{ID: m.nextTagID, Name: name}
+			m.nextTagID++
+		}
+	}
+	return names
+}
+
+func (m *MemoryStore) tagID(name string) (int, bool) {
+	for _, t := range m.tags {
+		if t.Name == name {
+			return t.ID, true
+		}
+	}
+	return 0, false
+}
+
+// tag returns a stored tag with the number of books outside the trash
+// having it.
+func (m *MemoryStore) tag(t models.Tag) models.Tag {
+	t.Books = 0
+	for _, b := range m.liveBooks() {
+		if slices.Contains(b.Tags, t.Name) {
+			t.Books++
+		}
+	}
+	return t
+}
+
+func (m *MemoryStore) GetTags(opts ListOptions) ([]models.Tag, Page, error) {
+	sort, err := opts.sortField(TagSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var tags []models.Tag
+	for _, t := range m.tags {
+		tags = append(tags, m.tag(t))
+	}
+
+	value := func(t models.Tag) string {
+		if sort == "name" {
+			return t.Name
+		}
+		return ""
+	}
+	tags, page := memoryPage(tags, sort, opts, after, value, func(t models.Tag) int { return t.ID })
+	return tags, page, nil
+}
+
+func (m *MemoryStore) GetTag(id int) (models.Tag, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, ok := m.tags[id]
+	if !ok {
+		return models.Tag{}, notFound("tag", id)
+	}
+	return m.tag(t), nil
+}
+
+func (m *MemoryStore) CreateTag(t models.Tag) (int, error) {
+	name, err := validTag(t.Name)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.tagID(name); ok {
+		return 0, duplicateTag(ErrConflict, name)
+	}
+	t = models.Tag{ID: m.nextTagID, Name: name}
+	m.tags[t.ID] = t
+	m.nextTagID++
+	return t.ID, nil
+}
+
+func (m *MemoryStore) UpdateTag(t models.Tag) error {
+	name, err := validTag(t.Name)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.tags[t.ID]
+	if !ok {
+		return notFound("tag", t.ID)
+	}
+	if id, ok := m.tagID(name); ok && id != t.ID {
+		return duplicateTag(ErrConflict, name)
+	}
+	m.tags[t.ID] = models.Tag{ID: t.ID, Name: name}
+	return m.retag(existing.Name, name)
+}
+
+func (m *MemoryStore) DeleteTag(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.tags[id]
+	if !ok {
+		return notFound("tag", id)
+	}
+	delete(m.tags, id)
+	return m.retag(existing.Name, "")
+}
+
+func (m *MemoryStore) MergeTags(sourceID, targetID int) error {
+	if sourceID == targetID {
+		return fmt.Errorf("%w merge, tag %d cannot be merged into itself", ErrInvalid, sourceID)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	source, ok := m.tags[sourceID]
+	if !ok {
+		return notFound("tag", sourceID)
+	}
+	target, ok := m.tags[targetID]
+	if !ok {
+		return notFound("tag", targetID)
+	}
+	delete(m.tags, sourceID)
+	return m.retag(source.Name, target.Name)
+}
+
+// retag replaces a tag name on all books having it, or removes it if to is
+// empty.
+func (m *MemoryStore) retag(from, to string) error {
+	ids := m.bookIDsWhere(func(b models.Book) bool { return slices.Contains(b.Tags, from) })
+	return m.changeTags(ids, func(b models.Book) []string {
+		tags := slices.DeleteFunc(slices.Clone(b.Tags), func(name string) bool { return name == from })
+		if to != "" {
+			tags = append(tags, to)
+			slices.Sort(tags)
+			tags = slices.Compact(tags)
+		}
+		return tags
+	})
+}
+
+func (m *MemoryStore) TagBooks(bookIDs []int, names []string) error {
+	tags, err := bookTags(names)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.booksExist(bookIDs); err != nil {
+		return err
+	}
+	m.ensureTags(tags)
+	return m.changeTags(bookIDs, func(b models.Book) []string {
+		all := append(slices.Clone(b.Tags), tags...)
+		slices.Sort(all)
+		return slices.Compact(all)
+	})
+}
+
+func (m *MemoryStore) UntagBooks(bookIDs []int, names []string) error {
+	tags, err := bookTags(names)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.booksExist(bookIDs); err != nil {
+		return err
+	}
+	return m.changeTags(bookIDs, func(b models.Book) []string {
+		return slices.DeleteFunc(slices.Clone(b.Tags), func(name string) bool { return slices.Contains(tags, name) })
+	})
+}
+
+func (m *MemoryStore) booksExist(ids []int) error {
+	for _, id := range ids {
+		if _, ok := m.liveBook(id); !ok {
+			return notFound("book", id)
+		}
+	}
+	return nil
+}
+
+// changeTags sets the tags of the books to the result of change.
+func (m *MemoryStore) changeTags(bookIDs []int, change func(models.Book) []string) error {
+	return m.changeBooks(bookIDs, func() error {
+		for _, id := range bookIDs {
+			b := m.books[id]
+			b.Tags = change(b)
+			m.books[id] = b
+		}
+		return nil
+	})
+}
+
+func (m *MemoryStore) seriesID(name string) (int, bool) {
+	for _, s := range m.series {
+		if s.Name == name {
+			return s.ID, true
+		}
+	}
+	return 0, false
+}
+
+func (m *MemoryStore) ListSeries(opts ListOptions) ([]models.Series, Page, error) {
+	sort, err := opts.sortField(SeriesSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var series []models.Series
+	for _, s := range m.series {
+		series = append(series, s)
+	}
+
+	value := func(s models.Series) string {
+		if sort == "name" {
+			return s.Name
+		}
+		return ""
+	}
+	series, page := memoryPage(series, sort, opts, after, value, func(s models.Series) int { return s.ID })
+	return series, page, nil
+}
+
+func (m *MemoryStore) GetSeries(id int) (models.Series, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.series[id]
+	if !ok {
+		return models.Series{}, notFound("series", id)
+	}
+	s.Books = []models.SeriesBook{}
+	for bookID, position := range m.seriesBooks[id] {
+		if b, ok := m.liveBook(bookID); ok {
+			s.Books = append(s.Books, models.SeriesBook{Position: position, Book: m.book(b)})
+		}
+	}
+	sort.Slice(s.Books, func(i, j int) bool { return s.Books[i].Position < s.Books[j].Position })
+	s.Missing = seriesGaps(s.Books)
+	return s, nil
+}
+
+func (m *MemoryStore) CreateSeries(s models.Series) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.seriesID(s.Name); ok {
+		return 0, fmt.Errorf("series already exists: %w", ErrConflict)
+	}
+	s = models.Series{ID: m.nextSeriesID, Name: s.Name, Description: s.Description}
+	m.series[s.ID] = s
+	m.seriesBooks[s.ID] = map[int]float64{}
+	m.nextSeriesID++
+	return s.ID, nil
+}
+
+func (m *MemoryStore) UpdateSeries(s models.Series) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.series[s.ID]; !ok {
+		return notFound("series", s.ID)
+	}
+	if id, ok := m.seriesID(s.Name); ok && id != s.ID {
+		return fmt.Errorf("series already exists: %w", ErrConflict)
+	}
+	return m.changeBooks(m.seriesBookIDs(s.ID), func() error {
+		m.series[s.ID] = models.Series{ID: s.ID, Name: s.Name, Description: s.Description}
+		return nil
+	})
+}
+
+func (m *MemoryStore) DeleteSeries(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.series[id]; !ok {
+		return notFound("series", id)
+	}
+	return m.changeBooks(m.seriesBookIDs(id), func() error {
+		delete(m.series, id)
+		delete(m.seriesBooks, id)
+		return nil
+	})
+}
+
+func (m *MemoryStore) SetSeriesBook(seriesID, bookID int, position float64) error {
+	if err := checkPosition(position); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.series[seriesID]; !ok {
+		return notFound("series", seriesID)
+	}
+	if _, ok := m.liveBook(bookID); !ok {
+		return notFound("book", bookID)
+	}
+	for other, p := range m.seriesBooks[seriesID] {
+		if p == position && other != bookID {
+			return fmt.Errorf("book %d is already at position %v of series %d: %w", other, position, seriesID, ErrConflict)
+		}
+	}
+	return m.changeBooks([]int{bookID}, func() error {
+		m.seriesBooks[seriesID][bookID] = position
+		return nil
+	})
+}
+
+func (m *MemoryStore) RemoveSeriesBook(seriesID, bookID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.seriesBooks[seriesID][bookID]; !ok {
+		return fmt.Errorf("book %d is not in series %d: %w", bookID, seriesID, ErrNotFound)
+	}
+	return m.changeBooks([]int{bookID}, func() error {
+		delete(m.seriesBooks[seriesID], bookID)
+		return nil
+	})
+}
+
+// seriesBookIDs returns the ids of the books in the series in ascending
+// order.
+func (m *MemoryStore) seriesBookIDs(seriesID int) []int {
+	var ids []int
+	for bookID := range m.seriesBooks[seriesID] {
+		ids = append(ids, bookID)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// latestSession returns the latest reading session of the book, false if it
+// has none.
+func (m *MemoryStore) latestSession(bookID int) (models.ReadingSession, bool) {
+	var latest models.ReadingSession
+	for _, s := range m.readingSessions {
+		if s.BookID == bookID && s.ID > latest.ID {
+			latest = s
+		}
+	}
+	return latest, latest.ID != 0
+}
+
+// session returns a copy of a stored session that the caller may modify.
+func session(s models.ReadingSession) models.ReadingSession {
+	s.Progress = append([]models.ReadingProgress{}, s.Progress...)
+	return s
+}
+
+func (m *MemoryStore) GetReadingLog(bookID int) (models.ReadingLog, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.liveBook(bookID); !ok {
+		return models.ReadingLog{}, notFound("book", bookID)
+	}
+	log := models.ReadingLog{BookID: bookID, Sessions: []models.ReadingSession{}}
+	for _, s := range m.readingSessions {
+		if s.BookID == bookID {
+			log.Sessions = append(log.Sessions, session(s))
+		}
+	}
+	sort.Slice(log.Sessions, func(i, j int) bool { return log.Sessions[i].ID < log.Sessions[j].ID })
+	if n := len(log.Sessions); n > 0 {
+		log.Status = log.Sessions[n-1].Status
+	}
+	return log, nil
+}
+
+func (m *MemoryStore) GetReadingSession(bookID, id int) (models.ReadingSession, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.readingSessions[id]
+	if !ok || s.BookID != bookID {
+		return models.ReadingSession{}, notFound("reading session", id)
+	}
+	return session(s), nil
+}
+
+func (m *MemoryStore) CreateReadingSession(s models.ReadingSession) (int, error) {
+	if err := checkSession(&s); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.liveBook(s.BookID); !ok {
+		return 0, notFound("book", s.BookID)
+	}
+	err := m.changeBooks([]int{s.BookID}, func() error {
+		if latest, ok := m.latestSession(s.BookID); ok && openStatus(latest.Status) {
+			return fmt.Errorf("book %d already has an open reading session %d: %w", s.BookID, latest.ID, ErrConflict)
+		}
+		s.ID = m.nextSessionID
+		s.Progress = nil
+		s.CreatedAt = now()
+		s.UpdatedAt = s.CreatedAt
+		m.readingSessions[s.ID] = s
+		m.nextSessionID++
+		return nil
+	})
+	return s.ID, err
+}
+
+func (m *MemoryStore) UpdateReadingSession(s models.ReadingSession) error {
+	if err := checkSession(&s); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.readingSessions[s.ID]
+	if !ok || existing.BookID != s.BookID {
+		return notFound("reading session", s.ID)
+	}
+	return m.changeBooks([]int{s.BookID}, func() error {
+		if latest, _ := m.latestSession(s.BookID); openStatus(s.Status) && latest.ID > s.ID {
+			return fmt.Errorf("only the latest reading session %d of book %d can be open: %w", latest.ID, s.BookID, ErrConflict)
+		}
+		existing.Status, existing.StartedOn, existing.FinishedOn = s.Status, s.StartedOn, s.FinishedOn
+		existing.UpdatedAt = now()
+		m.readingSessions[s.ID] = existing
+		return nil
+	})
+}
+
+func (m *MemoryStore) DeleteReadingSession(bookID, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.readingSessions[id]; !ok || s.BookID != bookID {
+		return notFound("reading session", id)
+	}
+	return m.changeBooks([]int{bookID}, func() error {
+		delete(m.readingSessions, id)
+		return nil
+	})
+}
+
+func (m *MemoryStore) AddReadingProgress(bookID, sessionID int, p models.ReadingProgress) (int, error) {
+	if err := checkProgress(p); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.readingSessions[sessionID]
+	if !ok || s.BookID != bookID {
+		return 0, notFound("reading session", sessionID)
+	}
+	p.ID = m.nextProgressID
+	p.RecordedAt = now()
+	s.Progress = append(s.Progress, p)
+	s.UpdatedAt = p.RecordedAt
+	m.readingSessions[sessionID] = s
+	m.nextProgressID++
+	return p.ID, nil
+}
+
+// rating returns the average rating and the number of ratings of the book.
+func (m *MemoryStore) rating(bookID int) (float64, int) {
+	var sum float64
+	var count int
+	for _, r := range m.reviews {
+		if r.BookID == bookID {
+			sum += r.Rating
+			count++
+		}
+	}
+	return averageRating(sum, count), count
+}
+
+// rateBook runs fn, which changes the reviews of the book, and records the
+// book through changeBooks if its rating changed.
+func (m *MemoryStore) rateBook(bookID int, fn func()) error {
+	return m.changeBooks([]int{bookID}, func() error {
+		fn()
+		return nil
+	})
+}
+
+func (m *MemoryStore) GetReviews(filter ReviewFilter, opts ListOptions) ([]models.Review, Page, error) {
+	sort, err := opts.sortField(ReviewSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var reviews []models.Review
+	for _, r := range m.reviews {
+		if filter.BookID != 0 && r.BookID != filter.BookID {
+			continue
+		}
+		if filter.Reviewer != "" && r.Reviewer != strings.TrimSpace(filter.Reviewer) {
+			continue
+		}
+		r.History = nil
+		reviews = append(reviews, r)
+	}
+
+	value := func(r models.Review) string {
+		if sort == "rating" {
+			return strconv.FormatFloat(r.Rating, 'f', -1, 64)
+		}
+		return ""
+	}
+	reviews, page := memoryPage(reviews, sort, opts, after, value, func(r models.Review) int { return r.ID })
+	return reviews, page, nil
+}
+
+func (m *MemoryStore) GetReview(id int) (models.Review, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r, ok := m.reviews[id]
+	if !ok {
+		return models.Review{}, notFound("review", id)
+	}
+	r.History = append([]models.ReviewRevision{}, r.History...)
+	return r, nil
+}
+
+func (m *MemoryStore) CreateReview(r models.Review) (int, error) {
+	if err := checkReview(&r); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.liveBook(r.BookID); !ok {
+		return 0, notFound("book", r.BookID)
+	}
+	for _, existing := range m.reviews {
+		if existing.BookID == r.BookID && existing.Reviewer == r.Reviewer {
+			return 0, duplicateReview(ErrConflict, r)
+		}
+	}
+	err := m.rateBook(r.BookID, func() {
+		r.ID = m.nextReviewID
+		r.CreatedAt = now()
+		r.UpdatedAt = r.CreatedAt
+		r.History = nil
+		m.reviews[r.ID] = r
+		m.nextReviewID++
+	})
+	return r.ID, err
+}
+
+func (m *MemoryStore) UpdateReview(r models.Review) error {
+	if err := checkRating(r.Rating); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.reviews[r.ID]
+	if !ok {
+		return notFound("review", r.ID)
+	}
+	if existing.Rating == r.Rating && existing.Body == r.Body {
+		return nil
+	}
+	return m.rateBook(existing.BookID, func() {
+		existing.History = append(existing.History, models.ReviewRevision{Rating: existing.Rating, Body: existing.Body, WrittenAt: existing.UpdatedAt})
+		existing.Rating, existing.Body = r.Rating, r.Body
+		existing.UpdatedAt = now()
+		m.reviews[r.ID] = existing
+	})
+}
+
+func (m *MemoryStore) DeleteReview(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.reviews[id]
+	if !ok {
+		return notFound("review", id)
+	}
+	return m.rateBook(r.BookID, func() { delete(m.reviews, id) })
+}
+
+func (m *MemoryStore) GetLoans(filter LoanFilter, opts ListOptions) ([]models.Loan, Page, error) {
+	sort, err := opts.sortField(LoanSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	today := time.Now().Format(dateLayout)
+	var loans []models.Loan
+	for _, l := range m.loans {
+		l.Overdue = overdue(l, today)
+		_, live := m.liveBook(l.BookID)
+		switch {
+		case !live,
+			filter.BookID != 0 && l.BookID != filter.BookID,
+			filter.Borrower != "" && l.Borrower != strings.TrimSpace(filter.Borrower),
+			filter.Open && l.ReturnedAt != "",
+			filter.Overdue && !l.Overdue:
+			continue
+		}
+		loans = append(loans, l)
+	}
+
+	value := func(l models.Loan) string { return loanSortValue(l, sort) }
+	loans, page := memoryPage(loans, sort, opts, after, value, func(l models.Loan) int { return l.ID })
+	return loans, page, nil
+}
+
+func (m *MemoryStore) GetLoan(id int) (models.Loan, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	l, ok := m.loans[id]
+	if !ok {
+		return models.Loan{}, notFound("loan", id)
+	}
+	l.Overdue = overdue(l, time.Now().Format(dateLayout))
+	return l, nil
+}
+
+// openLoan reports whether the book is out on a loan other than exceptID.
+// It must be called with the lock held.
+func (m *MemoryStore) openLoan(bookID, exceptID int) bool {
+	for _, l := range m.loans {
+		if l.BookID == bookID && l.ID != exceptID && l.ReturnedAt == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MemoryStore) CreateLoan(l models.Loan) (int, error) {
+	if err := checkLoan(&l); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.liveBook(l.BookID); !ok {
+		return 0, notFound("book", l.BookID)
+	}
+	if l.ReturnedAt == "" && m.openLoan(l.BookID, 0) {
+		return 0, duplicateLoan(ErrConflict, l.BookID)
+	}
+	l.ID = m.nextLoanID
+	l.Overdue = false
+	l.CreatedAt = now()
+	l.UpdatedAt = l.CreatedAt
+	m.loans[l.ID] = l
+	m.nextLoanID++
+	return l.ID, nil
+}
+
+func (m *MemoryStore) UpdateLoan(l models.Loan) error {
+	if err := checkLoan(&l); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.loans[l.ID]
+	if !ok {
+		return notFound("loan", l.ID)
+	}
+	if l.ReturnedAt == "" && m.openLoan(existing.BookID, l.ID) {
+		return duplicateLoan(ErrConflict, existing.BookID)
+	}
+	existing.Borrower, existing.Contact = l.Borrower, l.Contact
+	existing.LoanedAt, existing.DueAt, existing.ReturnedAt = l.LoanedAt, l.DueAt, l.ReturnedAt
+	existing.UpdatedAt = now()
+	m.loans[l.ID] = existing
+	return nil
+}
+
+func (m *MemoryStore) ReturnLoan(id int, returnedAt string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l, ok := m.loans[id]
+	if !ok {
+		return notFound("loan", id)
+	}
+	if err := returnLoan(&l, returnedAt); err != nil {
+		return err
+	}
+	l.UpdatedAt = now()
+	m.loans[id] = l
+	return nil
+}
+
+func (m *MemoryStore) DeleteLoan(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.loans[id]; !ok {
+		return notFound("loan", id)
+	}
+	delete(m.loans, id)
+	return nil
+}
+
+// location returns the location with its path. It must be called with the
+// lock held.
+func (m *MemoryStore) location(id int) (models.Location, bool) {
+	l, ok := m.locations[id]
+	l.Path = locationPath(m.locations, id)
+	return l, ok
+}
+
+func (m *MemoryStore) GetLocations(opts ListOptions) ([]models.Location, Page, error) {
+	sort, err := opts.sortField(LocationSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var locations []models.Location
+	for id := range m.locations {
+		l, _ := m.location(id)
+		locations = append(locations, l)
+	}
+
+	value := func(l models.Location) string {
+		if sort == "name" {
+			return l.Name
+		}
+		return ""
+	}
+	locations, page := memoryPage(locations, sort, opts, after, value, func(l models.Location) int { return l.ID })
+	return locations, page, nil
+}
+
+func (m *MemoryStore) GetLocation(id int) (models.Location, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	l, ok := m.location(id)
+	if !ok {
+		return models.Location{}, notFound("location", id)
+	}
+	return l, nil
+}
+
+// checkLocationName fails with ErrConflict if another location in the same
+// parent has the name. It must be called with the lock held.
+func (m *MemoryStore) checkLocationName(l models.Location) error {
+	for _, other := range m.locations {
+		if other.ID != l.ID && derefID(other.ParentID) == derefID(l.ParentID) && other.Name == l.Name {
+			return duplicateLocation(ErrConflict, l.Name)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) CreateLocation(l models.Location) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l.ID = 0
+	if err := checkLocation(m.locations, &l); err != nil {
+		return 0, err
+	}
+	if err := m.checkLocationName(l); err != nil {
+		return 0, err
+	}
+	l.ID = m.nextLocationID
+	l.Path = ""
+	l.CreatedAt = now()
+	l.UpdatedAt = l.CreatedAt
+	m.locations[l.ID] = l
+	m.nextLocationID++
+	return l.ID, nil
+}
+
+func (m *MemoryStore) UpdateLocation(l models.Location) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.locations[l.ID]
+	if !ok {
+		return notFound("location", l.ID)
+	}
+	if err := checkLocation(m.locations, &l); err != nil {
+		return err
+	}
+	if err := m.checkLocationName(l); err != nil {
+		return err
+	}
+	existing.ParentID, existing.Name, existing.Kind = l.ParentID, l.Name, l.Kind
+	existing.UpdatedAt = now()
+	m.locations[l.ID] = existing
+	return nil
+}
+
+func (m *MemoryStore) DeleteLocation(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.locations[id]; !ok {
+		return notFound("location", id)
+	}
+	var locations, copies int
+	for _, l := range m.locations {
+		if derefID(l.ParentID) == id {
+			locations++
+		}
+	}
+	for _, c := range m.copies {
+		if derefID(c.LocationID) == id {
+			copies++
+		}
+	}
+	if locations > 0 || copies > 0 {
+		return fmt.Errorf("location %d still contains %d locations and %d copies: %w", id, locations, copies, ErrConflict)
+	}
+	delete(m.locations, id)
+	return nil
+}
+
+// copyOf returns the copy with the title of its book and the path of its
+// location. It must be called with the lock held.
+func (m *MemoryStore) copyOf(c models.Copy) models.Copy {
+	c.Title = m.books[c.BookID].Title
+	c.Location = ""
+	if c.LocationID != nil {
+		c.Location = locationPath(m.locations, *c.LocationID)
+	}
+	return c
+}
+
+func (m *MemoryStore) GetCopies(filter CopyFilter, opts ListOptions) ([]models.Copy, Page, error) {
+	sort, err := opts.sortField(CopySortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	locations := map[int]bool{filter.LocationID: true}
+	if filter.Recursive {
+		locations = subtree(m.locations, filter.LocationID)
+	}
+	var copies []models.Copy
+	for _, c := range m.copies {
+		_, live := m.liveBook(c.BookID)
+		switch {
+		case !live,
+			filter.BookID != 0 && c.BookID != filter.BookID,
+			filter.LocationID != 0 && (c.LocationID == nil || !locations[*c.LocationID]),
+			filter.Barcode != "" && c.Barcode != strings.TrimSpace(filter.Barcode),
+			filter.Format != "" && c.Format != filter.Format:
+			continue
+		}
+		copies = append(copies, m.copyOf(c))
+	}
+
+	value := func(c models.Copy) string {
+		if sort == "title" {
+			return c.Title
+		}
+		return ""
+	}
+	copies, page := memoryPage(copies, sort, opts, after, value, func(c models.Copy) int { return c.ID })
+	return copies, page, nil
+}
+
+func (m *MemoryStore) GetCopy(id int) (models.Copy, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, ok := m.copies[id]
+	if !ok {
+		return models.Copy{}, notFound("copy", id)
+	}
+	return m.copyOf(c), nil
+}
+
+// checkCopyRefs checks that the location of the copy exists and that no
+// other copy has its barcode. It must be called with the lock held.
+func (m *MemoryStore) checkCopyRefs(c models.Copy) error {
+	if c.LocationID != nil {
+		if _, ok := m.locations[*c.LocationID]; !ok {
+			return notFound("location", *c.LocationID)
+		}
+	}
+	for _, other := range m.copies {
+		if other.ID != c.ID && c.Barcode != "" && other.Barcode == c.Barcode {
+			return duplicateBarcode(ErrConflict, c.Barcode)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) CreateCopy(c models.Copy) (int, error) {
+	if err := checkCopy(&c); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.liveBook(c.BookID); !ok {
+		return 0, notFound("book", c.BookID)
+	}
+	c.ID = 0
+	if err := m.checkCopyRefs(c); err != nil {
+		return 0, err
+	}
+	c.ID = m.nextCopyID
+	c.Title, c.Location = "", ""
+	c.CreatedAt = now()
+	c.UpdatedAt = c.CreatedAt
+	m.copies[c.ID] = c
+	m.nextCopyID++
+	return c.ID, nil
+}
+
+func (m *MemoryStore) UpdateCopy(c models.Copy) error {
+	if err := checkCopy(&c); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.copies[c.ID]
+	if !ok {
+		return notFound("copy", c.ID)
+	}
+	if err := m.checkCopyRefs(c); err != nil {
+		return err
+	}
+	existing.Format, existing.Condition, existing.AcquiredOn = c.Format, c.Condition, c.AcquiredOn
+	existing.Price, existing.Barcode, existing.LocationID = c.Price, c.Barcode, c.LocationID
+	existing.UpdatedAt = now()
+	m.copies[c.ID] = existing
+	return nil
+}
+
+func (m *MemoryStore) DeleteCopy(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.copies[id]; !ok {
+		return notFound("copy", id)
+	}
+	delete(m.copies, id)
+	return nil
+}
+
+func (m *MemoryStore) MoveCopies(ids []int, locationID *int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if locationID != nil {
+		if _, ok := m.locations[*locationID]; !ok {
+			return notFound("location", *locationID)
+		}
+	}
+	for _, id := range ids {
+		if _, ok := m.copies[id]; !ok {
+			return notFound("copy", id)
+		}
+	}
+	m.moveCopies(ids, locationID)
+	return nil
+}
+
+// moveCopies must be called with the write lock held.
+func (m *MemoryStore) moveCopies(ids []int, locationID *int) {
+	for _, id := range ids {
+		c := m.copies[id]
+		c.LocationID = locationID
+		c.UpdatedAt = now()
+		m.copies[id] = c
+	}
+}
+
+func (m *MemoryStore) CheckInventory(locationID int, barcodes []string, apply bool) (models.InventoryReport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.locations[locationID]; !ok {
+		return models.InventoryReport{}, notFound("location", locationID)
+	}
+	locations := subtree(m.locations, locationID)
+	scanned := scannedBarcodes(barcodes)
+
+	var candidates []models.Copy
+	for _, c := range m.copies {
+		if _, live := m.liveBook(c.BookID); !live {
+			continue
+		}
+		if (c.LocationID != nil && locations[*c.LocationID]) || slices.Contains(scanned, c.Barcode) {
+			candidates = append(candidates, m.copyOf(c))
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID < candidates[j].ID })
+
+	report := inventory(locationID, locations, scanned, candidates)
+	report.Applied = apply
+	if apply {
+		m.moveCopies(copyIDs(report.Misplaced), &locationID)
+	}
+	return report, nil
+}
+
+func (m *MemoryStore) touchBook(id int) {
+	b := m.books[id]
+	b.UpdatedAt = now()
+	b.Version++
+	m.books[id] = b
+}
+
+// changeBooks runs fn, which changes the books with the ids other than
+// through UpdateBook, and records the books it changed like DB.changeBooks.
+// It must be called with the write lock held.
+func (m *MemoryStore) changeBooks(ids []int, fn func() error) error {
+	var before []models.Book
+	seen := map[int]bool{}
+	for _, id := range ids {
+		b, ok := m.books[id]
+		if !ok {
+			return notFound("book", id)
+		}
+		if !seen[id] {
+			seen[id] = true
+			before = append(before, m.book(b))
+		}
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+
+	for _, b := range before {
+		after := m.book(m.books[b.ID])
+		changes, err := diffFields(b, after)
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		m.touchBook(b.ID)
+		m.touchCollectionsOf(b.ID)
+		if err := m.audit(models.AuditEntry{Action: models.AuditUpdate, Entity: models.AuditBook, BookID: b.ID}, b, after); err != nil {
+			return err
+		}
+		m.startBookRevisions(b)
+		m.addBookRevision(after)
+	}
+	return nil
+}
+
+// bookIDsWhere returns the ids of the stored books matching fn in ascending
+// order, including those in the trash.
+func (m *MemoryStore) bookIDsWhere(fn func(models.Book) bool) []int {
+	var ids []int
+	for id, b := range m.books {
+		if fn(b) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// work returns the work with the number of its editions outside the trash.
+func (m *MemoryStore) work(w models.Work) models.Work {
+	w.EditionCount = 0
+	for _, b := range m.liveBooks() {
+		if derefID(b.WorkID) == w.ID {
+			w.EditionCount++
+		}
+	}
+	return w
+}
+
+func (m *MemoryStore) GetWorks(opts ListOptions) ([]models.Work, Page, error) {
+	sort, err := opts.sortField(WorkSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var works []models.Work
+	for _, w := range m.works {
+		works = append(works, m.work(w))
+	}
+
+	value := func(w models.Work) string {
+		if sort == "title" {
+			return w.Title
+		}
+		return ""
+	}
+	works, page := memoryPage(works, sort, opts, after, value, func(w models.Work) int { return w.ID })
+	return works, page, nil
+}
+
+func (m *MemoryStore) GetWork(id int) (models.Work, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	w, ok := m.works[id]
+	if !ok {
+		return models.Work{}, notFound("work", id)
+	}
+	return m.work(w), nil
+}
+
+func (m *MemoryStore) CreateWork(w models.Work) (int, error) {
+	if err := checkWork(&w); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w.ID = m.nextWorkID
+	w.CreatedAt = now()
+	w.UpdatedAt = w.CreatedAt
+	m.works[w.ID] = w
+	m.nextWorkID++
+	return w.ID, nil
+}
+
+func (m *MemoryStore) UpdateWork(w models.Work) error {
+	if err := checkWork(&w); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.works[w.ID]
+	if !ok {
+		return notFound("work", w.ID)
+	}
+	w.CreatedAt = existing.CreatedAt
+	w.UpdatedAt = now()
+	m.works[w.ID] = w
+	return nil
+}
+
+func (m *MemoryStore) DeleteWork(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.works[id]; !ok {
+		return notFound("work", id)
+	}
+	ids := m.bookIDsWhere(func(b models.Book) bool { return derefID(b.WorkID) == id })
+	return m.changeBooks(ids, func() error {
+		for _, bookID := range ids {
+			m.setWork(bookID, nil)
+		}
+		delete(m.works, id)
+		return nil
+	})
+}
+
+func (m *MemoryStore) MergeEditions(workID int, bookIDs []int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.works[workID]; !ok {
+		return notFound("work", workID)
+	}
+	if err := m.booksExist(bookIDs); err != nil {
+		return err
+	}
+	return m.changeBooks(bookIDs, func() error {
+		left := map[int]bool{}
+		for _, id := range bookIDs {
+			current := m.books[id].WorkID
+			if derefID(current) == workID {
+				continue
+			}
+			if current != nil {
+				left[*current] = true
+			}
+			m.setWork(id, &workID)
+		}
+		for id := range left {
+			if len(m.bookIDsWhere(func(b models.Book) bool { return derefID(b.WorkID) == id })) == 0 {
+				delete(m.works, id)
+			}
+		}
+		return nil
+	})
+}
+
+func (m *MemoryStore) SplitEditions(workID int, bookIDs []int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.works[workID]; !ok {
+		return 0, notFound("work", workID)
+	}
+	var editions []models.Book
+	for _, b := range m.books {
+		if derefID(b.WorkID) == workID {
+			editions = append(editions, b)
+		}
+	}
+	split, err := splitWork(workID, editions, bookIDs)
+	if err != nil {
+		return 0, err
+	}
+	split.ID = m.nextWorkID
+	split.CreatedAt = now()
+	split.UpdatedAt = split.CreatedAt
+	err = m.changeBooks(bookIDs, func() error {
+		m.works[split.ID] = split
+		m.nextWorkID++
+		for _, id := range bookIDs {
+			m.setWork(id, &split.ID)
+		}
+		return nil
+	})
+	return split.ID, err
+}
+
+// setWork makes the book an edition of the work, or of none if it is nil.
+func (m *MemoryStore) setWork(bookID int, workID *int) {
+	b := m.books[bookID]
+	if workID != nil {
+		id := *workID
+		workID = &id
+	}
+	b.WorkID = workID
+	m.books[bookID] = b
+}
+
+// withinLimit reports whether a value is within a maximum, where a zero
+// maximum is no limit and a zero value is unknown and never within one.
+func withinLimit(value, limit float64) bool {
+	return limit <= 0 || value > 0 && value <= limit
+}
+
+// ensurePublisher creates the publisher named on a book if it is unknown
+// and returns the trimmed name.
+func (m *MemoryStore) ensurePublisher(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ""
+	}
+	if _, ok := m.publisherID(name); !ok {
+		m.publishers[m.nextPublisherID] = models.Publisher{ID: m.nextPublisherID, Name: name}
+		m.nextPublisherID++
+	}
+	return name
+}
+
+func (m *MemoryStore) publisherID(name string) (int, bool) {
+	for _, p := range m.publishers {
+		if p.Name == name {
+			return p.ID, true
+		}
+	}
+	return 0, false
+}
+
+// publisher returns a stored publisher with the number of its books outside
+// the trash.
+func (m *MemoryStore) publisher(p models.Publisher) models.Publisher {
+	p.BookCount = 0
+	for _, b := range m.liveBooks() {
+		if b.Publisher == p.Name {
+			p.BookCount++
+		}
+	}
+	return p
+}
+
+func (m *MemoryStore) GetPublishers(opts ListOptions) ([]models.Publisher, Page, error) {
+	sort, err := opts.sortField(PublisherSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var publishers []models.Publisher
+	for _, p := range m.publishers {
+		publishers = append(publishers, m.publisher(p))
+	}
+
+	value := func(p models.Publisher) string {
+		if sort == "name" {
+			return p.Name
+		}
+		return ""
+	}
+	publishers, page := memoryPage(publishers, sort, opts, after, value, func(p models.Publisher) int { return p.ID })
+	return publishers, page, nil
+}
+
+func (m *MemoryStore) GetPublisher(id int) (models.Publisher, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	p, ok := m.publishers[id]
+	if !ok {
+		return models.Publisher{}, notFound("publisher", id)
+	}
+	return m.publisher(p), nil
+}
+
+func (m *MemoryStore) UpdatePublisher(p models.Publisher) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.publishers[p.ID]
+	if !ok {
+		return notFound("publisher", p.ID)
+	}
+	if id, ok := m.publisherID(p.Name); ok && id != p.ID {
+		return fmt.Errorf("publisher already exists: %w", ErrConflict)
+	}
+	m.publishers[p.ID] = models.Publisher{ID: p.ID, Name: p.Name}
+
+	ids := m.bookIDsWhere(func(b models.Book) bool { return b.Publisher == existing.Name })
+	return m.changeBooks(ids, func() error {
+		for _, id := range ids {
+			b := m.books[id]
+			b.Publisher = p.Name
+			m.books[id] = b
+		}
+		return nil
+	})
+}
+
+func (m *MemoryStore) DeletePublisher(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.publishers[id]
+	if !ok {
+		return notFound("publisher", id)
+	}
+	if books := len(m.bookIDsWhere(func(b models.Book) bool { return b.Publisher == p.Name })); books > 0 {
+		return fmt.Errorf("publisher %d has %d books: %w", id, books, ErrConflict)
+	}
+	delete(m.publishers, id)
+	return nil
+}
+
+func (m *MemoryStore) customFieldsByName() map[string]models.CustomField {
+	fields := map[string]models.CustomField{}
+	for _, f := range m.customFields {
+		fields[f.Name] = f
+	}
+	return fields
+}
+
+// bookCustom checks the custom values of a book about to be stored and
+// returns them as they are read back from the SQL stores.
+func (m *MemoryStore) bookCustom(values map[string]interface{}) (map[string]interface{}, error) {
+	fields := m.customFieldsByName()
+	texts, err := bookCustom(fields, values)
+	if err != nil {
+		return nil, err
+	}
+	custom := map[string]interface{}{}
+	for name, text := range texts {
+		custom[name] = customValue(fields[name].Type, text)
+	}
+	return custom, nil
+}
+
+// customField returns a stored custom field with the number of books
+// outside the trash having a value for it.
+func (m *MemoryStore) customField(f models.CustomField) models.CustomField {
+	f.Choices = append([]string{}, f.Choices...)
+	f.BookCount = 0
+	for _, b := range m.liveBooks() {
+		if _, ok := b.Custom[f.Name]; ok {
+			f.BookCount++
+		}
+	}
+	return f
+}
+
+func (m *MemoryStore) GetCustomFields(opts ListOptions) ([]models.CustomField, Page, error) {
+	sort, err := opts.sortField(CustomFieldSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var fields []models.CustomField
+	for _, f := range m.customFields {
+		fields = append(fields, m.customField(f))
+	}
+
+	value := func(f models.CustomField) string {
+		if sort == "name" {
+			return f.Name
+		}
+		return ""
+	}
+	fields, page := memoryPage(fields, sort, opts, after, value, func(f models.CustomField) int { return f.ID })
+	return fields, page, nil
+}
+
+func (m *MemoryStore) GetCustomField(id int) (models.CustomField, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	f, ok := m.customFields[id]
+	if !ok {
+		return models.CustomField{}, notFound("custom field", id)
+	}
+	return m.customField(f), nil
+}
+
+func (m *MemoryStore) CreateCustomField(f models.CustomField) (int, error) {
+	if err := checkCustomField(&f); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.customFieldsByName()[f.Name]; ok {
+		return 0, fmt.Errorf("custom field %q already exists: %w", f.Name, ErrConflict)
+	}
+	f.ID = m.nextFieldID
+	f.BookCount = 0
+	f.CreatedAt = now()
+	f.UpdatedAt = f.CreatedAt
+	m.customFields[f.ID] = f
+	m.nextFieldID++
+	return f.ID, nil
+}
+
+func (m *MemoryStore) UpdateCustomField(f models.CustomField) error {
+	if err := checkCustomField(&f); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.customFields[f.ID]
+	if !ok {
+		return notFound("custom field", f.ID)
+	}
+	if other, ok := m.customFieldsByName()[f.Name]; ok && other.ID != f.ID {
+		return fmt.Errorf("custom field %q already exists: %w", f.Name, ErrConflict)
+	}
+	var texts []string
+	for _, b := range m.books {
+		if value, ok := b.Custom[current.Name]; ok {
+			text, _, _ := customText(current, value)
+			if !slices.Contains(texts, text) {
+				texts = append(texts, text)
+			}
+		}
+	}
+	slices.Sort(texts)
+	if err := checkCustomValues(current, f, texts); err != nil {
+		return err
+	}
+
+	f.BookCount = 0
+	f.CreatedAt = current.CreatedAt
+	f.UpdatedAt = now()
+	m.customFields[f.ID] = f
+	if f.Name == current.Name {
+		return nil
+	}
+	ids := m.bookIDsWhere(func(b models.Book) bool { _, ok := b.Custom[current.Name]; return ok })
+	return m.changeBooks(ids, func() error {
+		for _, id := range ids {
+			b := m.books[id]
+			b.Custom[f.Name] = b.Custom[current.Name]
+			delete(b.Custom, current.Name)
+		}
+		return nil
+	})
+}
+
+func (m *MemoryStore) DeleteCustomField(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, ok := m.customFields[id]
+	if !ok {
+		return notFound("custom field", id)
+	}
+	ids := m.bookIDsWhere(func(b models.Book) bool { _, ok := b.Custom[f.Name]; return ok })
+	return m.changeBooks(ids, func() error {
+		for _, bookID := range ids {
+			delete(m.books[bookID].Custom, f.Name)
+		}
+		delete(m.customFields, id)
+		return nil
+	})
+}
+
+// liveBooks returns the stored books that are not in the trash.
+func (m *MemoryStore) liveBooks() map[int]models.Book {
+	books := map[int]models.Book{}
+	for id, b := range m.books {
+		if _, deleted := m.deletedBooks[id]; !deleted {
+			books[id] = b
+		}
+	}
+	return books
+}
+
+// liveBook returns the book unless it is missing or in the trash.
+func (m *MemoryStore) liveBook(id int) (models.Book, bool) {
+	b, ok := m.books[id]
+	if _, deleted := m.deletedBooks[id]; deleted {
+		return models.Book{}, false
+	}
+	return b, ok
+}
+
+// liveCollection returns the collection unless it is missing or in the
+// trash.
+func (m *MemoryStore) liveCollection(id int) (models.Collection, bool) {
+	c, ok := m.collections[id]
+	if _, deleted := m.deletedCols[id]; deleted {
+		return models.Collection{}, false
+	}
+	return c, ok
+}
+
+func (m *MemoryStore) GetTrash() ([]models.TrashItem, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.trash(time.Time{}), nil
+}
+
+// trash returns the items in the trash, those deleted before the given time
+// unless it is zero.
+func (m *MemoryStore) trash(before time.Time) []models.TrashItem {
+	items := []models.TrashItem{}
+	for id, deletedAt := range m.deletedBooks {
+		if !before.IsZero() && !deletedAt.Before(before) {
+			continue
+		}
+		b := m.book(m.books[id])
+		memberships := 0
+		for _, ids := range m.collectionBooks {
+			if slices.Contains(ids, id) {
+				memberships++
+			}
+		}
+		items = append(items, models.TrashItem{Type: models.TrashBook, ID: id, Name: b.Title,
+			Memberships: memberships, DeletedAt: deletedAt, Book: &b})
+	}
+	for id, deletedAt := range m.deletedCols {
+		if !before.IsZero() && !deletedAt.Before(before) {
+			continue
+		}
+		items = append(items, models.TrashItem{Type: models.TrashCollection, ID: id, Name: m.collections[id].Name,
+			Memberships: len(m.collectionBooks[id]), DeletedAt: deletedAt})
+	}
+	sortTrash(items)
+	return items
+}
+
+func (m *MemoryStore) RestoreBook(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.deletedBooks[id]; !ok {
+		return fmt.Errorf("book %d is not in the trash: %w", id, ErrNotFound)
+	}
+	before := m.book(m.books[id])
+	delete(m.deletedBooks, id)
+	if err := m.checkISBN(m.books[id]); err != nil {
+		m.deletedBooks[id] = now()
+		return err
+	}
+	m.touchBook(id)
+	m.touchCollectionsOf(id)
+	after := m.book(m.books[id])
+	m.startBookRevisions(before)
+	m.addBookRevision(after)
+	return m.audit(models.AuditEntry{Action: models.AuditRestore, Entity: models.AuditBook, BookID: id}, models.Book{}, after)
+}
+
+func (m *MemoryStore) RestoreCollection(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.deletedCols[id]; !ok {
+		return fmt.Errorf("collection %d is not in the trash: %w", id, ErrNotFound)
+	}
+	delete(m.deletedCols, id)
+	m.touchCollection(id)
+	// The collection kept its name and books in the trash
+	after := m.collectionState(id)
+	m.startCollectionRevisions(id, after)
+	m.insertCollectionRevision(id, after)
+	return m.audit(models.AuditEntry{Action: models.AuditRestore, Entity: models.AuditCollection, CollectionID: id},
+		models.Collection{}, models.Collection{Name: m.collections[id].Name})
+}
+
+func (m *MemoryStore) PurgeTrash(before time.Time) ([]models.TrashItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	items := m.trash(before)
+	for _, item := range items {
+		entry := models.AuditEntry{Action: models.AuditPurge}
+		var before, after interface{}
+		if item.Type == models.TrashBook {
+			entry.Entity, entry.BookID = models.AuditBook, item.ID
+			before, after = *item.Book, models.Book{}
+			m.purgeBook(item.ID)
+		} else {
+			entry.Entity, entry.CollectionID = models.AuditCollection, item.ID
+			before, after = models.Collection{Name: item.Name}, models.Collection{}
+			delete(m.collections, item.ID)
+			delete(m.collectionBooks, item.ID)
+			delete(m.deletedCols, item.ID)
+			delete(m.colRevisions, item.ID)
+		}
+		if err := m.audit(entry, before, after); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// purgeBook deletes a book and everything that belongs to it.
+func (m *MemoryStore) purgeBook(id int) {
+	delete(m.books, id)
+	delete(m.deletedBooks, id)
+	delete(m.covers, id)
+	delete(m.bookRevisions, id)
+	for collectionID := range m.collectionBooks {
+		m.removeMembership(collectionID, id)
+	}
+	for _, positions := range m.seriesBooks {
+		delete(positions, id)
+	}
+	for sessionID, s := range m.readingSessions {
+		if s.BookID == id {
+			delete(m.readingSessions, sessionID)
+		}
+	}
+	for reviewID, r := range m.reviews {
+		if r.BookID == id {
+			delete(m.reviews, reviewID)
+		}
+	}
+	for loanID, l := range m.loans {
+		if l.BookID == id {
+			delete(m.loans, loanID)
+		}
+	}
+	for copyID, c := range m.copies {
+		if c.BookID == id {
+			delete(m.copies, copyID)
+		}
+	}
+}
+
+func (m *MemoryStore) WithActor(actor string) Store {
+	return &MemoryStore{memoryData: m.memoryData, actor: actor}
+}
+
+// audit appends an entry with the changes from before to after to the audit
+// log. It must be called with the lock held.
+func (m *MemoryStore) audit(entry models.AuditEntry, before, after interface{}) error {
+	changes, err := diffFields(before, after)
+	if err != nil {
+		return err
+	}
+	entry.ID, entry.At, entry.Actor, entry.Changes = m.nextAuditID, now(), m.actor, changes
+	m.auditLog = append(m.auditLog, entry)
+	m.nextAuditID++
+	return nil
+}
+
+func (m *MemoryStore) GetAudit(filter AuditFilter, opts ListOptions) ([]models.AuditEntry, Page, error) {
+	sort, err := opts.sortField(AuditSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var entries []models.AuditEntry
+	for _, e := range m.auditLog {
+		switch {
+		case filter.BookID != 0 && e.BookID != filter.BookID,
+			filter.CollectionID != 0 && e.CollectionID != filter.CollectionID,
+			filter.Entity != "" && e.Entity != filter.Entity,
+			filter.Action != "" && e.Action != filter.Action,
+			filter.Actor != "" && e.Actor != filter.Actor,
+			!filter.Since.IsZero() && e.At.Before(filter.Since),
+			!filter.Until.IsZero() && !e.At.Before(filter.Until):
+			continue
+		}
+		e.Changes = slices.Clone(e.Changes)
+		entries = append(entries, e)
+	}
+
+	value := func(models.AuditEntry) string { return "" }
+	entries, page := memoryPage(entries, sort, opts, after, value, func(e models.AuditEntry) int { return e.ID })
+	return entries, page, nil
+}
+
+// addBookRevision records b as the next revision of the book. It must be
+// called with the write lock held, like the other revision helpers.
+func (m *MemoryStore) addBookRevision(b models.Book) {
+	revisions := m.bookRevisions[b.ID]
+	m.bookRevisions[b.ID] = append(revisions, models.BookRevision{
+		Revision: len(revisions) + 1, At: now(), Actor: m.actor, Book: bookSnapshot(b)})
+}
+
+// startBookRevisions records before as the first revision of a book that
+// has none.
+func (m *MemoryStore) startBookRevisions(before models.Book) {
+	if len(m.bookRevisions[before.ID]) == 0 {
+		m.addBookRevision(before)
+	}
+}
+
+// bookRevision returns a copy of a revision that the caller may modify.
+func bookRevision(r models.BookRevision) models.BookRevision {
+	r.Book.Authors = slices.Clone(r.Book.Authors)
+	r.Book.Tags = slices.Clone(r.Book.Tags)
+	custom := r.Book.Custom
+	r.Book.Custom = map[string]interface{}{}
+	for name, value := range custom {
+		r.Book.Custom[name] = value
+	}
+	return r
+}
+
+func (m *MemoryStore) GetBookRevisions(bookID int) ([]models.BookRevision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.liveBook(bookID); !ok {
+		return nil, notFound("book", bookID)
+	}
+	revisions := []models.BookRevision{}
+	for _, r := range m.bookRevisions[bookID] {
+		revisions = append(revisions, bookRevision(r))
+	}
+	return revisions, nil
+}
+
+func (m *MemoryStore) GetBookRevision(bookID, revision int) (models.BookRevision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r, err := m.findBookRevision(bookID, revision)
+	return bookRevision(r), err
+}
+
+func (m *MemoryStore) findBookRevision(bookID, revision int) (models.BookRevision, error) {
+	if _, ok := m.liveBook(bookID); !ok {
+		return models.BookRevision{}, notFound("book", bookID)
+	}
+	revisions := m.bookRevisions[bookID]
+	if revision < 1 || revision > len(revisions) {
+		return models.BookRevision{}, fmt.Errorf("revision %d of book %d: %w", revision, bookID, ErrNotFound)
+	}
+	return revisions[revision-1], nil
+}
+
+func (m *MemoryStore) RestoreBookRevision(bookID, revision, version int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, err := m.findBookRevision(bookID, revision)
+	if err != nil {
+		return err
+	}
+	authors := map[int]models.Author{}
+	for _, a := range r.Book.Authors {
+		if author, ok := m.authors[a.ID]; ok {
+			authors[a.ID] = author
+		}
+	}
+	b := restorable(r.Book, authors, m.customFieldsByName())
+	b.ID, b.Version = bookID, version
+	return m.updateBook(b)
+}
+
+// collectionState returns a collection as its revisions keep it, with the
+// books that are not in the trash.
+func (m *MemoryStore) collectionState(id int) models.CollectionRevision {
+	r := models.CollectionRevision{Name: m.collections[id].Name, BookIDs: []int{}}
+	for _, bookID := range m.collectionBooks[id] {
+		if _, ok := m.liveBook(bookID); ok {
+			r.BookIDs = append(r.BookIDs, bookID)
+		}
+	}
+	sort.Ints(r.BookIDs)
+	return r
+}
+
+func (m *MemoryStore) insertCollectionRevision(id int, r models.CollectionRevision) {
+	revisions := m.colRevisions[id]
+	r.Revision, r.At, r.Actor = len(revisions)+1, now(), m.actor
+	m.colRevisions[id] = append(revisions, r)
+}
+
+// addCollectionRevision records the collection as it is now as its next
+// revision.
+func (m *MemoryStore) addCollectionRevision(id int) {
+	m.insertCollectionRevision(id, m.collectionState(id))
+}
+
+// startCollectionRevisions records before as the first revision of a
+// collection that has none.
+func (m *MemoryStore) startCollectionRevisions(id int, before models.CollectionRevision) {
+	if len(m.colRevisions[id]) == 0 {
+		m.insertCollectionRevision(id, before)
+	}
+}
+
+func (m *MemoryStore) GetCollectionRevisions(collectionID int) ([]models.CollectionRevision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.liveCollection(collectionID); !ok {
+		return nil, notFound("collection", collectionID)
+	}
+	revisions := []models.CollectionRevision{}
+	for _, r := range m.colRevisions[collectionID] {
+		r.BookIDs = slices.Clone(r.BookIDs)
+		revisions = append(revisions, r)
+	}
+	return revisions, nil
+}
+
+func (m *MemoryStore) GetCollectionRevision(collectionID, revision int) (models.CollectionRevision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r, err := m.findCollectionRevision(collectionID, revision)
+	r.BookIDs = slices.Clone(r.BookIDs)
+	return r, err
+}
+
+func (m *MemoryStore) findCollectionRevision(collectionID, revision int) (models.CollectionRevision, error) {
+	if _, ok := m.liveCollection(collectionID); !ok {
+		return models.CollectionRevision{}, notFound("collection", collectionID)
+	}
+	revisions := m.colRevisions[collectionID]
+	if revision < 1 || revision > len(revisions) {
+		return models.CollectionRevision{}, fmt.Errorf("revision %d of collection %d: %w", revision, collectionID, ErrNotFound)
+	}
+	return revisions[revision-1], nil
+}
+
+func (m *MemoryStore) RestoreCollectionRevision(collectionID, revision, version int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, err := m.findCollectionRevision(collectionID, revision)
+	if err != nil {
+		return err
+	}
+	existing := m.collections[collectionID]
+	if err := checkVersion("collection", collectionID, existing.Version, version); err != nil {
+		return err
+	}
+	before := m.collectionState(collectionID)
+	existing.Name = r.Name
+	existing.Version++
+	m.collections[collectionID] = existing
+	m.startCollectionRevisions(collectionID, before)
+	err = m.audit(models.AuditEntry{Action: models.AuditUpdate, Entity: models.AuditCollection, CollectionID: collectionID},
+		models.Collection{Name: before.Name}, models.Collection{Name: r.Name})
+	if err != nil {
+		return err
+	}
+
+	membership := func(action string, bookID int) error {
+		return m.audit(models.AuditEntry{Action: action, Entity: models.AuditMembership,
+			BookID: bookID, CollectionID: collectionID}, nil, nil)
+	}
+	for _, bookID := range before.BookIDs {
+		if slices.Contains(r.BookIDs, bookID) {
+			continue
+		}
+		m.removeMembership(collectionID, bookID)
+		if err := membership(models.AuditDelete, bookID); err != nil {
+			return err
+		}
+	}
+	for _, bookID := range r.BookIDs {
+		if _, ok := m.liveBook(bookID); !ok || slices.Contains(before.BookIDs, bookID) {
+			continue
+		}
+		m.collectionBooks[collectionID] = append(m.collectionBooks[collectionID], bookID)
+		if err := membership(models.AuditCreate, bookID); err != nil {
+			return err
+		}
+	}
+	m.addCollectionRevision(collectionID)
+	return nil
+}
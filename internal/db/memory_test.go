@@ -0,0 +1,121 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore_Books(t *testing.T) {
+	store := NewMemoryStore()
+
+	// Create books
+	id, err := store.CreateBook(models.Book{Title: "First Book", Author: "Test Author", PublishedDate: "2021-05-01", Genre: "Test Genre"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, id)
+	id, err = store.CreateBook(models.Book{Title: "Second Book", Author: "Other Author", PublishedDate: "2023-05-01", Genre: "Test Genre"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, id)
+
+	// Retrieve book
+	book, err := store.GetBook(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "First Book", book.Title)
+	assert.False(t, book.CreatedAt.IsZero())
+	assert.Equal(t, book.CreatedAt, book.UpdatedAt)
+
+	_, err = store.GetBook(42)
+	assert.Error(t, err)
+
+	// Retrieve books with filters
+	books, _, err := store.GetBooks(BookFilter{}, ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, books, 2)
+	assert.Equal(t, 1, books[0].ID)
+
+	books, _, err = store.GetBooks(BookFilter{Author: "Other Author"}, ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, books, 1)
+	assert.Equal(t, "Second Book", books[0].Title)
+
+	books, _, err = store.GetBooks(BookFilter{Genre: "Test Genre", From: "2021-01-01", To: "2021-12-31"}, ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, books, 1)
+	assert.Equal(t, "First Book", books[0].Title)
+
+	// Update book
+	time.Sleep(1 * time.Second)
+	err = store.UpdateBook(models.Book{ID: 1, Title: "Updated Book", Author: "Test Author", PublishedDate: "2021-05-01"})
+	assert.NoError(t, err)
+	updated, err := store.GetBook(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Updated Book", updated.Title)
+	assert.Equal(t, book.CreatedAt, updated.CreatedAt)
+	assert.NotEqual(t, updated.CreatedAt, updated.UpdatedAt)
+
+	// Delete book
+	err = store.DeleteBook(1, 0)
+	assert.NoError(t, err)
+	_, err = store.GetBook(1)
+	assert.Error(t, err)
+}
+
+func TestMemoryStore_Collections(t *testing.T) {
+	store := NewMemoryStore()
+
+	bookID, err := store.CreateBook(models.Book{Title: "Test Book", Author: "Test Author", PublishedDate: "2022-01-01"})
+	assert.NoError(t, err)
+	collectionID, err := store.CreateCollection(models.Collection{Name: "Test Collection"})
+	assert.NoError(t, err)
+
+	// Add book to collection
+	err = store.AddBookToCollection(collectionID, bookID)
+	assert.NoError(t, err)
+	err = store.AddBookToCollection(collectionID, bookID)
+	assert.Error(t, err)
+	err = store.AddBookToCollection(collectionID, 42)
+	assert.Error(t, err)
+
+	inCollection, err := store.IsBookInCollection(collectionID, bookID)
+	assert.NoError(t, err)
+	assert.True(t, inCollection)
+
+	collection, err := store.GetCollection(collectionID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Test Collection", collection.Name)
+	assert.Len(t, collection.Books, 1)
+	assert.Equal(t, "Test Book", collection.Books[0].Title)
+
+	// Listing collections does not include books
+	collections, _, err := store.GetCollections(ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, collections, 1)
+	assert.Empty(t, collections[0].Books)
+
+	// Update collection
+	err = store.UpdateCollection(models.Collection{ID: collectionID, Name: "Updated Collection"})
+	assert.NoError(t, err)
+	collection, err = store.GetCollection(collectionID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Updated Collection", collection.Name)
+	assert.Len(t, collection.Books, 1)
+
+	// Deleting a book hides it in its collections, purging it removes it
+	err = store.DeleteBook(bookID, 0)
+	assert.NoError(t, err)
+	collection, err = store.GetCollection(collectionID)
+	assert.NoError(t, err)
+	assert.Empty(t, collection.Books)
+	emptyTrash(t, store)
+	inCollection, err = store.IsBookInCollection(collectionID, bookID)
+	assert.NoError(t, err)
+	assert.False(t, inCollection)
+
+	// Delete collection
+	err = store.DeleteCollection(collectionID, 0)
+	assert.NoError(t, err)
+	_, err = store.GetCollection(collectionID)
+	assert.Error(t, err)
+}
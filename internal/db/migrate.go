@@ -0,0 +1,294 @@
+package db
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migrations are embedded into the binary so that a fresh or outdated
+// database can be brought up to date without any external files. Every driver
+// has its own directory of migrations, each consisting of a pair of files
+// named NNNN_description.up.sql and NNNN_description.down.sql. Both
+// directories must define the same versions.
+//
+//go:embed migrations/sqlite/*.sql migrations/postgres/*.sql
+var migrationFiles embed.FS
+
+var migrationDirs = map[string]string{
+	SQLite:   "migrations/sqlite",
+	Postgres: "migrations/postgres",
+}
+
+var schemaVersionTables = map[string]string{
+	SQLite: `
+CREATE TABLE IF NOT EXISTS schema_version (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TEXT DEFAULT (datetime('now'))
+);`,
+	Postgres: `
+CREATE TABLE IF NOT EXISTS schema_version (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMPTZ DEFAULT now()
+);`,
+}
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrations returns all embedded migrations for the given driver ordered by
+// version.
+func Migrations(driver string) ([]Migration, error) {
+	dir, ok := migrationDirs[driver]
+	if !ok {
+		return nil, fmt.Errorf("no migrations for driver %q", driver)
+	}
+	return loadMigrations(migrationFiles, dir)
+}
+
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		filename := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(filename, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(filename, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(filename, "."+direction+".sql")
+		prefix, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %q", filename)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q: %w", filename, err)
+		}
+
+		contents, err := fs.ReadFile(fsys, path.Join(dir, filename))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("conflicting names for migration %d: %q and %q", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(contents)
+		} else {
+			m.Down = string(contents)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d (%s) has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// LatestVersion returns the version of the newest embedded migration for the
+// given driver.
+func LatestVersion(driver string) (int, error) {
+	migrations, err := Migrations(driver)
+	if err != nil {
+		return 0, err
+	}
+	if len(migrations) == 0 {
+		return 0, nil
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// SchemaVersion returns the version of the last migration applied to the
+// database, or 0 if none has been applied yet.
+func (db *DB) SchemaVersion() (int, error) {
+	if _, err := db.Exec(schemaVersionTables[db.Driver]); err != nil {
+		return 0, err
+	}
+	var version int
+	err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version)
+	return version, err
+}
+
+// MigrationStatus reports, for every embedded migration, whether and when it
+// was applied to the database.
+func (db *DB) MigrationStatus() ([]MigrationStatus, error) {
+	migrations, err := Migrations(db.Driver)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schemaVersionTables[db.Driver]); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query("SELECT version, applied_at FROM schema_version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	appliedAt := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, timestamp{&at}); err != nil {
+			return nil, err
+		}
+		appliedAt[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		at, applied := appliedAt[m.Version]
+		statuses = append(statuses, MigrationStatus{Migration: m, Applied: applied, AppliedAt: at})
+	}
+	return statuses, nil
+}
+
+// Migrate brings the database up to the latest embedded migration.
+func (db *DB) Migrate() error {
+	latest, err := LatestVersion(db.Driver)
+	if err != nil {
+		return err
+	}
+	return db.MigrateTo(latest)
+}
+
+// MigrateTo applies up or down migrations until the database is at the given
+// version. Each migration runs in its own transaction together with the
+// bookkeeping in schema_version, so a failing migration leaves the database at
+// the previous version.
+func (db *DB) MigrateTo(target int) error {
+	migrations, err := Migrations(db.Driver)
+	if err != nil {
+		return err
+	}
+	if target < 0 || (target > 0 && !hasVersion(migrations, target)) {
+		return fmt.Errorf("unknown schema version %d", target)
+	}
+
+	current, err := db.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	if target >= current {
+		for _, m := range migrations {
+			if m.Version <= current || m.Version > target {
+				continue
+			}
+			if err := db.applyMigration(m.Version, m.Name, m.Up, true); err != nil {
+				return fmt.Errorf("migration %d (%s) up: %w", m.Version, m.Name, err)
+			}
+		}
+		return nil
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version > current || m.Version <= target {
+			continue
+		}
+		if m.Down == "" {
+			return fmt.Errorf("migration %d (%s) cannot be reverted", m.Version, m.Name)
+		}
+		if err := db.applyMigration(m.Version, m.Name, m.Down, false); err != nil {
+			return fmt.Errorf("migration %d (%s) down: %w", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+func (db *DB) applyMigration(version int, name, script string, up bool) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	script, err = db.adaptScript(tx, script)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(script); err != nil {
+		return err
+	}
+	if up {
+		_, err = tx.Exec(db.rebind("INSERT INTO schema_version (version, name) VALUES (?, ?)"), version, name)
+	} else {
+		_, err = tx.Exec(db.rebind("DELETE FROM schema_version WHERE version = ?"), version)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// adaptScript adjusts a migration to the capabilities of the database.
+// go-sqlite3 only includes FTS5 when built with the sqlite_fts5 tag, so
+// without it full-text indexes are created with FTS4 instead. The search
+// queries check which of the two the index ended up using.
+func (db *DB) adaptScript(tx *sql.Tx, script string) (string, error) {
+	if db.Driver != SQLite || !strings.Contains(script, "USING fts5(") {
+		return script, nil
+	}
+	var fts5 bool
+	if err := tx.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5); err != nil {
+		return "", err
+	}
+	if fts5 {
+		return script, nil
+	}
+	return strings.ReplaceAll(script, "USING fts5(", "USING fts4("), nil
+}
+
+func hasVersion(migrations []Migration, version int) bool {
+	for _, m := range migrations {
+		if m.Version == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tableExists(t *testing.T, db *DB, name string) bool {
+	t.Helper()
+	query := "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?"
+	if db.Driver == Postgres {
+		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = ?"
+	}
+	var count int
+	err := db.QueryRow(query, name).Scan(&count)
+	assert.NoError(t, err)
+	return count > 0
+}
+
+func TestDB_InitDBAppliesMigrations(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *DB) {
+		latest, err := LatestVersion(db.Driver)
+		assert.NoError(t, err)
+
+		version, err := db.SchemaVersion()
+		assert.NoError(t, err)
+		assert.Equal(t, latest, version)
+
+		assert.True(t, tableExists(t, db, "books"))
+		assert.True(t, tableExists(t, db, "collections"))
+		assert.True(t, tableExists(t, db, "collection_books"))
+	})
+}
+
+func TestDB_MigrateIsIdempotent(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *DB) {
+		_, err := db.Exec("INSERT INTO books (title, author, published_date) VALUES (?, ?, ?)", "Test Book", "Test Author", "2022-01-01")
+		assert.NoError(t, err)
+
+		// Running the migrations again must neither fail nor touch existing data
+		err = db.Migrate()
+		assert.NoError(t, err)
+
+		var count int
+		err = db.QueryRow("SELECT COUNT(*) FROM books").Scan(&count)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+}
+
+func TestMigrations_SameVersionsForAllDrivers(t *testing.T) {
+	sqliteMigrations, err := Migrations(SQLite)
+	assert.NoError(t, err)
+	postgresMigrations, err := Migrations(Postgres)
+	assert.NoError(t, err)
+
+	assert.Equal(t, len(sqliteMigrations), len(postgresMigrations))
+	for i := range sqliteMigrations {
+		if i >= len(postgresMigrations) {
+			break
+		}
+		assert.Equal(t, sqliteMigrations[i].Version, postgresMigrations[i].Version)
+		assert.Equal(t, sqliteMigrations[i].Name, postgresMigrations[i].Name)
+	}
+}
+
+func TestDB_MigrateAdoptsUnversionedDatabase(t *testing.T) {
+	db := openTestDB(t, SQLite)
+	defer db.Close()
+
+	// Databases created by piping the old schema.sql into sqlite3 have the
+	// tables but no schema_version bookkeeping
+	migrations, err := Migrations(SQLite)
+	assert.NoError(t, err)
+	_, err = db.Exec(migrations[0].Up)
+	assert.NoError(t, err)
+	_, err = db.Exec("INSERT INTO collections (name) VALUES (?)", "Test Collection")
+	assert.NoError(t, err)
+
+	err = db.Migrate()
+	assert.NoError(t, err)
+
+	var name string
+	err = db.QueryRow("SELECT name FROM collections WHERE id = 1").Scan(&name)
+	assert.NoError(t, err)
+	assert.Equal(t, "Test Collection", name)
+}
+
+func TestDB_MigrateDownAndUp(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *DB) {
+		err := db.MigrateTo(0)
+		assert.NoError(t, err)
+
+		version, err := db.SchemaVersion()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, version)
+		assert.False(t, tableExists(t, db, "books"))
+		assert.False(t, tableExists(t, db, "collections"))
+		assert.False(t, tableExists(t, db, "collection_books"))
+
+		err = db.Migrate()
+		assert.NoError(t, err)
+		assert.True(t, tableExists(t, db, "books"))
+
+		err = db.MigrateTo(9999)
+		assert.Error(t, err)
+	})
+}
+
+func TestDB_MigrationStatus(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, driver string) {
+		db := openTestDB(t, driver)
+		defer db.Close()
+
+		statuses, err := db.MigrationStatus()
+		assert.NoError(t, err)
+		assert.NotEmpty(t, statuses)
+		for _, s := range statuses {
+			assert.False(t, s.Applied)
+		}
+
+		err = db.Migrate()
+		assert.NoError(t, err)
+
+		statuses, err = db.MigrationStatus()
+		assert.NoError(t, err)
+		for _, s := range statuses {
+			assert.True(t, s.Applied)
+			assert.False(t, s.AppliedAt.IsZero())
+		}
+	})
+}
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0002_second.up.sql":   {Data: []byte("CREATE TABLE b (id INTEGER);")},
+		"m/0002_second.down.sql": {Data: []byte("DROP TABLE b;")},
+		"m/0001_first.up.sql":    {Data: []byte("CREATE TABLE a (id INTEGER);")},
+		"m/README.md":            {Data: []byte("ignored")},
+	}
+
+	migrations, err := loadMigrations(fsys, "m")
+	assert.NoError(t, err)
+	assert.Len(t, migrations, 2)
+	assert.Equal(t, 1, migrations[0].Version)
+	assert.Equal(t, "first", migrations[0].Name)
+	assert.Empty(t, migrations[0].Down)
+	assert.Equal(t, 2, migrations[1].Version)
+	assert.Equal(t, "DROP TABLE b;", migrations[1].Down)
+
+	_, err = loadMigrations(fstest.MapFS{"m/0003_orphan.down.sql": {Data: []byte("DROP TABLE c;")}}, "m")
+	assert.Error(t, err)
+
+	_, err = loadMigrations(fstest.MapFS{"m/x_bad.up.sql": {Data: []byte("SELECT 1;")}}, "m")
+	assert.Error(t, err)
+}
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_collection_books_book_id;
+DROP INDEX IF EXISTS idx_collection_books_collection_id;
+DROP TABLE IF EXISTS collection_books;
+
+DROP TABLE IF EXISTS collections;
+
+DROP INDEX IF EXISTS idx_books_published_date;
+DROP INDEX IF EXISTS idx_books_genre;
+DROP INDEX IF EXISTS idx_books_author;
+DROP TABLE IF EXISTS books;
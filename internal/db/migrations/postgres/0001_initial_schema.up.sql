@@ -0,0 +1,39 @@
+-- Books table
+CREATE TABLE IF NOT EXISTS books (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    title TEXT NOT NULL,
+    author TEXT NOT NULL,
+    published_date TEXT NOT NULL,
+    edition TEXT,
+    description TEXT,
+    genre TEXT,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+-- Create indexes for books table
+CREATE INDEX IF NOT EXISTS idx_books_author ON books(author);
+CREATE INDEX IF NOT EXISTS idx_books_genre ON books(genre);
+CREATE INDEX IF NOT EXISTS idx_books_published_date ON books(published_date);
+
+-- Collections table
+CREATE TABLE IF NOT EXISTS collections (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+-- CollectionBooksMapping table (for many-to-many relationship)
+CREATE TABLE IF NOT EXISTS collection_books (
+    collection_id INTEGER NOT NULL,
+    book_id INTEGER NOT NULL,
+    added_at TIMESTAMPTZ DEFAULT now(),
+    PRIMARY KEY (collection_id, book_id),
+    FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE,
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+);
+
+-- Create index for collection_books table
+CREATE INDEX IF NOT EXISTS idx_collection_books_collection_id ON collection_books(collection_id);
+CREATE INDEX IF NOT EXISTS idx_collection_books_book_id ON collection_books(book_id);
@@ -0,0 +1,6 @@
+-- ISBNs in both forms. Books without one store NULL, so the unique index
+-- only applies to books that have an ISBN. Every ISBN-10 has an ISBN-13, so
+-- indexing the latter is enough to keep ISBNs unique.
+ALTER TABLE books ADD COLUMN isbn_10 TEXT;
+ALTER TABLE books ADD COLUMN isbn_13 TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_books_isbn_13 ON books(isbn_13);
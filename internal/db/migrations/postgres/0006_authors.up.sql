@@ -0,0 +1,33 @@
+-- Authors, credited on books in order and with a role. books.author is kept
+-- as the display form of the authors, for sorting and full-text search.
+CREATE TABLE IF NOT EXISTS authors (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS book_authors (
+    book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    author_id INTEGER NOT NULL REFERENCES authors(id),
+    role TEXT NOT NULL DEFAULT 'author' CHECK (role IN ('author', 'editor', 'translator', 'illustrator')),
+    position INTEGER NOT NULL,
+    PRIMARY KEY (book_id, author_id, role)
+);
+
+CREATE INDEX IF NOT EXISTS idx_book_authors_author_id ON book_authors(author_id);
+
+-- Split the existing author strings into authors. Names are separated by
+-- commas, " and " or " & ", like splitAuthors does for new books.
+INSERT INTO authors (name)
+SELECT DISTINCT trim(part)
+FROM books CROSS JOIN LATERAL regexp_split_to_table(replace(replace(author, ' & ', ','), ' and ', ','), ',') AS part
+WHERE trim(part) <> ''
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO book_authors (book_id, author_id, role, position)
+SELECT books.id, authors.id, 'author', split.position
+FROM books
+CROSS JOIN LATERAL regexp_split_to_table(replace(replace(books.author, ' & ', ','), ' and ', ','), ',') WITH ORDINALITY AS split(part, position)
+JOIN authors ON authors.name = trim(split.part)
+ON CONFLICT DO NOTHING;
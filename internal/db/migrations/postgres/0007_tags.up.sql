@@ -0,0 +1,17 @@
+-- Tags classify books along any number of axes, unlike the single genre.
+-- Names are stored normalized: lower case with single spaces.
+CREATE TABLE IF NOT EXISTS tags (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS book_tags (
+    book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (book_id, tag_id)
+);
+
+-- The primary key serves lookups by book, this one the tag filters
+CREATE INDEX IF NOT EXISTS idx_book_tags_tag_id ON book_tags(tag_id);
@@ -0,0 +1,19 @@
+-- Series of books, such as the volumes of a multi-volume work. Positions are
+-- fractional, so a novella can sit at 2.5 between the second and third book.
+CREATE TABLE IF NOT EXISTS series (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS series_books (
+    series_id INTEGER NOT NULL REFERENCES series(id) ON DELETE CASCADE,
+    book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    position DOUBLE PRECISION NOT NULL CHECK (position >= 0),
+    PRIMARY KEY (series_id, book_id),
+    UNIQUE (series_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS idx_series_books_book_id ON series_books(book_id);
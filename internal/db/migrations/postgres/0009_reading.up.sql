@@ -0,0 +1,26 @@
+-- Reading log. Every read of a book is a session, so re-reads are kept as
+-- separate sessions, and the status of the latest session is the reading
+-- status of the book. Dates are YYYY-MM-DD, empty when unknown.
+CREATE TABLE IF NOT EXISTS reading_sessions (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    status TEXT NOT NULL CHECK (status IN ('want-to-read', 'reading', 'finished', 'abandoned')),
+    started_on TEXT NOT NULL DEFAULT '',
+    finished_on TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reading_sessions_book_id ON reading_sessions(book_id);
+
+-- Progress through a session over time, as a page, a percentage or both
+CREATE TABLE IF NOT EXISTS reading_progress (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    session_id INTEGER NOT NULL REFERENCES reading_sessions(id) ON DELETE CASCADE,
+    page INTEGER CHECK (page >= 0),
+    percent DOUBLE PRECISION CHECK (percent >= 0 AND percent <= 100),
+    recorded_at TIMESTAMPTZ DEFAULT now(),
+    CHECK (page IS NOT NULL OR percent IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reading_progress_session_id ON reading_progress(session_id);
@@ -0,0 +1,32 @@
+-- Ratings and reviews. There are no user accounts, so a review is signed
+-- with the reviewer's name and every reviewer reviews a book at most once.
+-- Ratings go from 1 to 5 in half stars, the body is markdown.
+CREATE TABLE IF NOT EXISTS reviews (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    reviewer TEXT NOT NULL,
+    rating DOUBLE PRECISION NOT NULL CHECK (rating >= 1 AND rating <= 5 AND rating * 2 = ROUND(rating * 2)),
+    body TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    UNIQUE (book_id, reviewer)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reviews_reviewer ON reviews(reviewer);
+
+-- Earlier versions of reviews, each with the time it was written
+CREATE TABLE IF NOT EXISTS review_history (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    review_id INTEGER NOT NULL REFERENCES reviews(id) ON DELETE CASCADE,
+    rating DOUBLE PRECISION NOT NULL,
+    body TEXT NOT NULL,
+    written_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_history_review_id ON review_history(review_id);
+
+-- The average rating is kept on the book, rounded to two decimals and 0
+-- while there are no ratings, so books can be filtered and sorted by it
+ALTER TABLE books ADD COLUMN average_rating DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE books ADD COLUMN rating_count INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_books_average_rating ON books(average_rating);
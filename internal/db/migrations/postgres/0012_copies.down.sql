@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS copies;
+DROP TABLE IF EXISTS locations;
@@ -0,0 +1,30 @@
+-- Places books are kept in, nested as room > bookcase > shelf. Names are
+-- unique among the locations sharing a parent.
+CREATE TABLE IF NOT EXISTS locations (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    parent_id INTEGER REFERENCES locations(id),
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('room', 'bookcase', 'shelf')),
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_locations_parent_id_name ON locations(COALESCE(parent_id, 0), name);
+
+-- Physical and digital copies of books. A copy is kept at a location or
+-- nowhere in particular, like an ebook. Barcodes are unique when set.
+CREATE TABLE IF NOT EXISTS copies (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    format TEXT NOT NULL CHECK (format IN ('hardcover', 'paperback', 'ebook', 'audiobook', 'other')),
+    condition TEXT NOT NULL DEFAULT '' CHECK (condition IN ('', 'new', 'fine', 'good', 'fair', 'poor')),
+    acquired_on TEXT NOT NULL DEFAULT '',
+    price NUMERIC(10, 2) CHECK (price >= 0),
+    barcode TEXT UNIQUE,
+    location_id INTEGER REFERENCES locations(id),
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_copies_book_id ON copies(book_id);
+CREATE INDEX IF NOT EXISTS idx_copies_location_id ON copies(location_id);
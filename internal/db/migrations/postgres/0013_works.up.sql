@@ -0,0 +1,16 @@
+-- Works group the editions and translations of the same text. A book that
+-- belongs to no work stands for a work of its own. The original publication
+-- date is YYYY-MM-DD, empty when unknown.
+CREATE TABLE IF NOT EXISTS works (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    title TEXT NOT NULL,
+    original_language TEXT NOT NULL DEFAULT '',
+    original_published_date TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_works_title ON works(title);
+
+ALTER TABLE books ADD COLUMN work_id INTEGER REFERENCES works(id);
+CREATE INDEX IF NOT EXISTS idx_books_work_id ON books(work_id);
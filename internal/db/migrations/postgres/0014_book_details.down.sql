@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_books_language;
+DROP INDEX IF EXISTS idx_books_publisher_id;
+ALTER TABLE books DROP COLUMN thickness_mm;
+ALTER TABLE books DROP COLUMN width_mm;
+ALTER TABLE books DROP COLUMN height_mm;
+ALTER TABLE books DROP COLUMN format;
+ALTER TABLE books DROP COLUMN page_count;
+ALTER TABLE books DROP COLUMN language;
+ALTER TABLE books DROP COLUMN publisher_id;
+DROP TABLE IF EXISTS publishers;
@@ -0,0 +1,21 @@
+-- Publishers, named on books and renamed in one place
+CREATE TABLE IF NOT EXISTS publishers (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+-- Details of the edition. The language is a BCP 47 tag and the dimensions
+-- are in millimetres; existing books start out with all of them unknown,
+-- which is '' or 0.
+ALTER TABLE books ADD COLUMN publisher_id INTEGER REFERENCES publishers(id);
+ALTER TABLE books ADD COLUMN language TEXT NOT NULL DEFAULT '';
+ALTER TABLE books ADD COLUMN page_count INTEGER NOT NULL DEFAULT 0 CHECK (page_count >= 0);
+ALTER TABLE books ADD COLUMN format TEXT NOT NULL DEFAULT '' CHECK (format IN ('', 'hardcover', 'paperback', 'ebook', 'audiobook', 'other'));
+ALTER TABLE books ADD COLUMN height_mm DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (height_mm >= 0);
+ALTER TABLE books ADD COLUMN width_mm DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (width_mm >= 0);
+ALTER TABLE books ADD COLUMN thickness_mm DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (thickness_mm >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_books_publisher_id ON books(publisher_id);
+CREATE INDEX IF NOT EXISTS idx_books_language ON books(language);
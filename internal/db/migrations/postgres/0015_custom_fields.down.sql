@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_book_custom_values_field_id;
+DROP TABLE IF EXISTS book_custom_values;
+DROP TABLE IF EXISTS custom_field_choices;
+DROP TABLE IF EXISTS custom_fields;
@@ -0,0 +1,29 @@
+-- Custom fields are defined at run time and give books values beyond their
+-- built-in columns. Values are stored as text in a canonical form per type:
+-- integers in decimal, dates as YYYY-MM-DD and booleans as true or false.
+CREATE TABLE IF NOT EXISTS custom_fields (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    type TEXT NOT NULL CHECK (type IN ('string', 'int', 'date', 'bool', 'enum')),
+    pattern TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+-- The values an enum field accepts, in the order they are listed
+CREATE TABLE IF NOT EXISTS custom_field_choices (
+    field_id INTEGER NOT NULL REFERENCES custom_fields(id) ON DELETE CASCADE,
+    value TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (field_id, value)
+);
+
+CREATE TABLE IF NOT EXISTS book_custom_values (
+    book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    field_id INTEGER NOT NULL REFERENCES custom_fields(id) ON DELETE CASCADE,
+    value TEXT NOT NULL,
+    PRIMARY KEY (book_id, field_id)
+);
+
+-- The primary key serves lookups by book, this one the custom field filters
+CREATE INDEX IF NOT EXISTS idx_book_custom_values_field_id ON book_custom_values(field_id, value);
@@ -0,0 +1,11 @@
+-- The cover image of a book. The image and its thumbnails are kept in a
+-- blob store, this only records what was uploaded.
+CREATE TABLE IF NOT EXISTS book_covers (
+    book_id INTEGER PRIMARY KEY REFERENCES books(id) ON DELETE CASCADE,
+    content_type TEXT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    size INTEGER NOT NULL,
+    digest TEXT NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
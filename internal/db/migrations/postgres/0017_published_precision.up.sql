@@ -0,0 +1,5 @@
+-- Publication dates known only to the month or year, or approximately.
+-- published_date keeps the first day of the date as written, which books
+-- sort by, and the precision says how much of it is known. Existing dates
+-- are full dates.
+ALTER TABLE books ADD COLUMN published_precision TEXT NOT NULL DEFAULT 'day' CHECK (published_precision IN ('day', 'month', 'year', 'circa'));
@@ -0,0 +1,11 @@
+-- Without the column books and collections in the trash would come back,
+-- so they are purged first. Their other rows go with them.
+DELETE FROM collections WHERE deleted_at IS NOT NULL;
+DELETE FROM books WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_books_isbn_13;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_books_isbn_13 ON books(isbn_13);
+DROP INDEX IF EXISTS idx_collections_deleted_at;
+DROP INDEX IF EXISTS idx_books_deleted_at;
+ALTER TABLE collections DROP COLUMN deleted_at;
+ALTER TABLE books DROP COLUMN deleted_at;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS collection_revisions;
+DROP TABLE IF EXISTS book_revisions;
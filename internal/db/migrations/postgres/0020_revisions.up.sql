@@ -0,0 +1,21 @@
+-- Full revisions of books and collections, numbered from 1 for every book
+-- or collection. A book revision keeps the fields written with a book as
+-- JSON, a collection revision its name and the ids of its books.
+CREATE TABLE IF NOT EXISTS book_revisions (
+    book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    actor TEXT NOT NULL DEFAULT '',
+    book TEXT NOT NULL,
+    PRIMARY KEY (book_id, revision)
+);
+
+CREATE TABLE IF NOT EXISTS collection_revisions (
+    collection_id INTEGER NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    actor TEXT NOT NULL DEFAULT '',
+    name TEXT NOT NULL,
+    book_ids TEXT NOT NULL DEFAULT '[]',
+    PRIMARY KEY (collection_id, revision)
+);
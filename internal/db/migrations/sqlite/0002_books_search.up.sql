@@ -0,0 +1,21 @@
+-- Full-text index over books. The index keeps its own copy of the searchable
+-- columns, keyed by the book ID, and is kept in sync by triggers.
+CREATE VIRTUAL TABLE IF NOT EXISTS books_fts USING fts5(title, author, description, genre);
+
+INSERT INTO books_fts (rowid, title, author, description, genre)
+SELECT id, title, author, description, genre FROM books;
+
+CREATE TRIGGER IF NOT EXISTS books_fts_insert AFTER INSERT ON books BEGIN
+    INSERT INTO books_fts (rowid, title, author, description, genre)
+    VALUES (new.id, new.title, new.author, new.description, new.genre);
+END;
+
+CREATE TRIGGER IF NOT EXISTS books_fts_update AFTER UPDATE ON books BEGIN
+    UPDATE books_fts
+    SET title = new.title, author = new.author, description = new.description, genre = new.genre
+    WHERE rowid = old.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS books_fts_delete AFTER DELETE ON books BEGIN
+    DELETE FROM books_fts WHERE rowid = old.id;
+END;
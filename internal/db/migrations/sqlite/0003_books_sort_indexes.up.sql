@@ -0,0 +1,3 @@
+-- Indexes backing the sort orders of the paged book listing
+CREATE INDEX IF NOT EXISTS idx_books_title ON books(title);
+CREATE INDEX IF NOT EXISTS idx_books_created_at ON books(created_at);
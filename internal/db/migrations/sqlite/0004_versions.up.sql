@@ -0,0 +1,3 @@
+-- Versions for optimistic concurrency control, bumped on every change
+ALTER TABLE books ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE collections ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_books_isbn_13;
+ALTER TABLE books DROP COLUMN isbn_13;
+ALTER TABLE books DROP COLUMN isbn_10;
@@ -0,0 +1,41 @@
+-- Authors, credited on books in order and with a role. books.author is kept
+-- as the display form of the authors, for sorting and full-text search.
+CREATE TABLE IF NOT EXISTS authors (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    created_at TEXT DEFAULT (datetime('now')),
+    updated_at TEXT DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS book_authors (
+    book_id INTEGER NOT NULL,
+    author_id INTEGER NOT NULL,
+    role TEXT NOT NULL DEFAULT 'author' CHECK (role IN ('author', 'editor', 'translator', 'illustrator')),
+    position INTEGER NOT NULL,
+    PRIMARY KEY (book_id, author_id, role),
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+    FOREIGN KEY (author_id) REFERENCES authors(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_book_authors_author_id ON book_authors(author_id);
+
+-- Split the existing author strings into authors. Names are separated by
+-- commas, " and " or " & ", like splitAuthors does for new books.
+WITH RECURSIVE split(book_id, rest, name, position) AS (
+    SELECT id, REPLACE(REPLACE(author, ' & ', ','), ' and ', ',') || ',', '', 0 FROM books
+    UNION ALL
+    SELECT book_id, substr(rest, instr(rest, ',') + 1), trim(substr(rest, 1, instr(rest, ',') - 1)), position + 1
+    FROM split WHERE rest <> ''
+)
+INSERT OR IGNORE INTO authors (name)
+SELECT name FROM split WHERE name <> '' ORDER BY book_id, position;
+
+WITH RECURSIVE split(book_id, rest, name, position) AS (
+    SELECT id, REPLACE(REPLACE(author, ' & ', ','), ' and ', ',') || ',', '', 0 FROM books
+    UNION ALL
+    SELECT book_id, substr(rest, instr(rest, ',') + 1), trim(substr(rest, 1, instr(rest, ',') - 1)), position + 1
+    FROM split WHERE rest <> ''
+)
+INSERT OR IGNORE INTO book_authors (book_id, author_id, role, position)
+SELECT split.book_id, authors.id, 'author', split.position
+FROM split JOIN authors ON authors.name = split.name;
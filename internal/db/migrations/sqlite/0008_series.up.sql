@@ -0,0 +1,21 @@
+-- Series of books, such as the volumes of a multi-volume work. Positions are
+-- fractional, so a novella can sit at 2.5 between the second and third book.
+CREATE TABLE IF NOT EXISTS series (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TEXT DEFAULT (datetime('now')),
+    updated_at TEXT DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS series_books (
+    series_id INTEGER NOT NULL,
+    book_id INTEGER NOT NULL,
+    position REAL NOT NULL CHECK (position >= 0),
+    PRIMARY KEY (series_id, book_id),
+    UNIQUE (series_id, position),
+    FOREIGN KEY (series_id) REFERENCES series(id) ON DELETE CASCADE,
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_series_books_book_id ON series_books(book_id);
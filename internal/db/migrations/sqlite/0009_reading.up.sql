@@ -0,0 +1,28 @@
+-- Reading log. Every read of a book is a session, so re-reads are kept as
+-- separate sessions, and the status of the latest session is the reading
+-- status of the book. Dates are YYYY-MM-DD, empty when unknown.
+CREATE TABLE IF NOT EXISTS reading_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    book_id INTEGER NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('want-to-read', 'reading', 'finished', 'abandoned')),
+    started_on TEXT NOT NULL DEFAULT '',
+    finished_on TEXT NOT NULL DEFAULT '',
+    created_at TEXT DEFAULT (datetime('now')),
+    updated_at TEXT DEFAULT (datetime('now')),
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_reading_sessions_book_id ON reading_sessions(book_id);
+
+-- Progress through a session over time, as a page, a percentage or both
+CREATE TABLE IF NOT EXISTS reading_progress (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id INTEGER NOT NULL,
+    page INTEGER CHECK (page >= 0),
+    percent REAL CHECK (percent >= 0 AND percent <= 100),
+    recorded_at TEXT DEFAULT (datetime('now')),
+    CHECK (page IS NOT NULL OR percent IS NOT NULL),
+    FOREIGN KEY (session_id) REFERENCES reading_sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_reading_progress_session_id ON reading_progress(session_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_books_average_rating;
+ALTER TABLE books DROP COLUMN rating_count;
+ALTER TABLE books DROP COLUMN average_rating;
+DROP TABLE IF EXISTS review_history;
+DROP TABLE IF EXISTS reviews;
@@ -0,0 +1,20 @@
+-- Loans of physical books. Dates are YYYY-MM-DD, returned_at is empty while
+-- the book is out, and a book is out on at most one loan at a time.
+CREATE TABLE IF NOT EXISTS loans (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    book_id INTEGER NOT NULL,
+    borrower TEXT NOT NULL,
+    contact TEXT NOT NULL DEFAULT '',
+    loaned_at TEXT NOT NULL,
+    due_at TEXT NOT NULL,
+    returned_at TEXT NOT NULL DEFAULT '',
+    created_at TEXT DEFAULT (datetime('now')),
+    updated_at TEXT DEFAULT (datetime('now')),
+    CHECK (due_at >= loaned_at),
+    CHECK (returned_at = '' OR returned_at >= loaned_at),
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_loans_book_id ON loans(book_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_loans_open_book_id ON loans(book_id) WHERE returned_at = '';
+CREATE INDEX IF NOT EXISTS idx_loans_due_at ON loans(due_at);
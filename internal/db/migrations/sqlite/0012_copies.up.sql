@@ -0,0 +1,33 @@
+-- Places books are kept in, nested as room > bookcase > shelf. Names are
+-- unique among the locations sharing a parent.
+CREATE TABLE IF NOT EXISTS locations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    parent_id INTEGER,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('room', 'bookcase', 'shelf')),
+    created_at TEXT DEFAULT (datetime('now')),
+    updated_at TEXT DEFAULT (datetime('now')),
+    FOREIGN KEY (parent_id) REFERENCES locations(id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_locations_parent_id_name ON locations(COALESCE(parent_id, 0), name);
+
+-- Physical and digital copies of books. A copy is kept at a location or
+-- nowhere in particular, like an ebook. Barcodes are unique when set.
+CREATE TABLE IF NOT EXISTS copies (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    book_id INTEGER NOT NULL,
+    format TEXT NOT NULL CHECK (format IN ('hardcover', 'paperback', 'ebook', 'audiobook', 'other')),
+    condition TEXT NOT NULL DEFAULT '' CHECK (condition IN ('', 'new', 'fine', 'good', 'fair', 'poor')),
+    acquired_on TEXT NOT NULL DEFAULT '',
+    price REAL CHECK (price >= 0),
+    barcode TEXT UNIQUE,
+    location_id INTEGER,
+    created_at TEXT DEFAULT (datetime('now')),
+    updated_at TEXT DEFAULT (datetime('now')),
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+    FOREIGN KEY (location_id) REFERENCES locations(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_copies_book_id ON copies(book_id);
+CREATE INDEX IF NOT EXISTS idx_copies_location_id ON copies(location_id);
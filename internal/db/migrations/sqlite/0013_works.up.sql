@@ -0,0 +1,18 @@
+-- Works group the editions and translations of the same text. A book that
+-- belongs to no work stands for a work of its own. The original publication
+-- date is YYYY-MM-DD, empty when unknown.
+CREATE TABLE IF NOT EXISTS works (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    original_language TEXT NOT NULL DEFAULT '',
+    original_published_date TEXT NOT NULL DEFAULT '',
+    created_at TEXT DEFAULT (datetime('now')),
+    updated_at TEXT DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_works_title ON works(title);
+
+-- SQLite cannot drop a column with a foreign key, so the reference to the
+-- work is checked by the store instead
+ALTER TABLE books ADD COLUMN work_id INTEGER;
+CREATE INDEX IF NOT EXISTS idx_books_work_id ON books(work_id);
@@ -0,0 +1,32 @@
+-- Custom fields are defined at run time and give books values beyond their
+-- built-in columns. Values are stored as text in a canonical form per type:
+-- integers in decimal, dates as YYYY-MM-DD and booleans as true or false.
+CREATE TABLE IF NOT EXISTS custom_fields (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    type TEXT NOT NULL CHECK (type IN ('string', 'int', 'date', 'bool', 'enum')),
+    pattern TEXT NOT NULL DEFAULT '',
+    created_at TEXT DEFAULT (datetime('now')),
+    updated_at TEXT DEFAULT (datetime('now'))
+);
+
+-- The values an enum field accepts, in the order they are listed
+CREATE TABLE IF NOT EXISTS custom_field_choices (
+    field_id INTEGER NOT NULL,
+    value TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (field_id, value),
+    FOREIGN KEY (field_id) REFERENCES custom_fields(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS book_custom_values (
+    book_id INTEGER NOT NULL,
+    field_id INTEGER NOT NULL,
+    value TEXT NOT NULL,
+    PRIMARY KEY (book_id, field_id),
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+    FOREIGN KEY (field_id) REFERENCES custom_fields(id) ON DELETE CASCADE
+);
+
+-- The primary key serves lookups by book, this one the custom field filters
+CREATE INDEX IF NOT EXISTS idx_book_custom_values_field_id ON book_custom_values(field_id, value);
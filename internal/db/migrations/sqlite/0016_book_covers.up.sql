@@ -0,0 +1,12 @@
+-- The cover image of a book. The image and its thumbnails are kept in a
+-- blob store, this only records what was uploaded.
+CREATE TABLE IF NOT EXISTS book_covers (
+    book_id INTEGER PRIMARY KEY,
+    content_type TEXT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    size INTEGER NOT NULL,
+    digest TEXT NOT NULL,
+    updated_at TEXT DEFAULT (datetime('now')),
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+);
@@ -0,0 +1,24 @@
+-- Without the column books and collections in the trash would come back,
+-- so they are purged first
+DELETE FROM collection_books WHERE collection_id IN (SELECT id FROM collections WHERE deleted_at IS NOT NULL);
+DELETE FROM collections WHERE deleted_at IS NOT NULL;
+DELETE FROM collection_books WHERE book_id IN (SELECT id FROM books WHERE deleted_at IS NOT NULL);
+DELETE FROM book_authors WHERE book_id IN (SELECT id FROM books WHERE deleted_at IS NOT NULL);
+DELETE FROM book_tags WHERE book_id IN (SELECT id FROM books WHERE deleted_at IS NOT NULL);
+DELETE FROM book_custom_values WHERE book_id IN (SELECT id FROM books WHERE deleted_at IS NOT NULL);
+DELETE FROM book_covers WHERE book_id IN (SELECT id FROM books WHERE deleted_at IS NOT NULL);
+DELETE FROM series_books WHERE book_id IN (SELECT id FROM books WHERE deleted_at IS NOT NULL);
+DELETE FROM reading_progress WHERE session_id IN (SELECT id FROM reading_sessions WHERE book_id IN (SELECT id FROM books WHERE deleted_at IS NOT NULL));
+DELETE FROM reading_sessions WHERE book_id IN (SELECT id FROM books WHERE deleted_at IS NOT NULL);
+DELETE FROM review_history WHERE review_id IN (SELECT id FROM reviews WHERE book_id IN (SELECT id FROM books WHERE deleted_at IS NOT NULL));
+DELETE FROM reviews WHERE book_id IN (SELECT id FROM books WHERE deleted_at IS NOT NULL);
+DELETE FROM loans WHERE book_id IN (SELECT id FROM books WHERE deleted_at IS NOT NULL);
+DELETE FROM copies WHERE book_id IN (SELECT id FROM books WHERE deleted_at IS NOT NULL);
+DELETE FROM books WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_books_isbn_13;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_books_isbn_13 ON books(isbn_13);
+DROP INDEX IF EXISTS idx_collections_deleted_at;
+DROP INDEX IF EXISTS idx_books_deleted_at;
+ALTER TABLE collections DROP COLUMN deleted_at;
+ALTER TABLE books DROP COLUMN deleted_at;
@@ -0,0 +1,11 @@
+-- Deleted books and collections go to the trash, from which they can be
+-- restored until they are purged. Their memberships and everything else
+-- that belongs to them is kept until then.
+ALTER TABLE books ADD COLUMN deleted_at TEXT;
+ALTER TABLE collections ADD COLUMN deleted_at TEXT;
+CREATE INDEX IF NOT EXISTS idx_books_deleted_at ON books(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_collections_deleted_at ON collections(deleted_at);
+
+-- Books in the trash do not keep a new book from taking their ISBN
+DROP INDEX IF EXISTS idx_books_isbn_13;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_books_isbn_13 ON books(isbn_13) WHERE deleted_at IS NULL;
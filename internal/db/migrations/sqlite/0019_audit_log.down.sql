@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS audit_log_no_delete;
+DROP TRIGGER IF EXISTS audit_log_no_update;
+DROP INDEX IF EXISTS idx_audit_log_at;
+DROP INDEX IF EXISTS idx_audit_log_collection_id;
+DROP INDEX IF EXISTS idx_audit_log_book_id;
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,28 @@
+-- Every change to a book, a collection or the books in a collection is
+-- appended to the audit log with who made it and the fields it changed.
+-- Entries outlive what they describe, so there are no foreign keys, and the
+-- log is append-only.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    at TEXT NOT NULL DEFAULT (datetime('now')),
+    actor TEXT NOT NULL DEFAULT '',
+    action TEXT NOT NULL CHECK (action IN ('create', 'update', 'delete', 'restore', 'purge')),
+    entity TEXT NOT NULL CHECK (entity IN ('book', 'collection', 'membership')),
+    book_id INTEGER,
+    collection_id INTEGER,
+    changes TEXT NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_book_id ON audit_log(book_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_collection_id ON audit_log(collection_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_at ON audit_log(at);
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'the audit log is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'the audit log is append-only');
+END;
@@ -0,0 +1,167 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+var (
+	ErrInvalidCursor = fmt.Errorf("%w cursor", ErrInvalid)
+	ErrInvalidSort   = fmt.Errorf("%w sort field", ErrInvalid)
+)
+
+// BookSortFields and CollectionSortFields are the fields lists can be
+// ordered by. Ties are always broken by id.
+var (
+	BookSortFields       = []string{"id", "title", "author", "published_date", "created_at", "rating"}
+	CollectionSortFields = []string{"id", "name"}
+)
+
+// BookFilter restricts GetBooks to books matching all of its non-empty
+// fields. Author matches any of the book's authors by name, or the whole
+// author line. Tags holds groups of alternatives: a book must have at least
+// one tag of every group. From and To bound the published date, inclusively.
+// ISBN is a normalized ISBN-13. Status matches the reading status of the
+// latest reading session. MinRating is the lowest average rating.
+type BookFilter struct {
+	Author    string
+	AuthorID  int
+	Genre     string
+	Tags      [][]string
+	From      string
+	To        string
+	ISBN      string
+	Status    string
+	MinRating float64
+	Publisher string
+	// Language also matches the tags it is a prefix of, "en" matches "en-GB"
+	Language string
+	Format   string
+	MinPages int
+	MaxPages int
+	// MaxHeight, MaxWidth and MaxThickness are in millimetres. Like
+	// MaxPages they leave out the books for which the value is unknown.
+	MaxHeight    float64
+	MaxWidth     float64
+	MaxThickness float64
+	// Custom maps names of custom fields to the value books must have,
+	// written like in a query string, e.g. "true" for a bool field
+	Custom map[string]string
+	WorkID int
+	// CollapseWorks keeps one book per work, the matching edition with the
+	// lowest id. Books of no work are works of their own.
+	CollapseWorks bool
+}
+
+// ListOptions orders and pages a list query. Pages are keyset based: After
+// is the cursor returned with the previous page, so pages stay stable while
+// items are added or removed.
+type ListOptions struct {
+	Sort  string // one of the sort fields, defaults to "id"
+	Desc  bool
+	Limit int // zero returns all remaining items
+	After string
+}
+
+// Page tells where a page sits in the whole list.
+type Page struct {
+	Total int    // items matching the query across all pages
+	Next  string // cursor of the next page, empty on the last page
+}
+
+// cursor is the position after which the next page starts. It records the
+// ordering it was created for so it cannot be replayed against another one.
+type cursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d,omitempty"`
+	Value string `json:"v,omitempty"`
+	ID    int    `json:"id"`
+}
+
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// sortField validates the requested sort field against the allowed ones.
+func (o ListOptions) sortField(allowed []string) (string, error) {
+	if o.Sort == "" {
+		return "id", nil
+	}
+	if !slices.Contains(allowed, o.Sort) {
+		return "", fmt.Errorf("%w %q, expected one of %v", ErrInvalidSort, o.Sort, allowed)
+	}
+	return o.Sort, nil
+}
+
+// cursor decodes After, which must have been created for the same ordering.
+// It returns nil for the first page.
+func (o ListOptions) cursor(sort string) (*cursor, error) {
+	if o.After == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(o.After)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != sort || c.Desc != o.Desc {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// paginate trims a result fetched with one extra item down to the limit and
+// returns the cursor of the next page, if there is one.
+func paginate[T any](items []T, limit int, next func(T) cursor) ([]T, string) {
+	if limit <= 0 || len(items) <= limit {
+		return items, ""
+	}
+	items = items[:limit]
+	return items, next(items[limit-1]).encode()
+}
+
+// keyset returns the condition selecting the rows after the cursor and the
+// ORDER BY clause for sorting by column, with id as the tie breaker.
+func keyset(column, idColumn string, desc bool, after *cursor) (string, []interface{}, string) {
+	direction, comparison := "", ">"
+	if desc {
+		direction, comparison = " DESC", "<"
+	}
+	if column == idColumn {
+		order := idColumn + direction
+		if after == nil {
+			return "", nil, order
+		}
+		return idColumn + " " + comparison + " ?", []interface{}{after.ID}, order
+	}
+	order := column + direction + ", " + idColumn + direction
+	if after == nil {
+		return "", nil, order
+	}
+	condition := "(" + column + ", " + idColumn + ") " + comparison + " (?, ?)"
+	return condition, []interface{}{after.Value, after.ID}, order
+}
+
+// bookSortValue is the value of a book's sort field as the database compares
+// it.
+func (db *DB) bookSortValue(b models.Book, field string) string {
+	switch field {
+	case "title":
+		return b.Title
+	case "author":
+		return b.Author
+	case "published_date":
+		return publishedStart(b)
+	case "created_at":
+		return db.timeValue(b.CreatedAt)
+	case "rating":
+		return strconv.FormatFloat(b.AverageRating, 'f', -1, 64)
+	}
+	return ""
+}
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// listAllBooks walks all pages of the listing and returns the ids in order.
+func listAllBooks(t *testing.T, store Store, filter BookFilter, opts ListOptions) []int {
+	t.Helper()
+	var ids []int
+	for {
+		books, page, err := store.GetBooks(filter, opts)
+		if !assert.NoError(t, err) {
+			return ids
+		}
+		assert.LessOrEqual(t, len(books), opts.Limit)
+		for _, b := range books {
+			ids = append(ids, b.ID)
+		}
+		if page.Next == "" {
+			return ids
+		}
+		opts.After = page.Next
+	}
+}
+
+func TestGetBooks_Pagination(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		// Duplicate sort values make sure ties are broken by id
+		books := []models.Book{
+			{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01", Genre: "Science Fiction"},
+			{Title: "Emma", Author: "Jane Austen", PublishedDate: "1815-12-23", Genre: "Romance"},
+			{Title: "Children of Dune", Author: "Frank Herbert", PublishedDate: "1976-04-01", Genre: "Science Fiction"},
+			{Title: "Persuasion", Author: "Jane Austen", PublishedDate: "1817-12-20", Genre: "Romance"},
+			{Title: "Dune", Author: "Brian Herbert", PublishedDate: "1999-01-01", Genre: "Science Fiction"},
+		}
+		for _, b := range books {
+			_, err := store.CreateBook(b)
+			assert.NoError(t, err)
+		}
+
+		tests := []struct {
+			sort string
+			desc bool
+			want []int
+		}{
+			{"", false, []int{1, 2, 3, 4, 5}},
+			{"id", true, []int{5, 4, 3, 2, 1}},
+			{"title", false, []int{3, 1, 5, 2, 4}},
+			{"title", true, []int{4, 2, 5, 1, 3}},
+			{"author", false, []int{5, 1, 3, 2, 4}},
+			{"published_date", false, []int{2, 4, 1, 3, 5}},
+			{"published_date", true, []int{5, 3, 1, 4, 2}},
+			{"created_at", false, []int{1, 2, 3, 4, 5}},
+			{"created_at", true, []int{5, 4, 3, 2, 1}},
+		}
+		for _, tt := range tests {
+			for _, limit := range []int{1, 2, 5} {
+				opts := ListOptions{Sort: tt.sort, Desc: tt.desc, Limit: limit}
+				ids := listAllBooks(t, store, BookFilter{}, opts)
+				assert.Equal(t, tt.want, ids, fmt.Sprintf("sort=%s desc=%v limit=%d", tt.sort, tt.desc, limit))
+			}
+		}
+
+		// Totals count all matching books, not just the page
+		page1, page, err := store.GetBooks(BookFilter{Author: "Jane Austen"}, ListOptions{Limit: 1})
+		assert.NoError(t, err)
+		assert.Len(t, page1, 1)
+		assert.Equal(t, 2, page.Total)
+		assert.NotEmpty(t, page.Next)
+
+		// Filters apply to every page
+		ids := listAllBooks(t, store, BookFilter{Genre: "Science Fiction"}, ListOptions{Sort: "title", Limit: 2})
+		assert.Equal(t, []int{3, 1, 5}, ids)
+
+		// The last page has no next cursor
+		_, page, err = store.GetBooks(BookFilter{}, ListOptions{Limit: 5})
+		assert.NoError(t, err)
+		assert.Equal(t, 5, page.Total)
+		assert.Empty(t, page.Next)
+	})
+}
+
+func TestGetBooks_PaginationIsStable(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		for i := 0; i < 4; i++ {
+			_, err := store.CreateBook(models.Book{Title: fmt.Sprintf("Book %d", i), Author: "Author", PublishedDate: "2020-01-01"})
+			assert.NoError(t, err)
+		}
+
+		books, page, err := store.GetBooks(BookFilter{}, ListOptions{Sort: "title", Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Book 0", "Book 1"}, []string{books[0].Title, books[1].Title})
+
+		// Deleting a book already seen does not shift the next page
+		err = store.DeleteBook(books[0].ID, 0)
+		assert.NoError(t, err)
+		books, _, err = store.GetBooks(BookFilter{}, ListOptions{Sort: "title", Limit: 2, After: page.Next})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Book 2", "Book 3"}, []string{books[0].Title, books[1].Title})
+	})
+}
+
+func TestGetBooks_InvalidListOptions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		_, err := store.CreateBook(models.Book{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01"})
+		assert.NoError(t, err)
+		_, err = store.CreateBook(models.Book{Title: "Emma", Author: "Jane Austen", PublishedDate: "1815-12-23"})
+		assert.NoError(t, err)
+
+		_, _, err = store.GetBooks(BookFilter{}, ListOptions{Sort: "genre"})
+		assert.ErrorIs(t, err, ErrInvalidSort)
+
+		_, _, err = store.GetBooks(BookFilter{}, ListOptions{After: "not a cursor"})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+
+		// Cursors only work for the ordering they were created for
+		_, page, err := store.GetBooks(BookFilter{}, ListOptions{Sort: "title", Limit: 1})
+		assert.NoError(t, err)
+		_, _, err = store.GetBooks(BookFilter{}, ListOptions{Sort: "author", Limit: 1, After: page.Next})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+		_, _, err = store.GetBooks(BookFilter{}, ListOptions{Sort: "title", Desc: true, Limit: 1, After: page.Next})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+}
+
+func TestGetCollections_Pagination(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		for _, name := range []string{"To Read", "Favourites", "Lent Out"} {
+			_, err := store.CreateCollection(models.Collection{Name: name})
+			assert.NoError(t, err)
+		}
+
+		collections, page, err := store.GetCollections(ListOptions{Sort: "name", Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, page.Total)
+		assert.Len(t, collections, 2)
+		assert.Equal(t, "Favourites", collections[0].Name)
+		assert.Equal(t, "Lent Out", collections[1].Name)
+
+		collections, page, err = store.GetCollections(ListOptions{Sort: "name", Limit: 2, After: page.Next})
+		assert.NoError(t, err)
+		assert.Len(t, collections, 1)
+		assert.Equal(t, "To Read", collections[0].Name)
+		assert.Empty(t, page.Next)
+
+		collections, _, err = store.GetCollections(ListOptions{Desc: true})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, collections[0].ID)
+
+		_, _, err = store.GetCollections(ListOptions{Sort: "title"})
+		assert.ErrorIs(t, err, ErrInvalidSort)
+	})
+}
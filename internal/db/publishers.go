@@ -0,0 +1,133 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// PublisherSortFields are the fields the publisher list can be ordered by.
+var PublisherSortFields = []string{"id", "name"}
+
+// resolvePublisher finds or creates the publisher named on a book and
+// returns its id, or nil for books without a publisher.
+func resolvePublisher(q querier, name string) (interface{}, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, nil
+	}
+	var id int
+	err := q.QueryRow("SELECT id FROM publishers WHERE name = ?", name).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	err = q.QueryRow("INSERT INTO publishers (name) VALUES (?) RETURNING id", name).Scan(&id)
+	if err != nil {
+		return nil, translateError(err, "publisher", 0)
+	}
+	return id, nil
+}
+
+const publisherColumns = "p.id, p.name, (SELECT COUNT(*) FROM books b WHERE b.publisher_id = p.id AND b.deleted_at IS NULL)"
+
+// GetPublishers returns a page of publishers with the number of their books.
+func (db *DB) GetPublishers(opts ListOptions) ([]models.Publisher, Page, error) {
+	sort, err := opts.sortField(PublisherSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	var page Page
+	err = db.QueryRow("SELECT COUNT(*) FROM publishers").Scan(&page.Total)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	query := "SELECT " + publisherColumns + " FROM publishers p"
+	condition, args, order := keyset("p."+sort, "p.id", opts.Desc, after)
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+	query += " ORDER BY " + order
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit+1)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	defer rows.Close()
+
+	var publishers []models.Publisher
+	for rows.Next() {
+		var p models.Publisher
+		if err := rows.Scan(&p.ID, &p.Name, &p.BookCount); err != nil {
+			return nil, Page{}, err
+		}
+		publishers = append(publishers, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, Page{}, err
+	}
+
+	publishers, page.Next = paginate(publishers, opts.Limit, func(p models.Publisher) cursor {
+		if sort == "name" {
+			return cursor{Sort: sort, Desc: opts.Desc, Value: p.Name, ID: p.ID}
+		}
+		return cursor{Sort: sort, Desc: opts.Desc, ID: p.ID}
+	})
+	return publishers, page, nil
+}
+
+func (db *DB) GetPublisher(id int) (models.Publisher, error) {
+	var p models.Publisher
+	err := db.QueryRow("SELECT "+publisherColumns+" FROM publishers p WHERE p.id = ?", id).Scan(&p.ID, &p.Name, &p.BookCount)
+	if err != nil {
+		return models.Publisher{}, translateError(err, "publisher", id)
+	}
+	return p, nil
+}
+
+// UpdatePublisher renames the publisher, failing with ErrConflict if
+// another one has the name. Its books embed the name, so their version is
+// bumped.
+func (db *DB) UpdatePublisher(p models.Publisher) error {
+	return db.inTx(func(tx *Tx) error {
+		ids, err := queryIDs(tx, "SELECT id FROM books WHERE publisher_id = ? ORDER BY id", p.ID)
+		if err != nil {
+			return err
+		}
+		return db.changeBooks(tx, ids, func() error {
+			res, err := tx.Exec("UPDATE publishers SET name = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", p.Name, p.ID)
+			return expectVersion(tx, res, err, "publisher", p.ID, 0)
+		})
+	})
+}
+
+// DeletePublisher fails with ErrConflict while books name the publisher.
+func (db *DB) DeletePublisher(id int) error {
+	return db.inTx(func(tx *Tx) error {
+		var books int
+		err := tx.QueryRow("SELECT COUNT(*) FROM books WHERE publisher_id = ?", id).Scan(&books)
+		if err != nil {
+			return err
+		}
+		if books > 0 {
+			return fmt.Errorf("publisher %d has %d books: %w", id, books, ErrConflict)
+		}
+		res, err := tx.Exec("DELETE FROM publishers WHERE id = ?", id)
+		return expectVersion(tx, res, err, "publisher", id, 0)
+	})
+}
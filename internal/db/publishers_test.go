@@ -0,0 +1,82 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore_BookDetails(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		var bookIDs []int
+		for _, b := range []models.Book{
+			{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01", Publisher: " Chilton Books ", Language: "en-US",
+				PageCount: 412, Format: "hardcover", Dimensions: models.Dimensions{Height: 235, Width: 157, Thickness: 38}},
+			{Title: "Der Process", Author: "Franz Kafka", PublishedDate: "1925-04-26", Publisher: "Die Schmiede", Language: "de",
+				PageCount: 288, Format: "paperback", Dimensions: models.Dimensions{Height: 190}},
+			{Title: "The Hobbit", Author: "J.R.R. Tolkien", PublishedDate: "1937-09-21", Publisher: "Chilton Books", Language: "en"},
+		} {
+			id, err := store.CreateBook(b)
+			assert.NoError(t, err)
+			bookIDs = append(bookIDs, id)
+		}
+
+		book, err := store.GetBook(bookIDs[0])
+		assert.NoError(t, err)
+		assert.Equal(t, "Chilton Books", book.Publisher)
+		assert.Equal(t, "en-US", book.Language)
+		assert.Equal(t, 412, book.PageCount)
+		assert.Equal(t, "hardcover", book.Format)
+		assert.Equal(t, models.Dimensions{Height: 235, Width: 157, Thickness: 38}, book.Dimensions)
+
+		for _, tt := range []struct {
+			filter BookFilter
+			want   []int
+		}{
+			{BookFilter{Publisher: "Chilton Books"}, []int{bookIDs[0], bookIDs[2]}},
+			{BookFilter{Language: "en"}, []int{bookIDs[0], bookIDs[2]}},
+			{BookFilter{Language: "en-US"}, []int{bookIDs[0]}},
+			{BookFilter{Format: "paperback"}, []int{bookIDs[1]}},
+			{BookFilter{MinPages: 300}, []int{bookIDs[0]}},
+			{BookFilter{MaxPages: 300}, []int{bookIDs[1]}},
+			{BookFilter{MaxHeight: 200}, []int{bookIDs[1]}},
+			{BookFilter{MaxThickness: 50}, []int{bookIDs[0]}},
+		} {
+			books, _, err := store.GetBooks(tt.filter, ListOptions{})
+			assert.NoError(t, err)
+			var ids []int
+			for _, b := range books {
+				ids = append(ids, b.ID)
+			}
+			assert.Equal(t, tt.want, ids, "%+v", tt.filter)
+		}
+
+		publishers, page, err := store.GetPublishers(ListOptions{Sort: "name"})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, page.Total)
+		assert.Equal(t, "Chilton Books", publishers[0].Name)
+		assert.Equal(t, 2, publishers[0].BookCount)
+
+		// Renaming a publisher changes its books
+		chilton := publishers[0].ID
+		assert.ErrorIs(t, store.UpdatePublisher(models.Publisher{ID: chilton, Name: "Die Schmiede"}), ErrConflict)
+		assert.NoError(t, store.UpdatePublisher(models.Publisher{ID: chilton, Name: "Chilton"}))
+		book, err = store.GetBook(bookIDs[2])
+		assert.NoError(t, err)
+		assert.Equal(t, "Chilton", book.Publisher)
+		assert.Equal(t, 2, book.Version)
+		assert.ErrorIs(t, store.UpdatePublisher(models.Publisher{ID: 42, Name: "Ace"}), ErrNotFound)
+
+		assert.ErrorIs(t, store.DeletePublisher(chilton), ErrConflict)
+		for _, id := range []int{bookIDs[0], bookIDs[2]} {
+			book, err := store.GetBook(id)
+			assert.NoError(t, err)
+			book.Publisher = ""
+			assert.NoError(t, store.UpdateBook(book))
+		}
+		assert.NoError(t, store.DeletePublisher(chilton))
+		_, err = store.GetPublisher(chilton)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
@@ -0,0 +1,274 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+const dateLayout = "2006-01-02"
+
+// openStatus tells whether a session with the status may still change, a
+// book has at most one open session and it is the latest one.
+func openStatus(status string) bool {
+	return status == "want-to-read" || status == "reading"
+}
+
+// checkSession validates a session about to be written and fills in the
+// dates its status implies: a session being read started today and a
+// finished or abandoned one ended today, unless the dates are given.
+func checkSession(s *models.ReadingSession) error {
+	if !slices.Contains(models.ReadingStatuses, s.Status) {
+		return fmt.Errorf("%w reading status %q, expected one of %v", ErrInvalid, s.Status, models.ReadingStatuses)
+	}
+	today := time.Now().Format(dateLayout)
+	if s.Status == "reading" && s.StartedOn == "" {
+		s.StartedOn = today
+	}
+	if !openStatus(s.Status) && s.FinishedOn == "" {
+		s.FinishedOn = today
+	}
+	if openStatus(s.Status) && s.FinishedOn != "" {
+		return fmt.Errorf("%w reading session, only finished or abandoned sessions have a finish date", ErrInvalid)
+	}
+	for _, date := range []string{s.StartedOn, s.FinishedOn} {
+		if _, err := time.Parse(dateLayout, date); date != "" && err != nil {
+			return fmt.Errorf("%w date %q, expected YYYY-MM-DD", ErrInvalid, date)
+		}
+	}
+	if s.StartedOn != "" && s.FinishedOn != "" && s.FinishedOn < s.StartedOn {
+		return fmt.Errorf("%w reading session, finished on %s before starting on %s", ErrInvalid, s.FinishedOn, s.StartedOn)
+	}
+	return nil
+}
+
+func checkProgress(p models.ReadingProgress) error {
+	if p.Page == nil && p.Percent == nil {
+		return fmt.Errorf("%w progress, expected a page or a percentage", ErrInvalid)
+	}
+	if p.Page != nil && *p.Page < 0 {
+		return fmt.Errorf("%w page %d, expected at least 0", ErrInvalid, *p.Page)
+	}
+	if p.Percent != nil && !(*p.Percent >= 0 && *p.Percent <= 100) {
+		return fmt.Errorf("%w percentage %v, expected 0 to 100", ErrInvalid, *p.Percent)
+	}
+	return nil
+}
+
+// latestSession returns the ID and status of the latest reading session of
+// the book, or zero values if it has none.
+func latestSession(q querier, bookID int) (int, string, error) {
+	var id int
+	var status string
+	err := q.QueryRow("SELECT id, status FROM reading_sessions WHERE book_id = ? ORDER BY id DESC LIMIT 1", bookID).Scan(&id, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, "", nil
+	}
+	return id, status, err
+}
+
+// loadReadingStatus fills in the reading status of the books.
+func loadReadingStatus(q querier, books ...*models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+	byID := map[int][]*models.Book{}
+	args := make([]interface{}, 0, len(books))
+	for _, b := range books {
+		if _, ok := byID[b.ID]; !ok {
+			args = append(args, b.ID)
+		}
+		byID[b.ID] = append(byID[b.ID], b)
+		b.ReadingStatus = ""
+	}
+
+	rows, err := q.Query(`
+		SELECT rs.book_id, rs.status
+		FROM reading_sessions rs
+		WHERE rs.book_id IN (?`+strings.Repeat(", ?", len(args)-1)+`)
+		AND rs.id = (SELECT MAX(id) FROM reading_sessions WHERE book_id = rs.book_id)`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bookID int
+		var status string
+		if err := rows.Scan(&bookID, &status); err != nil {
+			return err
+		}
+		for _, b := range byID[bookID] {
+			b.ReadingStatus = status
+		}
+	}
+	return rows.Err()
+}
+
+// readingSessions returns the sessions of the book with their progress, the
+// one with sessionID or all of them if it is zero.
+func readingSessions(q querier, bookID, sessionID int) ([]models.ReadingSession, error) {
+	condition, args := "book_id = ?", []interface{}{bookID}
+	if sessionID != 0 {
+		condition += " AND id = ?"
+		args = append(args, sessionID)
+	}
+
+	rows, err := q.Query("SELECT id, book_id, status, started_on, finished_on, created_at, updated_at FROM reading_sessions WHERE "+condition+" ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.ReadingSession{}
+	index := map[int]int{}
+	for rows.Next() {
+		var s models.ReadingSession
+		err := rows.Scan(&s.ID, &s.BookID, &s.Status, &s.StartedOn, &s.FinishedOn, timestamp{&s.CreatedAt}, timestamp{&s.UpdatedAt})
+		if err != nil {
+			return nil, err
+		}
+		s.Progress = []models.ReadingProgress{}
+		index[s.ID] = len(sessions)
+		sessions = append(sessions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	rows, err = q.Query(`
+		SELECT rp.id, rp.session_id, rp.page, rp.percent, rp.recorded_at
+		FROM reading_progress rp
+		WHERE rp.session_id IN (SELECT id FROM reading_sessions WHERE `+condition+`)
+		ORDER BY rp.recorded_at, rp.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p models.ReadingProgress
+		var id int
+		if err := rows.Scan(&p.ID, &id, &p.Page, &p.Percent, timestamp{&p.RecordedAt}); err != nil {
+			return nil, err
+		}
+		s := &sessions[index[id]]
+		s.Progress = append(s.Progress, p)
+	}
+	return sessions, rows.Err()
+}
+
+// GetReadingLog returns all reading sessions of the book, oldest first.
+func (db *DB) GetReadingLog(bookID int) (models.ReadingLog, error) {
+	if err := exists(db, "books", bookID); err != nil {
+		return models.ReadingLog{}, translateError(err, "book", bookID)
+	}
+	sessions, err := readingSessions(db, bookID, 0)
+	if err != nil {
+		return models.ReadingLog{}, err
+	}
+	log := models.ReadingLog{BookID: bookID, Sessions: sessions}
+	if len(sessions) > 0 {
+		log.Status = sessions[len(sessions)-1].Status
+	}
+	return log, nil
+}
+
+func (db *DB) GetReadingSession(bookID, id int) (models.ReadingSession, error) {
+	sessions, err := readingSessions(db, bookID, id)
+	if err != nil {
+		return models.ReadingSession{}, err
+	}
+	if len(sessions) == 0 {
+		return models.ReadingSession{}, notFound("reading session", id)
+	}
+	return sessions[0], nil
+}
+
+// CreateReadingSession starts a new session for the book. It fails with
+// ErrConflict while the latest session of the book is still open.
+func (db *DB) CreateReadingSession(s models.ReadingSession) (int, error) {
+	if err := checkSession(&s); err != nil {
+		return 0, err
+	}
+	var id int
+	err := db.inTx(func(tx *Tx) error {
+		if err := exists(tx, "books", s.BookID); err != nil {
+			return translateError(err, "book", s.BookID)
+		}
+		return db.changeBooks(tx, []int{s.BookID}, func() error {
+			latest, status, err := latestSession(tx, s.BookID)
+			if err != nil {
+				return err
+			}
+			if openStatus(status) {
+				return fmt.Errorf("book %d already has an open reading session %d: %w", s.BookID, latest, ErrConflict)
+			}
+			return tx.QueryRow("INSERT INTO reading_sessions (book_id, status, started_on, finished_on) VALUES (?, ?, ?, ?) RETURNING id",
+				s.BookID, s.Status, s.StartedOn, s.FinishedOn).Scan(&id)
+		})
+	})
+	return id, err
+}
+
+// UpdateReadingSession replaces the status and dates of a session. Only the
+// latest session of a book can be open, reopening an earlier one fails with
+// ErrConflict.
+func (db *DB) UpdateReadingSession(s models.ReadingSession) error {
+	if err := checkSession(&s); err != nil {
+		return err
+	}
+	return db.inTx(func(tx *Tx) error {
+		return db.changeBooks(tx, []int{s.BookID}, func() error {
+			latest, _, err := latestSession(tx, s.BookID)
+			if err != nil {
+				return err
+			}
+			if openStatus(s.Status) && latest > s.ID {
+				return fmt.Errorf("only the latest reading session %d of book %d can be open: %w", latest, s.BookID, ErrConflict)
+			}
+			res, err := tx.Exec("UPDATE reading_sessions SET status = ?, started_on = ?, finished_on = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND book_id = ?",
+				s.Status, s.StartedOn, s.FinishedOn, s.ID, s.BookID)
+			return expectVersion(tx, res, err, "reading session", s.ID, 0)
+		})
+	})
+}
+
+// DeleteReadingSession deletes the session with its progress.
+func (db *DB) DeleteReadingSession(bookID, id int) error {
+	return db.inTx(func(tx *Tx) error {
+		return db.changeBooks(tx, []int{bookID}, func() error {
+			res, err := tx.Exec("DELETE FROM reading_sessions WHERE id = ? AND book_id = ?", id, bookID)
+			if err := expectVersion(tx, res, err, "reading session", id, 0); err != nil {
+				return err
+			}
+			// SQLite does not enforce the cascade
+			_, err = tx.Exec("DELETE FROM reading_progress WHERE session_id = ?", id)
+			return err
+		})
+	})
+}
+
+// AddReadingProgress records the progress of a session now. The book keeps
+// its version, its reading status does not change.
+func (db *DB) AddReadingProgress(bookID, sessionID int, p models.ReadingProgress) (int, error) {
+	if err := checkProgress(p); err != nil {
+		return 0, err
+	}
+	var id int
+	err := db.inTx(func(tx *Tx) error {
+		res, err := tx.Exec("UPDATE reading_sessions SET updated_at = CURRENT_TIMESTAMP WHERE id = ? AND book_id = ?", sessionID, bookID)
+		if err := expectVersion(tx, res, err, "reading session", sessionID, 0); err != nil {
+			return err
+		}
+		return tx.QueryRow("INSERT INTO reading_progress (session_id, page, percent) VALUES (?, ?, ?) RETURNING id",
+			sessionID, p.Page, p.Percent).Scan(&id)
+	})
+	return id, err
+}
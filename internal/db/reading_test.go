@@ -0,0 +1,122 @@
+package db
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSession(t *testing.T) {
+	today := time.Now().Format(dateLayout)
+
+	s := models.ReadingSession{Status: "reading"}
+	assert.NoError(t, checkSession(&s))
+	assert.Equal(t, today, s.StartedOn)
+	assert.Empty(t, s.FinishedOn)
+
+	s = models.ReadingSession{Status: "finished", StartedOn: "2024-01-02"}
+	assert.NoError(t, checkSession(&s))
+	assert.Equal(t, today, s.FinishedOn)
+
+	for _, s := range []models.ReadingSession{
+		{Status: "skimmed"},
+		{Status: "reading", FinishedOn: "2024-01-02"},
+		{Status: "abandoned", StartedOn: "2024-02-01", FinishedOn: "2024-01-02"},
+		{Status: "want-to-read", StartedOn: "January"},
+	} {
+		assert.ErrorIs(t, checkSession(&s), ErrInvalid, s)
+	}
+
+	page, percent := 10, 101.0
+	assert.ErrorIs(t, checkProgress(models.ReadingProgress{}), ErrInvalid)
+	assert.ErrorIs(t, checkProgress(models.ReadingProgress{Page: &page, Percent: &percent}), ErrInvalid)
+	assert.NoError(t, checkProgress(models.ReadingProgress{Page: &page}))
+}
+
+func TestStore_Reading(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		duneID, err := store.CreateBook(models.Book{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01"})
+		assert.NoError(t, err)
+		hobbitID, err := store.CreateBook(models.Book{Title: "The Hobbit", Author: "J. R. R. Tolkien", PublishedDate: "1937-09-21"})
+		assert.NoError(t, err)
+
+		log, err := store.GetReadingLog(duneID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.ReadingLog{BookID: duneID, Sessions: []models.ReadingSession{}}, log)
+		_, err = store.GetReadingLog(42)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		// A book has at most one open session
+		first, err := store.CreateReadingSession(models.ReadingSession{BookID: duneID, Status: "reading", StartedOn: "2024-01-01"})
+		assert.NoError(t, err)
+		_, err = store.CreateReadingSession(models.ReadingSession{BookID: duneID, Status: "want-to-read"})
+		assert.ErrorIs(t, err, ErrConflict)
+		_, err = store.CreateReadingSession(models.ReadingSession{BookID: hobbitID, Status: "want-to-read"})
+		assert.NoError(t, err)
+
+		page, percent := 120, 25.0
+		_, err = store.AddReadingProgress(duneID, first, models.ReadingProgress{Page: &page})
+		assert.NoError(t, err)
+		_, err = store.AddReadingProgress(duneID, first, models.ReadingProgress{Percent: &percent})
+		assert.NoError(t, err)
+		_, err = store.AddReadingProgress(hobbitID, first, models.ReadingProgress{Page: &page})
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		book, err := store.GetBook(duneID)
+		assert.NoError(t, err)
+		assert.Equal(t, "reading", book.ReadingStatus)
+		assert.Equal(t, 2, book.Version)
+		entries, _, err := store.GetAudit(AuditFilter{BookID: duneID, Action: models.AuditUpdate}, ListOptions{})
+		assert.NoError(t, err)
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, []models.FieldChange{
+				{Field: "reading_status", Before: json.RawMessage("null"), After: json.RawMessage(`"reading"`)},
+			}, entries[0].Changes)
+		}
+
+		// Finishing and re-reading keeps the sessions apart
+		err = store.UpdateReadingSession(models.ReadingSession{ID: first, BookID: duneID, Status: "finished", StartedOn: "2024-01-01", FinishedOn: "2024-02-01"})
+		assert.NoError(t, err)
+		second, err := store.CreateReadingSession(models.ReadingSession{BookID: duneID, Status: "reading"})
+		assert.NoError(t, err)
+		err = store.UpdateReadingSession(models.ReadingSession{ID: first, BookID: duneID, Status: "reading", StartedOn: "2024-01-01"})
+		assert.ErrorIs(t, err, ErrConflict)
+
+		log, err = store.GetReadingLog(duneID)
+		assert.NoError(t, err)
+		assert.Equal(t, "reading", log.Status)
+		assert.Len(t, log.Sessions, 2)
+		assert.Equal(t, "2024-02-01", log.Sessions[0].FinishedOn)
+		assert.Len(t, log.Sessions[0].Progress, 2)
+		assert.Equal(t, 120, *log.Sessions[0].Progress[0].Page)
+		assert.Nil(t, log.Sessions[0].Progress[0].Percent)
+		assert.Equal(t, 25.0, *log.Sessions[0].Progress[1].Percent)
+		assert.Empty(t, log.Sessions[1].Progress)
+
+		// Books are filtered by the status of their latest session
+		books, _, err := store.GetBooks(BookFilter{Status: "reading"}, ListOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, books, 1)
+		assert.Equal(t, duneID, books[0].ID)
+		books, _, err = store.GetBooks(BookFilter{Status: "finished"}, ListOptions{})
+		assert.NoError(t, err)
+		assert.Empty(t, books)
+
+		assert.NoError(t, store.DeleteReadingSession(duneID, second))
+		assert.ErrorIs(t, store.DeleteReadingSession(duneID, second), ErrNotFound)
+		book, err = store.GetBook(duneID)
+		assert.NoError(t, err)
+		assert.Equal(t, "finished", book.ReadingStatus)
+		_, err = store.GetReadingSession(hobbitID, first)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		// Purging the book deletes its reading log
+		assert.NoError(t, store.DeleteBook(duneID, 0))
+		emptyTrash(t, store)
+		_, err = store.GetReadingSession(duneID, first)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
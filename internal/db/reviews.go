@@ -0,0 +1,240 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// ReviewSortFields are the fields review lists can be ordered by.
+var ReviewSortFields = []string{"id", "rating"}
+
+// ReviewFilter restricts GetReviews to the reviews of a book, of a reviewer
+// or both.
+type ReviewFilter struct {
+	BookID   int
+	Reviewer string
+}
+
+// checkReview validates a review about to be written and trims the name of
+// its reviewer.
+func checkReview(r *models.Review) error {
+	r.Reviewer = strings.TrimSpace(r.Reviewer)
+	if r.Reviewer == "" {
+		return fmt.Errorf("%w review, the reviewer is empty", ErrInvalid)
+	}
+	return checkRating(r.Rating)
+}
+
+func checkRating(rating float64) error {
+	if rating < 1 || rating > 5 || rating*2 != math.Round(rating*2) {
+		return fmt.Errorf("%w rating %v, expected 1 to 5 in half stars", ErrInvalid, rating)
+	}
+	return nil
+}
+
+// averageRating rounds the average to two decimals, it is 0 without ratings.
+func averageRating(sum float64, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	return math.Round(sum/float64(count)*100) / 100
+}
+
+// rateBook runs fn, which changes the reviews of the book, and updates its
+// average rating and rating count from them. A book whose rating changed is
+// recorded like other changes through changeBooks.
+func (db *DB) rateBook(tx *Tx, bookID int, fn func() error) error {
+	return db.changeBooks(tx, []int{bookID}, func() error {
+		if err := fn(); err != nil {
+			return err
+		}
+		var sum float64
+		var count int
+		err := tx.QueryRow("SELECT COALESCE(SUM(rating), 0), COUNT(*) FROM reviews WHERE book_id = ?", bookID).Scan(&sum, &count)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("UPDATE books SET average_rating = ?, rating_count = ? WHERE id = ?", averageRating(sum, count), count, bookID)
+		return err
+	})
+}
+
+// duplicateReview names the book and reviewer in conflicts caused by a
+// second review.
+func duplicateReview(err error, r models.Review) error {
+	if errors.Is(err, ErrConflict) {
+		return fmt.Errorf("book %d already has a review by %q: %w", r.BookID, r.Reviewer, ErrConflict)
+	}
+	return err
+}
+
+const reviewColumns = "r.id, r.book_id, r.reviewer, r.rating, r.body, r.created_at, r.updated_at"
+
+func scanReview(row scanner) (models.Review, error) {
+	var r models.Review
+	err := row.Scan(&r.ID, &r.BookID, &r.Reviewer, &r.Rating, &r.Body, timestamp{&r.CreatedAt}, timestamp{&r.UpdatedAt})
+	return r, err
+}
+
+// GetReviews returns a page of the reviews matching the filter, without
+// their history.
+func (db *DB) GetReviews(filter ReviewFilter, opts ListOptions) ([]models.Review, Page, error) {
+	sort, err := opts.sortField(ReviewSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	where := " WHERE 1=1"
+	args := []interface{}{}
+	if filter.BookID != 0 {
+		where += " AND r.book_id = ?"
+		args = append(args, filter.BookID)
+	}
+	if filter.Reviewer != "" {
+		where += " AND r.reviewer = ?"
+		args = append(args, strings.TrimSpace(filter.Reviewer))
+	}
+
+	var page Page
+	err = db.QueryRow("SELECT COUNT(*) FROM reviews r"+where, args...).Scan(&page.Total)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	condition, keysetArgs, order := keyset("r."+sort, "r.id", opts.Desc, after)
+	if condition != "" {
+		where += " AND " + condition
+		args = append(args, keysetArgs...)
+	}
+	query := "SELECT " + reviewColumns + " FROM reviews r" + where + " ORDER BY " + order
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit+1)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	defer rows.Close()
+
+	var reviews []models.Review
+	for rows.Next() {
+		r, err := scanReview(rows)
+		if err != nil {
+			return nil, Page{}, err
+		}
+		reviews = append(reviews, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, Page{}, err
+	}
+
+	reviews, page.Next = paginate(reviews, opts.Limit, func(r models.Review) cursor {
+		if sort == "rating" {
+			return cursor{Sort: sort, Desc: opts.Desc, Value: strconv.FormatFloat(r.Rating, 'f', -1, 64), ID: r.ID}
+		}
+		return cursor{Sort: sort, Desc: opts.Desc, ID: r.ID}
+	})
+	return reviews, page, nil
+}
+
+// GetReview returns the review with its earlier versions.
+func (db *DB) GetReview(id int) (models.Review, error) {
+	r, err := scanReview(db.QueryRow("SELECT "+reviewColumns+" FROM reviews r WHERE r.id = ?", id))
+	if err != nil {
+		return models.Review{}, translateError(err, "review", id)
+	}
+
+	rows, err := db.Query("SELECT rating, body, written_at FROM review_history WHERE review_id = ? ORDER BY id", id)
+	if err != nil {
+		return models.Review{}, err
+	}
+	defer rows.Close()
+
+	r.History = []models.ReviewRevision{}
+	for rows.Next() {
+		var rev models.ReviewRevision
+		if err := rows.Scan(&rev.Rating, &rev.Body, timestamp{&rev.WrittenAt}); err != nil {
+			return models.Review{}, err
+		}
+		r.History = append(r.History, rev)
+	}
+	return r, rows.Err()
+}
+
+// CreateReview fails with ErrConflict if the reviewer already reviewed the
+// book.
+func (db *DB) CreateReview(r models.Review) (int, error) {
+	if err := checkReview(&r); err != nil {
+		return 0, err
+	}
+	var id int
+	err := db.inTx(func(tx *Tx) error {
+		if err := exists(tx, "books", r.BookID); err != nil {
+			return translateError(err, "book", r.BookID)
+		}
+		return db.rateBook(tx, r.BookID, func() error {
+			err := tx.QueryRow("INSERT INTO reviews (book_id, reviewer, rating, body) VALUES (?, ?, ?, ?) RETURNING id",
+				r.BookID, r.Reviewer, r.Rating, r.Body).Scan(&id)
+			return duplicateReview(translateError(err, "review", 0), r)
+		})
+	})
+	return id, err
+}
+
+// UpdateReview changes the rating and body of the review. The version it
+// replaces is kept in its history, unless nothing changed.
+func (db *DB) UpdateReview(r models.Review) error {
+	if err := checkRating(r.Rating); err != nil {
+		return err
+	}
+	return db.inTx(func(tx *Tx) error {
+		var bookID int
+		var rating float64
+		var body string
+		err := tx.QueryRow("SELECT book_id, rating, body FROM reviews WHERE id = ?", r.ID).Scan(&bookID, &rating, &body)
+		if err != nil {
+			return translateError(err, "review", r.ID)
+		}
+		if rating == r.Rating && body == r.Body {
+			return nil
+		}
+		return db.rateBook(tx, bookID, func() error {
+			_, err := tx.Exec("INSERT INTO review_history (review_id, rating, body, written_at) SELECT id, rating, body, updated_at FROM reviews WHERE id = ?", r.ID)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec("UPDATE reviews SET rating = ?, body = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", r.Rating, r.Body, r.ID)
+			return err
+		})
+	})
+}
+
+// DeleteReview deletes the review with its history.
+func (db *DB) DeleteReview(id int) error {
+	return db.inTx(func(tx *Tx) error {
+		var bookID int
+		err := tx.QueryRow("SELECT book_id FROM reviews WHERE id = ?", id).Scan(&bookID)
+		if err != nil {
+			return translateError(err, "review", id)
+		}
+		return db.rateBook(tx, bookID, func() error {
+			// SQLite does not enforce the cascade
+			if _, err := tx.Exec("DELETE FROM review_history WHERE review_id = ?", id); err != nil {
+				return err
+			}
+			_, err := tx.Exec("DELETE FROM reviews WHERE id = ?", id)
+			return err
+		})
+	})
+}
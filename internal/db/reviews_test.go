@@ -0,0 +1,115 @@
+package db
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckReview(t *testing.T) {
+	r := models.Review{Reviewer: "  ada ", Rating: 4.5}
+	assert.NoError(t, checkReview(&r))
+	assert.Equal(t, "ada", r.Reviewer)
+
+	for _, rating := range []float64{0, 0.5, 4.25, 5.5} {
+		assert.ErrorIs(t, checkReview(&models.Review{Reviewer: "ada", Rating: rating}), ErrInvalid, rating)
+	}
+	assert.ErrorIs(t, checkReview(&models.Review{Reviewer: " ", Rating: 3}), ErrInvalid)
+
+	assert.Equal(t, 0.0, averageRating(0, 0))
+	assert.Equal(t, 3.67, averageRating(11, 3))
+}
+
+func TestStore_Reviews(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		duneID, err := store.CreateBook(models.Book{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01"})
+		assert.NoError(t, err)
+		hobbitID, err := store.CreateBook(models.Book{Title: "The Hobbit", Author: "J. R. R. Tolkien", PublishedDate: "1937-09-21"})
+		assert.NoError(t, err)
+		goID, err := store.CreateBook(models.Book{Title: "The Go Programming Language", Author: "Alan A. A. Donovan", PublishedDate: "2015-10-26"})
+		assert.NoError(t, err)
+
+		adaID, err := store.CreateReview(models.Review{BookID: duneID, Reviewer: "ada", Rating: 5, Body: "*Spice* must flow."})
+		assert.NoError(t, err)
+		_, err = store.CreateReview(models.Review{BookID: duneID, Reviewer: "grace", Rating: 3.5})
+		assert.NoError(t, err)
+		_, err = store.CreateReview(models.Review{BookID: hobbitID, Reviewer: "ada", Rating: 4})
+		assert.NoError(t, err)
+		_, err = store.CreateReview(models.Review{BookID: duneID, Reviewer: "ada", Rating: 1})
+		assert.ErrorIs(t, err, ErrConflict)
+		_, err = store.CreateReview(models.Review{BookID: 42, Reviewer: "ada", Rating: 1})
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		book, err := store.GetBook(duneID)
+		assert.NoError(t, err)
+		assert.Equal(t, 4.25, book.AverageRating)
+		assert.Equal(t, 2, book.RatingCount)
+		assert.Equal(t, 3, book.Version)
+		entries, _, err := store.GetAudit(AuditFilter{BookID: duneID, Action: models.AuditUpdate}, ListOptions{})
+		assert.NoError(t, err)
+		if assert.Len(t, entries, 2) {
+			assert.Equal(t, []models.FieldChange{
+				{Field: "average_rating", Before: json.RawMessage("5"), After: json.RawMessage("4.25")},
+				{Field: "rating_count", Before: json.RawMessage("1"), After: json.RawMessage("2")},
+			}, entries[1].Changes)
+		}
+		revisions, err := store.GetBookRevisions(duneID)
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 3)
+
+		// Books are filtered and sorted by their average rating
+		ids := func(filter BookFilter, opts ListOptions) []int {
+			books, _, err := store.GetBooks(filter, opts)
+			assert.NoError(t, err)
+			var ids []int
+			for _, b := range books {
+				ids = append(ids, b.ID)
+			}
+			return ids
+		}
+		assert.Equal(t, []int{duneID}, ids(BookFilter{MinRating: 4.25}, ListOptions{}))
+		assert.Equal(t, []int{duneID, hobbitID, goID}, ids(BookFilter{}, ListOptions{Sort: "rating", Desc: true}))
+		books, page, err := store.GetBooks(BookFilter{}, ListOptions{Sort: "rating", Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, goID, books[0].ID)
+		books, _, err = store.GetBooks(BookFilter{}, ListOptions{Sort: "rating", Limit: 2, After: page.Next})
+		assert.NoError(t, err)
+		assert.Equal(t, duneID, books[0].ID)
+
+		// Edits keep the earlier versions
+		assert.NoError(t, store.UpdateReview(models.Review{ID: adaID, Rating: 4.5, Body: "*Spice* must flow."}))
+		assert.NoError(t, store.UpdateReview(models.Review{ID: adaID, Rating: 4.5, Body: "Still great."}))
+		assert.NoError(t, store.UpdateReview(models.Review{ID: adaID, Rating: 4.5, Body: "Still great."}))
+		assert.ErrorIs(t, store.UpdateReview(models.Review{ID: adaID, Rating: 6}), ErrInvalid)
+		review, err := store.GetReview(adaID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Still great.", review.Body)
+		assert.Len(t, review.History, 2)
+		assert.Equal(t, models.ReviewRevision{Rating: 5, Body: "*Spice* must flow.", WrittenAt: review.CreatedAt}, review.History[0])
+		assert.Equal(t, 4.5, review.History[1].Rating)
+
+		reviews, page, err := store.GetReviews(ReviewFilter{Reviewer: "ada"}, ListOptions{Sort: "rating"})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, page.Total)
+		assert.Equal(t, 4.0, reviews[0].Rating)
+		assert.Nil(t, reviews[0].History)
+		reviews, _, err = store.GetReviews(ReviewFilter{BookID: duneID}, ListOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, reviews, 2)
+
+		assert.NoError(t, store.DeleteReview(adaID))
+		assert.ErrorIs(t, store.DeleteReview(adaID), ErrNotFound)
+		book, err = store.GetBook(duneID)
+		assert.NoError(t, err)
+		assert.Equal(t, 3.5, book.AverageRating)
+		assert.Equal(t, 1, book.RatingCount)
+
+		assert.NoError(t, store.DeleteBook(duneID, 0))
+		emptyTrash(t, store)
+		reviews, _, err = store.GetReviews(ReviewFilter{BookID: duneID}, ListOptions{})
+		assert.NoError(t, err)
+		assert.Empty(t, reviews)
+	})
+}
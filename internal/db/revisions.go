@@ -0,0 +1,440 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// Every change to a book that gives it a new version, and every change to
+// the name or the books of a collection, adds a revision with the result. Books and
+// collections that were last changed before revisions were kept get one of
+// how they were before their next change, so that state is not lost.
+
+// bookSnapshot keeps the fields of a book that are written with it.
+func bookSnapshot(b models.Book) models.Book {
+	return models.Book{
+		ID:                 b.ID,
+		Title:              b.Title,
+		Author:             b.Author,
+		Authors:            b.Authors,
+		PublishedDate:      b.PublishedDate,
+		PublishedPrecision: b.PublishedPrecision,
+		Edition:            b.Edition,
+		Description:        b.Description,
+		Genre:              b.Genre,
+		Publisher:          b.Publisher,
+		Language:           b.Language,
+		PageCount:          b.PageCount,
+		Format:             b.Format,
+		Dimensions:         b.Dimensions,
+		Tags:               b.Tags,
+		Custom:             b.Custom,
+		ISBN10:             b.ISBN10,
+		ISBN13:             b.ISBN13,
+	}
+}
+
+// restorable turns a revision back into a book to store, given the authors
+// of the revision that still exist. Those are kept under their current
+// names, authors deleted since are given by name, so they are created
+// again, and values of custom fields deleted since are left out.
+func restorable(b models.Book, authors map[int]models.Author, fields map[string]models.CustomField) models.Book {
+	b.Authors = append([]models.BookAuthor{}, b.Authors...)
+	for i, a := range b.Authors {
+		if author, ok := authors[a.ID]; ok {
+			b.Authors[i].Name = author.Name
+		} else {
+			b.Authors[i].ID = 0
+		}
+	}
+	if len(b.Authors) > 0 {
+		b.Author = authorLine(b.Authors)
+	}
+	custom := map[string]interface{}{}
+	for name, value := range b.Custom {
+		if _, ok := fields[name]; ok {
+			custom[name] = value
+		}
+	}
+	b.Custom = custom
+	return b
+}
+
+// collectionContents are the parts of a collection its revisions compare.
+type collectionContents struct {
+	Name    string `json:"name"`
+	BookIDs []int  `json:"book_ids"`
+}
+
+func contentsOf(r models.CollectionRevision) collectionContents {
+	return collectionContents{Name: r.Name, BookIDs: r.BookIDs}
+}
+
+// restorableRevision is restorable for a revision read through s.
+func restorableRevision(s Store, b models.Book) (models.Book, error) {
+	authors := map[int]models.Author{}
+	for _, a := range b.Authors {
+		author, err := s.GetAuthor(a.ID)
+		if err == nil {
+			authors[a.ID] = author
+		} else if !errors.Is(err, ErrNotFound) {
+			return models.Book{}, err
+		}
+	}
+	list, _, err := s.GetCustomFields(ListOptions{})
+	if err != nil {
+		return models.Book{}, err
+	}
+	fields := map[string]models.CustomField{}
+	for _, f := range list {
+		fields[f.Name] = f
+	}
+	return restorable(b, authors, fields), nil
+}
+
+// DiffBookRevisions compares two revisions of a book field by field, like
+// the audit log does. Revision 0 is the book as it is now, compared with
+// the other revision as restoring it would write it.
+func DiffBookRevisions(s Store, bookID, from, to int) (models.RevisionDiff, error) {
+	load := func(revision int) (models.Book, error) {
+		if revision == 0 {
+			b, err := s.GetBook(bookID)
+			return bookSnapshot(b), err
+		}
+		r, err := s.GetBookRevision(bookID, revision)
+		if err != nil || (from != 0 && to != 0) {
+			return r.Book, err
+		}
+		return restorableRevision(s, r.Book)
+	}
+	before, err := load(from)
+	if err != nil {
+		return models.RevisionDiff{}, err
+	}
+	after, err := load(to)
+	if err != nil {
+		return models.RevisionDiff{}, err
+	}
+	changes, err := diffFields(before, after)
+	return models.RevisionDiff{From: from, To: to, Changes: changes}, err
+}
+
+// DiffCollectionRevisions compares the names and the books of two revisions
+// of a collection. Revision 0 is the collection as it is now.
+func DiffCollectionRevisions(s Store, collectionID, from, to int) (models.RevisionDiff, error) {
+	load := func(revision int) (collectionContents, error) {
+		if revision == 0 {
+			c, err := s.GetCollection(collectionID)
+			contents := collectionContents{Name: c.Name, BookIDs: []int{}}
+			for _, b := range c.Books {
+				contents.BookIDs = append(contents.BookIDs, b.ID)
+			}
+			sort.Ints(contents.BookIDs)
+			return contents, err
+		}
+		r, err := s.GetCollectionRevision(collectionID, revision)
+		return contentsOf(r), err
+	}
+	before, err := load(from)
+	if err != nil {
+		return models.RevisionDiff{}, err
+	}
+	after, err := load(to)
+	if err != nil {
+		return models.RevisionDiff{}, err
+	}
+	changes, err := diffFields(before, after)
+	return models.RevisionDiff{From: from, To: to, Changes: changes}, err
+}
+
+// addBookRevision records b as the next revision of the book. It must run
+// after the book was locked by an update, or was created, in the same
+// transaction.
+func (db *DB) addBookRevision(q querier, b models.Book) error {
+	data, err := json.Marshal(bookSnapshot(b))
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec("INSERT INTO book_revisions (book_id, revision, actor, book) "+
+		"SELECT ?, COALESCE(MAX(revision), 0) + 1, ?, ? FROM book_revisions WHERE book_id = ?",
+		b.ID, db.actor, string(data), b.ID)
+	return err
+}
+
+// startBookRevisions records before as the first revision of a book that
+// has none.
+func (db *DB) startBookRevisions(q querier, before models.Book) error {
+	var found int
+	err := q.QueryRow("SELECT COUNT(*) FROM book_revisions WHERE book_id = ?", before.ID).Scan(&found)
+	if err != nil || found > 0 {
+		return err
+	}
+	return db.addBookRevision(q, before)
+}
+
+func scanBookRevision(row interface{ Scan(...interface{}) error }) (models.BookRevision, error) {
+	var r models.BookRevision
+	var data string
+	if err := row.Scan(&r.Revision, timestamp{&r.At}, &r.Actor, &data); err != nil {
+		return models.BookRevision{}, err
+	}
+	return r, json.Unmarshal([]byte(data), &r.Book)
+}
+
+// GetBookRevisions returns the revisions of a book, oldest first.
+func (db *DB) GetBookRevisions(bookID int) ([]models.BookRevision, error) {
+	if err := exists(db, "books", bookID); err != nil {
+		return nil, translateError(err, "book", bookID)
+	}
+	rows, err := db.Query("SELECT revision, at, actor, book FROM book_revisions WHERE book_id = ? ORDER BY revision", bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.BookRevision{}
+	for rows.Next() {
+		r, err := scanBookRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, r)
+	}
+	return revisions, rows.Err()
+}
+
+func (db *DB) GetBookRevision(bookID, revision int) (models.BookRevision, error) {
+	return getBookRevision(db, bookID, revision)
+}
+
+func getBookRevision(q querier, bookID, revision int) (models.BookRevision, error) {
+	if err := exists(q, "books", bookID); err != nil {
+		return models.BookRevision{}, translateError(err, "book", bookID)
+	}
+	r, err := scanBookRevision(q.QueryRow("SELECT revision, at, actor, book FROM book_revisions WHERE book_id = ? AND revision = ?", bookID, revision))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.BookRevision{}, fmt.Errorf("revision %d of book %d: %w", revision, bookID, ErrNotFound)
+	}
+	return r, err
+}
+
+// RestoreBookRevision updates the book to the fields it had at the
+// revision, which adds a new revision. A non-zero version makes the restore
+// conditional, like for UpdateBook.
+func (db *DB) RestoreBookRevision(bookID, revision, version int) error {
+	return db.inTx(func(tx *Tx) error {
+		r, err := getBookRevision(tx, bookID, revision)
+		if err != nil {
+			return err
+		}
+		authors := map[int]models.Author{}
+		for _, a := range r.Book.Authors {
+			author := models.Author{ID: a.ID}
+			err := tx.QueryRow("SELECT name FROM authors WHERE id = ?", a.ID).Scan(&author.Name)
+			if err == nil {
+				authors[a.ID] = author
+			} else if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+		}
+		fields, err := loadCustomFields(tx)
+		if err != nil {
+			return err
+		}
+		b := restorable(r.Book, authors, fields)
+		b.ID, b.Version = bookID, version
+		return db.updateBook(tx, b)
+	})
+}
+
+// collectionState returns a collection as its revisions keep it, with the
+// books that are not in the trash.
+func collectionState(q querier, id int) (models.CollectionRevision, error) {
+	var r models.CollectionRevision
+	err := q.QueryRow("SELECT name FROM collections WHERE id = ? AND deleted_at IS NULL", id).Scan(&r.Name)
+	if err != nil {
+		return models.CollectionRevision{}, translateError(err, "collection", id)
+	}
+	r.BookIDs, err = liveBookIDs(q, id)
+	return r, err
+}
+
+// liveBookIDs returns the books of a collection that are not in the trash,
+// in ascending order.
+func liveBookIDs(q querier, collectionID int) ([]int, error) {
+	rows, err := q.Query("SELECT cb.book_id FROM collection_books cb JOIN books b ON b.id = cb.book_id "+
+		"WHERE cb.collection_id = ? AND b.deleted_at IS NULL ORDER BY cb.book_id", collectionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (db *DB) insertCollectionRevision(q querier, id int, r models.CollectionRevision) error {
+	data, err := json.Marshal(r.BookIDs)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec("INSERT INTO collection_revisions (collection_id, revision, actor, name, book_ids) "+
+		"SELECT ?, COALESCE(MAX(revision), 0) + 1, ?, ?, ? FROM collection_revisions WHERE collection_id = ?",
+		id, db.actor, r.Name, string(data), id)
+	return err
+}
+
+// addCollectionRevision records the collection as it is now as its next
+// revision. Like addBookRevision it must run after the collection was
+// locked.
+func (db *DB) addCollectionRevision(q querier, id int) error {
+	r, err := collectionState(q, id)
+	if err != nil {
+		return err
+	}
+	return db.insertCollectionRevision(q, id, r)
+}
+
+// startCollectionRevisions records before as the first revision of a
+// collection that has none.
+func (db *DB) startCollectionRevisions(q querier, id int, before models.CollectionRevision) error {
+	var found int
+	err := q.QueryRow("SELECT COUNT(*) FROM collection_revisions WHERE collection_id = ?", id).Scan(&found)
+	if err != nil || found > 0 {
+		return err
+	}
+	return db.insertCollectionRevision(q, id, before)
+}
+
+func scanCollectionRevision(row interface{ Scan(...interface{}) error }) (models.CollectionRevision, error) {
+	var r models.CollectionRevision
+	var data string
+	if err := row.Scan(&r.Revision, timestamp{&r.At}, &r.Actor, &r.Name, &data); err != nil {
+		return models.CollectionRevision{}, err
+	}
+	return r, json.Unmarshal([]byte(data), &r.BookIDs)
+}
+
+// GetCollectionRevisions returns the revisions of a collection, oldest
+// first.
+func (db *DB) GetCollectionRevisions(collectionID int) ([]models.CollectionRevision, error) {
+	if err := exists(db, "collections", collectionID); err != nil {
+		return nil, translateError(err, "collection", collectionID)
+	}
+	rows, err := db.Query("SELECT revision, at, actor, name, book_ids FROM collection_revisions WHERE collection_id = ? ORDER BY revision", collectionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.CollectionRevision{}
+	for rows.Next() {
+		r, err := scanCollectionRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, r)
+	}
+	return revisions, rows.Err()
+}
+
+func (db *DB) GetCollectionRevision(collectionID, revision int) (models.CollectionRevision, error) {
+	return getCollectionRevision(db, collectionID, revision)
+}
+
+func getCollectionRevision(q querier, collectionID, revision int) (models.CollectionRevision, error) {
+	if err := exists(q, "collections", collectionID); err != nil {
+		return models.CollectionRevision{}, translateError(err, "collection", collectionID)
+	}
+	r, err := scanCollectionRevision(q.QueryRow("SELECT revision, at, actor, name, book_ids FROM collection_revisions "+
+		"WHERE collection_id = ? AND revision = ?", collectionID, revision))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.CollectionRevision{}, fmt.Errorf("revision %d of collection %d: %w", revision, collectionID, ErrNotFound)
+	}
+	return r, err
+}
+
+// RestoreCollectionRevision renames the collection and adds and removes
+// books until it is as it was at the revision, which adds a new revision.
+// Books of the revision that are in the trash or were purged since are left
+// out, and books in the trash keep their membership. A non-zero version
+// makes the restore conditional, like for UpdateBook.
+func (db *DB) RestoreCollectionRevision(collectionID, revision, version int) error {
+	return db.inTx(func(tx *Tx) error {
+		r, err := getCollectionRevision(tx, collectionID, revision)
+		if err != nil {
+			return err
+		}
+		before, err := collectionState(tx, collectionID)
+		if err != nil {
+			return err
+		}
+
+		query := "UPDATE collections SET name = ?, updated_at = CURRENT_TIMESTAMP, version = version + 1 WHERE id = ? AND deleted_at IS NULL"
+		args := []interface{}{r.Name, collectionID}
+		if version != 0 {
+			query += " AND version = ?"
+			args = append(args, version)
+		}
+		res, err := tx.Exec(query, args...)
+		if err := expectVersion(tx, res, err, "collection", collectionID, version); err != nil {
+			return err
+		}
+		if err := db.startCollectionRevisions(tx, collectionID, before); err != nil {
+			return err
+		}
+		err = db.audit(tx, models.AuditEntry{Action: models.AuditUpdate, Entity: models.AuditCollection, CollectionID: collectionID},
+			models.Collection{Name: before.Name}, models.Collection{Name: r.Name})
+		if err != nil {
+			return err
+		}
+
+		membership := func(action string, bookID int) error {
+			return db.audit(tx, models.AuditEntry{Action: action, Entity: models.AuditMembership,
+				BookID: bookID, CollectionID: collectionID}, nil, nil)
+		}
+		for _, bookID := range before.BookIDs {
+			if slices.Contains(r.BookIDs, bookID) {
+				continue
+			}
+			_, err := tx.Exec("DELETE FROM collection_books WHERE collection_id = ? AND book_id = ?", collectionID, bookID)
+			if err != nil {
+				return err
+			}
+			if err := membership(models.AuditDelete, bookID); err != nil {
+				return err
+			}
+		}
+		for _, bookID := range r.BookIDs {
+			if slices.Contains(before.BookIDs, bookID) {
+				continue
+			}
+			if err := exists(tx, "books", bookID); errors.Is(err, sql.ErrNoRows) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			_, err := tx.Exec("INSERT INTO collection_books (collection_id, book_id) VALUES (?, ?)", collectionID, bookID)
+			if err != nil {
+				return err
+			}
+			if err := membership(models.AuditCreate, bookID); err != nil {
+				return err
+			}
+		}
+		return db.addCollectionRevision(tx, collectionID)
+	})
+}
@@ -0,0 +1,252 @@
+package db
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore_BookRevisions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		null := json.RawMessage("null")
+		alice, bob := store.WithActor("alice"), store.WithActor("bob")
+
+		id, err := alice.CreateBook(models.Book{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01", Tags: []string{"classic"}})
+		assert.NoError(t, err)
+		book, err := store.GetBook(id)
+		assert.NoError(t, err)
+		book.Title, book.Genre, book.Authors, book.Author, book.Tags = "Dune Messiah", "Science Fiction", nil, "Brian Herbert", nil
+		assert.NoError(t, bob.UpdateBook(book))
+		// The first author is no longer credited and can go
+		authors, _, err := store.GetAuthors(ListOptions{})
+		assert.NoError(t, err)
+		for _, a := range authors {
+			if a.Name == "Frank Herbert" {
+				assert.NoError(t, store.DeleteAuthor(a.ID))
+			}
+		}
+
+		revisions, err := store.GetBookRevisions(id)
+		assert.NoError(t, err)
+		if assert.Len(t, revisions, 2) {
+			assert.Equal(t, 1, revisions[0].Revision)
+			assert.Equal(t, "alice", revisions[0].Actor)
+			assert.Equal(t, "Dune", revisions[0].Book.Title)
+			assert.Equal(t, []string{"classic"}, revisions[0].Book.Tags)
+			assert.WithinDuration(t, time.Now(), revisions[0].At, time.Minute)
+			assert.Equal(t, "bob", revisions[1].Actor)
+			assert.Equal(t, "Dune Messiah", revisions[1].Book.Title)
+		}
+
+		diff, err := DiffBookRevisions(store, id, 1, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, diff.From)
+		var fields []string
+		for _, c := range diff.Changes {
+			fields = append(fields, c.Field)
+		}
+		assert.Equal(t, []string{"author", "authors", "genre", "tags", "title"}, fields)
+		// Against the book as it is now
+		diff, err = DiffBookRevisions(store, id, 0, 1)
+		assert.NoError(t, err)
+		assert.Contains(t, diff.Changes, models.FieldChange{Field: "genre", Before: json.RawMessage(`"Science Fiction"`), After: null})
+		diff, err = DiffBookRevisions(store, id, 2, 0)
+		assert.NoError(t, err)
+		assert.Empty(t, diff.Changes)
+
+		book, err = store.GetBook(id)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, alice.RestoreBookRevision(id, 1, book.Version-1), ErrVersionMismatch)
+		assert.NoError(t, alice.RestoreBookRevision(id, 1, book.Version))
+		restored, err := store.GetBook(id)
+		assert.NoError(t, err)
+		assert.Equal(t, "Dune", restored.Title)
+		assert.Equal(t, "Frank Herbert", restored.Author)
+		assert.Empty(t, restored.Genre)
+		assert.Equal(t, []string{"classic"}, restored.Tags)
+		assert.Equal(t, book.Version+1, restored.Version)
+
+		revisions, err = store.GetBookRevisions(id)
+		assert.NoError(t, err)
+		if assert.Len(t, revisions, 3) {
+			assert.Equal(t, "alice", revisions[2].Actor)
+		}
+		diff, err = DiffBookRevisions(store, id, 1, 3)
+		assert.NoError(t, err)
+		for _, c := range diff.Changes {
+			// The author was created again
+			assert.Equal(t, "authors", c.Field)
+		}
+		entries, _, err := store.GetAudit(AuditFilter{BookID: id, Actor: "alice", Action: models.AuditUpdate}, ListOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+
+		_, err = store.GetBookRevision(id, 4)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.ErrorIs(t, store.RestoreBookRevision(id, 9, 0), ErrNotFound)
+		_, err = store.GetBookRevisions(id + 1)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestStore_BookRevisionsOfRelations(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		id, err := store.CreateBook(models.Book{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01"})
+		assert.NoError(t, err)
+		book, err := store.GetBook(id)
+		assert.NoError(t, err)
+		herbert := book.Authors[0].ID
+
+		// Tagging a book and renaming its author are new revisions
+		assert.NoError(t, store.WithActor("alice").TagBooks([]int{id}, []string{"classic"}))
+		assert.NoError(t, store.WithActor("bob").UpdateAuthor(models.Author{ID: herbert, Name: "Frank P. Herbert"}))
+		revisions, err := store.GetBookRevisions(id)
+		assert.NoError(t, err)
+		if assert.Len(t, revisions, 3) {
+			assert.Empty(t, revisions[0].Book.Tags)
+			assert.Equal(t, "alice", revisions[1].Actor)
+			assert.Equal(t, []string{"classic"}, revisions[1].Book.Tags)
+			assert.Equal(t, "bob", revisions[2].Actor)
+			assert.Equal(t, "Frank P. Herbert", revisions[2].Book.Author)
+		}
+
+		// The renamed author is restored as it is now rather than created again
+		diff, err := DiffBookRevisions(store, id, 0, 1)
+		assert.NoError(t, err)
+		var fields []string
+		for _, c := range diff.Changes {
+			fields = append(fields, c.Field)
+		}
+		assert.Equal(t, []string{"tags"}, fields)
+		assert.NoError(t, store.RestoreBookRevision(id, 1, 0))
+		book, err = store.GetBook(id)
+		assert.NoError(t, err)
+		assert.Equal(t, "Frank P. Herbert", book.Author)
+		assert.Equal(t, herbert, book.Authors[0].ID)
+		assert.Empty(t, book.Tags)
+		authors, _, err := store.GetAuthors(ListOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, authors, 1)
+	})
+}
+
+func TestStore_RevisionsOfRestores(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		bookID, err := store.CreateBook(models.Book{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01"})
+		assert.NoError(t, err)
+		collectionID, err := store.CreateCollection(models.Collection{Name: "Shelf"})
+		assert.NoError(t, err)
+		assert.NoError(t, store.AddBookToCollection(collectionID, bookID))
+
+		// Restoring from the trash is a change like any other
+		assert.NoError(t, store.DeleteBook(bookID, 0))
+		assert.NoError(t, store.WithActor("alice").RestoreBook(bookID))
+		revisions, err := store.GetBookRevisions(bookID)
+		assert.NoError(t, err)
+		if assert.Len(t, revisions, 2) {
+			assert.Equal(t, "alice", revisions[1].Actor)
+			assert.Equal(t, "Dune", revisions[1].Book.Title)
+		}
+
+		assert.NoError(t, store.DeleteCollection(collectionID, 0))
+		assert.NoError(t, store.WithActor("alice").RestoreCollection(collectionID))
+		collectionRevisions, err := store.GetCollectionRevisions(collectionID)
+		assert.NoError(t, err)
+		if assert.NotEmpty(t, collectionRevisions) {
+			last := collectionRevisions[len(collectionRevisions)-1]
+			assert.Equal(t, "alice", last.Actor)
+			assert.Equal(t, "Shelf", last.Name)
+			assert.Equal(t, []int{bookID}, last.BookIDs)
+		}
+	})
+}
+
+func TestStore_CollectionRevisions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		var books []int
+		for _, title := range []string{"Dune", "Emma", "Ulysses"} {
+			id, err := store.CreateBook(models.Book{Title: title, Author: "Someone", PublishedDate: "1900"})
+			assert.NoError(t, err)
+			books = append(books, id)
+		}
+		id, err := store.CreateCollection(models.Collection{Name: "Shelf"})
+		assert.NoError(t, err)
+		assert.NoError(t, store.AddBookToCollection(id, books[1]))
+		assert.NoError(t, store.AddBookToCollection(id, books[0]))
+		assert.NoError(t, store.UpdateCollection(models.Collection{ID: id, Name: "Favourites"}))
+		assert.NoError(t, store.RemoveBookFromCollection(id, books[0]))
+		assert.NoError(t, store.AddBookToCollection(id, books[2]))
+
+		revisions, err := store.GetCollectionRevisions(id)
+		assert.NoError(t, err)
+		if assert.Len(t, revisions, 6) {
+			assert.Equal(t, "Shelf", revisions[0].Name)
+			assert.Empty(t, revisions[0].BookIDs)
+			assert.Equal(t, []int{books[0], books[1]}, revisions[2].BookIDs)
+			assert.Equal(t, "Favourites", revisions[3].Name)
+		}
+		diff, err := DiffCollectionRevisions(store, id, 3, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.FieldChange{
+			{Field: "book_ids", Before: json.RawMessage("[1,2]"), After: json.RawMessage("[2,3]")},
+			{Field: "name", Before: json.RawMessage(`"Shelf"`), After: json.RawMessage(`"Favourites"`)},
+		}, diff.Changes)
+
+		// A book of the revision that is in the trash stays out
+		assert.NoError(t, store.DeleteBook(books[0], 0))
+		collection, err := store.GetCollection(id)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, store.RestoreCollectionRevision(id, 3, collection.Version+1), ErrVersionMismatch)
+		assert.NoError(t, store.RestoreCollectionRevision(id, 3, collection.Version))
+		collection, err = store.GetCollection(id)
+		assert.NoError(t, err)
+		assert.Equal(t, "Shelf", collection.Name)
+		if assert.Len(t, collection.Books, 1) {
+			assert.Equal(t, books[1], collection.Books[0].ID)
+		}
+		entries, _, err := store.GetAudit(AuditFilter{CollectionID: id, Entity: models.AuditMembership, Action: models.AuditDelete}, ListOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, entries, 2)
+
+		// and does not come back into the collection with the book
+		assert.NoError(t, store.RestoreBook(books[0]))
+		in, err := store.IsBookInCollection(id, books[0])
+		assert.NoError(t, err)
+		assert.False(t, in)
+
+		_, err = store.GetCollectionRevision(id, 8)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.ErrorIs(t, store.RestoreCollectionRevision(id+1, 1, 0), ErrNotFound)
+	})
+}
+
+func TestDB_RevisionsStartBeforeTheFirstChange(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *DB) {
+		id, err := db.CreateBook(models.Book{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965"})
+		assert.NoError(t, err)
+		// As if the book was created before revisions were kept
+		_, err = db.Exec("DELETE FROM book_revisions")
+		assert.NoError(t, err)
+
+		book, err := db.GetBook(id)
+		assert.NoError(t, err)
+		book.Title = "Dune Messiah"
+		assert.NoError(t, db.UpdateBook(book))
+		revisions, err := db.GetBookRevisions(id)
+		assert.NoError(t, err)
+		if assert.Len(t, revisions, 2) {
+			assert.Equal(t, "Dune", revisions[0].Book.Title)
+			assert.Equal(t, "Dune Messiah", revisions[1].Book.Title)
+		}
+
+		assert.NoError(t, db.DeleteBook(id, 0))
+		_, err = db.PurgeTrash(time.Now().Add(time.Minute))
+		assert.NoError(t, err)
+		var count int
+		assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM book_revisions").Scan(&count))
+		assert.Zero(t, count)
+	})
+}
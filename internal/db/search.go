@@ -0,0 +1,268 @@
+package db
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+const (
+	highlightStart = "<mark>"
+	highlightEnd   = "</mark>"
+	snippetWords   = 12
+)
+
+// searchColumns are the columns covered by the full-text index, in index
+// order, with the weight a match in each of them contributes to the score.
+var searchColumns = []struct {
+	name   string
+	weight float64
+}{
+	{"title", 10},
+	{"author", 5},
+	{"description", 1},
+	{"genre", 2},
+}
+
+// searchTerms splits a free-text query into lower-cased words. Everything
+// that is not a letter or a digit separates words, so the terms can be passed
+// to the full-text engines without escaping.
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// SearchBooks returns up to limit books matching any of the words in query,
+// best matches first. Words match as prefixes, so "prog" finds "Programming".
+func (db *DB) SearchBooks(query string, limit int) ([]models.BookSearchResult, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	var results []models.BookSearchResult
+	var err error
+	if db.Driver == Postgres {
+		results, err = db.searchBooksPostgres(terms, limit)
+	} else {
+		var definition string
+		err = db.QueryRow("SELECT sql FROM sqlite_master WHERE name = 'books_fts'").Scan(&definition)
+		if err != nil {
+			return nil, err
+		}
+		if strings.Contains(strings.ToLower(definition), "fts4") {
+			results, err = db.searchBooksFTS4(terms, limit)
+		} else {
+			results, err = db.searchBooksFTS5(terms, limit)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	books := make([]*models.Book, len(results))
+	for i := range results {
+		books[i] = &results[i].Book
+	}
+	return results, loadRelations(db, books...)
+}
+
+// ftsMatch builds a MATCH expression that accepts any of the terms as a
+// prefix. It is understood by both FTS4 and FTS5.
+func ftsMatch(terms []string) string {
+	prefixes := make([]string, len(terms))
+	for i, term := range terms {
+		prefixes[i] = term + "*"
+	}
+	return strings.Join(prefixes, " OR ")
+}
+
+func (db *DB) searchBooksFTS5(terms []string, limit int) ([]models.BookSearchResult, error) {
+	weights := make([]string, len(searchColumns))
+	for i, column := range searchColumns {
+		weights[i] = strconv.FormatFloat(column.weight, 'f', -1, 64)
+	}
+	rows, err := db.Query(`
+		SELECT `+bookColumns+`, -bm25(books_fts, `+strings.Join(weights, ", ")+`) AS score,
+			snippet(books_fts, -1, '`+highlightStart+`', '`+highlightEnd+`', '…', `+strconv.Itoa(snippetWords)+`)
+		FROM books_fts
+		JOIN books b ON b.id = books_fts.rowid
+		WHERE books_fts MATCH ? AND b.deleted_at IS NULL
+		ORDER BY score DESC, b.id
+		LIMIT ?`, ftsMatch(terms), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.BookSearchResult
+	for rows.Next() {
+		var r models.BookSearchResult
+		var err error
+		r.Book, err = scanBook(rows, &r.Score, &r.Snippet)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// searchBooksFTS4 serves builds without FTS5. FTS4 has no ranking function,
+// so matches are scored in Go from the statistics returned by matchinfo.
+func (db *DB) searchBooksFTS4(terms []string, limit int) ([]models.BookSearchResult, error) {
+	rows, err := db.Query(`
+		SELECT `+bookColumns+`, matchinfo(books_fts, 'pcnx'),
+			snippet(books_fts, '`+highlightStart+`', '`+highlightEnd+`', '…', -1, `+strconv.Itoa(snippetWords)+`)
+		FROM books_fts
+		JOIN books b ON b.id = books_fts.rowid
+		WHERE books_fts MATCH ? AND b.deleted_at IS NULL`, ftsMatch(terms))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.BookSearchResult
+	for rows.Next() {
+		var r models.BookSearchResult
+		var matchinfo []byte
+		var err error
+		r.Book, err = scanBook(rows, &matchinfo, &r.Snippet)
+		if err != nil {
+			return nil, err
+		}
+		r.Score = scoreMatchinfo(matchinfo)
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sortSearchResults(results)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// scoreMatchinfo computes a TF-IDF score from FTS4 matchinfo 'pcnx' output:
+// the number of phrases and columns, the number of rows in the index, and
+// for every phrase and column the hits in this row, the hits in all rows and
+// the number of rows with at least one hit.
+func scoreMatchinfo(matchinfo []byte) float64 {
+	values := make([]uint32, len(matchinfo)/4)
+	for i := range values {
+		values[i] = binary.NativeEndian.Uint32(matchinfo[i*4:])
+	}
+	if len(values) < 3 {
+		return 0
+	}
+	phrases, columns, total := int(values[0]), int(values[1]), float64(values[2])
+
+	var score float64
+	for p := 0; p < phrases; p++ {
+		for c := 0; c < columns && c < len(searchColumns); c++ {
+			i := 3 + 3*(p*columns+c)
+			if i+2 >= len(values) {
+				return score
+			}
+			hits, docs := float64(values[i]), float64(values[i+2])
+			if hits == 0 {
+				continue
+			}
+			idf := math.Log(1 + (total-docs+0.5)/(docs+0.5))
+			score += searchColumns[c].weight * hits * idf
+		}
+	}
+	return score
+}
+
+func (db *DB) searchBooksPostgres(terms []string, limit int) ([]models.BookSearchResult, error) {
+	prefixes := make([]string, len(terms))
+	for i, term := range terms {
+		prefixes[i] = term + ":*"
+	}
+	rows, err := db.Query(`
+		SELECT `+bookColumns+`, ts_rank(b.search_vector, q) AS score,
+			ts_headline('english', concat_ws(' ', b.title, b.author, b.genre, b.description), q,
+				'StartSel=`+highlightStart+`, StopSel=`+highlightEnd+`, MaxWords=`+strconv.Itoa(snippetWords)+`, MinWords=5')
+		FROM books b, to_tsquery('english', ?) q
+		WHERE b.search_vector @@ q AND b.deleted_at IS NULL
+		ORDER BY score DESC, b.id
+		LIMIT ?`, strings.Join(prefixes, " | "), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.BookSearchResult
+	for rows.Next() {
+		var r models.BookSearchResult
+		var err error
+		r.Book, err = scanBook(rows, &r.Score, &r.Snippet)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+func matchesAnyTerm(word string, terms []string) bool {
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// highlight marks the words of text that match one of the terms and cuts the
+// text down to a window of words around the first match.
+func highlight(text string, terms []string) string {
+	words := strings.Fields(text)
+	first := -1
+	for i, word := range words {
+		start := strings.IndexFunc(word, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) })
+		if start < 0 {
+			continue
+		}
+		end := start + strings.IndexFunc(word[start:], func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if end < start {
+			end = len(word)
+		}
+		if !matchesAnyTerm(strings.ToLower(word[start:end]), terms) {
+			continue
+		}
+		words[i] = word[:start] + highlightStart + word[start:end] + highlightEnd + word[end:]
+		if first < 0 {
+			first = i
+		}
+	}
+
+	from := max(0, first-snippetWords/4)
+	to := min(len(words), from+snippetWords)
+	snippet := strings.Join(words[from:to], " ")
+	if from > 0 {
+		snippet = "…" + snippet
+	}
+	if to < len(words) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+func sortSearchResults(results []models.BookSearchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Book.ID < results[j].Book.ID
+	})
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func createSearchTestBooks(t *testing.T, store Store) {
+	t.Helper()
+	books := []models.Book{
+		{Title: "The Go Programming Language", Author: "Alan A. A. Donovan, Brian W. Kernighan", PublishedDate: "2015-10-26",
+			Description: "An authoritative resource for writing clear and idiomatic Go", Genre: "Programming"},
+		{Title: "The C Programming Language", Author: "Brian W. Kernighan, Dennis M. Ritchie", PublishedDate: "1978-02-22",
+			Description: "The classic introduction to C", Genre: "Programming"},
+		{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01",
+			Description: "A science fiction novel set on the desert planet Arrakis", Genre: "Science Fiction"},
+	}
+	for _, b := range books {
+		_, err := store.CreateBook(b)
+		assert.NoError(t, err)
+	}
+}
+
+func TestSearchBooks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		createSearchTestBooks(t, store)
+
+		// Words match anywhere, without knowing the exact author string
+		results, err := store.SearchBooks("the go book", 10)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, results)
+		assert.Equal(t, "The Go Programming Language", results[0].Book.Title)
+		assert.Greater(t, results[0].Score, 0.0)
+		assert.Contains(t, results[0].Snippet, "<mark>")
+
+		// Prefixes match and title matches rank first
+		results, err = store.SearchBooks("kernig", 10)
+		assert.NoError(t, err)
+		assert.Len(t, results, 2)
+
+		results, err = store.SearchBooks("arrakis", 10)
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+		assert.Equal(t, "Dune", results[0].Book.Title)
+		assert.Equal(t, "Frank Herbert", results[0].Book.Author)
+		assert.False(t, results[0].Book.CreatedAt.IsZero())
+
+		// Limit
+		results, err = store.SearchBooks("programming", 1)
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+
+		// No match and no terms
+		results, err = store.SearchBooks("xylophone", 10)
+		assert.NoError(t, err)
+		assert.Empty(t, results)
+		results, err = store.SearchBooks(`"*"`, 10)
+		assert.NoError(t, err)
+		assert.Empty(t, results)
+	})
+}
+
+func TestSearchBooks_FollowsChanges(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		createSearchTestBooks(t, store)
+
+		// Updates are reflected in the index
+		err := store.UpdateBook(models.Book{ID: 3, Title: "Children of Dune", Author: "Frank Herbert", PublishedDate: "1976-04-01", Genre: "Science Fiction"})
+		assert.NoError(t, err)
+		results, err := store.SearchBooks("children", 10)
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+		results, err = store.SearchBooks("arrakis", 10)
+		assert.NoError(t, err)
+		assert.Empty(t, results)
+
+		// Deleted books disappear from the index
+		err = store.DeleteBook(3, 0)
+		assert.NoError(t, err)
+		results, err = store.SearchBooks("herbert", 10)
+		assert.NoError(t, err)
+		assert.Empty(t, results)
+	})
+}
+
+func TestDB_SearchIndexCoversExistingBooks(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, driver string) {
+		db := openTestDB(t, driver)
+		defer db.Close()
+
+		// Books created before the search migration are indexed by it
+		err := db.MigrateTo(1)
+		assert.NoError(t, err)
+		// Written with the columns of that schema version, which CreateBook
+		// has outgrown
+		_, err = db.Exec("INSERT INTO books (title, author, published_date, edition, description, genre) VALUES (?, ?, ?, ?, ?, ?)",
+			"Dune", "Frank Herbert", "1965-08-01", "", "A science fiction novel set on the desert planet Arrakis", "Science Fiction")
+		assert.NoError(t, err)
+		err = db.Migrate()
+		assert.NoError(t, err)
+
+		results, err := db.SearchBooks("dune", 10)
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+	})
+}
+
+func TestHighlight(t *testing.T) {
+	assert.Equal(t, "The <mark>Go</mark> Programming Language", highlight("The Go Programming Language", []string{"go"}))
+	assert.Equal(t, "(<mark>Kernighan</mark>),", highlight("(Kernighan),", []string{"kern"}))
+
+	// Long text is cut down to a window around the first match
+	text := "one two three four five six seven eight nine ten eleven twelve thirteen fourteen fifteen sixteen"
+	assert.Equal(t, "…two three four <mark>five</mark> six seven eight nine ten eleven twelve thirteen…", highlight(text, []string{"five"}))
+	assert.Equal(t, "one two three four five six seven eight nine ten eleven twelve…", highlight(text, []string{"zzz"}))
+}
@@ -0,0 +1,283 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// SeriesSortFields are the fields the series list can be ordered by.
+var SeriesSortFields = []string{"id", "name"}
+
+// seriesGaps returns the whole positions from 1 up to the last book of a
+// series that no book occupies. Books at fractional positions do not fill a
+// gap.
+func seriesGaps(books []models.SeriesBook) []int {
+	gaps := []int{}
+	if len(books) == 0 {
+		return gaps
+	}
+	occupied := map[float64]bool{}
+	last := 0.0
+	for _, b := range books {
+		occupied[b.Position] = true
+		last = max(last, b.Position)
+	}
+	for position := 1; position <= int(math.Floor(last)); position++ {
+		if !occupied[float64(position)] {
+			gaps = append(gaps, position)
+		}
+	}
+	return gaps
+}
+
+func checkPosition(position float64) error {
+	if position < 0 || math.IsNaN(position) || math.IsInf(position, 0) {
+		return fmt.Errorf("%w position %v, expected a number of at least 0", ErrInvalid, position)
+	}
+	return nil
+}
+
+// loadSeries fills in the series the books belong to, ordered by name.
+func loadSeries(q querier, books ...*models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+	byID := map[int][]*models.Book{}
+	args := make([]interface{}, 0, len(books))
+	for _, b := range books {
+		if _, ok := byID[b.ID]; !ok {
+			args = append(args, b.ID)
+		}
+		byID[b.ID] = append(byID[b.ID], b)
+		b.Series = []models.BookSeries{}
+	}
+
+	rows, err := q.Query(`
+		SELECT sb.book_id, s.id, s.name, sb.position
+		FROM series_books sb
+		JOIN series s ON s.id = sb.series_id
+		WHERE sb.book_id IN (?`+strings.Repeat(", ?", len(args)-1)+`)
+		ORDER BY sb.book_id, s.name`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bookID int
+		var s models.BookSeries
+		if err := rows.Scan(&bookID, &s.ID, &s.Name, &s.Position); err != nil {
+			return err
+		}
+		for _, b := range byID[bookID] {
+			b.Series = append(b.Series, s)
+		}
+	}
+	return rows.Err()
+}
+
+// ListSeries returns a page of series, without their books.
+func (db *DB) ListSeries(opts ListOptions) ([]models.Series, Page, error) {
+	sort, err := opts.sortField(SeriesSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	var page Page
+	err = db.QueryRow("SELECT COUNT(*) FROM series").Scan(&page.Total)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	query := "SELECT id, name, description FROM series"
+	condition, args, order := keyset(sort, "id", opts.Desc, after)
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+	query += " ORDER BY " + order
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit+1)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	defer rows.Close()
+
+	var series []models.Series
+	for rows.Next() {
+		var s models.Series
+		if err := rows.Scan(&s.ID, &s.Name, &s.Description); err != nil {
+			return nil, Page{}, err
+		}
+		series = append(series, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, Page{}, err
+	}
+
+	series, page.Next = paginate(series, opts.Limit, func(s models.Series) cursor {
+		if sort == "name" {
+			return cursor{Sort: sort, Desc: opts.Desc, Value: s.Name, ID: s.ID}
+		}
+		return cursor{Sort: sort, Desc: opts.Desc, ID: s.ID}
+	})
+	return series, page, nil
+}
+
+// GetSeries returns the series with its books ordered by position and the
+// gaps between them.
+func (db *DB) GetSeries(id int) (models.Series, error) {
+	var s models.Series
+	err := db.QueryRow("SELECT id, name, description FROM series WHERE id = ?", id).Scan(&s.ID, &s.Name, &s.Description)
+	if err != nil {
+		return models.Series{}, translateError(err, "series", id)
+	}
+
+	rows, err := db.Query(`
+		SELECT `+bookColumns+`, sb.position
+		FROM books b
+		JOIN series_books sb ON b.id = sb.book_id
+		WHERE sb.series_id = ? AND b.deleted_at IS NULL
+		ORDER BY sb.position`, id)
+	if err != nil {
+		return models.Series{}, err
+	}
+	defer rows.Close()
+
+	s.Books = []models.SeriesBook{}
+	for rows.Next() {
+		var sb models.SeriesBook
+		sb.Book, err = scanBook(rows, &sb.Position)
+		if err != nil {
+			return models.Series{}, err
+		}
+		s.Books = append(s.Books, sb)
+	}
+	if err := rows.Err(); err != nil {
+		return models.Series{}, err
+	}
+
+	books := make([]*models.Book, len(s.Books))
+	for i := range s.Books {
+		books[i] = &s.Books[i].Book
+	}
+	s.Missing = seriesGaps(s.Books)
+	return s, loadRelations(db, books...)
+}
+
+// CreateSeries fails with ErrConflict if a series with the name exists.
+func (db *DB) CreateSeries(s models.Series) (int, error) {
+	var id int
+	err := db.QueryRow("INSERT INTO series (name, description) VALUES (?, ?) RETURNING id", s.Name, s.Description).Scan(&id)
+	if err != nil {
+		return 0, translateError(err, "series", 0)
+	}
+	return id, nil
+}
+
+// UpdateSeries changes the name and description of the series. Its books
+// embed the name, so their version is bumped.
+func (db *DB) UpdateSeries(s models.Series) error {
+	return db.inTx(func(tx *Tx) error {
+		ids, err := seriesBookIDs(tx, s.ID)
+		if err != nil {
+			return err
+		}
+		return db.changeBooks(tx, ids, func() error {
+			res, err := tx.Exec("UPDATE series SET name = ?, description = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", s.Name, s.Description, s.ID)
+			return expectVersion(tx, res, err, "series", s.ID, 0)
+		})
+	})
+}
+
+// DeleteSeries deletes the series. Its books are kept.
+func (db *DB) DeleteSeries(id int) error {
+	return db.inTx(func(tx *Tx) error {
+		ids, err := seriesBookIDs(tx, id)
+		if err != nil {
+			return err
+		}
+		return db.changeBooks(tx, ids, func() error {
+			// SQLite does not enforce the cascade
+			if _, err := tx.Exec("DELETE FROM series_books WHERE series_id = ?", id); err != nil {
+				return err
+			}
+			res, err := tx.Exec("DELETE FROM series WHERE id = ?", id)
+			return expectVersion(tx, res, err, "series", id, 0)
+		})
+	})
+}
+
+// SetSeriesBook puts the book at the position in the series, adding it to
+// the series or moving it within. It fails with ErrConflict if another book
+// is at the position.
+func (db *DB) SetSeriesBook(seriesID, bookID int, position float64) error {
+	if err := checkPosition(position); err != nil {
+		return err
+	}
+	return db.inTx(func(tx *Tx) error {
+		if err := exists(tx, "series", seriesID); err != nil {
+			return translateError(err, "series", seriesID)
+		}
+		if err := exists(tx, "books", bookID); err != nil {
+			return translateError(err, "book", bookID)
+		}
+		var other int
+		err := tx.QueryRow("SELECT book_id FROM series_books WHERE series_id = ? AND position = ? AND book_id <> ?", seriesID, position, bookID).Scan(&other)
+		if err == nil {
+			return fmt.Errorf("book %d is already at position %v of series %d: %w", other, position, seriesID, ErrConflict)
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		return db.changeBooks(tx, []int{bookID}, func() error {
+			res, err := tx.Exec("UPDATE series_books SET position = ? WHERE series_id = ? AND book_id = ?", position, seriesID, bookID)
+			if err != nil {
+				return err
+			}
+			if n, err := res.RowsAffected(); err != nil {
+				return err
+			} else if n == 0 {
+				_, err = tx.Exec("INSERT INTO series_books (series_id, book_id, position) VALUES (?, ?, ?)", seriesID, bookID, position)
+				if err != nil {
+					return translateError(err, "series", seriesID)
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// RemoveSeriesBook fails with ErrNotFound if the book is not in the series.
+func (db *DB) RemoveSeriesBook(seriesID, bookID int) error {
+	return db.inTx(func(tx *Tx) error {
+		var found int
+		err := tx.QueryRow("SELECT 1 FROM series_books WHERE series_id = ? AND book_id = ?", seriesID, bookID).Scan(&found)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("book %d is not in series %d: %w", bookID, seriesID, ErrNotFound)
+		} else if err != nil {
+			return err
+		}
+		return db.changeBooks(tx, []int{bookID}, func() error {
+			_, err := tx.Exec("DELETE FROM series_books WHERE series_id = ? AND book_id = ?", seriesID, bookID)
+			return err
+		})
+	})
+}
+
+// seriesBookIDs returns the ids of the books in the series.
+func seriesBookIDs(q querier, seriesID int) ([]int, error) {
+	return queryIDs(q, "SELECT book_id FROM series_books WHERE series_id = ? ORDER BY book_id", seriesID)
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeriesGaps(t *testing.T) {
+	books := func(positions ...float64) []models.SeriesBook {
+		var books []models.SeriesBook
+		for _, p := range positions {
+			books = append(books, models.SeriesBook{Position: p})
+		}
+		return books
+	}
+	assert.Equal(t, []int{}, seriesGaps(nil))
+	assert.Equal(t, []int{}, seriesGaps(books(1, 2, 3)))
+	assert.Equal(t, []int{2, 4}, seriesGaps(books(1, 2.5, 3, 5)))
+	assert.Equal(t, []int{1}, seriesGaps(books(0, 2, 2.5)))
+}
+
+func TestStore_Series(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		var volumes []int
+		for _, title := range []string{"Fundamental Algorithms", "Seminumerical Algorithms", "Sorting and Searching", "Combinatorial Algorithms"} {
+			id, err := store.CreateBook(models.Book{Title: title, Author: "Donald E. Knuth", PublishedDate: "1968-01-01"})
+			assert.NoError(t, err)
+			volumes = append(volumes, id)
+		}
+
+		taocpID, err := store.CreateSeries(models.Series{Name: "The Art of Computer Programming"})
+		assert.NoError(t, err)
+		_, err = store.CreateSeries(models.Series{Name: "The Art of Computer Programming"})
+		assert.ErrorIs(t, err, ErrConflict)
+
+		assert.NoError(t, store.SetSeriesBook(taocpID, volumes[2], 3))
+		assert.NoError(t, store.SetSeriesBook(taocpID, volumes[0], 1))
+		assert.NoError(t, store.SetSeriesBook(taocpID, volumes[3], 4))
+		assert.ErrorIs(t, store.SetSeriesBook(taocpID, volumes[1], 3), ErrConflict)
+		assert.ErrorIs(t, store.SetSeriesBook(taocpID, volumes[1], -1), ErrInvalid)
+		assert.ErrorIs(t, store.SetSeriesBook(taocpID, 42, 2), ErrNotFound)
+		assert.ErrorIs(t, store.SetSeriesBook(42, volumes[1], 2), ErrNotFound)
+
+		// Moving a book within the series keeps a single membership
+		assert.NoError(t, store.SetSeriesBook(taocpID, volumes[3], 4.5))
+
+		series, err := store.GetSeries(taocpID)
+		assert.NoError(t, err)
+		var titles []string
+		for _, b := range series.Books {
+			titles = append(titles, b.Book.Title)
+		}
+		assert.Equal(t, []string{"Fundamental Algorithms", "Sorting and Searching", "Combinatorial Algorithms"}, titles)
+		assert.Equal(t, 4.5, series.Books[2].Position)
+		assert.Equal(t, []int{2, 4}, series.Missing)
+
+		// Books embed a summary of their series
+		book, err := store.GetBook(volumes[2])
+		assert.NoError(t, err)
+		assert.Equal(t, []models.BookSeries{{ID: taocpID, Name: "The Art of Computer Programming", Position: 3}}, book.Series)
+		assert.Equal(t, 2, book.Version)
+		book, err = store.GetBook(volumes[1])
+		assert.NoError(t, err)
+		assert.Equal(t, []models.BookSeries{}, book.Series)
+
+		assert.NoError(t, store.UpdateSeries(models.Series{ID: taocpID, Name: "TAOCP"}))
+		book, err = store.GetBook(volumes[0])
+		assert.NoError(t, err)
+		assert.Equal(t, "TAOCP", book.Series[0].Name)
+		assert.Equal(t, 3, book.Version)
+
+		assert.NoError(t, store.RemoveSeriesBook(taocpID, volumes[0]))
+		assert.ErrorIs(t, store.RemoveSeriesBook(taocpID, volumes[0]), ErrNotFound)
+		assert.NoError(t, store.DeleteBook(volumes[2], 0))
+		series, err = store.GetSeries(taocpID)
+		assert.NoError(t, err)
+		assert.Len(t, series.Books, 1)
+
+		list, page, err := store.ListSeries(ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, page.Total)
+		assert.Equal(t, "TAOCP", list[0].Name)
+
+		assert.NoError(t, store.DeleteSeries(taocpID))
+		assert.ErrorIs(t, store.DeleteSeries(taocpID), ErrNotFound)
+		book, err = store.GetBook(volumes[3])
+		assert.NoError(t, err)
+		assert.Empty(t, book.Series)
+	})
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"time"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// Store is the storage backend used by the API handlers. DB implements it on
+// top of SQL and MemoryStore keeps everything in memory.
+//
+// Books and collections carry a version that every change bumps. Updates and
+// deletes given a non-zero version only apply while the record is still at
+// that version and fail with ErrVersionMismatch otherwise.
+//
+// Deleted books and collections go to the trash, which keeps them out of
+// sight until they are restored or purged.
+//
+// Changes to books, collections and their memberships are appended to the
+// audit log, naming the actor of the store returned by WithActor. Every
+// change to a book or a collection also keeps the result as a revision,
+// which it can be restored to.
+type Store interface {
+	GetBook(id int) (models.Book, error)
+	GetBooks(filter BookFilter, opts ListOptions) ([]models.Book, Page, error)
+	SearchBooks(query string, limit int) ([]models.BookSearchResult, error)
+	CreateBook(b models.Book) (int, error)
+	UpdateBook(b models.Book) error
+	DeleteBook(id, version int) error
+	SetBookCover(bookID int, c models.Cover) error
+	DeleteBookCover(bookID int) error
+
+	GetCollections(opts ListOptions) ([]models.Collection, Page, error)
+	GetCollection(id int) (models.Collection, error)
+	CreateCollection(c models.Collection) (int, error)
+	UpdateCollection(c models.Collection) error
+	DeleteCollection(id, version int) error
+
+	GetAuthors(opts ListOptions) ([]models.Author, Page, error)
+	GetAuthor(id int) (models.Author, error)
+	CreateAuthor(a models.Author) (int, error)
+	UpdateAuthor(a models.Author) error
+	DeleteAuthor(id int) error
+
+	GetTags(opts ListOptions) ([]models.Tag, Page, error)
+	GetTag(id int) (models.Tag, error)
+	CreateTag(t models.Tag) (int, error)
+	UpdateTag(t models.Tag) error
+	DeleteTag(id int) error
+	MergeTags(sourceID, targetID int) error
+	TagBooks(bookIDs []int, tags []string) error
+	UntagBooks(bookIDs []int, tags []string) error
+
+	ListSeries(opts ListOptions) ([]models.Series, Page, error)
+	GetSeries(id int) (models.Series, error)
+	CreateSeries(s models.Series) (int, error)
+	UpdateSeries(s models.Series) error
+	DeleteSeries(id int) error
+	SetSeriesBook(seriesID, bookID int, position float64) error
+	RemoveSeriesBook(seriesID, bookID int) error
+
+	GetReadingLog(bookID int) (models.ReadingLog, error)
+	GetReadingSession(bookID, id int) (models.ReadingSession, error)
+	CreateReadingSession(s models.ReadingSession) (int, error)
+	UpdateReadingSession(s models.ReadingSession) error
+	DeleteReadingSession(bookID, id int) error
+	AddReadingProgress(bookID, sessionID int, p models.ReadingProgress) (int, error)
+
+	GetReviews(filter ReviewFilter, opts ListOptions) ([]models.Review, Page, error)
+	GetReview(id int) (models.Review, error)
+	CreateReview(r models.Review) (int, error)
+	UpdateReview(r models.Review) error
+	DeleteReview(id int) error
+
+	GetLoans(filter LoanFilter, opts ListOptions) ([]models.Loan, Page, error)
+	GetLoan(id int) (models.Loan, error)
+	CreateLoan(l models.Loan) (int, error)
+	UpdateLoan(l models.Loan) error
+	ReturnLoan(id int, returnedAt string) error
+	DeleteLoan(id int) error
+
+	GetLocations(opts ListOptions) ([]models.Location, Page, error)
+	GetLocation(id int) (models.Location, error)
+	CreateLocation(l models.Location) (int, error)
+	UpdateLocation(l models.Location) error
+	DeleteLocation(id int) error
+	GetCopies(filter CopyFilter, opts ListOptions) ([]models.Copy, Page, error)
+	GetCopy(id int) (models.Copy, error)
+	CreateCopy(c models.Copy) (int, error)
+	UpdateCopy(c models.Copy) error
+	DeleteCopy(id int) error
+	MoveCopies(ids []int, locationID *int) error
+	CheckInventory(locationID int, barcodes []string, apply bool) (models.InventoryReport, error)
+
+	GetWorks(opts ListOptions) ([]models.Work, Page, error)
+	GetWork(id int) (models.Work, error)
+	CreateWork(w models.Work) (int, error)
+	UpdateWork(w models.Work) error
+	DeleteWork(id int) error
+	MergeEditions(workID int, bookIDs []int) error
+	SplitEditions(workID int, bookIDs []int) (int, error)
+
+	GetPublishers(opts ListOptions) ([]models.Publisher, Page, error)
+	GetPublisher(id int) (models.Publisher, error)
+	UpdatePublisher(p models.Publisher) error
+	DeletePublisher(id int) error
+
+	GetCustomFields(opts ListOptions) ([]models.CustomField, Page, error)
+	GetCustomField(id int) (models.CustomField, error)
+	CreateCustomField(f models.CustomField) (int, error)
+	UpdateCustomField(f models.CustomField) error
+	DeleteCustomField(id int) error
+
+	AddBookToCollection(collectionID, bookID int) error
+	RemoveBookFromCollection(collectionID, bookID int) error
+	IsBookInCollection(collectionID, bookID int) (bool, error)
+
+	GetTrash() ([]models.TrashItem, error)
+	RestoreBook(id int) error
+	RestoreCollection(id int) error
+	PurgeTrash(before time.Time) ([]models.TrashItem, error)
+
+	WithActor(actor string) Store
+	GetAudit(filter AuditFilter, opts ListOptions) ([]models.AuditEntry, Page, error)
+
+	GetBookRevisions(bookID int) ([]models.BookRevision, error)
+	GetBookRevision(bookID, revision int) (models.BookRevision, error)
+	RestoreBookRevision(bookID, revision, version int) error
+	GetCollectionRevisions(collectionID int) ([]models.CollectionRevision, error)
+	GetCollectionRevision(collectionID, revision int) (models.CollectionRevision, error)
+	RestoreCollectionRevision(collectionID, revision, version int) error
+}
+
+var (
+	_ Store = (*DB)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
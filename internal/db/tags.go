@@ -0,0 +1,381 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// TagSortFields are the fields the tag list can be ordered by.
+var TagSortFields = []string{"id", "name"}
+
+// normalizeTag lower-cases a tag name and collapses its white space, so
+// "Science  Fiction" and "science fiction" are the same tag.
+func normalizeTag(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// validTag normalizes a tag name and rejects empty names and names with
+// commas, which separate alternatives in tag filters.
+func validTag(name string) (string, error) {
+	name = normalizeTag(name)
+	if name == "" {
+		return "", fmt.Errorf("%w tag, the name is empty", ErrInvalid)
+	}
+	if strings.Contains(name, ",") {
+		return "", fmt.Errorf("%w tag %q, names cannot contain commas", ErrInvalid, name)
+	}
+	return name, nil
+}
+
+// bookTags normalizes the tags of a book about to be written, dropping
+// duplicates and sorting them by name.
+func bookTags(names []string) ([]string, error) {
+	tags := make([]string, 0, len(names))
+	for _, name := range names {
+		name, err := validTag(name)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, name)
+	}
+	slices.Sort(tags)
+	return slices.Compact(tags), nil
+}
+
+// tagFilter normalizes the groups of a tag filter. Empty names and groups are
+// left out.
+func tagFilter(groups [][]string) [][]string {
+	var filter [][]string
+	for _, group := range groups {
+		var names []string
+		for _, name := range group {
+			if name = normalizeTag(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		if len(names) > 0 {
+			filter = append(filter, names)
+		}
+	}
+	return filter
+}
+
+// resolveTags returns the IDs of the named tags, creating those that do not
+// exist.
+func resolveTags(q querier, names []string) ([]int, error) {
+	ids := make([]int, len(names))
+	for i, name := range names {
+		err := q.QueryRow("SELECT id FROM tags WHERE name = ?", name).Scan(&ids[i])
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		err = q.QueryRow("INSERT INTO tags (name) VALUES (?) RETURNING id", name).Scan(&ids[i])
+		if err != nil {
+			return nil, translateError(err, "tag", 0)
+		}
+	}
+	return ids, nil
+}
+
+// writeBookTags replaces the tags of a book.
+func writeBookTags(q querier, bookID int, names []string) error {
+	ids, err := resolveTags(q, names)
+	if err != nil {
+		return err
+	}
+	if _, err := q.Exec("DELETE FROM book_tags WHERE book_id = ?", bookID); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := q.Exec("INSERT INTO book_tags (book_id, tag_id) VALUES (?, ?)", bookID, id); err != nil {
+			return translateError(err, "book", bookID)
+		}
+	}
+	return nil
+}
+
+// loadTags fills in the tags of the books in a single query.
+func loadTags(q querier, books ...*models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+	byID := map[int][]*models.Book{}
+	args := make([]interface{}, 0, len(books))
+	for _, b := range books {
+		if _, ok := byID[b.ID]; !ok {
+			args = append(args, b.ID)
+		}
+		byID[b.ID] = append(byID[b.ID], b)
+		b.Tags = []string{}
+	}
+
+	rows, err := q.Query(`
+		SELECT bt.book_id, t.name
+		FROM book_tags bt
+		JOIN tags t ON t.id = bt.tag_id
+		WHERE bt.book_id IN (?`+strings.Repeat(", ?", len(args)-1)+`)
+		ORDER BY bt.book_id, t.name`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bookID int
+		var name string
+		if err := rows.Scan(&bookID, &name); err != nil {
+			return err
+		}
+		for _, b := range byID[bookID] {
+			b.Tags = append(b.Tags, name)
+		}
+	}
+	return rows.Err()
+}
+
+const tagColumns = "t.id, t.name, (SELECT COUNT(*) FROM book_tags bt JOIN books b ON b.id = bt.book_id WHERE bt.tag_id = t.id AND b.deleted_at IS NULL)"
+
+// GetTags returns a page of tags with the number of books having each.
+func (db *DB) GetTags(opts ListOptions) ([]models.Tag, Page, error) {
+	sort, err := opts.sortField(TagSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	var page Page
+	err = db.QueryRow("SELECT COUNT(*) FROM tags").Scan(&page.Total)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	query := "SELECT " + tagColumns + " FROM tags t"
+	condition, args, order := keyset("t."+sort, "t.id", opts.Desc, after)
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+	query += " ORDER BY " + order
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit+1)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	defer rows.Close()
+
+	var tags []models.Tag
+	for rows.Next() {
+		var t models.Tag
+		if err := rows.Scan(&t.ID, &t.Name, &t.Books); err != nil {
+			return nil, Page{}, err
+		}
+		tags = append(tags, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, Page{}, err
+	}
+
+	tags, page.Next = paginate(tags, opts.Limit, func(t models.Tag) cursor {
+		if sort == "name" {
+			return cursor{Sort: sort, Desc: opts.Desc, Value: t.Name, ID: t.ID}
+		}
+		return cursor{Sort: sort, Desc: opts.Desc, ID: t.ID}
+	})
+	return tags, page, nil
+}
+
+func (db *DB) GetTag(id int) (models.Tag, error) {
+	var t models.Tag
+	err := db.QueryRow("SELECT "+tagColumns+" FROM tags t WHERE t.id = ?", id).Scan(&t.ID, &t.Name, &t.Books)
+	if err != nil {
+		return models.Tag{}, translateError(err, "tag", id)
+	}
+	return t, nil
+}
+
+// CreateTag fails with ErrConflict if a tag with the normalized name exists.
+func (db *DB) CreateTag(t models.Tag) (int, error) {
+	name, err := validTag(t.Name)
+	if err != nil {
+		return 0, err
+	}
+	var id int
+	err = db.QueryRow("INSERT INTO tags (name) VALUES (?) RETURNING id", name).Scan(&id)
+	if err != nil {
+		return 0, duplicateTag(translateError(err, "tag", 0), name)
+	}
+	return id, nil
+}
+
+// UpdateTag renames the tag. The tagged books change with it, so their
+// version is bumped. Renaming a tag to the name of another one fails with
+// ErrConflict, MergeTags combines them instead.
+func (db *DB) UpdateTag(t models.Tag) error {
+	name, err := validTag(t.Name)
+	if err != nil {
+		return err
+	}
+	return db.inTx(func(tx *Tx) error {
+		ids, err := taggedBooks(tx, t.ID)
+		if err != nil {
+			return err
+		}
+		return db.changeBooks(tx, ids, func() error {
+			res, err := tx.Exec("UPDATE tags SET name = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", name, t.ID)
+			return duplicateTag(expectVersion(tx, res, err, "tag", t.ID, 0), name)
+		})
+	})
+}
+
+// DeleteTag deletes the tag and removes it from its books.
+func (db *DB) DeleteTag(id int) error {
+	return db.inTx(func(tx *Tx) error {
+		if err := exists(tx, "tags", id); err != nil {
+			return translateError(err, "tag", id)
+		}
+		ids, err := taggedBooks(tx, id)
+		if err != nil {
+			return err
+		}
+		return db.changeBooks(tx, ids, func() error {
+			// SQLite does not enforce the cascade
+			if _, err := tx.Exec("DELETE FROM book_tags WHERE tag_id = ?", id); err != nil {
+				return err
+			}
+			_, err := tx.Exec("DELETE FROM tags WHERE id = ?", id)
+			return err
+		})
+	})
+}
+
+// MergeTags moves the books of the source tag to the target tag and deletes
+// the source tag.
+func (db *DB) MergeTags(sourceID, targetID int) error {
+	if sourceID == targetID {
+		return fmt.Errorf("%w merge, tag %d cannot be merged into itself", ErrInvalid, sourceID)
+	}
+	return db.inTx(func(tx *Tx) error {
+		if err := exists(tx, "tags", sourceID); err != nil {
+			return translateError(err, "tag", sourceID)
+		}
+		if err := exists(tx, "tags", targetID); err != nil {
+			return translateError(err, "tag", targetID)
+		}
+		ids, err := taggedBooks(tx, sourceID)
+		if err != nil {
+			return err
+		}
+		return db.changeBooks(tx, ids, func() error {
+			_, err := tx.Exec(`
+				INSERT INTO book_tags (book_id, tag_id)
+				SELECT book_id, ? FROM book_tags WHERE tag_id = ?
+				ON CONFLICT DO NOTHING`, targetID, sourceID)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec("DELETE FROM book_tags WHERE tag_id = ?", sourceID); err != nil {
+				return err
+			}
+			_, err = tx.Exec("DELETE FROM tags WHERE id = ?", sourceID)
+			return err
+		})
+	})
+}
+
+// TagBooks adds the tags to all of the books, creating tags that do not
+// exist. Nothing changes if one of the books does not exist. Only books
+// that did not have all of the tags yet get a new version.
+func (db *DB) TagBooks(bookIDs []int, names []string) error {
+	tags, err := bookTags(names)
+	if err != nil {
+		return err
+	}
+	return db.inTx(func(tx *Tx) error {
+		if err := booksExist(tx, bookIDs); err != nil {
+			return err
+		}
+		tagIDs, err := resolveTags(tx, tags)
+		if err != nil {
+			return err
+		}
+		return db.changeBooks(tx, bookIDs, func() error {
+			return changeBookTags(tx, bookIDs, tagIDs, "INSERT INTO book_tags (book_id, tag_id) VALUES (?, ?) ON CONFLICT DO NOTHING")
+		})
+	})
+}
+
+// UntagBooks removes the tags from all of the books, like TagBooks adds
+// them. Tags that do not exist are ignored.
+func (db *DB) UntagBooks(bookIDs []int, names []string) error {
+	tags, err := bookTags(names)
+	if err != nil {
+		return err
+	}
+	return db.inTx(func(tx *Tx) error {
+		if err := booksExist(tx, bookIDs); err != nil {
+			return err
+		}
+		var tagIDs []int
+		for _, name := range tags {
+			var id int
+			err := tx.QueryRow("SELECT id FROM tags WHERE name = ?", name).Scan(&id)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			tagIDs = append(tagIDs, id)
+		}
+		return db.changeBooks(tx, bookIDs, func() error {
+			return changeBookTags(tx, bookIDs, tagIDs, "DELETE FROM book_tags WHERE book_id = ? AND tag_id = ?")
+		})
+	})
+}
+
+// changeBookTags runs statement for every pair of book and tag.
+func changeBookTags(tx *Tx, bookIDs, tagIDs []int, statement string) error {
+	for _, bookID := range bookIDs {
+		for _, tagID := range tagIDs {
+			if _, err := tx.Exec(statement, bookID, tagID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func booksExist(q querier, ids []int) error {
+	for _, id := range ids {
+		if err := exists(q, "books", id); err != nil {
+			return translateError(err, "book", id)
+		}
+	}
+	return nil
+}
+
+// taggedBooks returns the ids of the books with the tag.
+func taggedBooks(q querier, tagID int) ([]int, error) {
+	return queryIDs(q, "SELECT book_id FROM book_tags WHERE tag_id = ? ORDER BY book_id", tagID)
+}
+
+// duplicateTag names the tag in conflicts caused by its name.
+func duplicateTag(err error, name string) error {
+	if errors.Is(err, ErrConflict) {
+		return fmt.Errorf("tag %q already exists: %w", name, ErrConflict)
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookTags(t *testing.T) {
+	tags, err := bookTags([]string{" Science  Fiction", "classics", "science fiction"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"classics", "science fiction"}, tags)
+
+	_, err = bookTags([]string{" "})
+	assert.ErrorIs(t, err, ErrInvalid)
+	_, err = bookTags([]string{"a,b"})
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	assert.Equal(t, [][]string{{"a"}, {"b", "c"}}, tagFilter([][]string{{" A", ""}, {}, {"B", "c"}}))
+}
+
+func TestStore_Tags(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		duneID, err := store.CreateBook(models.Book{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01", Tags: []string{"Science Fiction", "classics"}})
+		assert.NoError(t, err)
+		hobbitID, err := store.CreateBook(models.Book{Title: "The Hobbit", Author: "J. R. R. Tolkien", PublishedDate: "1937-09-21", Tags: []string{"fantasy", "classics"}})
+		assert.NoError(t, err)
+		goID, err := store.CreateBook(models.Book{Title: "The Go Programming Language", Author: "Alan A. A. Donovan", PublishedDate: "2015-10-26"})
+		assert.NoError(t, err)
+
+		book, err := store.GetBook(duneID)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"classics", "science fiction"}, book.Tags)
+		book, err = store.GetBook(goID)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{}, book.Tags)
+
+		tags, page, err := store.GetTags(ListOptions{Sort: "name"})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, page.Total)
+		assert.Equal(t, models.Tag{ID: tags[0].ID, Name: "classics", Books: 2}, tags[0])
+
+		// Tags of a group are alternatives, groups must all match
+		filter := func(groups ...[]string) []int {
+			books, _, err := store.GetBooks(BookFilter{Tags: groups}, ListOptions{})
+			assert.NoError(t, err)
+			var ids []int
+			for _, b := range books {
+				ids = append(ids, b.ID)
+			}
+			return ids
+		}
+		assert.Equal(t, []int{duneID, hobbitID}, filter([]string{"Classics"}))
+		assert.Equal(t, []int{duneID}, filter([]string{"classics"}, []string{"science fiction"}))
+		assert.Equal(t, []int{duneID, hobbitID}, filter([]string{"science fiction", "fantasy"}))
+		assert.Empty(t, filter([]string{"fantasy"}, []string{"science fiction"}))
+
+		// Bulk changes only bump the books they change
+		err = store.TagBooks([]int{duneID, goID}, []string{"to-read", "classics"})
+		assert.NoError(t, err)
+		book, err = store.GetBook(goID)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"classics", "to-read"}, book.Tags)
+		assert.Equal(t, 2, book.Version)
+		err = store.UntagBooks([]int{goID, hobbitID}, []string{"to-read", "unknown"})
+		assert.NoError(t, err)
+		book, err = store.GetBook(hobbitID)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, book.Version)
+		assert.ErrorIs(t, store.TagBooks([]int{duneID, 42}, []string{"favorites"}), ErrNotFound)
+		book, err = store.GetBook(duneID)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"classics", "science fiction", "to-read"}, book.Tags)
+
+		// Renaming and merging change the tagged books
+		sciFi := tags[2]
+		assert.Equal(t, "science fiction", sciFi.Name)
+		assert.NoError(t, store.UpdateTag(models.Tag{ID: sciFi.ID, Name: "SF"}))
+		assert.ErrorIs(t, store.UpdateTag(models.Tag{ID: sciFi.ID, Name: "fantasy"}), ErrConflict)
+		fantasy := tags[1]
+		assert.NoError(t, store.MergeTags(sciFi.ID, fantasy.ID))
+		book, err = store.GetBook(duneID)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"classics", "fantasy", "to-read"}, book.Tags)
+		_, err = store.GetTag(sciFi.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+		fantasy, err = store.GetTag(fantasy.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, fantasy.Books)
+		assert.ErrorIs(t, store.MergeTags(fantasy.ID, fantasy.ID), ErrInvalid)
+
+		// Deleting a tag removes it from its books
+		assert.NoError(t, store.DeleteTag(fantasy.ID))
+		book, err = store.GetBook(hobbitID)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"classics"}, book.Tags)
+		assert.ErrorIs(t, store.DeleteTag(fantasy.ID), ErrNotFound)
+
+		_, err = store.CreateTag(models.Tag{Name: "Classics"})
+		assert.ErrorIs(t, err, ErrConflict)
+		_, err = store.CreateBook(models.Book{Title: "Emma", Author: "Jane Austen", PublishedDate: "1815-12-23", Tags: []string{""}})
+		assert.ErrorIs(t, err, ErrInvalid)
+	})
+}
@@ -0,0 +1,246 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// notTrashed is the condition that leaves out the books and collections in
+// the trash, for tables that have one.
+func notTrashed(table string) string {
+	if table == "books" || table == "collections" {
+		return " AND deleted_at IS NULL"
+	}
+	return ""
+}
+
+// GetTrash returns the books and collections in the trash, the most
+// recently deleted first.
+func (db *DB) GetTrash() ([]models.TrashItem, error) {
+	return trash(db, "")
+}
+
+// trash loads the items in the trash. If before is set, only those deleted
+// before it.
+func trash(q querier, before string) ([]models.TrashItem, error) {
+	condition, args := "IS NOT NULL", []interface{}{}
+	if before != "" {
+		condition, args = "< ?", []interface{}{before}
+	}
+
+	rows, err := q.Query(`
+		SELECT `+bookColumns+`, b.deleted_at, (SELECT COUNT(*) FROM collection_books cb WHERE cb.book_id = b.id)
+		FROM books b
+		WHERE b.deleted_at `+condition, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.TrashItem
+	var books []*models.Book
+	for rows.Next() {
+		item := models.TrashItem{Type: models.TrashBook}
+		b, err := scanBook(rows, timestamp{&item.DeletedAt}, &item.Memberships)
+		if err != nil {
+			return nil, err
+		}
+		item.ID, item.Name, item.Book = b.ID, b.Title, &b
+		items = append(items, item)
+		books = append(books, &b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := loadRelations(q, books...); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.Query(`
+		SELECT c.id, c.name, c.deleted_at, (SELECT COUNT(*) FROM collection_books cb WHERE cb.collection_id = c.id)
+		FROM collections c
+		WHERE c.deleted_at `+condition, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item := models.TrashItem{Type: models.TrashCollection}
+		if err := rows.Scan(&item.ID, &item.Name, timestamp{&item.DeletedAt}, &item.Memberships); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sortTrash(items)
+	return items, nil
+}
+
+// sortTrash puts the most recently deleted items first, then books before
+// collections and lower IDs first.
+func sortTrash(items []models.TrashItem) {
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if !a.DeletedAt.Equal(b.DeletedAt) {
+			return a.DeletedAt.After(b.DeletedAt)
+		}
+		if a.Type != b.Type {
+			return a.Type == models.TrashBook
+		}
+		return a.ID < b.ID
+	})
+}
+
+// RestoreBook takes a book out of the trash, back into the collections it
+// was in. It fails with ErrNotFound if the book is not in the trash and with
+// ErrConflict if another book has taken its ISBN in the meantime.
+func (db *DB) RestoreBook(id int) error {
+	return db.inTx(func(tx *Tx) error {
+		var isbn string
+		err := tx.QueryRow("SELECT COALESCE(isbn_13, '') FROM books WHERE id = ? AND deleted_at IS NOT NULL", id).Scan(&isbn)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("book %d is not in the trash: %w", id, ErrNotFound)
+		} else if err != nil {
+			return err
+		}
+		before, err := loadBook(tx, id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("UPDATE books SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP, version = version + 1 WHERE id = ?", id)
+		if err != nil {
+			return duplicateISBN(translateError(err, "book", id), models.Book{ISBN13: isbn})
+		}
+		if err := touchCollectionsOf(tx, id); err != nil {
+			return err
+		}
+		after, err := getBook(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := db.startBookRevisions(tx, before); err != nil {
+			return err
+		}
+		if err := db.addBookRevision(tx, after); err != nil {
+			return err
+		}
+		return db.audit(tx, models.AuditEntry{Action: models.AuditRestore, Entity: models.AuditBook, BookID: id}, models.Book{}, after)
+	})
+}
+
+// RestoreCollection takes a collection out of the trash with the books that
+// were in it. It fails with ErrNotFound if the collection is not in the
+// trash.
+func (db *DB) RestoreCollection(id int) error {
+	return db.inTx(func(tx *Tx) error {
+		res, err := tx.Exec("UPDATE collections SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP, version = version + 1 "+
+			"WHERE id = ? AND deleted_at IS NOT NULL", id)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("collection %d is not in the trash: %w", id, ErrNotFound)
+		}
+		after, err := collectionState(tx, id)
+		if err != nil {
+			return err
+		}
+		// The collection kept its name and books in the trash
+		if err := db.startCollectionRevisions(tx, id, after); err != nil {
+			return err
+		}
+		if err := db.insertCollectionRevision(tx, id, after); err != nil {
+			return err
+		}
+		return db.audit(tx, models.AuditEntry{Action: models.AuditRestore, Entity: models.AuditCollection, CollectionID: id},
+			models.Collection{}, models.Collection{Name: after.Name})
+	})
+}
+
+// PurgeTrash permanently deletes the books and collections that were moved
+// to the trash before the given time, along with everything that belongs to
+// them, and returns what was purged.
+func (db *DB) PurgeTrash(before time.Time) ([]models.TrashItem, error) {
+	var items []models.TrashItem
+	err := db.inTx(func(tx *Tx) error {
+		var err error
+		items, err = trash(tx, db.timeValue(before))
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			entry := models.AuditEntry{Action: models.AuditPurge}
+			var before, after interface{}
+			if item.Type == models.TrashBook {
+				entry.Entity, entry.BookID = models.AuditBook, item.ID
+				before, after = *item.Book, models.Book{}
+				err = purgeBook(tx, item.ID)
+			} else {
+				entry.Entity, entry.CollectionID = models.AuditCollection, item.ID
+				before, after = models.Collection{Name: item.Name}, models.Collection{}
+				err = purgeCollection(tx, item.ID)
+			}
+			if err != nil {
+				return err
+			}
+			if err := db.audit(tx, entry, before, after); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return items, err
+}
+
+// purgeBook deletes a book and the rows that refer to it. SQLite does not
+// enforce the cascades.
+func purgeBook(tx *Tx, id int) error {
+	for _, query := range []string{
+		"DELETE FROM collection_books WHERE book_id = ?",
+		"DELETE FROM book_authors WHERE book_id = ?",
+		"DELETE FROM book_tags WHERE book_id = ?",
+		"DELETE FROM book_custom_values WHERE book_id = ?",
+		"DELETE FROM book_covers WHERE book_id = ?",
+		"DELETE FROM series_books WHERE book_id = ?",
+		"DELETE FROM reading_progress WHERE session_id IN (SELECT id FROM reading_sessions WHERE book_id = ?)",
+		"DELETE FROM reading_sessions WHERE book_id = ?",
+		"DELETE FROM review_history WHERE review_id IN (SELECT id FROM reviews WHERE book_id = ?)",
+		"DELETE FROM reviews WHERE book_id = ?",
+		"DELETE FROM loans WHERE book_id = ?",
+		"DELETE FROM copies WHERE book_id = ?",
+		"DELETE FROM book_revisions WHERE book_id = ?",
+		"DELETE FROM books WHERE id = ?",
+	} {
+		if _, err := tx.Exec(query, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeCollection deletes a collection with its memberships and revisions.
+func purgeCollection(tx *Tx, id int) error {
+	for _, query := range []string{
+		"DELETE FROM collection_books WHERE collection_id = ?",
+		"DELETE FROM collection_revisions WHERE collection_id = ?",
+		"DELETE FROM collections WHERE id = ?",
+	} {
+		if _, err := tx.Exec(query, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
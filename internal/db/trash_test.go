@@ -0,0 +1,176 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// emptyTrash purges everything in the trash.
+func emptyTrash(t *testing.T, store Store) {
+	t.Helper()
+	_, err := store.PurgeTrash(time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+}
+
+func TestStore_Trash(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		duneID, err := store.CreateBook(models.Book{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01", ISBN13: "9780441013593"})
+		assert.NoError(t, err)
+		hobbitID, err := store.CreateBook(models.Book{Title: "The Hobbit", Author: "J.R.R. Tolkien", PublishedDate: "1937-09-21"})
+		assert.NoError(t, err)
+		shelfID, err := store.CreateCollection(models.Collection{Name: "Shelf"})
+		assert.NoError(t, err)
+		assert.NoError(t, store.AddBookToCollection(shelfID, duneID))
+		assert.NoError(t, store.AddBookToCollection(shelfID, hobbitID))
+		shelf, err := store.GetCollection(shelfID)
+		assert.NoError(t, err)
+
+		// A deleted book is out of sight but keeps its memberships
+		assert.NoError(t, store.DeleteBook(duneID, 0))
+		_, err = store.GetBook(duneID)
+		assert.ErrorIs(t, err, ErrNotFound)
+		books, page, err := store.GetBooks(BookFilter{}, ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, page.Total)
+		assert.Equal(t, hobbitID, books[0].ID)
+		deleted, err := store.GetCollection(shelfID)
+		assert.NoError(t, err)
+		assert.Len(t, deleted.Books, 1)
+		assert.Greater(t, deleted.Version, shelf.Version)
+		assert.ErrorIs(t, store.DeleteBook(duneID, 0), ErrNotFound)
+		assert.ErrorIs(t, store.UpdateBook(models.Book{ID: duneID, Title: "Dune", PublishedDate: "1965"}), ErrNotFound)
+		assert.ErrorIs(t, store.AddBookToCollection(shelfID, hobbitID+1), ErrNotFound)
+
+		items, err := store.GetTrash()
+		assert.NoError(t, err)
+		if assert.Len(t, items, 1) {
+			assert.Equal(t, models.TrashBook, items[0].Type)
+			assert.Equal(t, duneID, items[0].ID)
+			assert.Equal(t, "Dune", items[0].Name)
+			assert.Equal(t, 1, items[0].Memberships)
+			assert.False(t, items[0].DeletedAt.IsZero())
+			if assert.NotNil(t, items[0].Book) {
+				assert.Equal(t, "Frank Herbert", items[0].Book.Author)
+			}
+		}
+
+		// Its ISBN is free to take, but then it cannot come back
+		otherID, err := store.CreateBook(models.Book{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1990", ISBN13: "9780441013593"})
+		assert.NoError(t, err)
+		assert.ErrorIs(t, store.RestoreBook(duneID), ErrConflict)
+		assert.NoError(t, store.DeleteBook(otherID, 0))
+		assert.NoError(t, store.RestoreBook(duneID))
+		assert.ErrorIs(t, store.RestoreBook(duneID), ErrNotFound)
+		book, err := store.GetBook(duneID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Frank Herbert", book.Author)
+		restored, err := store.GetCollection(shelfID)
+		assert.NoError(t, err)
+		assert.Len(t, restored.Books, 2)
+		assert.Greater(t, restored.Version, deleted.Version)
+
+		// A deleted collection keeps its books
+		assert.NoError(t, store.DeleteCollection(shelfID, 0))
+		_, err = store.GetCollection(shelfID)
+		assert.ErrorIs(t, err, ErrNotFound)
+		collections, _, err := store.GetCollections(ListOptions{})
+		assert.NoError(t, err)
+		assert.Empty(t, collections)
+		assert.ErrorIs(t, store.AddBookToCollection(shelfID, duneID), ErrNotFound)
+		items, err = store.GetTrash()
+		assert.NoError(t, err)
+		assert.Len(t, items, 2)
+		assert.NoError(t, store.RestoreCollection(shelfID))
+		assert.ErrorIs(t, store.RestoreCollection(shelfID), ErrNotFound)
+		restored, err = store.GetCollection(shelfID)
+		assert.NoError(t, err)
+		assert.Len(t, restored.Books, 2)
+
+		// Only items deleted before the given time are purged
+		assert.NoError(t, store.DeleteCollection(shelfID, 0))
+		purged, err := store.PurgeTrash(time.Now().Add(-time.Hour))
+		assert.NoError(t, err)
+		assert.Empty(t, purged)
+		purged, err = store.PurgeTrash(time.Now().Add(time.Minute))
+		assert.NoError(t, err)
+		assert.Len(t, purged, 2)
+		items, err = store.GetTrash()
+		assert.NoError(t, err)
+		assert.Empty(t, items)
+		assert.ErrorIs(t, store.RestoreBook(otherID), ErrNotFound)
+		assert.ErrorIs(t, store.RestoreCollection(shelfID), ErrNotFound)
+		inCollection, err := store.IsBookInCollection(shelfID, duneID)
+		assert.NoError(t, err)
+		assert.False(t, inCollection)
+	})
+}
+
+func TestStore_TrashHidesBooksFromListings(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		_, err := store.CreateCustomField(models.CustomField{Name: "signed", Type: "bool"})
+		assert.NoError(t, err)
+		workID, err := store.CreateWork(models.Work{Title: "Dune"})
+		assert.NoError(t, err)
+		shelfID, err := store.CreateLocation(models.Location{Name: "Shelf", Kind: "shelf"})
+		assert.NoError(t, err)
+		var ids []int
+		for _, barcode := range []string{"0001", "0002"} {
+			id, err := store.CreateBook(models.Book{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01",
+				Publisher: "Chilton Books", Tags: []string{"classic"}, Custom: map[string]interface{}{"signed": true}})
+			assert.NoError(t, err)
+			_, err = store.CreateCopy(models.Copy{BookID: id, Format: "paperback", Barcode: barcode, LocationID: &shelfID})
+			assert.NoError(t, err)
+			_, err = store.CreateLoan(models.Loan{BookID: id, Borrower: "ada", LoanedAt: "2024-03-01", DueAt: "2024-03-15"})
+			assert.NoError(t, err)
+			ids = append(ids, id)
+		}
+		assert.NoError(t, store.MergeEditions(workID, ids))
+		assert.NoError(t, store.DeleteBook(ids[0], 0))
+
+		// Copies, loans and counts only cover the book that is not in the trash
+		copies, page, err := store.GetCopies(CopyFilter{}, ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, page.Total)
+		if assert.Len(t, copies, 1) {
+			assert.Equal(t, ids[1], copies[0].BookID)
+		}
+		report, err := store.CheckInventory(shelfID, []string{"0002"}, false)
+		assert.NoError(t, err)
+		assert.Len(t, report.Found, 1)
+		assert.Empty(t, report.Missing)
+		loans, page, err := store.GetLoans(LoanFilter{Overdue: true}, ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, page.Total)
+		if assert.Len(t, loans, 1) {
+			assert.Equal(t, ids[1], loans[0].BookID)
+		}
+		work, err := store.GetWork(workID)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, work.EditionCount)
+		publishers, _, err := store.GetPublishers(ListOptions{})
+		assert.NoError(t, err)
+		if assert.Len(t, publishers, 1) {
+			assert.Equal(t, 1, publishers[0].BookCount)
+		}
+		tags, _, err := store.GetTags(ListOptions{})
+		assert.NoError(t, err)
+		if assert.Len(t, tags, 1) {
+			assert.Equal(t, 1, tags[0].Books)
+		}
+		fields, _, err := store.GetCustomFields(ListOptions{})
+		assert.NoError(t, err)
+		if assert.Len(t, fields, 1) {
+			assert.Equal(t, 1, fields[0].BookCount)
+		}
+
+		// The book in the trash still keeps its publisher
+		assert.NoError(t, store.DeleteBook(ids[1], 0))
+		assert.ErrorIs(t, store.DeletePublisher(publishers[0].ID), ErrConflict)
+		copies, _, err = store.GetCopies(CopyFilter{}, ListOptions{})
+		assert.NoError(t, err)
+		assert.Empty(t, copies)
+	})
+}
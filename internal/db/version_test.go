@@ -0,0 +1,94 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore_BookVersions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		id, err := store.CreateBook(models.Book{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01"})
+		assert.NoError(t, err)
+		book, err := store.GetBook(id)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, book.Version)
+
+		// Unconditional updates bump the version too
+		book.Version = 0
+		book.Genre = "Science Fiction"
+		assert.NoError(t, store.UpdateBook(book))
+		book, err = store.GetBook(id)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, book.Version)
+
+		// A stale version is rejected and leaves the book alone
+		stale := book
+		stale.Version = 1
+		stale.Genre = "Fantasy"
+		err = store.UpdateBook(stale)
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+		assert.ErrorIs(t, err, ErrConflict)
+		book, err = store.GetBook(id)
+		assert.NoError(t, err)
+		assert.Equal(t, "Science Fiction", book.Genre)
+
+		book.Edition = "First"
+		assert.NoError(t, store.UpdateBook(book))
+
+		err = store.DeleteBook(id, 2)
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+		assert.NoError(t, store.DeleteBook(id, 3))
+
+		// A missing book is not a version mismatch
+		err = store.DeleteBook(id, 3)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestStore_CollectionVersions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		bookID, err := store.CreateBook(models.Book{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01"})
+		assert.NoError(t, err)
+		id, err := store.CreateCollection(models.Collection{Name: "Favourites"})
+		assert.NoError(t, err)
+
+		version := func() int {
+			c, err := store.GetCollection(id)
+			assert.NoError(t, err)
+			return c.Version
+		}
+		assert.Equal(t, 1, version())
+
+		// Membership changes are changes of the collection
+		assert.NoError(t, store.AddBookToCollection(id, bookID))
+		assert.Equal(t, 2, version())
+		assert.NoError(t, store.RemoveBookFromCollection(id, bookID))
+		assert.Equal(t, 3, version())
+
+		err = store.UpdateCollection(models.Collection{ID: id, Name: "Classics", Version: 1})
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+		assert.NoError(t, store.UpdateCollection(models.Collection{ID: id, Name: "Classics", Version: 3}))
+		assert.Equal(t, 4, version())
+
+		collections, _, err := store.GetCollections(ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, 4, collections[0].Version)
+
+		// Collections embed their books, so changing a book changes them too
+		assert.NoError(t, store.AddBookToCollection(id, bookID))
+		assert.Equal(t, 5, version())
+		book, err := store.GetBook(bookID)
+		assert.NoError(t, err)
+		book.Genre = "Science Fiction"
+		assert.NoError(t, store.UpdateBook(book))
+		assert.Equal(t, 6, version())
+		assert.NoError(t, store.TagBooks([]int{bookID}, []string{"classic"}))
+		assert.Equal(t, 7, version())
+
+		err = store.DeleteCollection(id, 6)
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+		assert.NoError(t, store.DeleteCollection(id, 7))
+	})
+}
@@ -0,0 +1,277 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// WorkSortFields are the fields the work list can be ordered by.
+var WorkSortFields = []string{"id", "title"}
+
+// checkWork validates a work about to be written and trims its title.
+func checkWork(w *models.Work) error {
+	w.Title = strings.TrimSpace(w.Title)
+	if w.Title == "" {
+		return fmt.Errorf("%w work, the title is empty", ErrInvalid)
+	}
+	return nil
+}
+
+// splitWork checks that the books are editions of the work and that at
+// least one edition stays with it, and returns the work the books are split
+// into: it takes the title of the first book and the earliest publication
+// date of the books.
+func splitWork(workID int, editions []models.Book, bookIDs []int) (models.Work, error) {
+	byID := map[int]models.Book{}
+	for _, b := range editions {
+		byID[b.ID] = b
+	}
+	var split models.Work
+	moved := map[int]bool{}
+	for _, id := range bookIDs {
+		b, ok := byID[id]
+		if !ok {
+			return models.Work{}, fmt.Errorf("%w split, book %d is not an edition of work %d", ErrInvalid, id, workID)
+		}
+		if split.Title == "" {
+			split.Title = b.Title
+		}
+		if start := publishedStart(b); split.OriginalPublishedDate == "" || start < split.OriginalPublishedDate {
+			split.OriginalPublishedDate = start
+		}
+		moved[id] = true
+	}
+	if len(moved) >= len(editions) {
+		return models.Work{}, fmt.Errorf("%w split, at least one edition has to stay with work %d", ErrInvalid, workID)
+	}
+	return split, nil
+}
+
+// collapseWorks keeps the book with the lowest id of each work, in the
+// order of the books. Books of no work are kept.
+func collapseWorks(books []models.Book) []models.Book {
+	first := map[int]int{}
+	for _, b := range books {
+		if b.WorkID == nil {
+			continue
+		}
+		if id, ok := first[*b.WorkID]; !ok || b.ID < id {
+			first[*b.WorkID] = b.ID
+		}
+	}
+	return slices.DeleteFunc(books, func(b models.Book) bool {
+		return b.WorkID != nil && first[*b.WorkID] != b.ID
+	})
+}
+
+const workColumns = "w.id, w.title, w.original_language, w.original_published_date, " +
+	"(SELECT COUNT(*) FROM books b WHERE b.work_id = w.id AND b.deleted_at IS NULL), w.created_at, w.updated_at"
+
+func scanWork(row scanner) (models.Work, error) {
+	var w models.Work
+	err := row.Scan(&w.ID, &w.Title, &w.OriginalLanguage, &w.OriginalPublishedDate, &w.EditionCount, timestamp{&w.CreatedAt}, timestamp{&w.UpdatedAt})
+	return w, err
+}
+
+// GetWorks returns a page of works, without their editions.
+func (db *DB) GetWorks(opts ListOptions) ([]models.Work, Page, error) {
+	sort, err := opts.sortField(WorkSortFields)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	after, err := opts.cursor(sort)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	var page Page
+	err = db.QueryRow("SELECT COUNT(*) FROM works").Scan(&page.Total)
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	query := "SELECT " + workColumns + " FROM works w"
+	condition, args, order := keyset("w."+sort, "w.id", opts.Desc, after)
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+	query += " ORDER BY " + order
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit+1)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	defer rows.Close()
+
+	var works []models.Work
+	for rows.Next() {
+		w, err := scanWork(rows)
+		if err != nil {
+			return nil, Page{}, err
+		}
+		works = append(works, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, Page{}, err
+	}
+
+	works, page.Next = paginate(works, opts.Limit, func(w models.Work) cursor {
+		if sort == "title" {
+			return cursor{Sort: sort, Desc: opts.Desc, Value: w.Title, ID: w.ID}
+		}
+		return cursor{Sort: sort, Desc: opts.Desc, ID: w.ID}
+	})
+	return works, page, nil
+}
+
+func (db *DB) GetWork(id int) (models.Work, error) {
+	w, err := scanWork(db.QueryRow("SELECT "+workColumns+" FROM works w WHERE w.id = ?", id))
+	if err != nil {
+		return models.Work{}, translateError(err, "work", id)
+	}
+	return w, nil
+}
+
+func (db *DB) CreateWork(w models.Work) (int, error) {
+	if err := checkWork(&w); err != nil {
+		return 0, err
+	}
+	var id int
+	err := db.QueryRow("INSERT INTO works (title, original_language, original_published_date) VALUES (?, ?, ?) RETURNING id",
+		w.Title, w.OriginalLanguage, w.OriginalPublishedDate).Scan(&id)
+	if err != nil {
+		return 0, translateError(err, "work", 0)
+	}
+	return id, nil
+}
+
+// UpdateWork changes the title, original language and original publication
+// date of the work. Its editions only refer to it by id and are left as
+// they are.
+func (db *DB) UpdateWork(w models.Work) error {
+	if err := checkWork(&w); err != nil {
+		return err
+	}
+	res, err := db.Exec("UPDATE works SET title = ?, original_language = ?, original_published_date = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+		w.Title, w.OriginalLanguage, w.OriginalPublishedDate, w.ID)
+	return expectVersion(db, res, err, "work", w.ID, 0)
+}
+
+// DeleteWork deletes the work. Its editions are kept as books of no work.
+func (db *DB) DeleteWork(id int) error {
+	return db.inTx(func(tx *Tx) error {
+		ids, err := queryIDs(tx, "SELECT id FROM books WHERE work_id = ? ORDER BY id", id)
+		if err != nil {
+			return err
+		}
+		return db.changeBooks(tx, ids, func() error {
+			if _, err := tx.Exec("UPDATE books SET work_id = NULL WHERE work_id = ?", id); err != nil {
+				return err
+			}
+			res, err := tx.Exec("DELETE FROM works WHERE id = ?", id)
+			return expectVersion(tx, res, err, "work", id, 0)
+		})
+	})
+}
+
+// MergeEditions makes the books editions of the work, taking them from the
+// works they were editions of. Works left without editions are deleted.
+// Nothing changes if the work or one of the books does not exist.
+func (db *DB) MergeEditions(workID int, bookIDs []int) error {
+	return db.inTx(func(tx *Tx) error {
+		if err := exists(tx, "works", workID); err != nil {
+			return translateError(err, "work", workID)
+		}
+		return db.changeBooks(tx, bookIDs, func() error {
+			left := map[int]bool{}
+			for _, id := range bookIDs {
+				var current *int
+				err := tx.QueryRow("SELECT work_id FROM books WHERE id = ?", id).Scan(&current)
+				if err != nil {
+					return translateError(err, "book", id)
+				}
+				if derefID(current) == workID {
+					continue
+				}
+				if current != nil {
+					left[*current] = true
+				}
+				if _, err := tx.Exec("UPDATE books SET work_id = ? WHERE id = ?", workID, id); err != nil {
+					return err
+				}
+			}
+			for id := range left {
+				_, err := tx.Exec("DELETE FROM works WHERE id = ? AND NOT EXISTS (SELECT 1 FROM books WHERE work_id = ?)", id, id)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// SplitEditions moves editions of the work into a new work and returns its
+// id. At least one edition has to stay with the work.
+func (db *DB) SplitEditions(workID int, bookIDs []int) (int, error) {
+	var id int
+	err := db.inTx(func(tx *Tx) error {
+		if err := exists(tx, "works", workID); err != nil {
+			return translateError(err, "work", workID)
+		}
+		editions, err := workEditions(tx, workID)
+		if err != nil {
+			return err
+		}
+		split, err := splitWork(workID, editions, bookIDs)
+		if err != nil {
+			return err
+		}
+		return db.changeBooks(tx, bookIDs, func() error {
+			err := tx.QueryRow("INSERT INTO works (title, original_language, original_published_date) VALUES (?, ?, ?) RETURNING id",
+				split.Title, split.OriginalLanguage, split.OriginalPublishedDate).Scan(&id)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec("UPDATE books SET work_id = ? WHERE id IN ("+placeholders(len(bookIDs))+")",
+				append([]interface{}{id}, intArgs(bookIDs)...)...)
+			return err
+		})
+	})
+	return id, err
+}
+
+// workEditions returns the id, title and publication date of the editions
+// of the work.
+func workEditions(q querier, workID int) ([]models.Book, error) {
+	rows, err := q.Query("SELECT id, title, published_date FROM books WHERE work_id = ?", workID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var editions []models.Book
+	for rows.Next() {
+		var b models.Book
+		if err := rows.Scan(&b.ID, &b.Title, &b.PublishedDate); err != nil {
+			return nil, err
+		}
+		editions = append(editions, b)
+	}
+	return editions, rows.Err()
+}
+
+func intArgs(ids []int) []interface{} {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return args
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitWork(t *testing.T) {
+	editions := []models.Book{
+		{ID: 1, Title: "Der Process", PublishedDate: "1925-04-26"},
+		{ID: 2, Title: "The Trial", PublishedDate: "1937-01-01"},
+		{ID: 3, Title: "The Trial", PublishedDate: "1935-06-01"},
+	}
+	split, err := splitWork(7, editions, []int{2, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, models.Work{Title: "The Trial", OriginalPublishedDate: "1935-06-01"}, split)
+
+	_, err = splitWork(7, editions, []int{2, 4})
+	assert.ErrorIs(t, err, ErrInvalid)
+	_, err = splitWork(7, editions, []int{1, 2, 3, 3})
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestCollapseWorks(t *testing.T) {
+	one, two := 1, 2
+	books := []models.Book{{ID: 5, WorkID: &one}, {ID: 2, WorkID: &one}, {ID: 3}, {ID: 4, WorkID: &two}, {ID: 6}}
+	var ids []int
+	for _, b := range collapseWorks(books) {
+		ids = append(ids, b.ID)
+	}
+	assert.Equal(t, []int{2, 3, 4, 6}, ids)
+}
+
+func TestStore_Works(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		var bookIDs []int
+		for _, b := range []models.Book{
+			{Title: "Der Process", Author: "Franz Kafka", PublishedDate: "1925-04-26", Genre: "novel"},
+			{Title: "The Trial", Author: "Franz Kafka", PublishedDate: "1937-01-01", Genre: "novel"},
+			{Title: "Le Procès", Author: "Franz Kafka", PublishedDate: "1933-01-01", Genre: "novel"},
+			{Title: "Dune", Author: "Frank Herbert", PublishedDate: "1965-08-01", Genre: "science fiction"},
+		} {
+			id, err := store.CreateBook(b)
+			assert.NoError(t, err)
+			bookIDs = append(bookIDs, id)
+		}
+
+		_, err := store.CreateWork(models.Work{Title: " "})
+		assert.ErrorIs(t, err, ErrInvalid)
+		trialID, err := store.CreateWork(models.Work{Title: " The Trial ", OriginalLanguage: "de", OriginalPublishedDate: "1925-04-26"})
+		assert.NoError(t, err)
+		otherID, err := store.CreateWork(models.Work{Title: "Le Procès"})
+		assert.NoError(t, err)
+
+		assert.NoError(t, store.MergeEditions(otherID, []int{bookIDs[2]}))
+		assert.NoError(t, store.MergeEditions(trialID, bookIDs[:3]))
+		assert.ErrorIs(t, store.MergeEditions(trialID, []int{bookIDs[3], 42}), ErrNotFound)
+		assert.ErrorIs(t, store.MergeEditions(42, bookIDs[:1]), ErrNotFound)
+
+		// The work the French edition left has no editions and is gone
+		_, err = store.GetWork(otherID)
+		assert.ErrorIs(t, err, ErrNotFound)
+		work, err := store.GetWork(trialID)
+		assert.NoError(t, err)
+		assert.Equal(t, "The Trial", work.Title)
+		assert.Equal(t, 3, work.EditionCount)
+		book, err := store.GetBook(bookIDs[1])
+		assert.NoError(t, err)
+		assert.Equal(t, trialID, *book.WorkID)
+		assert.Equal(t, 2, book.Version)
+		book, err = store.GetBook(bookIDs[3])
+		assert.NoError(t, err)
+		assert.Nil(t, book.WorkID)
+
+		// Updating a book keeps its work
+		book, err = store.GetBook(bookIDs[0])
+		assert.NoError(t, err)
+		book.WorkID = nil
+		book.Version = 0
+		assert.NoError(t, store.UpdateBook(book))
+		book, err = store.GetBook(bookIDs[0])
+		assert.NoError(t, err)
+		assert.Equal(t, trialID, *book.WorkID)
+
+		ids := func(filter BookFilter) []int {
+			books, page, err := store.GetBooks(filter, ListOptions{Sort: "title"})
+			assert.NoError(t, err)
+			assert.Equal(t, len(books), page.Total)
+			var ids []int
+			for _, b := range books {
+				ids = append(ids, b.ID)
+			}
+			return ids
+		}
+		assert.Equal(t, []int{bookIDs[0], bookIDs[3]}, ids(BookFilter{CollapseWorks: true}))
+		assert.Equal(t, []int{bookIDs[1]}, ids(BookFilter{CollapseWorks: true, From: "1930-01-01", Genre: "novel"}))
+		assert.Equal(t, []int{bookIDs[0], bookIDs[2], bookIDs[1]}, ids(BookFilter{WorkID: trialID}))
+
+		_, err = store.SplitEditions(trialID, bookIDs[:3])
+		assert.ErrorIs(t, err, ErrInvalid)
+		_, err = store.SplitEditions(trialID, []int{bookIDs[3]})
+		assert.ErrorIs(t, err, ErrInvalid)
+		splitID, err := store.SplitEditions(trialID, []int{bookIDs[2]})
+		assert.NoError(t, err)
+		work, err = store.GetWork(splitID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Le Procès", work.Title)
+		assert.Equal(t, "1933-01-01", work.OriginalPublishedDate)
+		assert.Equal(t, 1, work.EditionCount)
+
+		works, page, err := store.GetWorks(ListOptions{Sort: "title", Limit: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, page.Total)
+		assert.Equal(t, splitID, works[0].ID)
+
+		work, err = store.GetWork(trialID)
+		assert.NoError(t, err)
+		work.Title = "Der Process"
+		assert.NoError(t, store.UpdateWork(work))
+		assert.ErrorIs(t, store.UpdateWork(models.Work{ID: 42, Title: "Amerika"}), ErrNotFound)
+
+		assert.NoError(t, store.DeleteWork(trialID))
+		book, err = store.GetBook(bookIDs[1])
+		assert.NoError(t, err)
+		assert.Nil(t, book.WorkID)
+		assert.ErrorIs(t, store.DeleteWork(trialID), ErrNotFound)
+	})
+}
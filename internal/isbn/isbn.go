@@ -0,0 +1,135 @@
+// Package isbn validates International Standard Book Numbers and converts
+// between their 10 and 13 digit forms.
+package isbn
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalid  = errors.New("not a valid ISBN")
+	ErrChecksum = errors.New("ISBN check digit does not match")
+
+	// ErrNoISBN10 is returned when converting ISBN-13s outside of the 978
+	// prefix, which have no 10 digit form.
+	ErrNoISBN10 = errors.New("ISBN-13 has no ISBN-10 form")
+)
+
+// Normalize strips the hyphens and spaces that ISBNs are often printed with
+// and upper-cases the X check digit of an ISBN-10. It does not validate.
+func Normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '-', ' ':
+			return -1
+		case 'x':
+			return 'X'
+		}
+		return r
+	}, strings.TrimSpace(s))
+}
+
+// Parse accepts an ISBN-10 or ISBN-13, with or without hyphens, and returns
+// it in both forms. isbn10 is empty for ISBN-13s that have no 10 digit form.
+func Parse(s string) (isbn10, isbn13 string, err error) {
+	s = Normalize(s)
+	switch len(s) {
+	case 10:
+		if err := Validate10(s); err != nil {
+			return "", "", err
+		}
+		return s, To13(s), nil
+	case 13:
+		if err := Validate13(s); err != nil {
+			return "", "", err
+		}
+		isbn10, err := To10(s)
+		if errors.Is(err, ErrNoISBN10) {
+			return "", s, nil
+		}
+		return isbn10, s, err
+	}
+	return "", "", ErrInvalid
+}
+
+// Validate10 checks a normalized ISBN-10: nine digits followed by a digit or
+// X as check digit, with a weighted sum divisible by 11.
+func Validate10(s string) error {
+	if len(s) != 10 {
+		return ErrInvalid
+	}
+	sum := 0
+	for i := 0; i < 10; i++ {
+		var digit int
+		switch c := s[i]; {
+		case c >= '0' && c <= '9':
+			digit = int(c - '0')
+		case c == 'X' && i == 9:
+			digit = 10
+		default:
+			return ErrInvalid
+		}
+		sum += (10 - i) * digit
+	}
+	if sum%11 != 0 {
+		return ErrChecksum
+	}
+	return nil
+}
+
+// Validate13 checks a normalized ISBN-13: thirteen digits starting with 978
+// or 979 whose alternately weighted sum is divisible by 10.
+func Validate13(s string) error {
+	if len(s) != 13 || !isDigits(s) || !(strings.HasPrefix(s, "978") || strings.HasPrefix(s, "979")) {
+		return ErrInvalid
+	}
+	if checkDigit13(s[:12]) != s[12] {
+		return ErrChecksum
+	}
+	return nil
+}
+
+// To13 converts a valid ISBN-10 to its ISBN-13 form.
+func To13(isbn10 string) string {
+	body := "978" + isbn10[:9]
+	return body + string(checkDigit13(body))
+}
+
+// To10 converts a valid ISBN-13 to its ISBN-10 form.
+func To10(isbn13 string) (string, error) {
+	if !strings.HasPrefix(isbn13, "978") {
+		return "", ErrNoISBN10
+	}
+	body := isbn13[3:12]
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += (10 - i) * int(body[i]-'0')
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return body + "X", nil
+	}
+	return body + string(rune('0'+check)), nil
+}
+
+func checkDigit13(body string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(body[i]-'0')
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
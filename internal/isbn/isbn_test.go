@@ -0,0 +1,48 @@
+package isbn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input          string
+		isbn10, isbn13 string
+		err            error
+	}{
+		{"0-13-468599-7", "0134685997", "9780134685991", nil},
+		{"978-0-13-468599-1", "0134685997", "9780134685991", nil},
+		{" 9780134685991 ", "0134685997", "9780134685991", nil},
+		{"0-8044-2957-x", "080442957X", "9780804429573", nil},
+		{"978 0 8044 2957 3", "080442957X", "9780804429573", nil},
+		{"979-10-90636-07-1", "", "9791090636071", nil},
+		{"0-13-468599-8", "", "", ErrChecksum},
+		{"978-0-13-468599-2", "", "", ErrChecksum},
+		{"X134685997", "", "", ErrInvalid},
+		{"9770134685991", "", "", ErrInvalid},
+		{"12345", "", "", ErrInvalid},
+		{"", "", "", ErrInvalid},
+	}
+	for _, tt := range tests {
+		isbn10, isbn13, err := Parse(tt.input)
+		if tt.err != nil {
+			assert.ErrorIs(t, err, tt.err, tt.input)
+			continue
+		}
+		assert.NoError(t, err, tt.input)
+		assert.Equal(t, tt.isbn10, isbn10, tt.input)
+		assert.Equal(t, tt.isbn13, isbn13, tt.input)
+	}
+}
+
+func TestConversion(t *testing.T) {
+	assert.Equal(t, "9780306406157", To13("0306406152"))
+	isbn10, err := To10("9780306406157")
+	assert.NoError(t, err)
+	assert.Equal(t, "0306406152", isbn10)
+
+	_, err = To10("9791090636071")
+	assert.ErrorIs(t, err, ErrNoISBN10)
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Kinds of records in the audit log. A membership is a book in a
+// collection.
+const (
+	AuditBook       = "book"
+	AuditCollection = "collection"
+	AuditMembership = "membership"
+)
+
+// Actions recorded in the audit log. Adding a book to a collection creates a
+// membership and removing it deletes the membership.
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+)
+
+var (
+	AuditEntities = []string{AuditBook, AuditCollection, AuditMembership}
+	AuditActions  = []string{AuditCreate, AuditUpdate, AuditDelete, AuditRestore, AuditPurge}
+)
+
+// AuditEntry records a change to a book, a collection or a membership: who
+// made it, when and what it did to which fields. BookID and CollectionID
+// name the records involved, both are set for memberships.
+type AuditEntry struct {
+	ID           int           `json:"id"`
+	At           time.Time     `json:"at"`
+	Actor        string        `json:"actor"`
+	Action       string        `json:"action"`
+	Entity       string        `json:"entity"`
+	BookID       int           `json:"book_id,omitempty"`
+	CollectionID int           `json:"collection_id,omitempty"`
+	Changes      []FieldChange `json:"changes"`
+}
+
+// FieldChange is the value of a field before and after a change, as it
+// appears in the JSON of the record. A field that was or became empty is
+// null on that side.
+type FieldChange struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
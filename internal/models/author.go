@@ -0,0 +1,19 @@
+package models
+
+// Author is a person credited on books, in one of the AuthorRoles.
+type Author struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// AuthorRoles are the ways a person can be credited on a book.
+var AuthorRoles = []string{"author", "editor", "translator", "illustrator"}
+
+// BookAuthor credits an author on a book. Books list their authors in
+// credit order. When writing a book, an author is given either by ID or by
+// name, unknown names create new authors, and the role defaults to "author".
+type BookAuthor struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
@@ -5,13 +5,52 @@ import (
 )
 
 type Book struct {
-	ID            int       `json:"id"`
-	Title         string    `json:"title"`
-	Author        string    `json:"author"`
-	PublishedDate string    `json:"published_date"`
-	Edition       string    `json:"edition"`
-	Description   string    `json:"description"`
-	Genre         string    `json:"genre"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID                 int                    `json:"id"`
+	Title              string                 `json:"title"`
+	Author             string                 `json:"author"` // names of the authors, derived from Authors
+	Authors            []BookAuthor           `json:"authors"`
+	PublishedDate      string                 `json:"published_date"`      // YYYY-MM-DD, YYYY-MM, YYYY or c. YYYY
+	PublishedPrecision string                 `json:"published_precision"` // day, month, year or circa, derived from PublishedDate
+	Edition            string                 `json:"edition"`
+	Description        string                 `json:"description"`
+	Genre              string                 `json:"genre"`
+	Publisher          string                 `json:"publisher"` // name of the publisher, unknown names create new publishers
+	Language           string                 `json:"language"`  // BCP 47 language tag, e.g. "en-GB"
+	PageCount          int                    `json:"page_count"`
+	Format             string                 `json:"format"` // one of BookFormats, empty when unknown
+	Dimensions         Dimensions             `json:"dimensions"`
+	Tags               []string               `json:"tags"`
+	Custom             map[string]interface{} `json:"custom"`         // values of the custom fields by name, see CustomField
+	Cover              *Cover                 `json:"cover"`          // nil without a cover, ignored when writing a book
+	Series             []BookSeries           `json:"series"`         // managed through the series, ignored when writing a book
+	WorkID             *int                   `json:"work_id"`        // managed through the works, ignored when writing a book
+	ReadingStatus      string                 `json:"reading_status"` // of the latest reading session, ignored when writing a book
+	AverageRating      float64                `json:"average_rating"` // of the reviews, 0 without any, ignored when writing a book
+	RatingCount        int                    `json:"rating_count"`
+	ISBN10             string                 `json:"isbn_10"`
+	ISBN13             string                 `json:"isbn_13"`
+	CreatedAt          time.Time              `json:"created_at"`
+	UpdatedAt          time.Time              `json:"updated_at"`
+	Version            int                    `json:"version"`
+}
+
+// BookFormats are the accepted values of Book.Format, the same as those of
+// copies.
+var BookFormats = CopyFormats
+
+// Dimensions are the height, width and thickness of a book in millimetres,
+// 0 when unknown.
+type Dimensions struct {
+	Height    float64 `json:"height"`
+	Width     float64 `json:"width"`
+	Thickness float64 `json:"thickness"`
+}
+
+// BookSearchResult is a book matching a full-text search, together with its
+// relevance score and a snippet of the matching text. Matched terms in the
+// snippet are wrapped in <mark> tags.
+type BookSearchResult struct {
+	Book    Book    `json:"book"`
+	Score   float64 `json:"score"`
+	Snippet string  `json:"snippet"`
 }
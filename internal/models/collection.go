@@ -1,7 +1,8 @@
 package models
 
 type Collection struct {
-	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	Books []Book `json:"books"`
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Books   []Book `json:"books"`
+	Version int    `json:"version"`
 }
@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// LocationKinds are the kinds of locations from the outermost to the
+// innermost. A location only contains locations of the kinds after its own.
+var LocationKinds = []string{"room", "bookcase", "shelf"}
+
+// Location is a place books are kept in, such as a shelf in a bookcase in a
+// room. Path names the location with its parents, like
+// "Study > Bookcase 1 > Shelf 2", and is set by the server.
+type Location struct {
+	ID        int       `json:"id"`
+	ParentID  *int      `json:"parent_id"`
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"`
+	Path      string    `json:"path"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CopyFormats and CopyConditions are the accepted values of Copy.Format and
+// Copy.Condition, the condition may also be left empty.
+var (
+	CopyFormats    = []string{"hardcover", "paperback", "ebook", "audiobook", "other"}
+	CopyConditions = []string{"new", "fine", "good", "fair", "poor"}
+)
+
+// Copy is a copy of a book that is owned, physical or digital. Title and
+// Location are the title of the book and the path of the location, they are
+// set by the server.
+type Copy struct {
+	ID         int       `json:"id"`
+	BookID     int       `json:"book_id"`
+	Title      string    `json:"title"`
+	Format     string    `json:"format"`
+	Condition  string    `json:"condition"`
+	AcquiredOn string    `json:"acquired_on"` // YYYY-MM-DD, empty when unknown
+	Price      *float64  `json:"price"`
+	Barcode    string    `json:"barcode"`
+	LocationID *int      `json:"location_id"`
+	Location   string    `json:"location"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// MoveCopiesRequest moves several copies to a location at once, or takes
+// them out of any location if LocationID is null.
+type MoveCopiesRequest struct {
+	CopyIDs    []int `json:"copy_ids"`
+	LocationID *int  `json:"location_id"`
+}
+
+// InventoryRequest lists the barcodes found at a location. With Apply the
+// copies found there that were recorded elsewhere are moved to it.
+type InventoryRequest struct {
+	Barcodes []string `json:"barcodes"`
+	Apply    bool     `json:"apply"`
+}
+
+// InventoryReport compares the copies found at a location, including the
+// locations inside it, with those recorded there. Found copies were
+// expected, Missing ones were recorded there but not found, Misplaced ones
+// were found but recorded elsewhere, and Unknown lists barcodes of no copy.
+type InventoryReport struct {
+	LocationID int      `json:"location_id"`
+	Found      []Copy   `json:"found"`
+	Missing    []Copy   `json:"missing"`
+	Misplaced  []Copy   `json:"misplaced"`
+	Unknown    []string `json:"unknown"`
+	Applied    bool     `json:"applied"` // whether misplaced copies were moved here
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// CoverSizes are the widths in pixels of the thumbnails made of every cover,
+// by size name. Covers narrower than a size keep their width.
+var CoverSizes = map[string]int{
+	"small":  120,
+	"medium": 300,
+	"large":  600,
+}
+
+// coverPath is where the API serves the cover of a book, and its thumbnails
+// below it by size name.
+const coverPath = "/api/v1/books/%d/cover"
+
+// Cover describes the cover image of a book. The image and its thumbnails
+// are kept in a blob store, the book only records what was uploaded.
+type Cover struct {
+	ContentType string            `json:"content_type"` // image/jpeg, image/png or image/webp
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
+	Size        int               `json:"size"`   // in bytes
+	Digest      string            `json:"digest"` // hex encoded SHA-256 of the image
+	URL         string            `json:"url"`
+	Thumbnails  map[string]string `json:"thumbnails"` // URLs of the JPEG thumbnails by size name
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// SetURLs fills in the URLs of the cover of a book and of its thumbnails.
+// They name the digest, so a new cover gets new URLs and clients may cache
+// the image behind a URL for good.
+func (c *Cover) SetURLs(bookID int) {
+	path := fmt.Sprintf(coverPath, bookID)
+	version := c.Digest
+	if len(version) > 16 {
+		version = version[:16]
+	}
+	c.URL = path + "?v=" + version
+	c.Thumbnails = map[string]string{}
+	for size := range CoverSizes {
+		c.Thumbnails[size] = path + "/" + size + "?v=" + version
+	}
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// CustomField defines a field that books can have beyond the built-in ones.
+// Its values are listed under Book.Custom by name. Choices lists the values
+// of an enum field in order; Pattern is a regular expression that the whole
+// value of a string field must match, if set. BookCount is set by the
+// server.
+type CustomField struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Choices   []string  `json:"choices"`
+	Pattern   string    `json:"pattern"`
+	BookCount int       `json:"book_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CustomFieldTypes are the accepted values of CustomField.Type.
+var CustomFieldTypes = []string{"string", "int", "date", "bool", "enum"}
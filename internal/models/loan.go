@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Loan is a physical copy of a book lent to someone. Dates are YYYY-MM-DD,
+// ReturnedAt is empty while the book is out. Overdue is set by the server
+// for loans past their due date that have not been returned.
+type Loan struct {
+	ID         int       `json:"id"`
+	BookID     int       `json:"book_id"`
+	Borrower   string    `json:"borrower"`
+	Contact    string    `json:"contact"`
+	LoanedAt   string    `json:"loaned_at"`
+	DueAt      string    `json:"due_at"`
+	ReturnedAt string    `json:"returned_at"`
+	Overdue    bool      `json:"overdue"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
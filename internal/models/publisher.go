@@ -0,0 +1,9 @@
+package models
+
+// Publisher publishes books. Publishers are created by naming them on a
+// book, BookCount is set by the server.
+type Publisher struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	BookCount int    `json:"book_count"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// ReadingStatuses are the states of a reading session. The reading status of
+// a book is the status of its latest session.
+var ReadingStatuses = []string{"want-to-read", "reading", "finished", "abandoned"}
+
+// ReadingSession is one read of a book, from putting it on the list or
+// starting it to finishing or abandoning it. Re-reads are separate sessions.
+// Dates are in the format YYYY-MM-DD and empty when unknown.
+type ReadingSession struct {
+	ID         int    `json:"id"`
+	BookID     int    `json:"book_id"`
+	Status     string `json:"status"`
+	StartedOn  string `json:"started_on"`
+	FinishedOn string `json:"finished_on"`
+	// Progress is recorded through its own endpoint and ignored when writing
+	// a session.
+	Progress  []ReadingProgress `json:"progress"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// ReadingProgress records how far a session got, as a page, a percentage or
+// both. RecordedAt is set by the server.
+type ReadingProgress struct {
+	ID         int       `json:"id"`
+	Page       *int      `json:"page"`
+	Percent    *float64  `json:"percent"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// ReadingLog is the reading history of a book, oldest session first. Status
+// is that of the latest session, empty if the book was never read.
+type ReadingLog struct {
+	BookID   int              `json:"book_id"`
+	Status   string           `json:"status"`
+	Sessions []ReadingSession `json:"sessions"`
+}
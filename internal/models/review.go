@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Review is a reviewer's rating of a book, from 1 to 5 in half stars, with
+// an optional review in markdown. Every reviewer reviews a book at most
+// once. History is only set when a single review is retrieved.
+type Review struct {
+	ID        int              `json:"id"`
+	BookID    int              `json:"book_id"`
+	Reviewer  string           `json:"reviewer"`
+	Rating    float64          `json:"rating"`
+	Body      string           `json:"body"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	History   []ReviewRevision `json:"history"` // earlier versions, oldest first
+}
+
+// ReviewRevision is an earlier version of a review, as it was written at
+// WrittenAt.
+type ReviewRevision struct {
+	Rating    float64   `json:"rating"`
+	Body      string    `json:"body"`
+	WrittenAt time.Time `json:"written_at"`
+}
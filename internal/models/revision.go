@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// BookRevision is a book as a change left it, numbered from 1 for every
+// book. Book only holds the fields that are written with a book, the rest,
+// such as its cover, series and rating, are not part of a revision.
+type BookRevision struct {
+	Revision int       `json:"revision"`
+	At       time.Time `json:"at"`
+	Actor    string    `json:"actor"`
+	Book     Book      `json:"book"`
+}
+
+// CollectionRevision is the name and the books of a collection as a change
+// left them, numbered from 1 for every collection. BookIDs are in ascending
+// order.
+type CollectionRevision struct {
+	Revision int       `json:"revision"`
+	At       time.Time `json:"at"`
+	Actor    string    `json:"actor"`
+	Name     string    `json:"name"`
+	BookIDs  []int     `json:"book_ids"`
+}
+
+// RevisionDiff lists the fields that differ between two revisions, like the
+// changes of an audit entry. A revision of 0 stands for the current state.
+type RevisionDiff struct {
+	From    int           `json:"from"`
+	To      int           `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
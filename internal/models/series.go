@@ -0,0 +1,28 @@
+package models
+
+// Series orders books, such as the volumes of a multi-volume work. Books and
+// Missing are only set when a single series is retrieved.
+type Series struct {
+	ID          int          `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Books       []SeriesBook `json:"books"`
+	// Missing lists the whole positions up to the last book that no book
+	// occupies, set by the server.
+	Missing []int `json:"missing"`
+}
+
+// SeriesBook is a book at its position in a series. Positions may be
+// fractional, e.g. 2.5 for a novella between the second and third book.
+type SeriesBook struct {
+	Position float64 `json:"position"`
+	Book     Book    `json:"book"`
+}
+
+// BookSeries is the summary of a series a book belongs to, as embedded in
+// the book.
+type BookSeries struct {
+	ID       int     `json:"id"`
+	Name     string  `json:"name"`
+	Position float64 `json:"position"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// Tag classifies books. A book can have any number of tags, which are
+// listed by name in Book.Tags.
+type Tag struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Books int    `json:"books"` // number of books with the tag, set by the server
+}
+
+// BulkTagRequest adds tags to or removes them from several books at once.
+type BulkTagRequest struct {
+	BookIDs []int    `json:"book_ids"`
+	Tags    []string `json:"tags"`
+}
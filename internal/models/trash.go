@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Kinds of items in the trash.
+const (
+	TrashBook       = "book"
+	TrashCollection = "collection"
+)
+
+// TrashItem is a deleted book or collection, which can be restored until it
+// is purged. Name is the title of a book or the name of a collection, and
+// Memberships counts the collections a book is in or the books in a
+// collection, which come back with it. Book holds the deleted book.
+type TrashItem struct {
+	Type        string    `json:"type"`
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Memberships int       `json:"memberships"`
+	DeletedAt   time.Time `json:"deleted_at"`
+	Book        *Book     `json:"book,omitempty"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Work is a text as its author wrote it, of which books are the editions
+// and translations. The original language and publication date are those
+// of the first edition, the date is YYYY-MM-DD. EditionCount is set by the
+// server.
+type Work struct {
+	ID                    int       `json:"id"`
+	Title                 string    `json:"title"`
+	OriginalLanguage      string    `json:"original_language"`
+	OriginalPublishedDate string    `json:"original_published_date"`
+	EditionCount          int       `json:"edition_count"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+}
+
+// EditionsRequest lists the books to merge into a work or split off it.
+type EditionsRequest struct {
+	BookIDs []int `json:"book_ids"`
+}
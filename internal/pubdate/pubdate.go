@@ -0,0 +1,135 @@
+// Package pubdate parses publication dates that are known to the day, the
+// month or the year, or only approximately, such as "c. 1850".
+package pubdate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Precisions of a date.
+const (
+	Day   = "day"
+	Month = "month"
+	Year  = "year"
+	Circa = "circa" // about the year, within CircaYears of it
+)
+
+// Precisions lists the precisions from the most to the least precise.
+var Precisions = []string{Day, Month, Year, Circa}
+
+// CircaYears is how many years an approximate date may be off either way.
+const CircaYears = 5
+
+const layout = "2006-01-02"
+
+var ErrInvalid = errors.New("not a date as YYYY-MM-DD, YYYY-MM, YYYY or c. YYYY")
+
+// Approximate years may be written "c. 1850", "c1850", "ca. 1850" or
+// "circa 1850".
+var pattern = regexp.MustCompile(`^(?i:(c|ca|circa)\.?\s*)?(\d{4})(?:-(\d{2})(?:-(\d{2}))?)?$`)
+
+// Date is a publication date together with the days it may denote. All days
+// are formatted as YYYY-MM-DD, so they sort as strings.
+type Date struct {
+	Precision string
+	Start     string // first day of the date as written, which it sorts by
+	Earliest  string // first day it may denote, before Start for Circa
+	Latest    string // last day it may denote
+}
+
+// Parse reads a date written as YYYY-MM-DD, YYYY-MM, YYYY or, for an
+// approximate year, c. YYYY. Leading and trailing spaces are ignored.
+func Parse(s string) (Date, error) {
+	match := pattern.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil {
+		return Date{}, ErrInvalid
+	}
+	circa, year, month, day := match[1] != "", match[2], match[3], match[4]
+	if circa && month != "" {
+		return Date{}, fmt.Errorf("%w, only years can be approximate", ErrInvalid)
+	}
+	if year == "0000" {
+		return Date{}, fmt.Errorf("%w, there is no year 0", ErrInvalid)
+	}
+
+	var d Date
+	switch {
+	case day != "":
+		start, err := time.Parse(layout, year+"-"+month+"-"+day)
+		if err != nil {
+			return Date{}, ErrInvalid
+		}
+		d = Date{Precision: Day, Start: start.Format(layout), Latest: start.Format(layout)}
+	case month != "":
+		start, err := time.Parse(layout, year+"-"+month+"-01")
+		if err != nil {
+			return Date{}, ErrInvalid
+		}
+		d = Date{Precision: Month, Start: start.Format(layout), Latest: start.AddDate(0, 1, -1).Format(layout)}
+	default:
+		d = Date{Precision: Year, Start: year + "-01-01", Latest: year + "-12-31"}
+	}
+	d.Earliest = d.Start
+	if circa {
+		n, _ := strconv.Atoi(year)
+		d.Precision = Circa
+		d.Earliest = fmt.Sprintf("%04d-01-01", max(n-CircaYears, 1))
+		d.Latest = fmt.Sprintf("%04d-12-31", min(n+CircaYears, 9999))
+	}
+	return d, nil
+}
+
+// Format writes the date starting on start, a YYYY-MM-DD, with the given
+// precision: "1850-03-14", "1850-03", "1850" or "c. 1850". Parse reads it
+// back.
+func Format(start, precision string) string {
+	if len(start) != len(layout) {
+		return start
+	}
+	switch precision {
+	case Month:
+		return start[:7]
+	case Year:
+		return start[:4]
+	case Circa:
+		return "c. " + start[:4]
+	}
+	return start
+}
+
+// String writes the date like Format.
+func (d Date) String() string {
+	return Format(d.Start, d.Precision)
+}
+
+// MinStart is the first day a date of the given precision may start on and
+// still denote day or a later one. For day 1850-06-15 this is 1850-06-01 for
+// months and 1845-01-01 for approximate years.
+func MinStart(day, precision string) string {
+	switch precision {
+	case Month:
+		return day[:7] + "-01"
+	case Year:
+		return day[:4] + "-01-01"
+	case Circa:
+		year, _ := strconv.Atoi(day[:4])
+		return fmt.Sprintf("%04d-01-01", max(year-CircaYears, 1))
+	}
+	return day
+}
+
+// MaxStart is the last day a date of the given precision may start on and
+// still denote day or an earlier one. Only approximate years may start after
+// day, on the first of the year up to CircaYears later.
+func MaxStart(day, precision string) string {
+	if precision == Circa {
+		year, _ := strconv.Atoi(day[:4])
+		return fmt.Sprintf("%04d-01-01", min(year+CircaYears, 9999))
+	}
+	return day
+}
@@ -0,0 +1,62 @@
+package pubdate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Date
+		text  string
+	}{
+		{"1965-08-01", Date{Day, "1965-08-01", "1965-08-01", "1965-08-01"}, "1965-08-01"},
+		{" 1850-02 ", Date{Month, "1850-02-01", "1850-02-01", "1850-02-28"}, "1850-02"},
+		{"2024-02", Date{Month, "2024-02-01", "2024-02-01", "2024-02-29"}, "2024-02"},
+		{"1850", Date{Year, "1850-01-01", "1850-01-01", "1850-12-31"}, "1850"},
+		{"c. 1850", Date{Circa, "1850-01-01", "1845-01-01", "1855-12-31"}, "c. 1850"},
+		{"C.1850", Date{Circa, "1850-01-01", "1845-01-01", "1855-12-31"}, "c. 1850"},
+		{"ca. 1850", Date{Circa, "1850-01-01", "1845-01-01", "1855-12-31"}, "c. 1850"},
+		{"circa 1850", Date{Circa, "1850-01-01", "1845-01-01", "1855-12-31"}, "c. 1850"},
+		{"c. 0003", Date{Circa, "0003-01-01", "0001-01-01", "0008-12-31"}, "c. 0003"},
+	}
+	for _, tt := range tests {
+		d, err := Parse(tt.input)
+		assert.NoError(t, err, tt.input)
+		assert.Equal(t, tt.want, d, tt.input)
+		assert.Equal(t, tt.text, d.String(), tt.input)
+		again, err := Parse(d.String())
+		assert.NoError(t, err, tt.input)
+		assert.Equal(t, d, again, tt.input)
+	}
+
+	for _, input := range []string{
+		"", "yesterday", "01/02/2022", "850", "1850-2", "1850-13", "1850-02-30", "0000",
+		"c. 1850-02", "c. 1850-02-03", "about 1850", "1850s", "2022-01-01T00:00:00Z",
+	} {
+		_, err := Parse(input)
+		assert.ErrorIs(t, err, ErrInvalid, input)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "1850-03-14", Format("1850-03-14", Day))
+	assert.Equal(t, "1850-03", Format("1850-03-01", Month))
+	assert.Equal(t, "1850", Format("1850-01-01", Year))
+	assert.Equal(t, "c. 1850", Format("1850-01-01", Circa))
+	assert.Equal(t, "1850-03-14", Format("1850-03-14", ""))
+}
+
+func TestStartBounds(t *testing.T) {
+	assert.Equal(t, "1850-06-15", MinStart("1850-06-15", Day))
+	assert.Equal(t, "1850-06-01", MinStart("1850-06-15", Month))
+	assert.Equal(t, "1850-01-01", MinStart("1850-06-15", Year))
+	assert.Equal(t, "1845-01-01", MinStart("1850-06-15", Circa))
+	assert.Equal(t, "0001-01-01", MinStart("0003-06-15", Circa))
+
+	assert.Equal(t, "1850-06-15", MaxStart("1850-06-15", Day))
+	assert.Equal(t, "1850-06-15", MaxStart("1850-06-15", Year))
+	assert.Equal(t, "1855-01-01", MaxStart("1850-06-15", Circa))
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// actorTransport names the Actor of the client in the X-Actor header of
+// every request.
+type actorTransport struct {
+	client *Client
+}
+
+func (t actorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.client.Actor != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("X-Actor", t.client.Actor)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// AuditListOptions filters an audit log listing. Since and Until take dates
+// or RFC 3339 times.
+type AuditListOptions struct {
+	BookID       int
+	CollectionID int
+	Entity       string // book, collection or membership
+	Action       string // create, update, delete, restore or purge
+	Actor        string
+	Since        string
+	Until        string
+	Newest       bool // newest first
+}
+
+func (o AuditListOptions) query() url.Values {
+	query := url.Values{}
+	if o.BookID != 0 {
+		query.Set("book_id", strconv.Itoa(o.BookID))
+	}
+	if o.CollectionID != 0 {
+		query.Set("collection_id", strconv.Itoa(o.CollectionID))
+	}
+	for name, value := range map[string]string{"entity": o.Entity, "action": o.Action, "actor": o.Actor, "since": o.Since, "until": o.Until} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	if o.Newest {
+		query.Set("order", "desc")
+	}
+	return query
+}
+
+// GetAudit returns the audit log entries matching the options, following
+// the pages of the listing.
+func (c *Client) GetAudit(opts AuditListOptions) ([]models.AuditEntry, error) {
+	return c.getAudit("/api/v1/audit", opts.query())
+}
+
+// GetBookHistory returns the changes to a book and its memberships, oldest
+// first.
+func (c *Client) GetBookHistory(bookID int, opts AuditListOptions) ([]models.AuditEntry, error) {
+	opts.BookID = 0
+	return c.getAudit(fmt.Sprintf("/api/v1/books/%d/history", bookID), opts.query())
+}
+
+func (c *Client) getAudit(path string, query url.Values) ([]models.AuditEntry, error) {
+	var entries []models.AuditEntry
+	for {
+		var page []models.AuditEntry
+		_, next, err := c.getPage(path, query, &page)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, page...)
+		if next == "" {
+			return entries, nil
+		}
+		query.Set("after", next)
+	}
+}
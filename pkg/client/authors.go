@@ -0,0 +1,110 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// GetAuthors returns all authors ordered by name, following the pages of the
+// listing.
+func (c *Client) GetAuthors() ([]models.Author, error) {
+	var authors []models.Author
+	query := url.Values{"sort": {"name"}}
+	for {
+		var page []models.Author
+		_, next, err := c.getPage("/api/v1/authors", query, &page)
+		if err != nil {
+			return nil, err
+		}
+		authors = append(authors, page...)
+		if next == "" {
+			return authors, nil
+		}
+		query.Set("after", next)
+	}
+}
+
+func (c *Client) GetAuthor(id int) (models.Author, error) {
+	resp, err := c.HttpClient.Get(fmt.Sprintf("%s/api/v1/authors/%d", c.BaseURL, id))
+	if err != nil {
+		return models.Author{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "get author"); err != nil {
+		return models.Author{}, err
+	}
+
+	var author models.Author
+	err = json.NewDecoder(resp.Body).Decode(&author)
+	return author, err
+}
+
+// GetAuthorBooks returns all books crediting the author, in any role.
+func (c *Client) GetAuthorBooks(id int) ([]models.Book, error) {
+	var books []models.Book
+	query := url.Values{}
+	for {
+		var page []models.Book
+		_, next, err := c.getPage(fmt.Sprintf("/api/v1/authors/%d/books", id), query, &page)
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, page...)
+		if next == "" {
+			return books, nil
+		}
+		query.Set("after", next)
+	}
+}
+
+func (c *Client) CreateAuthor(author models.Author) (models.Author, error) {
+	authorJSON, _ := json.Marshal(author)
+	resp, err := c.HttpClient.Post(c.BaseURL+"/api/v1/authors", "application/json", bytes.NewBuffer(authorJSON))
+	if err != nil {
+		return models.Author{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusCreated, "create author"); err != nil {
+		return models.Author{}, err
+	}
+
+	var createdAuthor models.Author
+	err = json.NewDecoder(resp.Body).Decode(&createdAuthor)
+	return createdAuthor, err
+}
+
+// UpdateAuthor renames the author, which also changes the author line of
+// the author's books.
+func (c *Client) UpdateAuthor(author models.Author) error {
+	authorJSON, _ := json.Marshal(author)
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("%s/api/v1/authors/%d", c.BaseURL, author.ID), bytes.NewBuffer(authorJSON))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp, http.StatusOK, "update author")
+}
+
+// DeleteAuthor fails with ErrConflict while books still credit the author.
+func (c *Client) DeleteAuthor(id int) error {
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/api/v1/authors/%d", c.BaseURL, id), nil)
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp, http.StatusNoContent, "delete author")
+}
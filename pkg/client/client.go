@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"time"
@@ -15,48 +14,43 @@ import (
 type Client struct {
 	BaseURL    string
 	HttpClient *http.Client
+	// Actor is sent with every request made through HttpClient and names
+	// who made a change in the audit log, see actorTransport
+	Actor string
 }
 
 func New(baseURL string) *Client {
-	return &Client{
-		BaseURL:    baseURL,
-		HttpClient: &http.Client{Timeout: 10 * time.Second},
-	}
+	c := &Client{BaseURL: baseURL}
+	c.HttpClient = &http.Client{Timeout: 10 * time.Second, Transport: actorTransport{c}}
+	return c
 }
 
+// GetBooks returns all books matching the filters, following the pages of
+// the listing.
 func (c *Client) GetBooks(author, genre, from, to string) ([]models.Book, error) {
-	// Build the query parameters
-	queryParams := url.Values{}
-	if author != "" {
-		queryParams.Add("author", author)
-	}
-	if genre != "" {
-		queryParams.Add("genre", genre)
-	}
-	if from != "" {
-		queryParams.Add("from", from)
-	}
-	if to != "" {
-		queryParams.Add("to", to)
-	}
-
-	// Build the complete URL with query parameters
-	apiURL := c.BaseURL + "/api/v1/books"
-	if len(queryParams) > 0 {
-		apiURL += "?" + queryParams.Encode()
+	var books []models.Book
+	it := c.Books(BookListOptions{Author: author, Genre: genre, From: from, To: to})
+	for it.Next() {
+		books = append(books, it.Book())
 	}
+	return books, it.Err()
+}
 
-	// Make the HTTP GET request
+func (c *Client) SearchBooks(query string) ([]models.BookSearchResult, error) {
+	apiURL := c.BaseURL + "/api/v1/books/search?" + url.Values{"q": {query}}.Encode()
 	resp, err := c.HttpClient.Get(apiURL)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	// Decode the response into the books slice
-	var books []models.Book
-	err = json.NewDecoder(resp.Body).Decode(&books)
-	return books, err
+	if err := checkResponse(resp, http.StatusOK, "search books"); err != nil {
+		return nil, err
+	}
+
+	var results []models.BookSearchResult
+	err = json.NewDecoder(resp.Body).Decode(&results)
+	return results, err
 }
 
 func (c *Client) GetBook(id int) (models.Book, error) {
@@ -66,11 +60,32 @@ func (c *Client) GetBook(id int) (models.Book, error) {
 	}
 	defer resp.Body.Close()
 
+	if err := checkResponse(resp, http.StatusOK, "get book"); err != nil {
+		return models.Book{}, err
+	}
+
 	var book models.Book
 	err = json.NewDecoder(resp.Body).Decode(&book)
 	return book, err
 }
 
+// GetBookByISBN looks a book up by its ISBN-10 or ISBN-13. It fails with
+// ErrNotFound if no book has the ISBN.
+func (c *Client) GetBookByISBN(isbn string) (models.Book, error) {
+	page, err := c.ListBooks(BookListOptions{ISBN: isbn, Limit: 1})
+	if err != nil {
+		return models.Book{}, err
+	}
+	if len(page.Books) == 0 {
+		return models.Book{}, &Error{
+			Problem:    models.Problem{Status: http.StatusNotFound, Detail: fmt.Sprintf("no book has ISBN %s", isbn)},
+			Op:         "get book",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+	return page.Books[0], nil
+}
+
 func (c *Client) CreateBook(book models.Book) (models.Book, error) {
 	bookJSON, _ := json.Marshal(book)
 	resp, err := c.HttpClient.Post(c.BaseURL+"/api/v1/books", "application/json", bytes.NewBuffer(bookJSON))
@@ -79,15 +94,23 @@ func (c *Client) CreateBook(book models.Book) (models.Book, error) {
 	}
 	defer resp.Body.Close()
 
+	if err := checkResponse(resp, http.StatusCreated, "create book"); err != nil {
+		return models.Book{}, err
+	}
+
 	var createdBook models.Book
 	err = json.NewDecoder(resp.Body).Decode(&createdBook)
 	return createdBook, err
 }
 
+// UpdateBook replaces the book. If book.Version is set, as it is for books
+// returned by the client, the update only goes through while the book is
+// still at that version and fails with ErrPreconditionFailed otherwise.
 func (c *Client) UpdateBook(book models.Book) error {
 	bookJSON, _ := json.Marshal(book)
 	req, _ := http.NewRequest("PUT", fmt.Sprintf("%s/api/v1/books/%d", c.BaseURL, book.ID), bytes.NewBuffer(bookJSON))
 	req.Header.Set("Content-Type", "application/json")
+	setIfMatch(req, book.Version)
 
 	resp, err := c.HttpClient.Do(req)
 	if err != nil {
@@ -95,14 +118,18 @@ func (c *Client) UpdateBook(book models.Book) error {
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to update book: %s", resp.Status)
-	}
-	return nil
+	return checkResponse(resp, http.StatusOK, "update book")
 }
 
 func (c *Client) DeleteBook(id int) error {
+	return c.DeleteBookIfMatch(id, 0)
+}
+
+// DeleteBookIfMatch deletes the book only while it is at the given version.
+// A zero version deletes it unconditionally.
+func (c *Client) DeleteBookIfMatch(id, version int) error {
 	req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/api/v1/books/%d", c.BaseURL, id), nil)
+	setIfMatch(req, version)
 
 	resp, err := c.HttpClient.Do(req)
 	if err != nil {
@@ -110,22 +137,26 @@ func (c *Client) DeleteBook(id int) error {
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("failed to delete book: %s", resp.Status)
-	}
-	return nil
+	return checkResponse(resp, http.StatusNoContent, "delete book")
 }
 
+// GetCollections returns all collections, following the pages of the
+// listing.
 func (c *Client) GetCollections() ([]models.Collection, error) {
-	resp, err := c.HttpClient.Get(c.BaseURL + "/api/v1/collections")
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
 	var collections []models.Collection
-	err = json.NewDecoder(resp.Body).Decode(&collections)
-	return collections, err
+	query := url.Values{}
+	for {
+		var page []models.Collection
+		_, next, err := c.getPage("/api/v1/collections", query, &page)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, page...)
+		if next == "" {
+			return collections, nil
+		}
+		query.Set("after", next)
+	}
 }
 
 func (c *Client) GetCollection(id int) (models.Collection, error) {
@@ -135,6 +166,10 @@ func (c *Client) GetCollection(id int) (models.Collection, error) {
 	}
 	defer resp.Body.Close()
 
+	if err := checkResponse(resp, http.StatusOK, "get collection"); err != nil {
+		return models.Collection{}, err
+	}
+
 	var collection models.Collection
 	err = json.NewDecoder(resp.Body).Decode(&collection)
 	return collection, err
@@ -148,15 +183,22 @@ func (c *Client) CreateCollection(collection models.Collection) (models.Collecti
 	}
 	defer resp.Body.Close()
 
+	if err := checkResponse(resp, http.StatusCreated, "create collection"); err != nil {
+		return models.Collection{}, err
+	}
+
 	var createdCollection models.Collection
 	err = json.NewDecoder(resp.Body).Decode(&createdCollection)
 	return createdCollection, err
 }
 
+// UpdateCollection renames the collection. Like UpdateBook, it is
+// conditional on collection.Version if that is set.
 func (c *Client) UpdateCollection(collection models.Collection) error {
 	collectionJSON, _ := json.Marshal(collection)
 	req, _ := http.NewRequest("PUT", fmt.Sprintf("%s/api/v1/collections/%d", c.BaseURL, collection.ID), bytes.NewBuffer(collectionJSON))
 	req.Header.Set("Content-Type", "application/json")
+	setIfMatch(req, collection.Version)
 
 	resp, err := c.HttpClient.Do(req)
 	if err != nil {
@@ -164,14 +206,18 @@ func (c *Client) UpdateCollection(collection models.Collection) error {
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to update collection: %s", resp.Status)
-	}
-	return nil
+	return checkResponse(resp, http.StatusOK, "update collection")
 }
 
 func (c *Client) DeleteCollection(id int) error {
+	return c.DeleteCollectionIfMatch(id, 0)
+}
+
+// DeleteCollectionIfMatch deletes the collection only while it is at the
+// given version. A zero version deletes it unconditionally.
+func (c *Client) DeleteCollectionIfMatch(id, version int) error {
 	req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/api/v1/collections/%d", c.BaseURL, id), nil)
+	setIfMatch(req, version)
 
 	resp, err := c.HttpClient.Do(req)
 	if err != nil {
@@ -179,10 +225,7 @@ func (c *Client) DeleteCollection(id int) error {
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("failed to delete collection: %s", resp.Status)
-	}
-	return nil
+	return checkResponse(resp, http.StatusNoContent, "delete collection")
 }
 
 func (c *Client) AddBookToCollection(collectionID, bookID int) error {
@@ -192,11 +235,7 @@ func (c *Client) AddBookToCollection(collectionID, bookID int) error {
 		return err
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusNoContent {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to add book to collection: %s", string(body))
-	}
-	return nil
+	return checkResponse(resp, http.StatusNoContent, "add book to collection")
 }
 
 func (c *Client) RemoveBookFromCollection(collectionID, bookID int) error {
@@ -207,9 +246,5 @@ func (c *Client) RemoveBookFromCollection(collectionID, bookID int) error {
 		return err
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusNoContent {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to remove book from collection: %s", string(body))
-	}
-	return nil
+	return checkResponse(resp, http.StatusNoContent, "remove book from collection")
 }
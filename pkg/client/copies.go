@@ -0,0 +1,262 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// GetLocations returns all locations, following the pages of the listing.
+func (c *Client) GetLocations() ([]models.Location, error) {
+	var locations []models.Location
+	query := url.Values{}
+	for {
+		var page []models.Location
+		_, next, err := c.getPage("/api/v1/locations", query, &page)
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, page...)
+		if next == "" {
+			return locations, nil
+		}
+		query.Set("after", next)
+	}
+}
+
+func (c *Client) GetLocation(id int) (models.Location, error) {
+	resp, err := c.HttpClient.Get(fmt.Sprintf("%s/api/v1/locations/%d", c.BaseURL, id))
+	if err != nil {
+		return models.Location{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "get location"); err != nil {
+		return models.Location{}, err
+	}
+
+	var location models.Location
+	err = json.NewDecoder(resp.Body).Decode(&location)
+	return location, err
+}
+
+func (c *Client) CreateLocation(location models.Location) (models.Location, error) {
+	locationJSON, _ := json.Marshal(location)
+	resp, err := c.HttpClient.Post(c.BaseURL+"/api/v1/locations", "application/json", bytes.NewBuffer(locationJSON))
+	if err != nil {
+		return models.Location{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusCreated, "create location"); err != nil {
+		return models.Location{}, err
+	}
+
+	var created models.Location
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	return created, err
+}
+
+// UpdateLocation replaces the name, kind and parent of the location, the
+// locations and copies inside it move along.
+func (c *Client) UpdateLocation(location models.Location) (models.Location, error) {
+	locationJSON, _ := json.Marshal(location)
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("%s/api/v1/locations/%d", c.BaseURL, location.ID), bytes.NewBuffer(locationJSON))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return models.Location{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "update location"); err != nil {
+		return models.Location{}, err
+	}
+
+	var updated models.Location
+	err = json.NewDecoder(resp.Body).Decode(&updated)
+	return updated, err
+}
+
+// DeleteLocation deletes an empty location. It fails with ErrConflict while
+// the location contains other locations or copies.
+func (c *Client) DeleteLocation(id int) error {
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/api/v1/locations/%d", c.BaseURL, id), nil)
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp, http.StatusNoContent, "delete location")
+}
+
+// CopyListOptions filters a copy listing. Recursive also lists the copies
+// in the locations inside LocationID.
+type CopyListOptions struct {
+	LocationID int
+	Recursive  bool
+	Barcode    string
+	Format     string
+	Sort       string // id or title
+}
+
+func (o CopyListOptions) query() url.Values {
+	query := url.Values{}
+	if o.LocationID > 0 {
+		query.Set("location_id", strconv.Itoa(o.LocationID))
+	}
+	if o.Recursive {
+		query.Set("recursive", "true")
+	}
+	if o.Barcode != "" {
+		query.Set("barcode", o.Barcode)
+	}
+	if o.Format != "" {
+		query.Set("format", o.Format)
+	}
+	if o.Sort != "" {
+		query.Set("sort", o.Sort)
+	}
+	return query
+}
+
+// GetCopies returns the copies of all books matching the options, following
+// the pages of the listing.
+func (c *Client) GetCopies(opts CopyListOptions) ([]models.Copy, error) {
+	return c.getCopies("/api/v1/copies", opts.query())
+}
+
+// GetBookCopies returns the copies of the book.
+func (c *Client) GetBookCopies(bookID int) ([]models.Copy, error) {
+	return c.getCopies(fmt.Sprintf("/api/v1/books/%d/copies", bookID), url.Values{})
+}
+
+// GetLocationCopies returns the copies at the location, with recursive also
+// those in the locations inside it.
+func (c *Client) GetLocationCopies(locationID int, recursive bool) ([]models.Copy, error) {
+	return c.getCopies(fmt.Sprintf("/api/v1/locations/%d/copies", locationID), CopyListOptions{Recursive: recursive}.query())
+}
+
+func (c *Client) getCopies(path string, query url.Values) ([]models.Copy, error) {
+	var copies []models.Copy
+	for {
+		var page []models.Copy
+		_, next, err := c.getPage(path, query, &page)
+		if err != nil {
+			return nil, err
+		}
+		copies = append(copies, page...)
+		if next == "" {
+			return copies, nil
+		}
+		query.Set("after", next)
+	}
+}
+
+func (c *Client) GetCopy(id int) (models.Copy, error) {
+	resp, err := c.HttpClient.Get(fmt.Sprintf("%s/api/v1/copies/%d", c.BaseURL, id))
+	if err != nil {
+		return models.Copy{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "get copy"); err != nil {
+		return models.Copy{}, err
+	}
+
+	var copy models.Copy
+	err = json.NewDecoder(resp.Body).Decode(&copy)
+	return copy, err
+}
+
+// CreateCopy adds a copy of copy.BookID. It fails with ErrConflict if
+// another copy has the same barcode.
+func (c *Client) CreateCopy(copy models.Copy) (models.Copy, error) {
+	copyJSON, _ := json.Marshal(copy)
+	resp, err := c.HttpClient.Post(fmt.Sprintf("%s/api/v1/books/%d/copies", c.BaseURL, copy.BookID), "application/json", bytes.NewBuffer(copyJSON))
+	if err != nil {
+		return models.Copy{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusCreated, "create copy"); err != nil {
+		return models.Copy{}, err
+	}
+
+	var created models.Copy
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	return created, err
+}
+
+// UpdateCopy replaces the details and location of the copy.
+func (c *Client) UpdateCopy(copy models.Copy) (models.Copy, error) {
+	copyJSON, _ := json.Marshal(copy)
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("%s/api/v1/copies/%d", c.BaseURL, copy.ID), bytes.NewBuffer(copyJSON))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return models.Copy{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "update copy"); err != nil {
+		return models.Copy{}, err
+	}
+
+	var updated models.Copy
+	err = json.NewDecoder(resp.Body).Decode(&updated)
+	return updated, err
+}
+
+func (c *Client) DeleteCopy(id int) error {
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/api/v1/copies/%d", c.BaseURL, id), nil)
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp, http.StatusNoContent, "delete copy")
+}
+
+// MoveCopies moves the copies to the location, or out of any location if
+// locationID is nil. Either all of them move or none.
+func (c *Client) MoveCopies(copyIDs []int, locationID *int) error {
+	requestJSON, _ := json.Marshal(models.MoveCopiesRequest{CopyIDs: copyIDs, LocationID: locationID})
+	resp, err := c.HttpClient.Post(c.BaseURL+"/api/v1/copies/move", "application/json", bytes.NewBuffer(requestJSON))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp, http.StatusNoContent, "move copies")
+}
+
+// CheckInventory compares the barcodes found at the location with the
+// copies recorded there. With apply the misplaced copies are moved to it.
+func (c *Client) CheckInventory(locationID int, barcodes []string, apply bool) (models.InventoryReport, error) {
+	requestJSON, _ := json.Marshal(models.InventoryRequest{Barcodes: barcodes, Apply: apply})
+	resp, err := c.HttpClient.Post(fmt.Sprintf("%s/api/v1/locations/%d/inventory", c.BaseURL, locationID), "application/json", bytes.NewBuffer(requestJSON))
+	if err != nil {
+		return models.InventoryReport{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "check inventory"); err != nil {
+		return models.InventoryReport{}, err
+	}
+
+	var report models.InventoryReport
+	err = json.NewDecoder(resp.Body).Decode(&report)
+	return report, err
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// SetBookCover uploads the cover of a book, a JPEG, PNG or WebP image of the
+// given media type, replacing any earlier cover.
+func (c *Client) SetBookCover(bookID int, contentType string, image []byte) (models.Cover, error) {
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("%s/api/v1/books/%d/cover", c.BaseURL, bookID), bytes.NewReader(image))
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return models.Cover{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "set cover"); err != nil {
+		return models.Cover{}, err
+	}
+
+	var cover models.Cover
+	err = json.NewDecoder(resp.Body).Decode(&cover)
+	return cover, err
+}
+
+// GetBookCover downloads the cover of a book, or its thumbnail of the given
+// size if size is not empty. It returns the image and its media type.
+func (c *Client) GetBookCover(bookID int, size string) ([]byte, string, error) {
+	url := fmt.Sprintf("%s/api/v1/books/%d/cover", c.BaseURL, bookID)
+	if size != "" {
+		url += "/" + size
+	}
+	resp, err := c.HttpClient.Get(url)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "get cover"); err != nil {
+		return nil, "", err
+	}
+
+	image, err := io.ReadAll(resp.Body)
+	return image, resp.Header.Get("Content-Type"), err
+}
+
+func (c *Client) DeleteBookCover(bookID int) error {
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/api/v1/books/%d/cover", c.BaseURL, bookID), nil)
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp, http.StatusNoContent, "delete cover")
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// GetCustomFields returns the definitions of all custom fields ordered by
+// name, following the pages of the listing.
+func (c *Client) GetCustomFields() ([]models.CustomField, error) {
+	var fields []models.CustomField
+	query := url.Values{"sort": {"name"}}
+	for {
+		var page []models.CustomField
+		_, next, err := c.getPage("/api/v1/custom-fields", query, &page)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, page...)
+		if next == "" {
+			return fields, nil
+		}
+		query.Set("after", next)
+	}
+}
+
+func (c *Client) GetCustomField(id int) (models.CustomField, error) {
+	resp, err := c.HttpClient.Get(fmt.Sprintf("%s/api/v1/custom-fields/%d", c.BaseURL, id))
+	if err != nil {
+		return models.CustomField{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "get custom field"); err != nil {
+		return models.CustomField{}, err
+	}
+
+	var field models.CustomField
+	err = json.NewDecoder(resp.Body).Decode(&field)
+	return field, err
+}
+
+func (c *Client) CreateCustomField(field models.CustomField) (models.CustomField, error) {
+	fieldJSON, _ := json.Marshal(field)
+	resp, err := c.HttpClient.Post(c.BaseURL+"/api/v1/custom-fields", "application/json", bytes.NewBuffer(fieldJSON))
+	if err != nil {
+		return models.CustomField{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusCreated, "create custom field"); err != nil {
+		return models.CustomField{}, err
+	}
+
+	var created models.CustomField
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	return created, err
+}
+
+// UpdateCustomField replaces the definition of a custom field. It fails
+// with ErrConflict if values books already have would no longer be valid.
+func (c *Client) UpdateCustomField(field models.CustomField) (models.CustomField, error) {
+	fieldJSON, _ := json.Marshal(field)
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("%s/api/v1/custom-fields/%d", c.BaseURL, field.ID), bytes.NewBuffer(fieldJSON))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return models.CustomField{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "update custom field"); err != nil {
+		return models.CustomField{}, err
+	}
+
+	var updated models.CustomField
+	err = json.NewDecoder(resp.Body).Decode(&updated)
+	return updated, err
+}
+
+// DeleteCustomField deletes the custom field and its values on all books.
+func (c *Client) DeleteCustomField(id int) error {
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/api/v1/custom-fields/%d", c.BaseURL, id), nil)
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp, http.StatusNoContent, "delete custom field")
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+const problemContentType = "application/problem+json"
+
+// Errors reported by the server can be told apart with errors.Is against
+// these, e.g. errors.Is(err, client.ErrNotFound) for a missing book.
+var (
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("conflict")
+	ErrInvalid  = errors.New("invalid request")
+
+	// ErrPreconditionFailed is returned by conditional requests for records
+	// that changed since the given version.
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// Error is returned when the server answers with an unexpected status code.
+// The problem details sent by the server are embedded, so validation
+// failures list the rejected fields in Errors.
+type Error struct {
+	models.Problem
+	Op         string // what the client was trying to do, e.g. "update book"
+	StatusCode int
+}
+
+func (e *Error) Error() string {
+	message := e.Detail
+	if message == "" {
+		message = e.Title
+	}
+	if message == "" {
+		message = fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	if len(e.Errors) > 0 {
+		fields := make([]string, len(e.Errors))
+		for i, fieldErr := range e.Errors {
+			fields[i] = fieldErr.Field + " " + fieldErr.Message
+		}
+		message += ": " + strings.Join(fields, ", ")
+	}
+	return fmt.Sprintf("failed to %s: %s", e.Op, message)
+}
+
+// Is matches the sentinel error corresponding to the status code.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrInvalid:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrPreconditionFailed:
+		return e.StatusCode == http.StatusPreconditionFailed
+	}
+	return false
+}
+
+// checkResponse returns an *Error unless the response has the expected
+// status code. Bodies that are not problem details become the detail.
+func checkResponse(resp *http.Response, expected int, op string) error {
+	if resp.StatusCode == expected {
+		return nil
+	}
+	apiErr := &Error{Op: op, StatusCode: resp.StatusCode}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != problemContentType || json.Unmarshal(body, &apiErr.Problem) != nil {
+		apiErr.Problem = models.Problem{Status: resp.StatusCode, Detail: strings.TrimSpace(string(body))}
+	}
+	return apiErr
+}
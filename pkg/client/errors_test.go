@@ -0,0 +1,75 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckResponse_ProblemDetails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"type": "about:blank", "title": "Bad Request", "status": 400, "detail": "Invalid book",
+			"errors": [{"field": "title", "message": "is required"}, {"field": "author", "message": "is required"}]}`))
+	}))
+	defer server.Close()
+
+	_, err := New(server.URL).CreateBook(models.Book{})
+	var apiErr *Error
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, "create book", apiErr.Op)
+		assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+		assert.Equal(t, "Bad Request", apiErr.Title)
+		assert.Equal(t, []models.FieldError{{Field: "title", Message: "is required"}, {Field: "author", Message: "is required"}}, apiErr.Errors)
+	}
+	assert.EqualError(t, err, "failed to create book: Invalid book: title is required, author is required")
+	assert.ErrorIs(t, err, ErrInvalid)
+	assert.NotErrorIs(t, err, ErrNotFound)
+}
+
+func TestCheckResponse_PlainBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream unavailable", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	_, err := New(server.URL).GetBook(1)
+	assert.EqualError(t, err, "failed to get book: upstream unavailable")
+	var apiErr *Error
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, http.StatusBadGateway, apiErr.Status)
+	}
+}
+
+func TestError_Is(t *testing.T) {
+	tests := []struct {
+		status int
+		target error
+	}{
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusConflict, ErrConflict},
+		{http.StatusBadRequest, ErrInvalid},
+		{http.StatusPreconditionFailed, ErrPreconditionFailed},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", problemContentType)
+			w.WriteHeader(tt.status)
+			w.Write([]byte(`{"type": "about:blank", "title": "` + http.StatusText(tt.status) + `"}`))
+		}))
+		err := New(server.URL).DeleteBook(1)
+		server.Close()
+
+		assert.ErrorIs(t, err, tt.target, tt.status)
+		for _, other := range tests {
+			if other.target != tt.target {
+				assert.NotErrorIs(t, err, other.target, tt.status)
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// LoanListOptions filters a loan listing. Open keeps the books that are
+// still out and Overdue those of them past their due date.
+type LoanListOptions struct {
+	Borrower string
+	Open     bool
+	Overdue  bool
+	Sort     string // id, loaned_at or due_at
+}
+
+func (o LoanListOptions) query() url.Values {
+	query := url.Values{}
+	if o.Borrower != "" {
+		query.Set("borrower", o.Borrower)
+	}
+	if o.Open {
+		query.Set("open", "true")
+	}
+	if o.Overdue {
+		query.Set("overdue", "true")
+	}
+	if o.Sort != "" {
+		query.Set("sort", o.Sort)
+	}
+	return query
+}
+
+// GetLoans returns the loans of all books matching the options, following
+// the pages of the listing.
+func (c *Client) GetLoans(opts LoanListOptions) ([]models.Loan, error) {
+	return c.getLoans("/api/v1/loans", opts.query())
+}
+
+// GetBookLoans returns the loan history of the book, oldest first.
+func (c *Client) GetBookLoans(bookID int, opts LoanListOptions) ([]models.Loan, error) {
+	return c.getLoans(fmt.Sprintf("/api/v1/books/%d/loans", bookID), opts.query())
+}
+
+func (c *Client) getLoans(path string, query url.Values) ([]models.Loan, error) {
+	var loans []models.Loan
+	for {
+		var page []models.Loan
+		_, next, err := c.getPage(path, query, &page)
+		if err != nil {
+			return nil, err
+		}
+		loans = append(loans, page...)
+		if next == "" {
+			return loans, nil
+		}
+		query.Set("after", next)
+	}
+}
+
+func (c *Client) GetLoan(id int) (models.Loan, error) {
+	resp, err := c.HttpClient.Get(fmt.Sprintf("%s/api/v1/loans/%d", c.BaseURL, id))
+	if err != nil {
+		return models.Loan{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "get loan"); err != nil {
+		return models.Loan{}, err
+	}
+
+	var loan models.Loan
+	err = json.NewDecoder(resp.Body).Decode(&loan)
+	return loan, err
+}
+
+// CreateLoan lends loan.BookID. It fails with ErrConflict while the book is
+// out on another loan.
+func (c *Client) CreateLoan(loan models.Loan) (models.Loan, error) {
+	loanJSON, _ := json.Marshal(loan)
+	resp, err := c.HttpClient.Post(fmt.Sprintf("%s/api/v1/books/%d/loans", c.BaseURL, loan.BookID), "application/json", bytes.NewBuffer(loanJSON))
+	if err != nil {
+		return models.Loan{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusCreated, "lend book"); err != nil {
+		return models.Loan{}, err
+	}
+
+	var created models.Loan
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	return created, err
+}
+
+// UpdateLoan replaces the borrower, contact and dates of the loan.
+func (c *Client) UpdateLoan(loan models.Loan) (models.Loan, error) {
+	loanJSON, _ := json.Marshal(loan)
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("%s/api/v1/loans/%d", c.BaseURL, loan.ID), bytes.NewBuffer(loanJSON))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return models.Loan{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "update loan"); err != nil {
+		return models.Loan{}, err
+	}
+
+	var updated models.Loan
+	err = json.NewDecoder(resp.Body).Decode(&updated)
+	return updated, err
+}
+
+// ReturnLoan records that the book came back on returnedAt, YYYY-MM-DD, or
+// today if it is empty.
+func (c *Client) ReturnLoan(id int, returnedAt string) (models.Loan, error) {
+	bodyJSON, _ := json.Marshal(map[string]string{"returned_at": returnedAt})
+	resp, err := c.HttpClient.Post(fmt.Sprintf("%s/api/v1/loans/%d/return", c.BaseURL, id), "application/json", bytes.NewBuffer(bodyJSON))
+	if err != nil {
+		return models.Loan{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "return loan"); err != nil {
+		return models.Loan{}, err
+	}
+
+	var loan models.Loan
+	err = json.NewDecoder(resp.Body).Decode(&loan)
+	return loan, err
+}
+
+func (c *Client) DeleteLoan(id int) error {
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/api/v1/loans/%d", c.BaseURL, id), nil)
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp, http.StatusNoContent, "delete loan")
+}
@@ -0,0 +1,218 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// BookListOptions filters, orders and pages a book listing. Zero values leave
+// the choice to the server, which returns up to 100 books ordered by id.
+type BookListOptions struct {
+	Author string
+	Genre  string
+	From   string
+	To     string
+	ISBN   string // ISBN-10 or ISBN-13, hyphens are allowed
+	// Tags must all match, each may list comma separated alternatives,
+	// e.g. {"fantasy,science fiction", "classics"}
+	Tags []string
+	// Status is a reading status, e.g. "reading"
+	Status string
+	// MinRating keeps books with at least this average rating, 0 keeps all
+	MinRating float64
+	// CollapseWorks lists one edition per work
+	CollapseWorks bool
+	Publisher     string
+	// Language also matches regional variants, "en" matches "en-GB"
+	Language string
+	Format   string // hardcover, paperback, ebook, audiobook or other
+	MinPages int
+	MaxPages int
+	// MaxHeight, MaxWidth and MaxThickness are in millimetres and leave out
+	// books whose dimensions are unknown
+	MaxHeight    float64
+	MaxWidth     float64
+	MaxThickness float64
+	// Custom maps names of custom fields to the value books must have, e.g.
+	// {"signed": "true"}
+	Custom map[string]string
+
+	Sort  string // title, author, published_date, created_at, rating or id
+	Desc  bool
+	Limit int
+	After string // cursor of the page to continue after
+}
+
+func (o BookListOptions) query() url.Values {
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"author":    o.Author,
+		"genre":     o.Genre,
+		"from":      o.From,
+		"to":        o.To,
+		"isbn":      o.ISBN,
+		"status":    o.Status,
+		"publisher": o.Publisher,
+		"language":  o.Language,
+		"format":    o.Format,
+		"sort":      o.Sort,
+		"after":     o.After,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	for name, value := range o.Custom {
+		query.Set("custom."+name, value)
+	}
+	for _, tag := range o.Tags {
+		query.Add("tag", tag)
+	}
+	if o.Desc {
+		query.Set("order", "desc")
+	}
+	if o.CollapseWorks {
+		query.Set("collapse", "work")
+	}
+	if o.MinRating > 0 {
+		query.Set("min_rating", strconv.FormatFloat(o.MinRating, 'f', -1, 64))
+	}
+	for key, value := range map[string]int{"min_pages": o.MinPages, "max_pages": o.MaxPages} {
+		if value > 0 {
+			query.Set(key, strconv.Itoa(value))
+		}
+	}
+	for key, value := range map[string]float64{"max_height": o.MaxHeight, "max_width": o.MaxWidth, "max_thickness": o.MaxThickness} {
+		if value > 0 {
+			query.Set(key, strconv.FormatFloat(value, 'f', -1, 64))
+		}
+	}
+	if o.Limit > 0 {
+		query.Set("limit", strconv.Itoa(o.Limit))
+	}
+	return query
+}
+
+// BookPage is a single page of a book listing.
+type BookPage struct {
+	Books []models.Book
+	Total int    // books matching the listing across all pages
+	Next  string // cursor of the next page, empty on the last page
+}
+
+// ListBooks fetches a single page of books.
+func (c *Client) ListBooks(opts BookListOptions) (BookPage, error) {
+	var page BookPage
+	total, next, err := c.getPage("/api/v1/books", opts.query(), &page.Books)
+	page.Total, page.Next = total, next
+	return page, err
+}
+
+// BookIterator walks a book listing page by page, fetching the next page
+// only once the current one is used up:
+//
+//	it := client.Books(opts)
+//	for it.Next() {
+//		book := it.Book()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type BookIterator struct {
+	client  *Client
+	opts    BookListOptions
+	page    BookPage
+	current int
+	started bool
+	err     error
+}
+
+// Books returns an iterator over all books of the listing, starting at
+// opts.After. opts.Limit sets the page size.
+func (c *Client) Books(opts BookListOptions) *BookIterator {
+	return &BookIterator{client: c, opts: opts, current: -1}
+}
+
+// Next advances to the next book and reports whether there is one.
+func (it *BookIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	it.current++
+	for it.current >= len(it.page.Books) {
+		if it.started && it.page.Next == "" {
+			return false
+		}
+		if it.started {
+			it.opts.After = it.page.Next
+		}
+		it.page, it.err = it.client.ListBooks(it.opts)
+		it.started = true
+		it.current = 0
+		if it.err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Book returns the current book.
+func (it *BookIterator) Book() models.Book {
+	return it.page.Books[it.current]
+}
+
+// Total returns the number of books in the listing, as of the last page
+// fetched.
+func (it *BookIterator) Total() int {
+	return it.page.Total
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *BookIterator) Err() error {
+	return it.err
+}
+
+// getPage fetches one page of a list endpoint into v and returns the total
+// item count and the cursor of the next page.
+func (c *Client) getPage(path string, query url.Values, v interface{}) (int, string, error) {
+	apiURL := c.BaseURL + path
+	if len(query) > 0 {
+		apiURL += "?" + query.Encode()
+	}
+	resp, err := c.HttpClient.Get(apiURL)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "list "+strings.TrimPrefix(path, "/api/v1/")); err != nil {
+		return 0, "", err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return 0, "", err
+	}
+	total, _ := strconv.Atoi(resp.Header.Get("X-Total-Count"))
+	return total, nextCursor(resp.Header.Get("Link")), nil
+}
+
+// nextCursor extracts the cursor from the rel="next" entry of a Link header.
+func nextCursor(link string) string {
+	for _, entry := range strings.Split(link, ",") {
+		target, params, ok := strings.Cut(entry, ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		target = strings.Trim(strings.TrimSpace(target), "<>")
+		u, err := url.Parse(target)
+		if err != nil {
+			return ""
+		}
+		return u.Query().Get("after")
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// pagedBooks serves books 1 to total in pages of the requested limit,
+// linking each page to the next one like the server does.
+func pagedBooks(t *testing.T, total int, requests *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.URL.RawQuery)
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		assert.NoError(t, err)
+		after, _ := strconv.Atoi(r.URL.Query().Get("after"))
+
+		books := []models.Book{}
+		for id := after + 1; id <= total && len(books) < limit; id++ {
+			books = append(books, models.Book{ID: id, Title: fmt.Sprintf("Book %d", id)})
+		}
+		if len(books) > 0 && books[len(books)-1].ID < total {
+			next := fmt.Sprintf("/api/v1/books?after=%d&limit=%d", books[len(books)-1].ID, limit)
+			w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next", </api/v1/books?limit=%d>; rel="first"`, next, limit))
+		}
+		w.Header().Set("X-Total-Count", strconv.Itoa(total))
+		json.NewEncoder(w).Encode(books)
+	}
+}
+
+func TestBookIterator(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(pagedBooks(t, 5, &requests))
+	defer server.Close()
+
+	it := New(server.URL).Books(BookListOptions{Genre: "Fiction", Limit: 2})
+	var ids []int
+	for it.Next() {
+		ids = append(ids, it.Book().ID)
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, ids)
+	assert.Equal(t, 5, it.Total())
+	// Pages are fetched as they are needed, following the cursors
+	assert.Equal(t, []string{
+		"genre=Fiction&limit=2",
+		"after=2&genre=Fiction&limit=2",
+		"after=4&genre=Fiction&limit=2",
+	}, requests)
+}
+
+func TestBookIterator_EmptyListing(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(pagedBooks(t, 0, &requests))
+	defer server.Close()
+
+	it := New(server.URL).Books(BookListOptions{Limit: 10})
+	assert.False(t, it.Next())
+	assert.NoError(t, it.Err())
+	assert.Len(t, requests, 1)
+}
+
+func TestBookIterator_StopsOnError(t *testing.T) {
+	var requests []string
+	books := pagedBooks(t, 5, &requests)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("after") != "" {
+			http.Error(w, "gone", http.StatusInternalServerError)
+			return
+		}
+		books(w, r)
+	}))
+	defer server.Close()
+
+	it := New(server.URL).Books(BookListOptions{Limit: 2})
+	count := 0
+	for it.Next() {
+		count++
+	}
+	assert.Equal(t, 2, count)
+	assert.EqualError(t, it.Err(), "failed to list books: gone")
+	assert.False(t, it.Next())
+}
+
+func TestNextCursor(t *testing.T) {
+	assert.Equal(t, "abc", nextCursor(`</api/v1/books?limit=2>; rel="first", </api/v1/books?after=abc&limit=2>; rel="next"`))
+	assert.Empty(t, nextCursor(`</api/v1/books?limit=2>; rel="first"`))
+	assert.Empty(t, nextCursor(""))
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+const mergePatchContentType = "application/merge-patch+json"
+
+// BookPatch holds the fields to change in a book. Nil fields are left
+// untouched, a pointer to "" clears an optional field.
+type BookPatch struct {
+	Title         *string          `json:"title,omitempty"`
+	Author        *string          `json:"author,omitempty"`
+	PublishedDate *string          `json:"published_date,omitempty"`
+	Edition       *string          `json:"edition,omitempty"`
+	Description   *string          `json:"description,omitempty"`
+	Genre         *string          `json:"genre,omitempty"`
+	ISBN10        *string          `json:"isbn_10,omitempty"` // setting one form of the ISBN replaces the other
+	ISBN13        *string          `json:"isbn_13,omitempty"`
+	Tags          *[]string        `json:"tags,omitempty"` // replaces all tags, an empty list removes them
+	Publisher     *string          `json:"publisher,omitempty"`
+	Language      *string          `json:"language,omitempty"` // BCP 47 language tag, e.g. "en-GB"
+	PageCount     *int             `json:"page_count,omitempty"`
+	Format        *string          `json:"format,omitempty"`
+	Dimensions    *DimensionsPatch `json:"dimensions,omitempty"`
+	// Custom sets the values of custom fields by name, a nil value unsets one
+	Custom map[string]interface{} `json:"custom,omitempty"`
+}
+
+// DimensionsPatch holds the dimensions of a book to change, in millimetres.
+type DimensionsPatch struct {
+	Height    *float64 `json:"height,omitempty"`
+	Width     *float64 `json:"width,omitempty"`
+	Thickness *float64 `json:"thickness,omitempty"`
+}
+
+// CollectionPatch holds the fields to change in a collection.
+type CollectionPatch struct {
+	Name *string `json:"name,omitempty"`
+}
+
+// PatchBook changes only the fields set in the patch and returns the updated
+// book.
+func (c *Client) PatchBook(id int, patch BookPatch) (models.Book, error) {
+	return c.PatchBookIfMatch(id, 0, patch)
+}
+
+// PatchBookIfMatch patches the book only while it is at the given version and
+// fails with ErrPreconditionFailed otherwise. A zero version patches it
+// unconditionally.
+func (c *Client) PatchBookIfMatch(id, version int, patch BookPatch) (models.Book, error) {
+	var book models.Book
+	err := c.patch(fmt.Sprintf("/api/v1/books/%d", id), version, patch, &book, "patch book")
+	return book, err
+}
+
+// PatchCollection changes only the fields set in the patch and returns the
+// updated collection.
+func (c *Client) PatchCollection(id int, patch CollectionPatch) (models.Collection, error) {
+	return c.PatchCollectionIfMatch(id, 0, patch)
+}
+
+// PatchCollectionIfMatch patches the collection only while it is at the
+// given version. A zero version patches it unconditionally.
+func (c *Client) PatchCollectionIfMatch(id, version int, patch CollectionPatch) (models.Collection, error) {
+	var collection models.Collection
+	err := c.patch(fmt.Sprintf("/api/v1/collections/%d", id), version, patch, &collection, "patch collection")
+	return collection, err
+}
+
+// patch sends a JSON merge patch, conditional on version if it is not zero,
+// and decodes the patched resource into v.
+func (c *Client) patch(path string, version int, patch, v interface{}, op string) error {
+	patchJSON, _ := json.Marshal(patch)
+	req, _ := http.NewRequest("PATCH", c.BaseURL+path, bytes.NewBuffer(patchJSON))
+	req.Header.Set("Content-Type", mergePatchContentType)
+	setIfMatch(req, version)
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, op); err != nil {
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
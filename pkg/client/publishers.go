@@ -0,0 +1,92 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// GetPublishers returns all publishers ordered by name, following the pages
+// of the listing. Publishers are created by naming them on books.
+func (c *Client) GetPublishers() ([]models.Publisher, error) {
+	var publishers []models.Publisher
+	query := url.Values{"sort": {"name"}}
+	for {
+		var page []models.Publisher
+		_, next, err := c.getPage("/api/v1/publishers", query, &page)
+		if err != nil {
+			return nil, err
+		}
+		publishers = append(publishers, page...)
+		if next == "" {
+			return publishers, nil
+		}
+		query.Set("after", next)
+	}
+}
+
+func (c *Client) GetPublisher(id int) (models.Publisher, error) {
+	resp, err := c.HttpClient.Get(fmt.Sprintf("%s/api/v1/publishers/%d", c.BaseURL, id))
+	if err != nil {
+		return models.Publisher{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "get publisher"); err != nil {
+		return models.Publisher{}, err
+	}
+
+	var publisher models.Publisher
+	err = json.NewDecoder(resp.Body).Decode(&publisher)
+	return publisher, err
+}
+
+// GetPublisherBooks returns all books of the publisher.
+func (c *Client) GetPublisherBooks(id int) ([]models.Book, error) {
+	var books []models.Book
+	query := url.Values{}
+	for {
+		var page []models.Book
+		_, next, err := c.getPage(fmt.Sprintf("/api/v1/publishers/%d/books", id), query, &page)
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, page...)
+		if next == "" {
+			return books, nil
+		}
+		query.Set("after", next)
+	}
+}
+
+// UpdatePublisher renames the publisher on all of its books.
+func (c *Client) UpdatePublisher(publisher models.Publisher) error {
+	publisherJSON, _ := json.Marshal(publisher)
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("%s/api/v1/publishers/%d", c.BaseURL, publisher.ID), bytes.NewBuffer(publisherJSON))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp, http.StatusOK, "update publisher")
+}
+
+// DeletePublisher fails with ErrConflict while books name the publisher.
+func (c *Client) DeletePublisher(id int) error {
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/api/v1/publishers/%d", c.BaseURL, id), nil)
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp, http.StatusNoContent, "delete publisher")
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// GetReadingLog returns the reading sessions of the book, oldest first, and
+// its current reading status.
+func (c *Client) GetReadingLog(bookID int) (models.ReadingLog, error) {
+	resp, err := c.HttpClient.Get(fmt.Sprintf("%s/api/v1/books/%d/reading", c.BaseURL, bookID))
+	if err != nil {
+		return models.ReadingLog{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "get reading log"); err != nil {
+		return models.ReadingLog{}, err
+	}
+
+	var log models.ReadingLog
+	err = json.NewDecoder(resp.Body).Decode(&log)
+	return log, err
+}
+
+// CreateReadingSession starts a new read of the book. It fails with
+// ErrConflict while the previous session is still open.
+func (c *Client) CreateReadingSession(session models.ReadingSession) (models.ReadingSession, error) {
+	sessionJSON, _ := json.Marshal(session)
+	resp, err := c.HttpClient.Post(fmt.Sprintf("%s/api/v1/books/%d/reading", c.BaseURL, session.BookID), "application/json", bytes.NewBuffer(sessionJSON))
+	if err != nil {
+		return models.ReadingSession{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusCreated, "start reading session"); err != nil {
+		return models.ReadingSession{}, err
+	}
+
+	var created models.ReadingSession
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	return created, err
+}
+
+// UpdateReadingSession replaces the status and dates of the session, which
+// is identified by its ID and BookID.
+func (c *Client) UpdateReadingSession(session models.ReadingSession) (models.ReadingSession, error) {
+	sessionJSON, _ := json.Marshal(session)
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("%s/api/v1/books/%d/reading/%d", c.BaseURL, session.BookID, session.ID), bytes.NewBuffer(sessionJSON))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return models.ReadingSession{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "update reading session"); err != nil {
+		return models.ReadingSession{}, err
+	}
+
+	var updated models.ReadingSession
+	err = json.NewDecoder(resp.Body).Decode(&updated)
+	return updated, err
+}
+
+func (c *Client) DeleteReadingSession(bookID, id int) error {
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/api/v1/books/%d/reading/%d", c.BaseURL, bookID, id), nil)
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp, http.StatusNoContent, "delete reading session")
+}
+
+// AddReadingProgress records a page, a percentage or both for the session
+// and returns the session with the new entry.
+func (c *Client) AddReadingProgress(bookID, sessionID int, progress models.ReadingProgress) (models.ReadingSession, error) {
+	progressJSON, _ := json.Marshal(progress)
+	resp, err := c.HttpClient.Post(fmt.Sprintf("%s/api/v1/books/%d/reading/%d/progress", c.BaseURL, bookID, sessionID), "application/json", bytes.NewBuffer(progressJSON))
+	if err != nil {
+		return models.ReadingSession{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusCreated, "record reading progress"); err != nil {
+		return models.ReadingSession{}, err
+	}
+
+	var session models.ReadingSession
+	err = json.NewDecoder(resp.Body).Decode(&session)
+	return session, err
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// GetBookReviews returns the reviews of the book, following the pages of the
+// listing.
+func (c *Client) GetBookReviews(bookID int) ([]models.Review, error) {
+	return c.getReviews(fmt.Sprintf("/api/v1/books/%d/reviews", bookID), url.Values{})
+}
+
+// GetReviews returns the reviews of all books, only those written by the
+// reviewer unless it is empty.
+func (c *Client) GetReviews(reviewer string) ([]models.Review, error) {
+	query := url.Values{}
+	if reviewer != "" {
+		query.Set("reviewer", reviewer)
+	}
+	return c.getReviews("/api/v1/reviews", query)
+}
+
+func (c *Client) getReviews(path string, query url.Values) ([]models.Review, error) {
+	var reviews []models.Review
+	for {
+		var page []models.Review
+		_, next, err := c.getPage(path, query, &page)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, page...)
+		if next == "" {
+			return reviews, nil
+		}
+		query.Set("after", next)
+	}
+}
+
+// GetReview returns the review with its earlier versions.
+func (c *Client) GetReview(id int) (models.Review, error) {
+	resp, err := c.HttpClient.Get(fmt.Sprintf("%s/api/v1/reviews/%d", c.BaseURL, id))
+	if err != nil {
+		return models.Review{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "get review"); err != nil {
+		return models.Review{}, err
+	}
+
+	var review models.Review
+	err = json.NewDecoder(resp.Body).Decode(&review)
+	return review, err
+}
+
+// CreateReview adds a review of review.BookID. It fails with ErrConflict if
+// the reviewer already reviewed the book.
+func (c *Client) CreateReview(review models.Review) (models.Review, error) {
+	reviewJSON, _ := json.Marshal(review)
+	resp, err := c.HttpClient.Post(fmt.Sprintf("%s/api/v1/books/%d/reviews", c.BaseURL, review.BookID), "application/json", bytes.NewBuffer(reviewJSON))
+	if err != nil {
+		return models.Review{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusCreated, "create review"); err != nil {
+		return models.Review{}, err
+	}
+
+	var created models.Review
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	return created, err
+}
+
+// UpdateReview replaces the rating and body of the review, the server keeps
+// the previous version in its history.
+func (c *Client) UpdateReview(review models.Review) (models.Review, error) {
+	reviewJSON, _ := json.Marshal(review)
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("%s/api/v1/reviews/%d", c.BaseURL, review.ID), bytes.NewBuffer(reviewJSON))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return models.Review{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "update review"); err != nil {
+		return models.Review{}, err
+	}
+
+	var updated models.Review
+	err = json.NewDecoder(resp.Body).Decode(&updated)
+	return updated, err
+}
+
+func (c *Client) DeleteReview(id int) error {
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/api/v1/reviews/%d", c.BaseURL, id), nil)
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp, http.StatusNoContent, "delete review")
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// GetBookRevisions returns the revisions of a book, oldest first.
+func (c *Client) GetBookRevisions(bookID int) ([]models.BookRevision, error) {
+	var revisions []models.BookRevision
+	err := c.getJSON(fmt.Sprintf("/api/v1/books/%d/revisions", bookID), nil, &revisions, "list book revisions")
+	return revisions, err
+}
+
+func (c *Client) GetBookRevision(bookID, revision int) (models.BookRevision, error) {
+	var rev models.BookRevision
+	err := c.getJSON(fmt.Sprintf("/api/v1/books/%d/revisions/%d", bookID, revision), nil, &rev, "get book revision")
+	return rev, err
+}
+
+// DiffBookRevisions compares two revisions of a book. Revision 0 stands for
+// the book as it is now, so DiffBookRevisions(id, 0, 3) lists what
+// restoring revision 3 would change.
+func (c *Client) DiffBookRevisions(bookID, from, to int) (models.RevisionDiff, error) {
+	var diff models.RevisionDiff
+	err := c.getJSON(fmt.Sprintf("/api/v1/books/%d/revisions/diff", bookID), revisionRange(from, to), &diff, "compare book revisions")
+	return diff, err
+}
+
+// RestoreBookRevision sets the fields of a book back to those of the
+// revision, only while the book is at the given version unless it is 0.
+func (c *Client) RestoreBookRevision(bookID, revision, version int) (models.Book, error) {
+	var book models.Book
+	err := c.restoreRevision(fmt.Sprintf("/api/v1/books/%d/revisions/%d/restore", bookID, revision), version, &book, "restore book revision")
+	return book, err
+}
+
+// GetCollectionRevisions returns the revisions of a collection, oldest
+// first.
+func (c *Client) GetCollectionRevisions(collectionID int) ([]models.CollectionRevision, error) {
+	var revisions []models.CollectionRevision
+	err := c.getJSON(fmt.Sprintf("/api/v1/collections/%d/revisions", collectionID), nil, &revisions, "list collection revisions")
+	return revisions, err
+}
+
+func (c *Client) GetCollectionRevision(collectionID, revision int) (models.CollectionRevision, error) {
+	var rev models.CollectionRevision
+	err := c.getJSON(fmt.Sprintf("/api/v1/collections/%d/revisions/%d", collectionID, revision), nil, &rev, "get collection revision")
+	return rev, err
+}
+
+// DiffCollectionRevisions is DiffBookRevisions for collections.
+func (c *Client) DiffCollectionRevisions(collectionID, from, to int) (models.RevisionDiff, error) {
+	var diff models.RevisionDiff
+	err := c.getJSON(fmt.Sprintf("/api/v1/collections/%d/revisions/diff", collectionID), revisionRange(from, to), &diff, "compare collection revisions")
+	return diff, err
+}
+
+// RestoreCollectionRevision sets the name and the books of a collection
+// back to those of the revision, only while the collection is at the given
+// version unless it is 0.
+func (c *Client) RestoreCollectionRevision(collectionID, revision, version int) (models.Collection, error) {
+	var collection models.Collection
+	err := c.restoreRevision(fmt.Sprintf("/api/v1/collections/%d/revisions/%d/restore", collectionID, revision), version, &collection, "restore collection revision")
+	return collection, err
+}
+
+func revisionRange(from, to int) url.Values {
+	query := url.Values{}
+	if from != 0 {
+		query.Set("from", strconv.Itoa(from))
+	}
+	if to != 0 {
+		query.Set("to", strconv.Itoa(to))
+	}
+	return query
+}
+
+func (c *Client) getJSON(path string, query url.Values, v interface{}, op string) error {
+	apiURL := c.BaseURL + path
+	if len(query) > 0 {
+		apiURL += "?" + query.Encode()
+	}
+	resp, err := c.HttpClient.Get(apiURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, op); err != nil {
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (c *Client) restoreRevision(path string, version int, v interface{}, op string) error {
+	req, _ := http.NewRequest("POST", c.BaseURL+path, nil)
+	setIfMatch(req, version)
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, op); err != nil {
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
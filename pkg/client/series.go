@@ -0,0 +1,128 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// GetAllSeries returns all series ordered by name, following the pages of
+// the listing. The series are listed without their books.
+func (c *Client) GetAllSeries() ([]models.Series, error) {
+	var series []models.Series
+	query := url.Values{"sort": {"name"}}
+	for {
+		var page []models.Series
+		_, next, err := c.getPage("/api/v1/series", query, &page)
+		if err != nil {
+			return nil, err
+		}
+		series = append(series, page...)
+		if next == "" {
+			return series, nil
+		}
+		query.Set("after", next)
+	}
+}
+
+// GetSeries returns the series with its books in order and the whole
+// positions missing between them.
+func (c *Client) GetSeries(id int) (models.Series, error) {
+	resp, err := c.HttpClient.Get(fmt.Sprintf("%s/api/v1/series/%d", c.BaseURL, id))
+	if err != nil {
+		return models.Series{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "get series"); err != nil {
+		return models.Series{}, err
+	}
+
+	var series models.Series
+	err = json.NewDecoder(resp.Body).Decode(&series)
+	return series, err
+}
+
+func (c *Client) CreateSeries(series models.Series) (models.Series, error) {
+	seriesJSON, _ := json.Marshal(series)
+	resp, err := c.HttpClient.Post(c.BaseURL+"/api/v1/series", "application/json", bytes.NewBuffer(seriesJSON))
+	if err != nil {
+		return models.Series{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusCreated, "create series"); err != nil {
+		return models.Series{}, err
+	}
+
+	var createdSeries models.Series
+	err = json.NewDecoder(resp.Body).Decode(&createdSeries)
+	return createdSeries, err
+}
+
+// UpdateSeries replaces the name and description of the series.
+func (c *Client) UpdateSeries(id int, series models.Series) (models.Series, error) {
+	seriesJSON, _ := json.Marshal(series)
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("%s/api/v1/series/%d", c.BaseURL, id), bytes.NewBuffer(seriesJSON))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return models.Series{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "update series"); err != nil {
+		return models.Series{}, err
+	}
+
+	var updatedSeries models.Series
+	err = json.NewDecoder(resp.Body).Decode(&updatedSeries)
+	return updatedSeries, err
+}
+
+// DeleteSeries deletes the series but keeps its books.
+func (c *Client) DeleteSeries(id int) error {
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/api/v1/series/%d", c.BaseURL, id), nil)
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp, http.StatusNoContent, "delete series")
+}
+
+// SetSeriesBook puts the book at the position in the series, adding it or
+// moving it within the series. Positions may be fractional, like 2.5 for a
+// novella between the second and third volume.
+func (c *Client) SetSeriesBook(seriesID, bookID int, position float64) error {
+	positionJSON, _ := json.Marshal(map[string]float64{"position": position})
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("%s/api/v1/series/%d/books/%d", c.BaseURL, seriesID, bookID), bytes.NewBuffer(positionJSON))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp, http.StatusNoContent, "add book to series")
+}
+
+func (c *Client) RemoveSeriesBook(seriesID, bookID int) error {
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/api/v1/series/%d/books/%d", c.BaseURL, seriesID, bookID), nil)
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp, http.StatusNoContent, "remove book from series")
+}
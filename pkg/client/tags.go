@@ -0,0 +1,138 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// GetTags returns all tags ordered by name, following the pages of the
+// listing.
+func (c *Client) GetTags() ([]models.Tag, error) {
+	var tags []models.Tag
+	query := url.Values{"sort": {"name"}}
+	for {
+		var page []models.Tag
+		_, next, err := c.getPage("/api/v1/tags", query, &page)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, page...)
+		if next == "" {
+			return tags, nil
+		}
+		query.Set("after", next)
+	}
+}
+
+func (c *Client) GetTag(id int) (models.Tag, error) {
+	resp, err := c.HttpClient.Get(fmt.Sprintf("%s/api/v1/tags/%d", c.BaseURL, id))
+	if err != nil {
+		return models.Tag{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "get tag"); err != nil {
+		return models.Tag{}, err
+	}
+
+	var tag models.Tag
+	err = json.NewDecoder(resp.Body).Decode(&tag)
+	return tag, err
+}
+
+func (c *Client) CreateTag(tag models.Tag) (models.Tag, error) {
+	tagJSON, _ := json.Marshal(tag)
+	resp, err := c.HttpClient.Post(c.BaseURL+"/api/v1/tags", "application/json", bytes.NewBuffer(tagJSON))
+	if err != nil {
+		return models.Tag{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusCreated, "create tag"); err != nil {
+		return models.Tag{}, err
+	}
+
+	var createdTag models.Tag
+	err = json.NewDecoder(resp.Body).Decode(&createdTag)
+	return createdTag, err
+}
+
+// RenameTag renames the tag on all of its books. It fails with ErrConflict
+// if another tag has the name, MergeTags combines the two instead.
+func (c *Client) RenameTag(id int, name string) (models.Tag, error) {
+	tagJSON, _ := json.Marshal(models.Tag{Name: name})
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("%s/api/v1/tags/%d", c.BaseURL, id), bytes.NewBuffer(tagJSON))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return models.Tag{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "rename tag"); err != nil {
+		return models.Tag{}, err
+	}
+
+	var tag models.Tag
+	err = json.NewDecoder(resp.Body).Decode(&tag)
+	return tag, err
+}
+
+// MergeTags moves the books of one tag to another, deletes the first tag and
+// returns the one merged into.
+func (c *Client) MergeTags(id, intoID int) (models.Tag, error) {
+	resp, err := c.HttpClient.Post(fmt.Sprintf("%s/api/v1/tags/%d/merge/%d", c.BaseURL, id, intoID), "application/json", nil)
+	if err != nil {
+		return models.Tag{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "merge tags"); err != nil {
+		return models.Tag{}, err
+	}
+
+	var tag models.Tag
+	err = json.NewDecoder(resp.Body).Decode(&tag)
+	return tag, err
+}
+
+// DeleteTag deletes the tag and removes it from its books.
+func (c *Client) DeleteTag(id int) error {
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/api/v1/tags/%d", c.BaseURL, id), nil)
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp, http.StatusNoContent, "delete tag")
+}
+
+// TagBooks adds the tags to all of the books. Nothing changes if one of the
+// books does not exist.
+func (c *Client) TagBooks(bookIDs []int, tags []string) error {
+	return c.bulkTag("/api/v1/books/tag", bookIDs, tags, "tag books")
+}
+
+// UntagBooks removes the tags from all of the books, like TagBooks.
+func (c *Client) UntagBooks(bookIDs []int, tags []string) error {
+	return c.bulkTag("/api/v1/books/untag", bookIDs, tags, "untag books")
+}
+
+func (c *Client) bulkTag(path string, bookIDs []int, tags []string, op string) error {
+	requestJSON, _ := json.Marshal(models.BulkTagRequest{BookIDs: bookIDs, Tags: tags})
+	resp, err := c.HttpClient.Post(c.BaseURL+path, "application/json", bytes.NewBuffer(requestJSON))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp, http.StatusNoContent, op)
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// GetTrash returns the books and collections in the trash, the most recently
+// deleted first. An empty kind returns both.
+func (c *Client) GetTrash(kind string) ([]models.TrashItem, error) {
+	query := url.Values{}
+	if kind != "" {
+		query.Set("type", kind)
+	}
+	resp, err := c.HttpClient.Get(c.BaseURL + "/api/v1/trash?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "list trash"); err != nil {
+		return nil, err
+	}
+
+	var items []models.TrashItem
+	err = json.NewDecoder(resp.Body).Decode(&items)
+	return items, err
+}
+
+// RestoreBook takes a book out of the trash, back into its collections.
+func (c *Client) RestoreBook(id int) (models.Book, error) {
+	resp, err := c.HttpClient.Post(fmt.Sprintf("%s/api/v1/trash/books/%d/restore", c.BaseURL, id), "application/json", nil)
+	if err != nil {
+		return models.Book{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "restore book"); err != nil {
+		return models.Book{}, err
+	}
+
+	var book models.Book
+	err = json.NewDecoder(resp.Body).Decode(&book)
+	return book, err
+}
+
+// RestoreCollection takes a collection out of the trash with its books.
+func (c *Client) RestoreCollection(id int) (models.Collection, error) {
+	resp, err := c.HttpClient.Post(fmt.Sprintf("%s/api/v1/trash/collections/%d/restore", c.BaseURL, id), "application/json", nil)
+	if err != nil {
+		return models.Collection{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "restore collection"); err != nil {
+		return models.Collection{}, err
+	}
+
+	var collection models.Collection
+	err = json.NewDecoder(resp.Body).Decode(&collection)
+	return collection, err
+}
+
+// PurgeTrash permanently deletes the items that have been in the trash for
+// at least the given number of days, or all of them for 0, and returns what
+// was purged.
+func (c *Client) PurgeTrash(olderThanDays int) ([]models.TrashItem, error) {
+	query := url.Values{}
+	if olderThanDays > 0 {
+		query.Set("older_than", strconv.Itoa(olderThanDays))
+	}
+	req, _ := http.NewRequest("DELETE", c.BaseURL+"/api/v1/trash?"+query.Encode(), nil)
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "purge trash"); err != nil {
+		return nil, err
+	}
+
+	var items []models.TrashItem
+	err = json.NewDecoder(resp.Body).Decode(&items)
+	return items, err
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// MaxModifyAttempts bounds how often ModifyBook and ModifyCollection retry a
+// change that lost a race against another writer.
+const MaxModifyAttempts = 5
+
+// setIfMatch makes a request conditional on the version of the record it
+// changes. Zero leaves the request unconditional.
+func setIfMatch(req *http.Request, version int) {
+	if version != 0 {
+		req.Header.Set("If-Match", strconv.Quote(strconv.Itoa(version)))
+	}
+}
+
+// ModifyBook applies change to the current state of the book and stores the
+// result, provided nobody changed the book in the meantime. Otherwise it
+// fetches the book again and repeats, so change must be safe to run more
+// than once. An error returned by change aborts the update.
+func (c *Client) ModifyBook(id int, change func(book *models.Book) error) (models.Book, error) {
+	var err error
+	for attempt := 0; attempt < MaxModifyAttempts; attempt++ {
+		var book models.Book
+		book, err = c.GetBook(id)
+		if err != nil {
+			return models.Book{}, err
+		}
+		if err := change(&book); err != nil {
+			return models.Book{}, err
+		}
+		book.ID = id
+		err = c.UpdateBook(book)
+		if errors.Is(err, ErrPreconditionFailed) {
+			continue
+		}
+		if err != nil {
+			return models.Book{}, err
+		}
+		return c.GetBook(id)
+	}
+	return models.Book{}, err
+}
+
+// ModifyCollection is ModifyBook for collections.
+func (c *Client) ModifyCollection(id int, change func(collection *models.Collection) error) (models.Collection, error) {
+	var err error
+	for attempt := 0; attempt < MaxModifyAttempts; attempt++ {
+		var collection models.Collection
+		collection, err = c.GetCollection(id)
+		if err != nil {
+			return models.Collection{}, err
+		}
+		if err := change(&collection); err != nil {
+			return models.Collection{}, err
+		}
+		collection.ID = id
+		err = c.UpdateCollection(collection)
+		if errors.Is(err, ErrPreconditionFailed) {
+			continue
+		}
+		if err != nil {
+			return models.Collection{}, err
+		}
+		return c.GetCollection(id)
+	}
+	return models.Collection{}, err
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/mayank-02/bookman/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// racedBook serves a single book whose version is bumped behind the back of
+// the first races writers, as if someone else changed it each time.
+type racedBook struct {
+	mu    sync.Mutex
+	book  models.Book
+	races int
+	puts  []string // If-Match headers of the updates
+}
+
+func (s *racedBook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.Method {
+	case "GET":
+		json.NewEncoder(w).Encode(s.book)
+	case "PUT":
+		s.puts = append(s.puts, r.Header.Get("If-Match"))
+		if s.races > 0 {
+			s.races--
+			s.book.Version++
+			s.book.Genre = "Changed elsewhere " + strconv.Itoa(s.book.Version)
+		}
+		if r.Header.Get("If-Match") != strconv.Quote(strconv.Itoa(s.book.Version)) {
+			w.Header().Set("Content-Type", problemContentType)
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte(`{"type": "about:blank", "title": "Precondition Failed", "status": 412}`))
+			return
+		}
+		var book models.Book
+		json.NewDecoder(r.Body).Decode(&book)
+		book.Version = s.book.Version + 1
+		s.book = book
+		json.NewEncoder(w).Encode(s.book)
+	}
+}
+
+func TestModifyBook_RetriesOnPreconditionFailed(t *testing.T) {
+	state := &racedBook{book: models.Book{ID: 1, Title: "Dune", Version: 1}, races: 2}
+	server := httptest.NewServer(state)
+	defer server.Close()
+
+	calls := 0
+	book, err := New(server.URL).ModifyBook(1, func(book *models.Book) error {
+		calls++
+		book.Title = "Dune Messiah"
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []string{`"1"`, `"2"`, `"3"`}, state.puts)
+	assert.Equal(t, "Dune Messiah", book.Title)
+	// The change was applied on top of the last one made elsewhere
+	assert.Equal(t, "Changed elsewhere 3", book.Genre)
+	assert.Equal(t, 4, book.Version)
+}
+
+func TestModifyBook_GivesUp(t *testing.T) {
+	state := &racedBook{book: models.Book{ID: 1, Title: "Dune", Version: 1}, races: MaxModifyAttempts}
+	server := httptest.NewServer(state)
+	defer server.Close()
+
+	_, err := New(server.URL).ModifyBook(1, func(book *models.Book) error { return nil })
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+	assert.Len(t, state.puts, MaxModifyAttempts)
+}
+
+func TestModifyBook_ChangeError(t *testing.T) {
+	state := &racedBook{book: models.Book{ID: 1, Title: "Dune", Version: 1}}
+	server := httptest.NewServer(state)
+	defer server.Close()
+
+	abort := errors.New("abort")
+	_, err := New(server.URL).ModifyBook(1, func(book *models.Book) error { return abort })
+	assert.ErrorIs(t, err, abort)
+	assert.Empty(t, state.puts)
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/mayank-02/bookman/internal/models"
+)
+
+// GetWorks returns all works, following the pages of the listing.
+func (c *Client) GetWorks() ([]models.Work, error) {
+	var works []models.Work
+	query := url.Values{"sort": {"title"}}
+	for {
+		var page []models.Work
+		_, next, err := c.getPage("/api/v1/works", query, &page)
+		if err != nil {
+			return nil, err
+		}
+		works = append(works, page...)
+		if next == "" {
+			return works, nil
+		}
+		query.Set("after", next)
+	}
+}
+
+func (c *Client) GetWork(id int) (models.Work, error) {
+	resp, err := c.HttpClient.Get(fmt.Sprintf("%s/api/v1/works/%d", c.BaseURL, id))
+	if err != nil {
+		return models.Work{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "get work"); err != nil {
+		return models.Work{}, err
+	}
+
+	var work models.Work
+	err = json.NewDecoder(resp.Body).Decode(&work)
+	return work, err
+}
+
+// GetWorkEditions returns the books that are editions of the work, oldest
+// first.
+func (c *Client) GetWorkEditions(id int) ([]models.Book, error) {
+	var books []models.Book
+	path := fmt.Sprintf("/api/v1/works/%d/editions", id)
+	query := url.Values{"sort": {"published_date"}}
+	for {
+		var page []models.Book
+		_, next, err := c.getPage(path, query, &page)
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, page...)
+		if next == "" {
+			return books, nil
+		}
+		query.Set("after", next)
+	}
+}
+
+func (c *Client) CreateWork(work models.Work) (models.Work, error) {
+	workJSON, _ := json.Marshal(work)
+	resp, err := c.HttpClient.Post(c.BaseURL+"/api/v1/works", "application/json", bytes.NewBuffer(workJSON))
+	if err != nil {
+		return models.Work{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusCreated, "create work"); err != nil {
+		return models.Work{}, err
+	}
+
+	var created models.Work
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	return created, err
+}
+
+// UpdateWork replaces the title, original language and original
+// publication date of the work.
+func (c *Client) UpdateWork(work models.Work) (models.Work, error) {
+	workJSON, _ := json.Marshal(work)
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("%s/api/v1/works/%d", c.BaseURL, work.ID), bytes.NewBuffer(workJSON))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return models.Work{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK, "update work"); err != nil {
+		return models.Work{}, err
+	}
+
+	var updated models.Work
+	err = json.NewDecoder(resp.Body).Decode(&updated)
+	return updated, err
+}
+
+// DeleteWork deletes the work, its editions are kept as books of no work.
+func (c *Client) DeleteWork(id int) error {
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/api/v1/works/%d", c.BaseURL, id), nil)
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp, http.StatusNoContent, "delete work")
+}
+
+// MergeEditions makes the books editions of the work. Works the books leave
+// without editions are deleted.
+func (c *Client) MergeEditions(workID int, bookIDs []int) (models.Work, error) {
+	return c.editions(workID, "merge", bookIDs, http.StatusOK)
+}
+
+// SplitEditions moves editions of the work into a new work and returns it.
+// At least one edition has to stay with the work.
+func (c *Client) SplitEditions(workID int, bookIDs []int) (models.Work, error) {
+	return c.editions(workID, "split", bookIDs, http.StatusCreated)
+}
+
+func (c *Client) editions(workID int, action string, bookIDs []int, code int) (models.Work, error) {
+	requestJSON, _ := json.Marshal(models.EditionsRequest{BookIDs: bookIDs})
+	resp, err := c.HttpClient.Post(fmt.Sprintf("%s/api/v1/works/%d/%s", c.BaseURL, workID, action), "application/json", bytes.NewBuffer(requestJSON))
+	if err != nil {
+		return models.Work{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, code, action+" editions"); err != nil {
+		return models.Work{}, err
+	}
+
+	var work models.Work
+	err = json.NewDecoder(resp.Body).Decode(&work)
+	return work, err
+}